@@ -8,17 +8,18 @@ require (
 	github.com/gin-contrib/zap v0.2.0
 	github.com/gin-gonic/gin v1.9.1
 	github.com/go-playground/validator/v10 v10.16.0
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/google/uuid v1.3.0
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/mitchellh/mapstructure v1.5.0
 	github.com/spf13/viper v1.17.0
+	github.com/teambition/rrule-go v1.8.2
 	gitlab.com/Zandraz/gin-params-mapper v0.0.0-20230706121355-f9788104b2dd
 	go.uber.org/zap v1.26.0
 )
 
-require (
-	github.com/go-sql-driver/mysql v1.7.1 // indirect
-	github.com/lib/pq v1.10.9 // indirect
-)
+require github.com/lib/pq v1.10.9
 
 require (
 	github.com/Aloe-Corporation/sqldb v1.0.0
@@ -28,7 +29,7 @@ require (
 	github.com/fsnotify/fsnotify v1.6.0 // indirect
 	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
 	github.com/gin-contrib/cors v1.4.0 // indirect
-	github.com/gin-contrib/sse v0.1.0 // indirect
+	github.com/gin-contrib/sse v0.1.0
 	github.com/go-playground/locales v0.14.1 // indirect
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 	github.com/goccy/go-json v0.10.2 // indirect
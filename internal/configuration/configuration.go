@@ -88,7 +88,10 @@ func InitAllPkg() error {
 	log.Info("controllers ready")
 
 	log.Info("init routers package...")
-	ginrouters.Init()
+	err = ginrouters.Init()
+	if err != nil {
+		return fmt.Errorf("fail to init routers package: %w", err)
+	}
 	log.Info("routers ready")
 
 	return nil
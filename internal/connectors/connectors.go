@@ -15,6 +15,11 @@ var (
 // Conf for the repositories package.
 type Conf struct {
 	Postgres map[string]sqldb.Conf `mapstructure:"postgres"`
+	MySQL    map[string]sqldb.Conf `mapstructure:"mysql"`
+	SQLite   map[string]sqldb.Conf `mapstructure:"sqlite"`
+	File     map[string]FileConf   `mapstructure:"file"`
+	// AutoMigrate applies the pending schema migrations on each SQL connector during Init.
+	AutoMigrate bool `mapstructure:"auto_migrate"`
 }
 
 // Init the data sources connectors.
@@ -26,12 +31,44 @@ func Init() error {
 	}
 	log.Info("All Postgres  connector is ready to use")
 
+	// MySQL connectors
+	log.Info("Init all MySQL connector...")
+	if err := initAllConnectorMySQL(); err != nil {
+		return err
+	}
+	log.Info("All MySQL connector is ready to use")
+
+	// SQLite connectors
+	log.Info("Init all SQLite connector...")
+	if err := initAllConnectorSQLite(); err != nil {
+		return err
+	}
+	log.Info("All SQLite connector is ready to use")
+
+	// File connectors
+	log.Info("Init all File connector...")
+	if err := initAllConnectorFile(); err != nil {
+		return err
+	}
+	log.Info("All File connector is ready to use")
+
+	if Config.AutoMigrate {
+		log.Info("Apply schema migrations...")
+		if err := MigrateUp(); err != nil {
+			return err
+		}
+		log.Info("Schema migrations are applied")
+	}
+
 	return nil
 }
 
 // Close DAO connectors.
 func Close() error {
 	closePostgres()
+	closeMySQL()
+	closeSQLite()
+	closeFile()
 
 	return nil
 }
@@ -0,0 +1,217 @@
+package connectors
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+const (
+	TypeConnectorFile = "File"
+
+	// DefaultCompactEvery is the number of appended records after which a file is compacted.
+	DefaultCompactEvery = 1000
+)
+
+var Files = make(map[string]*FileConnector)
+
+// FileConf is the configuration of a local append-only log file.
+type FileConf struct {
+	Path string `mapstructure:"path"`
+	// CompactEvery is the number of appended records after which the owner should compact the file.
+	CompactEvery int `mapstructure:"compact_every"`
+}
+
+// FileConnector is an append-only log of records, one record per line.
+// Its owner replays the records at startup and regularly replaces them by a snapshot of its state.
+type FileConnector struct {
+	mu           sync.Mutex
+	path         string
+	file         *os.File
+	compactEvery int
+	appended     int
+}
+
+// Records returns all the records of the file.
+func (con *FileConnector) Records() ([][]byte, error) {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	content, err := os.ReadFile(con.path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s : %w", con.path, err)
+	}
+
+	records := make([][]byte, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		records = append(records, bytes.Clone(scanner.Bytes()))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("can't scan %s : %w", con.path, err)
+	}
+
+	return records, nil
+}
+
+// Append writes a record at the end of the file and flushes it to the disk.
+func (con *FileConnector) Append(record []byte) error {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	if _, err := con.file.Write(append(bytes.Clone(record), '\n')); err != nil {
+		return fmt.Errorf("can't append to %s : %w", con.path, err)
+	}
+	if err := con.file.Sync(); err != nil {
+		return fmt.Errorf("can't sync %s : %w", con.path, err)
+	}
+	con.appended++
+
+	return nil
+}
+
+// NeedCompaction reports whether enough records were appended since the last compaction.
+func (con *FileConnector) NeedCompaction() bool {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	return con.compactEvery > 0 && con.appended >= con.compactEvery
+}
+
+// Compact atomically replaces the content of the file by the records of a snapshot.
+func (con *FileConnector) Compact(records [][]byte) error {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	tmpPath := con.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("can't create %s : %w", tmpPath, err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, record := range records {
+		if _, err := writer.Write(append(bytes.Clone(record), '\n')); err != nil {
+			_ = tmp.Close()
+			return fmt.Errorf("can't write %s : %w", tmpPath, err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("can't flush %s : %w", tmpPath, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("can't sync %s : %w", tmpPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("can't close %s : %w", tmpPath, err)
+	}
+
+	if err := con.file.Close(); err != nil {
+		return fmt.Errorf("can't close %s : %w", con.path, err)
+	}
+	if err := os.Rename(tmpPath, con.path); err != nil {
+		return fmt.Errorf("can't replace %s : %w", con.path, err)
+	}
+
+	con.file, err = openLogFile(con.path)
+	if err != nil {
+		return err
+	}
+	con.appended = 0
+
+	return nil
+}
+
+// Close the file.
+func (con *FileConnector) Close() error {
+	con.mu.Lock()
+	defer con.mu.Unlock()
+
+	return con.file.Close()
+}
+
+func initAllConnectorFile() error {
+	for key, config := range Config.File {
+		if err := initConnectorFile(key, config); err != nil {
+			return fmt.Errorf("fail to init all File connectors: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// initConnectorFile init a file connector in Files map
+func initConnectorFile(key string, config FileConf) error {
+	log.Info("Init File connector " + key + "...")
+	if config.Path == "" {
+		return fmt.Errorf("fail to init File connector %s: empty path", key)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0o750); err != nil {
+		return fmt.Errorf("fail to init File connector %s: %w", key, err)
+	}
+
+	file, err := openLogFile(config.Path)
+	if err != nil {
+		return fmt.Errorf("fail to init File connector %s: %w", key, err)
+	}
+
+	compactEvery := config.CompactEvery
+	if compactEvery == 0 {
+		compactEvery = DefaultCompactEvery
+	}
+
+	log.Info("File connector " + key + " is ready to use")
+	Files[key] = &FileConnector{
+		path:         config.Path,
+		file:         file,
+		compactEvery: compactEvery,
+	}
+
+	return nil
+}
+
+// openLogFile opens the file in append mode, it is created if it doesn't exist.
+func openLogFile(path string) (*os.File, error) {
+	file, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("can't open %s : %w", path, err)
+	}
+
+	return file, nil
+}
+
+func closeFile() {
+	for key, connector := range Files {
+		err := connector.Close()
+		if err != nil {
+			log.Error("fail to close file connector",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func GetConnectorFile(connectorName string) (*FileConnector, error) {
+	connector, exist := Files[connectorName]
+	if !exist {
+		return nil, &ConnectorNotFoundError{
+			ConnectorType: TypeConnectorFile,
+			ConnectorName: connectorName,
+		}
+	}
+
+	return connector, nil
+}
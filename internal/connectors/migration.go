@@ -0,0 +1,89 @@
+package connectors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Aloe-Corporation/sqldb"
+	"github.com/CamilleLange/todolist/internal/migrations"
+	"go.uber.org/zap"
+)
+
+// sqlConnector is a SQL connector with the migrations dialect of its database.
+type sqlConnector struct {
+	connectorType string
+	key           string
+	connector     *sqldb.Connector
+	dialect       migrations.Dialect
+}
+
+// allSQLConnectors lists the connectors of every SQL database.
+func allSQLConnectors() []sqlConnector {
+	connectors := make([]sqlConnector, 0, len(Postgres)+len(MySQL)+len(SQLite))
+	for key, connector := range Postgres {
+		connectors = append(connectors, sqlConnector{TypeConnectorPostgres, key, connector, migrations.DialectPostgres})
+	}
+	for key, connector := range MySQL {
+		connectors = append(connectors, sqlConnector{TypeConnectorMySQL, key, connector, migrations.DialectMySQL})
+	}
+	for key, connector := range SQLite {
+		connectors = append(connectors, sqlConnector{TypeConnectorSQLite, key, connector, migrations.DialectSQLite})
+	}
+
+	return connectors
+}
+
+// MigrateUp applies all pending migrations on each SQL connector.
+func MigrateUp() error {
+	for _, c := range allSQLConnectors() {
+		migrator, err := migrations.NewMigrator(c.connector.DB, c.dialect)
+		if err != nil {
+			return fmt.Errorf("fail to build migrator of %s %s: %w", c.connectorType, c.key, err)
+		}
+
+		count, err := migrator.Up(context.Background())
+		if err != nil {
+			return fmt.Errorf("fail to migrate up %s %s: %w", c.connectorType, c.key, err)
+		}
+		log.Info(c.connectorType+" "+c.key+" is up to date", zap.Int("applied", count))
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the last steps migrations on each SQL connector.
+func MigrateDown(steps int) error {
+	for _, c := range allSQLConnectors() {
+		migrator, err := migrations.NewMigrator(c.connector.DB, c.dialect)
+		if err != nil {
+			return fmt.Errorf("fail to build migrator of %s %s: %w", c.connectorType, c.key, err)
+		}
+
+		count, err := migrator.Down(context.Background(), steps)
+		if err != nil {
+			return fmt.Errorf("fail to migrate down %s %s: %w", c.connectorType, c.key, err)
+		}
+		log.Info(c.connectorType+" "+c.key+" is migrated down", zap.Int("reverted", count))
+	}
+
+	return nil
+}
+
+// MigrationVersions returns the current schema version of each SQL connector, keyed by connector type and name.
+func MigrationVersions() (map[string]int64, error) {
+	versions := make(map[string]int64)
+	for _, c := range allSQLConnectors() {
+		migrator, err := migrations.NewMigrator(c.connector.DB, c.dialect)
+		if err != nil {
+			return nil, fmt.Errorf("fail to build migrator of %s %s: %w", c.connectorType, c.key, err)
+		}
+
+		version, err := migrator.Version(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("fail to get schema version of %s %s: %w", c.connectorType, c.key, err)
+		}
+		versions[c.connectorType+" "+c.key] = version
+	}
+
+	return versions, nil
+}
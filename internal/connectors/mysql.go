@@ -0,0 +1,99 @@
+package connectors
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Aloe-Corporation/sqldb"
+	"github.com/go-sql-driver/mysql"
+	"go.uber.org/zap"
+)
+
+const (
+	TypeConnectorMySQL = "MySQL"
+
+	// DefaultDriverMySQL is the driver used when a MySQL connector doesn't set one.
+	DefaultDriverMySQL = "mysql"
+)
+
+var MySQL = make(map[string]*sqldb.Connector)
+
+func initAllConnectorMySQL() error {
+	for key, config := range Config.MySQL {
+		if err := initConnectorMySQL(key, config); err != nil {
+			return fmt.Errorf("fail to init all MySQL connectors: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// initConnectorMySQL init a MySQL or MariaDB connector in MySQL map.
+func initConnectorMySQL(key string, config sqldb.Conf) error {
+	var err error
+
+	if config.Driver == "" {
+		config.Driver = DefaultDriverMySQL
+	}
+
+	config.DSN, err = normalizeMySQLDSN(config.DSN)
+	if err != nil {
+		return fmt.Errorf("fail to init MySQL connector %s: %w", key, err)
+	}
+
+	log.Info("Init MySQL connector " + key + "...")
+	connector, err := sqldb.FactoryConnector(config)
+	if err != nil {
+		return fmt.Errorf("fail to init MySQL connector %s: %w", key, err)
+	}
+
+	log.Info("Try connection MySQL " + key + "...")
+	err = connector.TryConnection(10)
+	if err != nil {
+		return fmt.Errorf("fail to ping MySQL %s: %w", key, err)
+	}
+
+	log.Info("MySQL connector " + key + " is ready to use")
+	MySQL[key] = connector
+
+	return err
+}
+
+// normalizeMySQLDSN forces the DSN options the DAOs rely on:
+// dates are scanned as time.Time in UTC, and UPDATE reports the matched rows instead of the changed ones.
+func normalizeMySQLDSN(dsn string) (string, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("invalid DSN: %w", err)
+	}
+
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.ClientFoundRows = true
+
+	return cfg.FormatDSN(), nil
+}
+
+func closeMySQL() {
+	for key, connector := range MySQL {
+		err := connector.Close()
+		if err != nil {
+			log.Error("fail to disconnect MySQL connector",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func GetConnectorMySQL(connectorName string) (*sqldb.Connector, error) {
+	connector, exist := MySQL[connectorName]
+	if !exist {
+		return nil, &ConnectorNotFoundError{
+			ConnectorType: TypeConnectorMySQL,
+			ConnectorName: connectorName,
+		}
+	}
+
+	return connector, nil
+}
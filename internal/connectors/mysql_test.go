@@ -0,0 +1,30 @@
+package connectors
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestNormalizeMySQLDSN(t *testing.T) {
+	dsn, err := normalizeMySQLDSN("todolist:secret@tcp(db:3306)/todolist?parseTime=false&loc=Local&charset=utf8mb4")
+	if err != nil {
+		t.Fatalf("normalizeMySQLDSN() error = %v", err)
+	}
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("mysql.ParseDSN(%q) error = %v", dsn, err)
+	}
+	if !cfg.ParseTime || cfg.Loc != time.UTC || !cfg.ClientFoundRows {
+		t.Errorf("normalizeMySQLDSN() = %q, want parseTime, loc=UTC and clientFoundRows", dsn)
+	}
+	if cfg.User != "todolist" || cfg.Passwd != "secret" || cfg.Addr != "db:3306" || cfg.DBName != "todolist" || cfg.Params["charset"] != "utf8mb4" {
+		t.Errorf("normalizeMySQLDSN() = %q, want the other options of the DSN kept", dsn)
+	}
+
+	if _, err := normalizeMySQLDSN("todolist@db/todolist"); err == nil {
+		t.Error("normalizeMySQLDSN() of an invalid DSN error = nil, want an error")
+	}
+}
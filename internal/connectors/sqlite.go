@@ -0,0 +1,81 @@
+package connectors
+
+import (
+	"fmt"
+
+	"github.com/Aloe-Corporation/sqldb"
+	_ "github.com/mattn/go-sqlite3" // sqlite3
+	"go.uber.org/zap"
+)
+
+const (
+	TypeConnectorSQLite = "SQLite"
+
+	// DefaultDriverSQLite is the driver used when a SQLite connector doesn't set one.
+	DefaultDriverSQLite = "sqlite3"
+)
+
+var SQLite = make(map[string]*sqldb.Connector)
+
+func initAllConnectorSQLite() error {
+	for key, config := range Config.SQLite {
+		if err := initConnectorSQLite(key, config); err != nil {
+			return fmt.Errorf("fail to init all SQLite connectors: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// initConnectorSQLite init a SQLite connector in SQLite map, the DSN is the path of the database file.
+func initConnectorSQLite(key string, config sqldb.Conf) error {
+	var err error
+
+	if config.Driver == "" {
+		config.Driver = DefaultDriverSQLite
+	}
+
+	log.Info("Init SQLite connector " + key + "...")
+	connector, err := sqldb.FactoryConnector(config)
+	if err != nil {
+		return fmt.Errorf("fail to init SQLite connector %s: %w", key, err)
+	}
+
+	// SQLite allows a single writer, serialize the accesses instead of failing on a locked database.
+	connector.SetMaxOpenConns(1)
+
+	log.Info("Try connection SQLite " + key + "...")
+	err = connector.TryConnection(10)
+	if err != nil {
+		return fmt.Errorf("fail to ping SQLite %s: %w", key, err)
+	}
+
+	log.Info("SQLite connector " + key + " is ready to use")
+	SQLite[key] = connector
+
+	return err
+}
+
+func closeSQLite() {
+	for key, connector := range SQLite {
+		err := connector.Close()
+		if err != nil {
+			log.Error("fail to disconnect SQLite connector",
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+func GetConnectorSQLite(connectorName string) (*sqldb.Connector, error) {
+	connector, exist := SQLite[connectorName]
+	if !exist {
+		return nil, &ConnectorNotFoundError{
+			ConnectorType: TypeConnectorSQLite,
+			ConnectorName: connectorName,
+		}
+	}
+
+	return connector, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/Aloe-Corporation/logs"
+	"github.com/CamilleLange/todolist/internal/repositories"
 )
 
 var (
@@ -15,24 +16,114 @@ var (
 
 	// TaskInstance is an instance of ITaskController.
 	TaskInstance ITaskController
+	// ListInstance is an instance of IListController.
+	ListInstance IListController
+	// TagInstance is an instance of ITagController.
+	TagInstance ITagController
+	// WebhookInstance is an instance of IWebhookController.
+	WebhookInstance IWebhookController
+
+	// purger removes the expired tasks of the trash of TaskInstance.
+	purger *periodicJob
+	// reminder fires the reminders of the tasks of TaskInstance.
+	reminder *periodicJob
+	// dispatcher sends the deliveries of the webhooks of WebhookInstance.
+	dispatcher *periodicJob
+	// changeFeed publishes the changes of the tasks of TaskInstance.
+	changeFeed *taskEventBus
+	// enqueuer queues the webhook deliveries of the changes of changeFeed.
+	enqueuer *changeWorker
 )
 
 // Conf for the controllers package
 type Conf struct {
-	TaskController TaskControllerConf `mapstructure:"task_controller"`
+	TaskController    TaskControllerConf    `mapstructure:"task_controller"`
+	ListController    ListControllerConf    `mapstructure:"list_controller"`
+	TagController     TagControllerConf     `mapstructure:"tag_controller"`
+	WebhookController WebhookControllerConf `mapstructure:"webhook_controller"`
 }
 
 // Init the controllerss
 func Init() error {
 	var err error
 
+	// The configurations written before the lists don't have a ListDAO.
+	if Config.ListController.ListDAO.Type == "" {
+		Config.ListController.ListDAO.Type = repositories.TypeListVoidDAO
+	}
+	// Nor a WebhookDAO.
+	if Config.WebhookController.WebhookDAO.Type == "" {
+		Config.WebhookController.WebhookDAO.Type = repositories.TypeWebhookVoidDAO
+	}
+	// Nor a TaskGrantDAO, the grants were stored by the TaskDAO.
+	if Config.TaskController.TaskGrantDAO.Type == "" {
+		Config.TaskController.TaskGrantDAO = repositories.TaskGrantDAOOptions(Config.TaskController.TaskDAO)
+	}
+	// Nor a TaskDependencyDAO, the dependencies were stored by the TaskDAO.
+	if Config.TaskController.TaskDependencyDAO.Type == "" {
+		Config.TaskController.TaskDependencyDAO = repositories.TaskDependencyDAOOptions(Config.TaskController.TaskDAO)
+	}
+	// Nor a TagDAO, the tags were stored by the TaskDAO.
+	if Config.TagController.TagDAO.Type == "" {
+		Config.TagController.TagDAO = repositories.TagDAOOptions(Config.TaskController.TaskDAO)
+	}
+
 	log.Info("init TaskController...")
-	TaskInstance, err = factoryTaskController(Config.TaskController)
+	taskController, err := factoryTaskController(Config.TaskController, Config.ListController.ListDAO, Config.TagController.TagDAO)
 	if err != nil {
 		return fmt.Errorf("fail to build TaskController: %w", err)
 	}
+	changeFeed = taskController.events
+	TaskInstance = &TaskAuthorizer{
+		ITaskController: taskController,
+		daoTask:         taskController.daoTask,
+	}
 	log.Info("TaskController is ready to use")
 
+	log.Info("init ListController...")
+	ListInstance, err = factoryListController(Config.ListController, Config.TaskController.TaskDAO)
+	if err != nil {
+		return fmt.Errorf("fail to build ListController: %w", err)
+	}
+	log.Info("ListController is ready to use")
+
+	log.Info("init TagController...")
+	TagInstance, err = factoryTagController(Config.TagController)
+	if err != nil {
+		return fmt.Errorf("fail to build TagController: %w", err)
+	}
+	log.Info("TagController is ready to use")
+
+	log.Info("init WebhookController...")
+	webhookController, err := factoryWebhookController(Config.WebhookController, Config.TaskController.TaskDAO)
+	if err != nil {
+		return fmt.Errorf("fail to build WebhookController: %w", err)
+	}
+	// The deliveries of each change of the tasks are queued by a worker, the writes of the tasks don't wait for them.
+	enqueuer = startChangeWorker(webhookController.delivery.QueueSize, webhookController.enqueue)
+	changeFeed.listen(enqueuer.push)
+	WebhookInstance = webhookController
+	log.Info("WebhookController is ready to use")
+
+	purger = startTrashPurger(TaskInstance, Config.TaskController.Trash)
+	reminder = startReminderScheduler(TaskInstance, Config.TaskController.Reminders)
+	dispatcher = startWebhookDispatcher(WebhookInstance, Config.WebhookController.Delivery)
+
 	log.Info("controllers package ready")
 	return err
 }
+
+// Close stops the background jobs of the controllers and ends the change feed,
+// once the deliveries of the changes already published are queued.
+func Close() {
+	purger.stop()
+	purger = nil
+	reminder.stop()
+	reminder = nil
+	dispatcher.stop()
+	dispatcher = nil
+	changeFeed.close()
+	changeFeed = nil
+	enqueuer.stop()
+	enqueuer = nil
+}
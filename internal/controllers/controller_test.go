@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/Aloe-Corporation/logs"
+)
+
+func TestMain(m *testing.M) {
+	logs.Config = logs.Conf{Level: logs.ERROR, Output: []string{os.DevNull}}
+	if err := logs.Init(); err != nil {
+		fmt.Println(fmt.Errorf("fail to init logs: %w", err))
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownStatus     *UnknownStatusError
+	ErrIllegalTransition *IllegalTransitionError
+	ErrInvalidWorkflow   *InvalidWorkflowError
+	ErrUnknownList       *UnknownListError
+	ErrListNotEmpty      *ListNotEmptyError
+	ErrForbidden         *ForbiddenError
+	ErrInvalidGrant      *InvalidGrantError
+	ErrInvalidRecurrence *InvalidRecurrenceError
+	ErrUnknownParent     *UnknownParentError
+	ErrParentCycle       *ParentCycleError
+	ErrUnknownBlocker    *UnknownBlockerError
+	ErrBlockedTask       *BlockedTaskError
+)
+
+type UnknownStatusError struct {
+	Status string
+	Known  []model.TaskStatus
+}
+
+func (e *UnknownStatusError) Error() string {
+	return fmt.Sprintf("unknown status %q, expected one of %v", e.Status, e.Known)
+}
+
+type IllegalTransitionError struct {
+	From model.TaskStatus
+	To   model.TaskStatus
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("a task can't move from status %v to %v", e.From, e.To)
+}
+
+type InvalidWorkflowError struct {
+	Reason string
+}
+
+func (e *InvalidWorkflowError) Error() string {
+	return "invalid status workflow: " + e.Reason
+}
+
+// UnknownListError is returned when a task references a list that doesn't exist or isn't visible by the caller.
+type UnknownListError struct {
+	UUID uuid.UUID
+}
+
+func (e *UnknownListError) Error() string {
+	return fmt.Sprintf("unknown list %v", e.UUID)
+}
+
+// ListNotEmptyError is returned when a list with tasks, live or in the trash, is deleted.
+type ListNotEmptyError struct {
+	UUID uuid.UUID
+}
+
+func (e *ListNotEmptyError) Error() string {
+	return fmt.Sprintf("the list %v still has tasks, in the trash too", e.UUID)
+}
+
+// ForbiddenError is returned when the role of the caller on a task doesn't allow the action.
+type ForbiddenError struct {
+	Role     model.TaskRole
+	Required model.TaskRole
+}
+
+func (e *ForbiddenError) Error() string {
+	return fmt.Sprintf("the role %s is required, the caller is %s", e.Required, e.Role)
+}
+
+// InvalidGrantError is returned when a role is given to the owner of the task.
+type InvalidGrantError struct {
+	PrincipalID string
+}
+
+func (e *InvalidGrantError) Error() string {
+	return fmt.Sprintf("%q owns the task, it can't be given a role", e.PrincipalID)
+}
+
+// InvalidRecurrenceError is returned when the recurrence rule of a task isn't a supported RRULE.
+type InvalidRecurrenceError struct {
+	Rule   string
+	Reason string
+}
+
+func (e *InvalidRecurrenceError) Error() string {
+	return fmt.Sprintf("invalid recurrence rule %q: %s", e.Rule, e.Reason)
+}
+
+// UnknownParentError is returned when a task references a parent task that doesn't exist or isn't visible by the caller.
+type UnknownParentError struct {
+	UUID uuid.UUID
+}
+
+func (e *UnknownParentError) Error() string {
+	return fmt.Sprintf("unknown parent task %v", e.UUID)
+}
+
+// ParentCycleError is returned when a task is moved under itself or one of its subtasks.
+type ParentCycleError struct {
+	UUID       uuid.UUID
+	ParentUUID uuid.UUID
+}
+
+func (e *ParentCycleError) Error() string {
+	return fmt.Sprintf("the task %v can't be a subtask of %v, its own subtask", e.UUID, e.ParentUUID)
+}
+
+// UnknownBlockerError is returned when a task is blocked by a task that doesn't exist or isn't visible by the caller.
+type UnknownBlockerError struct {
+	UUID uuid.UUID
+}
+
+func (e *UnknownBlockerError) Error() string {
+	return fmt.Sprintf("unknown blocker task %v", e.UUID)
+}
+
+// BlockedTaskError is returned when a task is closed while some of its blockers are open.
+type BlockedTaskError struct {
+	UUID     uuid.UUID
+	Blockers []uuid.UUID
+}
+
+func (e *BlockedTaskError) Error() string {
+	return fmt.Sprintf("the task %v is blocked by the open tasks %v", e.UUID, e.Blockers)
+}
+
+// InvalidTagError is returned when the name of a tag isn't valid.
+type InvalidTagError struct {
+	Name   string
+	Reason string
+}
+
+func (e *InvalidTagError) Error() string {
+	return fmt.Sprintf("invalid tag name %q: %s", e.Name, e.Reason)
+}
+
+// UnknownTagError is returned when a tag that doesn't exist or isn't owned by the caller is put on a task.
+type UnknownTagError struct {
+	UUID uuid.UUID
+}
+
+func (e *UnknownTagError) Error() string {
+	return fmt.Sprintf("unknown tag %v", e.UUID)
+}
+
+// UnknownLanguageError is returned when a search or the configuration uses a language the full-text search doesn't know.
+type UnknownLanguageError struct {
+	Language string
+	Known    []string
+}
+
+func (e *UnknownLanguageError) Error() string {
+	return fmt.Sprintf("unknown search language %q, expected one of %v", e.Language, e.Known)
+}
+
+// InvalidWebhookError is returned when the URL of a webhook can't receive the deliveries.
+type InvalidWebhookError struct {
+	URL    string
+	Reason string
+}
+
+func (e *InvalidWebhookError) Error() string {
+	return fmt.Sprintf("invalid webhook url %q: %s", e.URL, e.Reason)
+}
+
+// DeliveryPendingError is returned when a delivery waiting for its next attempt is retried.
+type DeliveryPendingError struct {
+	UUID uuid.UUID
+}
+
+func (e *DeliveryPendingError) Error() string {
+	return fmt.Sprintf("the delivery %v is still pending", e.UUID)
+}
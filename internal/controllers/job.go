@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"go.uber.org/zap"
+)
+
+// periodicJob runs a function at regular intervals until it is stopped.
+type periodicJob struct {
+	cancel context.CancelFunc
+	done   sync.WaitGroup
+}
+
+// startPeriodicJob runs run right away then every interval.
+func startPeriodicJob(interval time.Duration, run func(ctx context.Context)) *periodicJob {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &periodicJob{cancel: cancel}
+	job.done.Add(1)
+
+	go func() {
+		defer job.done.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			run(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return job
+}
+
+// stop cancels the job and waits for its end.
+func (j *periodicJob) stop() {
+	if j == nil {
+		return
+	}
+
+	j.cancel()
+	j.done.Wait()
+}
+
+// changeWorker handles the changes pushed to it one after the other, in a goroutine of its own.
+type changeWorker struct {
+	mu      sync.RWMutex
+	changes chan *model.TaskChange
+	stopped bool
+	done    sync.WaitGroup
+}
+
+// startChangeWorker calls handle with each pushed change, up to size changes wait for it.
+// The changes are handled without the context of their publisher, which may be gone by then.
+func startChangeWorker(size int, handle func(ctx context.Context, change *model.TaskChange)) *changeWorker {
+	worker := &changeWorker{changes: make(chan *model.TaskChange, size)}
+	worker.done.Add(1)
+
+	go func() {
+		defer worker.done.Done()
+
+		for change := range worker.changes {
+			handle(context.Background(), change)
+		}
+	}()
+
+	return worker
+}
+
+// push queues the change, it only waits while the queue is full. The changes pushed once the worker is stopped are dropped.
+func (w *changeWorker) push(_ context.Context, change *model.TaskChange) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.stopped {
+		log.Error("the change is dropped, its worker is stopped", zap.Any("task_uuid", change.Task.UUID), zap.String("type", string(change.Type)))
+		return
+	}
+	w.changes <- change
+}
+
+// stop waits for the queued changes to be handled then ends the worker.
+func (w *changeWorker) stop() {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.changes)
+	}
+	w.mu.Unlock()
+	w.done.Wait()
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestChangeWorker(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		handled []uint64
+	)
+	release := make(chan struct{})
+	worker := startChangeWorker(3, func(ctx context.Context, change *model.TaskChange) {
+		<-release
+		if ctx.Err() != nil {
+			t.Errorf("handled change %d with a done context", change.ID)
+		}
+		mu.Lock()
+		handled = append(handled, change.ID)
+		mu.Unlock()
+	})
+	change := func(id uint64) *model.TaskChange {
+		return &model.TaskChange{ID: id, Type: model.TaskEventCreated, Task: &model.Task{UUID: uuid.New()}}
+	}
+
+	// The publisher doesn't wait for a slow handler until the queue is full, nor for its own context.
+	ctx, cancel := context.WithCancel(context.Background())
+	pushed := make(chan struct{})
+	go func() {
+		defer close(pushed)
+		for id := uint64(1); id <= 4; id++ {
+			worker.push(ctx, change(id))
+		}
+		cancel()
+	}()
+	select {
+	case <-pushed:
+	case <-time.After(time.Second):
+		t.Fatal("push() waits for the handler before the queue is full")
+	}
+
+	full := make(chan struct{})
+	go func() {
+		defer close(full)
+		worker.push(context.Background(), change(5))
+	}()
+	select {
+	case <-full:
+		t.Fatal("push() doesn't wait once the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// stop waits for the queued changes, handled in order, and the later changes are dropped.
+	close(release)
+	<-full
+	worker.stop()
+	worker.push(context.Background(), change(6))
+	worker.stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []uint64{1, 2, 3, 4, 5}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled = %v, want %v", handled, want)
+	}
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+)
+
+var (
+	_ IListController = (*ListController)(nil)
+)
+
+// IListController is an interface for ListController and ListControllerMocking.
+type IListController interface {
+	Create(ctx context.Context, listToCreate *model.ListCreateDTO) (*model.ListPublicDTO, error)
+	Get(ctx context.Context, listUUID uuid.UUID) (*model.ListPublicDTO, error)
+	GetAll(ctx context.Context) (*model.ListsPublicDTO, error)
+	Update(ctx context.Context, listUUID uuid.UUID, patch *model.ListPatch) error
+	Delete(ctx context.Context, listUUID uuid.UUID) error
+}
+
+// ListControllerConf is a configuration structure for ListController.
+type ListControllerConf struct {
+	// ListDAO must use the database of the TaskDAO, the lists are not implemented without it.
+	ListDAO repositories.DAOFactoryOptions `mapstructure:"list_dao"`
+}
+
+// ListController is an controllers to manage business logic of List.
+type ListController struct {
+	daoList repositories.IListDAO
+	daoTask repositories.ITaskDAO
+}
+
+func (c *ListController) Create(ctx context.Context, listToCreate *model.ListCreateDTO) (*model.ListPublicDTO, error) {
+	list, err := c.daoList.Create(ctx, listToCreate)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create list: %w", err)
+	}
+
+	return model.FactoryListPublicDTO(list), nil
+}
+
+func (c *ListController) Get(ctx context.Context, listUUID uuid.UUID) (*model.ListPublicDTO, error) {
+	list, err := c.daoList.ReadByUUID(ctx, listUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get list: %w", err)
+	}
+
+	return model.FactoryListPublicDTO(list), nil
+}
+
+func (c *ListController) GetAll(ctx context.Context) (*model.ListsPublicDTO, error) {
+	lists, err := c.daoList.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get lists: %w", err)
+	}
+
+	publicLists := []model.ListPublicDTO{}
+	for _, list := range lists {
+		publicLists = append(publicLists, *model.FactoryListPublicDTO(list))
+	}
+
+	return &model.ListsPublicDTO{
+		Lists: publicLists,
+	}, nil
+}
+
+func (c *ListController) Update(ctx context.Context, listUUID uuid.UUID, patch *model.ListPatch) error {
+	if err := c.daoList.Update(ctx, listUUID, patch); err != nil {
+		return fmt.Errorf("fail to update list: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the list, it fails with a ListNotEmptyError while tasks are in the list, in the trash too.
+// The tasks of every owner are counted, an admin may have put its own tasks in the list.
+func (c *ListController) Delete(ctx context.Context, listUUID uuid.UUID) error {
+	if _, err := c.daoList.ReadByUUID(ctx, listUUID); err != nil {
+		return fmt.Errorf("fail to delete list: %w", err)
+	}
+
+	for _, deleted := range []bool{false, true} {
+		filter := &model.TaskFilterDTO{ListUUID: &listUUID, Deleted: deleted, Limit: 1}
+		filter.SetDefaults()
+
+		tasks, _, err := c.daoTask.ReadAll(systemContext(ctx), filter)
+		if err != nil {
+			return fmt.Errorf("fail to delete list: %w", err)
+		}
+		if len(tasks) > 0 {
+			return fmt.Errorf("fail to delete list: %w", &ListNotEmptyError{UUID: listUUID})
+		}
+	}
+
+	if err := c.daoList.Delete(ctx, listUUID); err != nil {
+		return fmt.Errorf("fail to delete list: %w", err)
+	}
+
+	return nil
+}
+
+// factoryListController is use to build an ListController according to the conf, the tasks are read with taskDAO.
+func factoryListController(c ListControllerConf, taskDAO repositories.DAOFactoryOptions) (*ListController, error) {
+	log.Info("loading ListDAO...")
+	daoList, err := repositories.ProxyFactoryListDAO(c.ListDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load ListDAO: %w", err)
+	}
+	log.Info("ListDAO loaded")
+
+	daoTask, err := repositories.ProxyFactoryTaskDAO(taskDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load TaskDAO: %w", err)
+	}
+
+	controllers := &ListController{
+		daoList: daoList,
+		daoTask: daoTask,
+	}
+	return controllers, nil
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// RecurrenceConf configures the recurrence rules accepted on the tasks.
+type RecurrenceConf struct {
+	// AllowHourly accepts the HOURLY rules, an occurrence is then spawned every hour the task is closed.
+	AllowHourly bool `mapstructure:"allow_hourly"`
+}
+
+// normalizeRecurrence parses an RFC 5545 RRULE, with or without its "RRULE:" prefix, and returns it
+// in its canonical form. The rule starts at the due date of the task, it can't have a DTSTART.
+// SECONDLY and MINUTELY rules are rejected, and HOURLY ones unless the configuration allows them.
+func normalizeRecurrence(rule string, conf RecurrenceConf) (string, error) {
+	option, err := parseRecurrence(rule)
+	if err != nil {
+		return "", err
+	}
+
+	switch option.Freq {
+	case rrule.SECONDLY, rrule.MINUTELY:
+		return "", &InvalidRecurrenceError{Rule: rule, Reason: "the frequency can't be shorter than HOURLY"}
+	case rrule.HOURLY:
+		if !conf.AllowHourly {
+			return "", &InvalidRecurrenceError{Rule: rule, Reason: "HOURLY isn't allowed, the frequency can't be shorter than DAILY"}
+		}
+	}
+
+	return option.RRuleString(), nil
+}
+
+// nextOccurrence returns the due date of the occurrence following the one due at due, and the rule
+// of the next occurrence. The dates already passed are skipped, ok is false once the series is over.
+// A COUNT is the number of occurrences left in the series, the rule of the next occurrence counts one less.
+func nextOccurrence(rule string, due, now time.Time) (next time.Time, nextRule string, ok bool) {
+	option, err := parseRecurrence(rule)
+	if err != nil || option.Count == 1 {
+		return time.Time{}, "", false
+	}
+
+	count := option.Count
+	option.Count = 0
+	option.Dtstart = due
+	recurrence, err := rrule.NewRRule(*option)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+
+	from := due
+	if now.After(from) {
+		from = now
+	}
+	next = recurrence.After(from, false)
+	if next.IsZero() {
+		return time.Time{}, "", false
+	}
+
+	if count > 1 {
+		option.Count = count - 1
+	}
+	option.Dtstart = time.Time{}
+	return next, option.RRuleString(), true
+}
+
+// parseRecurrence parses a rule accepted by normalizeRecurrence.
+func parseRecurrence(rule string) (*rrule.ROption, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(rule))
+	if strings.Contains(trimmed, "\n") {
+		return nil, &InvalidRecurrenceError{Rule: rule, Reason: "a single RRULE line is expected"}
+	}
+
+	option, err := rrule.StrToROption(trimmed)
+	if err != nil {
+		return nil, &InvalidRecurrenceError{Rule: rule, Reason: err.Error()}
+	}
+	if !option.Dtstart.IsZero() {
+		return nil, &InvalidRecurrenceError{Rule: rule, Reason: "DTSTART isn't supported, the rule starts at the due date"}
+	}
+	if option.Count < 0 || option.Interval < 0 {
+		return nil, &InvalidRecurrenceError{Rule: rule, Reason: "COUNT and INTERVAL must be positive"}
+	}
+	if _, err := rrule.NewRRule(*option); err != nil {
+		return nil, &InvalidRecurrenceError{Rule: rule, Reason: err.Error()}
+	}
+
+	return option, nil
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizeRecurrence(t *testing.T) {
+	tests := []struct {
+		rule  string
+		conf  RecurrenceConf
+		want  string
+		valid bool
+	}{
+		{rule: "FREQ=WEEKLY;BYDAY=MO", want: "FREQ=WEEKLY;BYDAY=MO", valid: true},
+		{rule: " rrule:freq=monthly;bymonthday=1;count=12 ", want: "FREQ=MONTHLY;COUNT=12;BYMONTHDAY=1", valid: true},
+		{rule: "FREQ=DAILY;UNTIL=20301231T000000Z", want: "FREQ=DAILY;UNTIL=20301231T000000Z", valid: true},
+		{rule: "FREQ=YEARLY;INTERVAL=2", want: "FREQ=YEARLY;INTERVAL=2", valid: true},
+		{rule: "FREQ=HOURLY;INTERVAL=4", conf: RecurrenceConf{AllowHourly: true}, want: "FREQ=HOURLY;INTERVAL=4", valid: true},
+		{rule: "FREQ=HOURLY;INTERVAL=4"},
+		{rule: "FREQ=MINUTELY", conf: RecurrenceConf{AllowHourly: true}},
+		{rule: "FREQ=SECONDLY", conf: RecurrenceConf{AllowHourly: true}},
+		{rule: "FREQ=NEVER"},
+		{rule: "BYDAY=MO"},
+		{rule: "DTSTART:20240101T000000Z\nRRULE:FREQ=DAILY"},
+		{rule: "FREQ=DAILY;DTSTART=20240101T000000Z"},
+		{rule: "FREQ=DAILY;COUNT=-1"},
+		{rule: "FREQ=DAILY;INTERVAL=-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			got, err := normalizeRecurrence(tt.rule, tt.conf)
+			if !tt.valid {
+				var errInvalidRecurrence *InvalidRecurrenceError
+				if !errors.As(err, &errInvalidRecurrence) || errInvalidRecurrence.Rule != tt.rule {
+					t.Errorf("normalizeRecurrence() = %q, %v, want an InvalidRecurrenceError", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("normalizeRecurrence() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	due := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC) // a Monday
+	tests := []struct {
+		name     string
+		rule     string
+		now      time.Time
+		want     time.Time
+		wantRule string
+		ok       bool
+	}{
+		{name: "next date", rule: "FREQ=WEEKLY;BYDAY=MO", now: due, want: due.AddDate(0, 0, 7), wantRule: "FREQ=WEEKLY;BYDAY=MO", ok: true},
+		{name: "past dates skipped", rule: "FREQ=DAILY", now: due.AddDate(0, 0, 3).Add(time.Hour), want: due.AddDate(0, 0, 4), wantRule: "FREQ=DAILY", ok: true},
+		{name: "count decremented", rule: "FREQ=DAILY;COUNT=3", now: due, want: due.AddDate(0, 0, 1), wantRule: "FREQ=DAILY;COUNT=2", ok: true},
+		{name: "last occurrence", rule: "FREQ=DAILY;COUNT=2", now: due, want: due.AddDate(0, 0, 1), wantRule: "FREQ=DAILY;COUNT=1", ok: true},
+		{name: "count exhausted", rule: "FREQ=DAILY;COUNT=1", now: due},
+		{name: "until", rule: "FREQ=DAILY;UNTIL=20240103T090000Z", now: due, want: due.AddDate(0, 0, 1), wantRule: "FREQ=DAILY;UNTIL=20240103T090000Z", ok: true},
+		{name: "until exhausted", rule: "FREQ=DAILY;UNTIL=20240103T090000Z", now: due.AddDate(0, 0, 2)},
+		{name: "until passed", rule: "FREQ=DAILY;UNTIL=20231231T000000Z", now: due},
+		{name: "invalid rule", rule: "FREQ=NEVER", now: due},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, rule, ok := nextOccurrence(tt.rule, due, tt.now)
+			if ok != tt.ok || !next.Equal(tt.want) || rule != tt.wantRule {
+				t.Errorf("nextOccurrence() = %v, %q, %v, want %v, %q, %v", next, rule, ok, tt.want, tt.wantRule, tt.ok)
+			}
+		})
+	}
+}
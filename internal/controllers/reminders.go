@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultReminderCheckInterval is the number of seconds between two checks of the reminders when none is configured.
+const DefaultReminderCheckInterval = 60
+
+// RemindersConf configures the job firing the reminders of the tasks.
+type RemindersConf struct {
+	// Disabled stops the reminders from firing, e.g. on the replicas of the API.
+	Disabled bool `mapstructure:"disabled"`
+	// CheckInterval is the number of seconds between two checks, a reminder fires at most this late.
+	CheckInterval int `mapstructure:"check_interval"`
+}
+
+// startReminderScheduler starts the job running SendReminders of a controller at regular intervals,
+// it returns nil if the reminders are disabled.
+func startReminderScheduler(ctl ITaskController, conf RemindersConf) *periodicJob {
+	if conf.Disabled {
+		return nil
+	}
+
+	interval := conf.CheckInterval
+	if interval <= 0 {
+		interval = DefaultReminderCheckInterval
+	}
+
+	return startPeriodicJob(time.Duration(interval)*time.Second, func(ctx context.Context) {
+		count, err := ctl.SendReminders(ctx)
+		if err != nil {
+			log.Error("fail to send the reminders", zap.Error(err))
+		} else if count > 0 {
+			log.Info("reminders sent", zap.Int64("tasks", count))
+		}
+	})
+}
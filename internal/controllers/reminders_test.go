@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// remindTaskDAO records the dates given to Remind and returns its tasks.
+type remindTaskDAO struct {
+	repositories.ITaskDAO
+	remindBefore []time.Time
+	tasks        []*model.Task
+	err          error
+}
+
+func (dao *remindTaskDAO) Remind(ctx context.Context, remindBefore time.Time) ([]*model.Task, error) {
+	dao.remindBefore = append(dao.remindBefore, remindBefore)
+	return dao.tasks, dao.err
+}
+
+func TestTaskControllerSendReminders(t *testing.T) {
+	ctx := context.Background()
+	remindAt := time.Now().Add(-time.Minute)
+	dao := &remindTaskDAO{tasks: []*model.Task{
+		{UUID: uuid.New(), WhatToDo: "call the bank", RemindAt: &remindAt},
+		{UUID: uuid.New(), WhatToDo: "water the plants", RemindAt: &remindAt},
+	}}
+	ctl := &TaskController{daoTask: dao}
+
+	before := time.Now()
+	count, err := ctl.SendReminders(ctx)
+	if err != nil || count != 2 {
+		t.Fatalf("SendReminders() = %d, %v, want 2", count, err)
+	}
+	if remindBefore := dao.remindBefore[0]; remindBefore.Before(before) || remindBefore.After(time.Now()) {
+		t.Errorf("Remind() remindBefore = %v, want now", remindBefore)
+	}
+
+	// The reminders fired before a failure are counted.
+	dao.tasks = dao.tasks[:1]
+	dao.err = errors.New("boom")
+	if count, err := ctl.SendReminders(ctx); !errors.Is(err, dao.err) || count != 1 {
+		t.Errorf("SendReminders() = %d, %v, want 1 and the error of the DAO", count, err)
+	}
+}
+
+// remindTaskController counts the calls to SendReminders.
+type remindTaskController struct {
+	ITaskController
+	reminded chan struct{}
+}
+
+func (c *remindTaskController) SendReminders(ctx context.Context) (int64, error) {
+	c.reminded <- struct{}{}
+	return 0, nil
+}
+
+func TestStartReminderScheduler(t *testing.T) {
+	ctl := &remindTaskController{reminded: make(chan struct{}, 1)}
+
+	if job := startReminderScheduler(ctl, RemindersConf{Disabled: true, CheckInterval: 1}); job != nil {
+		job.stop()
+		t.Fatal("startReminderScheduler() of disabled reminders started a job")
+	}
+
+	job := startReminderScheduler(ctl, RemindersConf{CheckInterval: 1})
+	defer job.stop()
+
+	// The reminders are checked right away, then every interval.
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ctl.reminded:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("startReminderScheduler() checked the reminders %d times, want 2", i)
+		}
+	}
+}
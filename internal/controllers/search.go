@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+// SearchConf configures the full-text search of the tasks.
+type SearchConf struct {
+	// DefaultLanguage is the language of the searches without language, model.TaskSearchSimple if empty.
+	DefaultLanguage string `mapstructure:"default_language"`
+}
+
+// Search returns the live tasks whose description matches the full-text query, best ranked first.
+// The language defaults to the configured one and the limit to model.DefaultTaskSearchLimit.
+func (c *TaskController) Search(ctx context.Context, search *model.TaskSearchDTO) (*model.TaskSearchPublicDTO, error) {
+	if search.Language == "" {
+		search.Language = c.search.DefaultLanguage
+	}
+	if !slices.Contains(model.TaskSearchLanguages, search.Language) {
+		return nil, &UnknownLanguageError{Language: search.Language, Known: model.TaskSearchLanguages}
+	}
+	if search.Limit <= 0 {
+		search.Limit = model.DefaultTaskSearchLimit
+	}
+	search.Limit = min(search.Limit, model.MaxTaskSearchLimit)
+
+	results, err := c.daoTask.Search(ctx, search)
+	if err != nil {
+		return nil, fmt.Errorf("fail to search tasks: %w", err)
+	}
+
+	publicResults := []model.TaskSearchResultPublicDTO{}
+	for _, result := range results {
+		publicResults = append(publicResults, *model.FactoryTaskSearchResultPublicDTO(result))
+	}
+	return &model.TaskSearchPublicDTO{
+		Language: search.Language,
+		Results:  publicResults,
+	}, nil
+}
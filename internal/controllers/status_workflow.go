@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"slices"
+	"sort"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+// DefaultStatusWorkflowConf is the workflow used when none is configured.
+var DefaultStatusWorkflowConf = StatusWorkflowConf{
+	Initial: "todo",
+	Transitions: map[string][]string{
+		"todo":        {"in_progress", "blocked", "done", "archived"},
+		"in_progress": {"todo", "blocked", "done"},
+		"blocked":     {"todo", "in_progress"},
+		"done":        {"in_progress", "archived"},
+		"archived":    {},
+	},
+	Closed: []string{"done", "archived"},
+}
+
+// StatusWorkflowConf is a configuration structure for StatusWorkflow.
+// Each key of Transitions is a status, mapped to the statuses a task can move to from it.
+// The tasks with a Closed status are never overdue.
+type StatusWorkflowConf struct {
+	Initial     string              `mapstructure:"initial"`
+	Transitions map[string][]string `mapstructure:"transitions"`
+	Closed      []string            `mapstructure:"closed"`
+}
+
+// StatusWorkflow holds the known task statuses and the allowed transitions between them.
+type StatusWorkflow struct {
+	initial     model.TaskStatus
+	transitions map[model.TaskStatus]map[model.TaskStatus]struct{}
+	closed      []model.TaskStatus
+}
+
+// Initial returns the status of a newly created task.
+func (w *StatusWorkflow) Initial() model.TaskStatus {
+	return w.initial
+}
+
+// Closed returns the statuses of the finished tasks.
+func (w *StatusWorkflow) Closed() []model.TaskStatus {
+	return w.closed
+}
+
+// IsClosed reports whether the status is a status of the finished tasks.
+func (w *StatusWorkflow) IsClosed(status model.TaskStatus) bool {
+	return slices.Contains(w.closed, model.NormalizeTaskStatus(string(status)))
+}
+
+// Statuses returns the known statuses, sorted.
+func (w *StatusWorkflow) Statuses() []model.TaskStatus {
+	statuses := make([]model.TaskStatus, 0, len(w.transitions))
+	for status := range w.transitions {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+
+	return statuses
+}
+
+// Parse normalizes the status and checks that it belongs to the workflow.
+func (w *StatusWorkflow) Parse(status model.TaskStatus) (model.TaskStatus, error) {
+	normalized := model.NormalizeTaskStatus(string(status))
+	if _, known := w.transitions[normalized]; !known {
+		return "", &UnknownStatusError{Status: string(status), Known: w.Statuses()}
+	}
+
+	return normalized, nil
+}
+
+// CheckTransition returns an IllegalTransitionError if a task can't move from one status to the other.
+// Staying in the same status is always allowed, and a task with a status unknown to the workflow
+// (e.g. stored before the workflow was configured) can move to any status.
+func (w *StatusWorkflow) CheckTransition(from, to model.TaskStatus) error {
+	from = model.NormalizeTaskStatus(string(from))
+	if from == to {
+		return nil
+	}
+
+	targets, known := w.transitions[from]
+	if !known {
+		return nil
+	}
+
+	if _, allowed := targets[to]; !allowed {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+
+	return nil
+}
+
+// NewStatusWorkflow builds a StatusWorkflow from its configuration, the default workflow is used if c is empty.
+func NewStatusWorkflow(c StatusWorkflowConf) (*StatusWorkflow, error) {
+	if len(c.Transitions) == 0 {
+		c = DefaultStatusWorkflowConf
+	}
+
+	w := &StatusWorkflow{
+		initial:     model.NormalizeTaskStatus(c.Initial),
+		transitions: make(map[model.TaskStatus]map[model.TaskStatus]struct{}),
+	}
+
+	for from := range c.Transitions {
+		w.transitions[model.NormalizeTaskStatus(from)] = make(map[model.TaskStatus]struct{})
+	}
+
+	for from, targets := range c.Transitions {
+		for _, to := range targets {
+			target := model.NormalizeTaskStatus(to)
+			if _, known := w.transitions[target]; !known {
+				return nil, &InvalidWorkflowError{Reason: "transition from " + from + " to undeclared status " + to}
+			}
+			w.transitions[model.NormalizeTaskStatus(from)][target] = struct{}{}
+		}
+	}
+
+	if _, known := w.transitions[w.initial]; !known {
+		return nil, &InvalidWorkflowError{Reason: "undeclared initial status " + c.Initial}
+	}
+
+	for _, closed := range c.Closed {
+		status := model.NormalizeTaskStatus(closed)
+		if _, known := w.transitions[status]; !known {
+			return nil, &InvalidWorkflowError{Reason: "undeclared closed status " + closed}
+		}
+		w.closed = append(w.closed, status)
+	}
+
+	return w, nil
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+func TestNewStatusWorkflowDefault(t *testing.T) {
+	w, err := NewStatusWorkflow(StatusWorkflowConf{})
+	if err != nil {
+		t.Fatalf("NewStatusWorkflow() error = %v", err)
+	}
+
+	if w.Initial() != "todo" {
+		t.Errorf("Initial() = %q, want todo", w.Initial())
+	}
+	if want := []model.TaskStatus{"archived", "blocked", "done", "in_progress", "todo"}; !reflect.DeepEqual(w.Statuses(), want) {
+		t.Errorf("Statuses() = %v, want %v", w.Statuses(), want)
+	}
+	if want := []model.TaskStatus{"done", "archived"}; !reflect.DeepEqual(w.Closed(), want) {
+		t.Errorf("Closed() = %v, want %v", w.Closed(), want)
+	}
+}
+
+func TestNewStatusWorkflowInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		conf StatusWorkflowConf
+	}{
+		{
+			name: "undeclared target",
+			conf: StatusWorkflowConf{Initial: "open", Transitions: map[string][]string{"open": {"closed"}}},
+		},
+		{
+			name: "undeclared initial",
+			conf: StatusWorkflowConf{Initial: "new", Transitions: map[string][]string{"open": {}}},
+		},
+		{
+			name: "undeclared closed",
+			conf: StatusWorkflowConf{Initial: "open", Transitions: map[string][]string{"open": {}}, Closed: []string{"done"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStatusWorkflow(tt.conf)
+			var errInvalidWorkflow *InvalidWorkflowError
+			if !errors.As(err, &errInvalidWorkflow) {
+				t.Errorf("NewStatusWorkflow() error = %v, want an InvalidWorkflowError", err)
+			}
+		})
+	}
+}
+
+func TestStatusWorkflowParse(t *testing.T) {
+	w, err := NewStatusWorkflow(StatusWorkflowConf{
+		Initial:     "To Do",
+		Transitions: map[string][]string{"To Do": {"in-progress"}, "In Progress": {"to_do"}},
+	})
+	if err != nil {
+		t.Fatalf("NewStatusWorkflow() error = %v", err)
+	}
+	if w.Initial() != "to_do" {
+		t.Errorf("Initial() = %q, want to_do", w.Initial())
+	}
+
+	tests := []struct {
+		status  model.TaskStatus
+		want    model.TaskStatus
+		unknown bool
+	}{
+		{status: "to_do", want: "to_do"},
+		{status: " TO DO ", want: "to_do"},
+		{status: "In-Progress", want: "in_progress"},
+		{status: "in\tprogress", want: "in_progress"},
+		{status: "in__progress", want: "in_progress"},
+		{status: "done", unknown: true},
+		{status: "", unknown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			got, err := w.Parse(tt.status)
+			if tt.unknown {
+				var errUnknownStatus *UnknownStatusError
+				if !errors.As(err, &errUnknownStatus) {
+					t.Errorf("Parse() error = %v, want an UnknownStatusError", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Parse() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusWorkflowCheckTransition(t *testing.T) {
+	w, err := NewStatusWorkflow(DefaultStatusWorkflowConf)
+	if err != nil {
+		t.Fatalf("NewStatusWorkflow() error = %v", err)
+	}
+
+	tests := []struct {
+		from, to model.TaskStatus
+		allowed  bool
+	}{
+		{from: "todo", to: "in_progress", allowed: true},
+		{from: "todo", to: "done", allowed: true},
+		{from: "in_progress", to: "done", allowed: true},
+		{from: "done", to: "in_progress", allowed: true},
+		{from: "blocked", to: "todo", allowed: true},
+		{from: "done", to: "todo"},
+		{from: "blocked", to: "done"},
+		{from: "in_progress", to: "archived"},
+		{from: "archived", to: "todo"},
+		// Staying in the same status is always allowed, even in a final status.
+		{from: "archived", to: "archived", allowed: true},
+		// The stored status is normalized.
+		{from: "In Progress", to: "done", allowed: true},
+		// A status stored before the workflow was configured can move anywhere.
+		{from: "legacy", to: "archived", allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			err := w.CheckTransition(tt.from, tt.to)
+			if tt.allowed && err != nil {
+				t.Errorf("CheckTransition() error = %v, want nil", err)
+			}
+			var errIllegalTransition *IllegalTransitionError
+			if !tt.allowed && !errors.As(err, &errIllegalTransition) {
+				t.Errorf("CheckTransition() error = %v, want an IllegalTransitionError", err)
+			}
+		})
+	}
+}
+
+func TestStatusWorkflowIsClosed(t *testing.T) {
+	w, err := NewStatusWorkflow(DefaultStatusWorkflowConf)
+	if err != nil {
+		t.Fatalf("NewStatusWorkflow() error = %v", err)
+	}
+
+	for status, want := range map[model.TaskStatus]bool{"done": true, "Archived": true, "todo": false, "blocked": false, "unknown": false} {
+		if got := w.IsClosed(status); got != want {
+			t.Errorf("IsClosed(%q) = %v, want %v", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+)
+
+var (
+	_ ITagController = (*TagController)(nil)
+)
+
+// ITagController is an interface for TagController and TagControllerMocking.
+type ITagController interface {
+	Create(ctx context.Context, tagToCreate *model.TagCreateDTO) (*model.TagPublicDTO, error)
+	Get(ctx context.Context, tagUUID uuid.UUID) (*model.TagPublicDTO, error)
+	GetAll(ctx context.Context) (*model.TagsPublicDTO, error)
+	Rename(ctx context.Context, tagUUID uuid.UUID, name string) error
+	Delete(ctx context.Context, tagUUID uuid.UUID) error
+}
+
+// TagControllerConf is a configuration structure for TagController.
+type TagControllerConf struct {
+	// TagDAO must use the database of the TaskDAO, which puts the tags on the tasks.
+	TagDAO repositories.DAOFactoryOptions `mapstructure:"tag_dao"`
+}
+
+// TagController is an controllers to manage business logic of Tag.
+type TagController struct {
+	daoTag repositories.ITagDAO
+}
+
+func (c *TagController) Create(ctx context.Context, tagToCreate *model.TagCreateDTO) (*model.TagPublicDTO, error) {
+	name, err := normalizeTagName(tagToCreate.Name)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create tag: %w", err)
+	}
+	tagToCreate.Name = name
+
+	tag, err := c.daoTag.CreateTag(ctx, tagToCreate)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create tag: %w", err)
+	}
+
+	return model.FactoryTagPublicDTO(tag), nil
+}
+
+func (c *TagController) Get(ctx context.Context, tagUUID uuid.UUID) (*model.TagPublicDTO, error) {
+	tag, err := c.daoTag.ReadTag(ctx, tagUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get tag: %w", err)
+	}
+
+	return model.FactoryTagPublicDTO(tag), nil
+}
+
+func (c *TagController) GetAll(ctx context.Context) (*model.TagsPublicDTO, error) {
+	tags, err := c.daoTag.ReadTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get tags: %w", err)
+	}
+
+	publicTags := []model.TagPublicDTO{}
+	for _, tag := range tags {
+		publicTags = append(publicTags, *model.FactoryTagPublicDTO(tag))
+	}
+
+	return &model.TagsPublicDTO{
+		Tags: publicTags,
+	}, nil
+}
+
+// Rename changes the name of the tag, the tasks show the new name without changing their version.
+func (c *TagController) Rename(ctx context.Context, tagUUID uuid.UUID, name string) error {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return fmt.Errorf("fail to rename tag: %w", err)
+	}
+
+	if err := c.daoTag.RenameTag(ctx, tagUUID, name); err != nil {
+		return fmt.Errorf("fail to rename tag: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the tag and takes it off every task.
+func (c *TagController) Delete(ctx context.Context, tagUUID uuid.UUID) error {
+	if err := c.daoTag.DeleteTag(ctx, tagUUID); err != nil {
+		return fmt.Errorf("fail to delete tag: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeTagName trims the blanks around the name, it returns an InvalidTagError if nothing is left
+// or if the name has a comma, the separator of the tags in the task history.
+func normalizeTagName(name string) (string, error) {
+	trimmed := strings.TrimSpace(name)
+	switch {
+	case trimmed == "":
+		return "", &InvalidTagError{Name: name, Reason: "the name is blank"}
+	case strings.Contains(trimmed, ","):
+		return "", &InvalidTagError{Name: name, Reason: "the name can't have a comma"}
+	}
+
+	return trimmed, nil
+}
+
+// factoryTagController is use to build an TagController.
+func factoryTagController(c TagControllerConf) (*TagController, error) {
+	log.Info("loading TagDAO...")
+	daoTag, err := repositories.ProxyFactoryTagDAO(c.TagDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load TagDAO: %w", err)
+	}
+	log.Info("TagDAO loaded")
+
+	controllers := &TagController{
+		daoTag: daoTag,
+	}
+	return controllers, nil
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+)
+
+var (
+	_ ITaskController = (*TaskAuthorizer)(nil)
+)
+
+// TaskAuthorizer checks the role of the caller on a task before handing the request to the TaskController.
+// The reads aren't checked, a task is only visible to its viewers.
+type TaskAuthorizer struct {
+	ITaskController
+	daoTask repositories.ITaskDAO
+}
+
+func (a *TaskAuthorizer) Update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) error {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleEditor); err != nil {
+		return fmt.Errorf("fail to update task: %w", err)
+	}
+
+	return a.ITaskController.Update(ctx, taskUUID, patch, version)
+}
+
+func (a *TaskAuthorizer) Delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleOwner); err != nil {
+		return fmt.Errorf("fail to delete task: %w", err)
+	}
+
+	return a.ITaskController.Delete(ctx, taskUUID, version, cascade)
+}
+
+func (a *TaskAuthorizer) Restore(ctx context.Context, taskUUID uuid.UUID) (*model.TaskPublicDTO, error) {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleOwner); err != nil {
+		return nil, fmt.Errorf("fail to restore task: %w", err)
+	}
+
+	return a.ITaskController.Restore(ctx, taskUUID)
+}
+
+func (a *TaskAuthorizer) GetGrants(ctx context.Context, taskUUID uuid.UUID) (*model.TaskGrantsPublicDTO, error) {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleOwner); err != nil {
+		return nil, fmt.Errorf("fail to get task grants: %w", err)
+	}
+
+	return a.ITaskController.GetGrants(ctx, taskUUID)
+}
+
+func (a *TaskAuthorizer) Grant(ctx context.Context, taskUUID uuid.UUID, principalID string, role model.TaskRole) (*model.TaskGrantPublicDTO, error) {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleOwner); err != nil {
+		return nil, fmt.Errorf("fail to grant task: %w", err)
+	}
+
+	return a.ITaskController.Grant(ctx, taskUUID, principalID, role)
+}
+
+func (a *TaskAuthorizer) Revoke(ctx context.Context, taskUUID uuid.UUID, principalID string) error {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleOwner); err != nil {
+		return fmt.Errorf("fail to revoke task grant: %w", err)
+	}
+
+	return a.ITaskController.Revoke(ctx, taskUUID, principalID)
+}
+
+func (a *TaskAuthorizer) AddDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) (*model.TaskDependencyPublicDTO, error) {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleEditor); err != nil {
+		return nil, fmt.Errorf("fail to add task dependency: %w", err)
+	}
+
+	return a.ITaskController.AddDependency(ctx, taskUUID, blockerUUID)
+}
+
+func (a *TaskAuthorizer) RemoveDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) error {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleEditor); err != nil {
+		return fmt.Errorf("fail to remove task dependency: %w", err)
+	}
+
+	return a.ITaskController.RemoveDependency(ctx, taskUUID, blockerUUID)
+}
+
+func (a *TaskAuthorizer) AddTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleEditor); err != nil {
+		return fmt.Errorf("fail to add task tag: %w", err)
+	}
+
+	return a.ITaskController.AddTag(ctx, taskUUID, tagUUID, version)
+}
+
+func (a *TaskAuthorizer) RemoveTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	if err := a.authorize(ctx, taskUUID, model.TaskRoleEditor); err != nil {
+		return fmt.Errorf("fail to remove task tag: %w", err)
+	}
+
+	return a.ITaskController.RemoveTag(ctx, taskUUID, tagUUID, version)
+}
+
+// Batch checks the role of the caller on the task of each update and delete, the forbidden operations
+// fail the batch in the all-or-nothing mode and are reported without being run in the best-effort mode.
+func (a *TaskAuthorizer) Batch(ctx context.Context, operations []*model.TaskBatchOperation, mode model.TaskBatchMode) ([]*model.TaskBatchResult, error) {
+	denied := make(map[int]error)
+	allowed := make([]*model.TaskBatchOperation, 0, len(operations))
+	for i, operation := range operations {
+		required := model.TaskRoleEditor
+		switch operation.Op {
+		case model.TaskBatchCreate:
+			allowed = append(allowed, operation)
+			continue
+		case model.TaskBatchDelete:
+			required = model.TaskRoleOwner
+		}
+
+		if err := a.authorize(ctx, operation.TaskUUID, required); err != nil {
+			if mode != model.TaskBatchBestEffort {
+				return nil, fmt.Errorf("fail to run task batch: %w", &repositories.BatchOperationError{Index: i, Err: err})
+			}
+			denied[i] = err
+			continue
+		}
+		allowed = append(allowed, operation)
+	}
+
+	results, err := a.ITaskController.Batch(ctx, allowed, mode)
+	if err != nil || len(denied) == 0 {
+		return results, err
+	}
+
+	merged := make([]*model.TaskBatchResult, 0, len(operations))
+	for i := range operations {
+		if err, isDenied := denied[i]; isDenied {
+			merged = append(merged, &model.TaskBatchResult{Err: err})
+			continue
+		}
+		merged = append(merged, results[0])
+		results = results[1:]
+	}
+	return merged, nil
+}
+
+// authorize returns a ForbiddenError if the role of the caller on the task doesn't allow required,
+// and the error of the TaskDAO if the task isn't visible by the caller.
+func (a *TaskAuthorizer) authorize(ctx context.Context, taskUUID uuid.UUID, required model.TaskRole) error {
+	role, err := a.daoTask.ReadRole(ctx, taskUUID)
+	if err != nil {
+		return fmt.Errorf("fail to get task role: %w", err)
+	}
+	if !role.Allows(required) {
+		return &ForbiddenError{Role: role, Required: required}
+	}
+
+	return nil
+}
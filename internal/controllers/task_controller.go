@@ -2,9 +2,15 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 
 	"github.com/CamilleLange/todolist/internal/repositories"
 )
@@ -13,45 +19,145 @@ var (
 	_ ITaskController = (*TaskController)(nil)
 )
 
+// maxStatusUpdateAttempts is the number of times an update of the status without expected version
+// is checked against the task before it fails because the status keeps changing.
+const maxStatusUpdateAttempts = 3
+
 // ITaskController is an interface for TaskController and TaskControllerMocking.
 type ITaskController interface {
-	Create(ctx context.Context) (*model.TaskPublicDTO, error)
-	Get(ctx context.Context) (*model.Task, error)
-	GetAll(ctx context.Context) ([]model.TaskPublicDTO, error)
-	Update(ctx context.Context) error
-	Delete(ctx context.Context) error
+	Create(ctx context.Context, taskToCreate *model.TaskCreateDTO) (*model.TaskPublicDTO, error)
+	Get(ctx context.Context, taskUUID uuid.UUID) (*model.TaskPublicDTO, error)
+	GetAll(ctx context.Context, filter *model.TaskFilterDTO) (*model.TaskListPublicDTO, error)
+	Search(ctx context.Context, search *model.TaskSearchDTO) (*model.TaskSearchPublicDTO, error)
+	Update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) error
+	Delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error
+	GetChildren(ctx context.Context, taskUUID uuid.UUID, filter *model.TaskFilterDTO) (*model.TaskListPublicDTO, error)
+	GetSubtree(ctx context.Context, taskUUID uuid.UUID) (*model.TaskNodePublicDTO, error)
+	GetTrash(ctx context.Context, filter *model.TaskFilterDTO) (*model.TaskListPublicDTO, error)
+	Restore(ctx context.Context, taskUUID uuid.UUID) (*model.TaskPublicDTO, error)
+	PurgeTrash(ctx context.Context) (int64, error)
+	SendReminders(ctx context.Context) (int64, error)
+	GetHistory(ctx context.Context, taskUUID uuid.UUID) (*model.TaskHistoryPublicDTO, error)
+	StopSeries(ctx context.Context, seriesUUID uuid.UUID) error
+	GetGrants(ctx context.Context, taskUUID uuid.UUID) (*model.TaskGrantsPublicDTO, error)
+	Grant(ctx context.Context, taskUUID uuid.UUID, principalID string, role model.TaskRole) (*model.TaskGrantPublicDTO, error)
+	Revoke(ctx context.Context, taskUUID uuid.UUID, principalID string) error
+	GetDependencies(ctx context.Context, taskUUID uuid.UUID) (*model.TaskDependencyGraphPublicDTO, error)
+	AddDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) (*model.TaskDependencyPublicDTO, error)
+	RemoveDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) error
+	AddTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error
+	RemoveTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error
+	Batch(ctx context.Context, operations []*model.TaskBatchOperation, mode model.TaskBatchMode) ([]*model.TaskBatchResult, error)
+	Subscribe(ctx context.Context, feed *model.TaskFeedDTO) (*TaskFeed, error)
 }
 
 // TaskControllerConf is a configuration structure for TaskController.
 type TaskControllerConf struct {
-	TaskDAO repositories.DAOFactoryOptions `mapstructure:"task_dao"`
+	TaskDAO           repositories.DAOFactoryOptions `mapstructure:"task_dao"`
+	TaskGrantDAO      repositories.DAOFactoryOptions `mapstructure:"task_grant_dao"`
+	TaskDependencyDAO repositories.DAOFactoryOptions `mapstructure:"task_dependency_dao"`
+	StatusWorkflow    StatusWorkflowConf             `mapstructure:"status_workflow"`
+	Trash             TrashConf                      `mapstructure:"trash"`
+	Reminders         RemindersConf                  `mapstructure:"reminders"`
+	Search            SearchConf                     `mapstructure:"search"`
+	Recurrence        RecurrenceConf                 `mapstructure:"recurrence"`
+	Events            EventsConf                     `mapstructure:"events"`
 }
 
 // TaskController is an controllers to manage business logic of Task.
 type TaskController struct {
-	daoTask repositories.ITaskDAO
+	daoTask       repositories.ITaskDAO
+	daoGrant      repositories.ITaskGrantDAO
+	daoDependency repositories.ITaskDependencyDAO
+	daoList       repositories.IListDAO
+	daoTag        repositories.ITagDAO
+	workflow      *StatusWorkflow
+	trash         TrashConf
+	search        SearchConf
+	recurrence    RecurrenceConf
+	events        *taskEventBus
 }
 
-func (c *TaskController) Create(ctx context.Context) (*model.TaskPublicDTO, error) {
-	task, err := c.daoTask.Create(ctx)
+func (c *TaskController) Create(ctx context.Context, taskToCreate *model.TaskCreateDTO) (*model.TaskPublicDTO, error) {
+	if err := c.prepareCreate(ctx, taskToCreate); err != nil {
+		return nil, fmt.Errorf("fail to create task: %w", err)
+	}
+
+	task, err := c.daoTask.Create(ctx, taskToCreate)
 	if err != nil {
 		return nil, fmt.Errorf("fail to create task: %w", err)
 	}
+	c.publish(ctx, model.TaskEventCreated, task, "")
 
 	return model.FactoryTaskPublicDTO(task), nil
 }
 
-func (c *TaskController) Get(ctx context.Context) (*model.Task, error) {
-	task, err := c.daoTask.ReadByUUID(ctx)
+// prepareCreate checks the task to create and normalizes its status and recurrence rule.
+func (c *TaskController) prepareCreate(ctx context.Context, taskToCreate *model.TaskCreateDTO) error {
+	if taskToCreate.Status == "" {
+		taskToCreate.Status = c.workflow.Initial()
+	}
+
+	status, err := c.workflow.Parse(taskToCreate.Status)
+	if err != nil {
+		return err
+	}
+	taskToCreate.Status = status
+
+	if taskToCreate.RRule != "" {
+		rule, err := normalizeRecurrence(taskToCreate.RRule, c.recurrence)
+		if err != nil {
+			return err
+		}
+		taskToCreate.RRule = rule
+	}
+
+	if taskToCreate.ListUUID != nil {
+		if err := c.checkList(ctx, *taskToCreate.ListUUID); err != nil {
+			return err
+		}
+	}
+	if taskToCreate.ParentUUID != nil {
+		if err := c.checkParent(ctx, *taskToCreate.ParentUUID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *TaskController) Get(ctx context.Context, taskUUID uuid.UUID) (*model.TaskPublicDTO, error) {
+	task, err := c.daoTask.ReadByUUID(ctx, taskUUID)
 	if err != nil {
 		return nil, fmt.Errorf("fail to get task: %w", err)
 	}
 
-	return task, nil
+	return model.FactoryTaskPublicDTO(task), nil
 }
 
-func (c *TaskController) GetAll(ctx context.Context) ([]model.TaskPublicDTO, error) {
-	tasks, err := c.daoTask.ReadAll(ctx)
+func (c *TaskController) GetAll(ctx context.Context, filter *model.TaskFilterDTO) (*model.TaskListPublicDTO, error) {
+	for i, status := range filter.Status {
+		filter.Status[i] = model.NormalizeTaskStatus(string(status))
+	}
+	// The tags are trimmed like the names of the tags, the blank ones are ignored and the duplicates
+	// counted once, a task has all of them with TagMatchAll.
+	filter.Tag = slices.DeleteFunc(filter.Tag, func(tag string) bool {
+		return strings.TrimSpace(tag) == ""
+	})
+	for i, tag := range filter.Tag {
+		filter.Tag[i] = strings.TrimSpace(tag)
+	}
+	slices.Sort(filter.Tag)
+	filter.Tag = slices.Compact(filter.Tag)
+	if filter.Overdue {
+		now := time.Now()
+		if filter.DueBefore == nil || filter.DueBefore.After(now) {
+			filter.DueBefore = &now
+		}
+		filter.ExcludedStatus = c.workflow.Closed()
+	}
+
+	tasks, nextCursor, err := c.daoTask.ReadAll(ctx, filter)
 	if err != nil {
 		return nil, fmt.Errorf("fail to get tasks: %w", err)
 	}
@@ -61,29 +167,574 @@ func (c *TaskController) GetAll(ctx context.Context) ([]model.TaskPublicDTO, err
 		publicTasks = append(publicTasks, *model.FactoryTaskPublicDTO(f))
 	}
 
-	return publicTasks, nil
+	return &model.TaskListPublicDTO{
+		Tasks:      publicTasks,
+		NextCursor: nextCursor,
+	}, nil
 }
 
-func (c *TaskController) Update(ctx context.Context) error {
-	err := c.daoTask.Update(ctx)
+// Update applies the patch on the task, version is the expected version of the task or 0 to skip the check.
+// A recurring task moving to a closed status hands its rule over to its next occurrence.
+// An update of the status is tied to the version its transition was checked against: without expected version,
+// it is checked again against a task changed meanwhile, and fails with a ConflictError if it keeps changing.
+func (c *TaskController) Update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) error {
+	for attempt := 1; ; attempt++ {
+		// prepareUpdate normalizes the fields of the patch, each attempt starts from the patch of the caller.
+		attemptPatch := *patch
+		checkedVersion, recurrence, previousStatus, err := c.prepareUpdate(ctx, taskUUID, &attemptPatch, version)
+		if err != nil {
+			return fmt.Errorf("fail to update task: %w", err)
+		}
+
+		err = c.daoTask.Update(ctx, taskUUID, &attemptPatch, checkedVersion)
+		var errVersionMismatch *repositories.VersionMismatchError
+		if errors.As(err, &errVersionMismatch) && version == 0 && patch.Status != nil {
+			if attempt < maxStatusUpdateAttempts {
+				continue
+			}
+			return fmt.Errorf("fail to update task: %w", &repositories.ConflictError{Err: errors.New("the status of the task keeps changing")})
+		}
+		if err != nil {
+			return fmt.Errorf("fail to update task: %w", err)
+		}
+		c.publishUpdated(ctx, taskUUID, previousStatus)
+
+		if recurrence != "" {
+			if err := c.spawnNextOccurrence(ctx, taskUUID, recurrence); err != nil {
+				return fmt.Errorf("fail to spawn the next occurrence: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// prepareUpdate checks the patch of the task and normalizes its recurrence rule.
+// It returns the version the update must be conditioned on, the one the transition was checked against
+// if the patch changes the status, the rule of the next occurrence to spawn
+// once the task is updated, empty if the update doesn't close a recurring task,
+// and the status of the task before an update of its status.
+func (c *TaskController) prepareUpdate(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) (int64, string, model.TaskStatus, error) {
+	if patch.RRule != nil && *patch.RRule != "" {
+		rule, err := normalizeRecurrence(*patch.RRule, c.recurrence)
+		if err != nil {
+			return 0, "", "", err
+		}
+		patch.RRule = &rule
+	}
+
+	var recurrence string
+	var previousStatus model.TaskStatus
+	if patch.Status != nil {
+		task, err := c.checkStatusUpdate(ctx, taskUUID, patch, version)
+		if err != nil {
+			return 0, "", "", err
+		}
+		previousStatus = task.Status
+		// A concurrent change of the status can't slip between the check of the transition and the update.
+		version = task.Version
+
+		if c.workflow.IsClosed(*patch.Status) && !c.workflow.IsClosed(task.Status) {
+			if err := c.checkBlockers(ctx, taskUUID); err != nil {
+				return 0, "", "", err
+			}
+		}
+
+		recurrence = task.RRule
+		if patch.RRule != nil {
+			recurrence = *patch.RRule
+		}
+		if recurrence != "" && c.workflow.IsClosed(*patch.Status) && !c.workflow.IsClosed(task.Status) {
+			// The version pins the task so a concurrent update can't spawn the occurrence twice.
+			stopped := ""
+			patch.RRule = &stopped
+		} else {
+			recurrence = ""
+		}
+	}
+	if patch.ListUUID != nil && *patch.ListUUID != uuid.Nil {
+		if err := c.checkList(ctx, *patch.ListUUID); err != nil {
+			return 0, "", "", err
+		}
+	}
+	if patch.ParentUUID != nil && *patch.ParentUUID != uuid.Nil {
+		if err := c.checkParent(ctx, *patch.ParentUUID); err != nil {
+			return 0, "", "", err
+		}
+		if err := c.checkCycle(ctx, taskUUID, *patch.ParentUUID); err != nil {
+			return 0, "", "", err
+		}
+	}
+
+	return version, recurrence, previousStatus, nil
+}
+
+// Delete moves the task to the trash, version is the expected version of the task or 0 to skip the check.
+// cascade tells what happens to the subtasks, a task with subtasks isn't deleted by default.
+func (c *TaskController) Delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error {
+	if cascade == "" {
+		cascade = model.TaskDeleteRestrict
+	}
+
+	task, descendants, err := c.readDeleted(ctx, taskUUID, cascade)
 	if err != nil {
-		return fmt.Errorf("fail to update task: %w", err)
+		return fmt.Errorf("fail to delete task: %w", err)
 	}
 
+	err = c.daoTask.Delete(ctx, taskUUID, version, cascade)
+	if err != nil {
+		return fmt.Errorf("fail to delete task: %w", err)
+	}
+	c.publishDeleted(ctx, task, descendants, cascade)
+
 	return nil
 }
 
-func (c *TaskController) Delete(ctx context.Context) error {
-	err := c.daoTask.Delete(ctx)
+// Batch runs the creates, updates and deletes of the batch in order, each checked like Create, Update and Delete.
+// The operations are checked against the tasks as they are before the batch. In the all-or-nothing mode,
+// the first failing operation fails the batch with a BatchOperationError and no task is changed.
+func (c *TaskController) Batch(ctx context.Context, operations []*model.TaskBatchOperation, mode model.TaskBatchMode) ([]*model.TaskBatchResult, error) {
+	atomic := mode != model.TaskBatchBestEffort
+	results := make([]*model.TaskBatchResult, len(operations))
+	recurrences := make([]string, len(operations))
+	previousStatuses := make([]model.TaskStatus, len(operations))
+	// deleted holds the tasks removed by the deletes and their descendants, as they are before the batch.
+	deleted := make([]*model.Task, len(operations))
+	descendants := make([][]*model.Task, len(operations))
+
+	// The operations failing their checks aren't handed to the TaskDAO, indexes maps the others to their position.
+	checked := make([]*model.TaskBatchOperation, 0, len(operations))
+	indexes := make([]int, 0, len(operations))
+	for i, operation := range operations {
+		var err error
+		switch operation.Op {
+		case model.TaskBatchCreate:
+			err = c.prepareCreate(ctx, operation.Create)
+		case model.TaskBatchUpdate:
+			operation.Version, recurrences[i], previousStatuses[i], err = c.prepareUpdate(ctx, operation.TaskUUID, operation.Patch, operation.Version)
+		case model.TaskBatchDelete:
+			if operation.Cascade == "" {
+				operation.Cascade = model.TaskDeleteRestrict
+			}
+			deleted[i], descendants[i], err = c.readDeleted(ctx, operation.TaskUUID, operation.Cascade)
+		}
+
+		if err != nil {
+			if atomic {
+				return nil, fmt.Errorf("fail to run task batch: %w", &repositories.BatchOperationError{Index: i, Err: err})
+			}
+			results[i] = &model.TaskBatchResult{Err: err}
+			continue
+		}
+		checked = append(checked, operation)
+		indexes = append(indexes, i)
+	}
+	if len(checked) == 0 {
+		return results, nil
+	}
+
+	checkedResults, err := c.daoTask.Batch(ctx, checked, atomic)
 	if err != nil {
-		return fmt.Errorf("fail to delete task: %w", err)
+		return nil, fmt.Errorf("fail to run task batch: %w", err)
+	}
+
+	for j, result := range checkedResults {
+		i := indexes[j]
+		results[i] = result
+		if result.Err != nil {
+			continue
+		}
+
+		switch operations[i].Op {
+		case model.TaskBatchCreate:
+			c.publish(ctx, model.TaskEventCreated, result.Task, "")
+		case model.TaskBatchUpdate:
+			c.publish(ctx, model.TaskEventUpdated, result.Task, previousStatuses[i])
+		case model.TaskBatchDelete:
+			c.publishDeleted(ctx, deleted[i], descendants[i], operations[i].Cascade)
+		}
+		if recurrences[i] == "" {
+			continue
+		}
+
+		// The task is closed whatever happens to its next occurrence, like with Update.
+		if err := c.spawnNextOccurrence(ctx, operations[i].TaskUUID, recurrences[i]); err != nil {
+			log.Error("fail to spawn the next occurrence", zap.Any("task_uuid", operations[i].TaskUUID), zap.Error(err))
+		}
+	}
+
+	return results, nil
+}
+
+// GetChildren lists the live subtasks of the task.
+func (c *TaskController) GetChildren(ctx context.Context, taskUUID uuid.UUID, filter *model.TaskFilterDTO) (*model.TaskListPublicDTO, error) {
+	if _, err := c.daoTask.ReadByUUID(ctx, taskUUID); err != nil {
+		return nil, fmt.Errorf("fail to get task children: %w", err)
+	}
+
+	filter.ParentUUID = &taskUUID
+	return c.GetAll(ctx, filter)
+}
+
+// GetSubtree returns the task with its live subtasks at any depth, and the completion of each task.
+func (c *TaskController) GetSubtree(ctx context.Context, taskUUID uuid.UUID) (*model.TaskNodePublicDTO, error) {
+	subtree, err := c.daoTask.ReadSubtree(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get task subtree: %w", err)
+	}
+
+	return c.buildTaskTree(taskUUID, subtree), nil
+}
+
+// GetTrash lists the tasks of the trash.
+func (c *TaskController) GetTrash(ctx context.Context, filter *model.TaskFilterDTO) (*model.TaskListPublicDTO, error) {
+	filter.Deleted = true
+	return c.GetAll(ctx, filter)
+}
+
+// Restore moves the task out of the trash and returns it. The subtasks deleted with it by a cascade delete
+// are restored too.
+func (c *TaskController) Restore(ctx context.Context, taskUUID uuid.UUID) (*model.TaskPublicDTO, error) {
+	restored, err := c.daoTask.Restore(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to restore task: %w", err)
+	}
+
+	task, err := c.daoTask.ReadByUUID(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get task: %w", err)
+	}
+	c.publish(ctx, model.TaskEventRestored, task, "")
+	for _, descendant := range restored[1:] {
+		c.publish(ctx, model.TaskEventRestored, descendant, "")
+	}
+
+	return model.FactoryTaskPublicDTO(task), nil
+}
+
+// PurgeTrash removes the tasks kept in the trash longer than the retention and returns how many were removed.
+func (c *TaskController) PurgeTrash(ctx context.Context) (int64, error) {
+	if c.trash.RetentionDays <= 0 {
+		return 0, nil
+	}
+
+	deletedBefore := time.Now().AddDate(0, 0, -c.trash.RetentionDays)
+	count, err := c.daoTask.Purge(ctx, deletedBefore)
+	if err != nil {
+		return count, fmt.Errorf("fail to purge trash: %w", err)
+	}
+
+	return count, nil
+}
+
+// SendReminders fires the reminders whose date passed and returns how many fired.
+func (c *TaskController) SendReminders(ctx context.Context) (int64, error) {
+	tasks, err := c.daoTask.Remind(ctx, time.Now())
+	if err != nil {
+		return int64(len(tasks)), fmt.Errorf("fail to send reminders: %w", err)
+	}
+
+	for _, task := range tasks {
+		log.Info("task reminder",
+			zap.Any("task_uuid", task.UUID),
+			zap.String("owner_id", task.OwnerID),
+			zap.String("description", task.WhatToDo),
+			zap.Timep("remind_at", task.RemindAt),
+			zap.Timep("due_at", task.DueAt),
+		)
+	}
+
+	return int64(len(tasks)), nil
+}
+
+// GetHistory returns the changes of the task, oldest first.
+func (c *TaskController) GetHistory(ctx context.Context, taskUUID uuid.UUID) (*model.TaskHistoryPublicDTO, error) {
+	events, err := c.daoTask.ReadHistory(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get task history: %w", err)
+	}
+
+	publicEvents := []model.TaskEventPublicDTO{}
+	for _, event := range events {
+		publicEvents = append(publicEvents, *model.FactoryTaskEventPublicDTO(event))
+	}
+
+	return &model.TaskHistoryPublicDTO{
+		Events: publicEvents,
+	}, nil
+}
+
+// GetGrants returns the roles given on the task.
+func (c *TaskController) GetGrants(ctx context.Context, taskUUID uuid.UUID) (*model.TaskGrantsPublicDTO, error) {
+	grants, err := c.daoGrant.ReadGrants(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get task grants: %w", err)
+	}
+
+	publicGrants := []model.TaskGrantPublicDTO{}
+	for _, grant := range grants {
+		publicGrants = append(publicGrants, *model.FactoryTaskGrantPublicDTO(grant))
+	}
+
+	return &model.TaskGrantsPublicDTO{
+		Grants: publicGrants,
+	}, nil
+}
+
+// Grant gives the role on the task to the principal, replacing its previous role.
+func (c *TaskController) Grant(ctx context.Context, taskUUID uuid.UUID, principalID string, role model.TaskRole) (*model.TaskGrantPublicDTO, error) {
+	task, err := c.daoTask.ReadByUUID(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to grant task: %w", err)
+	}
+	if principalID == task.OwnerID {
+		return nil, fmt.Errorf("fail to grant task: %w", &InvalidGrantError{PrincipalID: principalID})
+	}
+
+	grant := &model.TaskGrant{
+		TaskUUID:    taskUUID,
+		PrincipalID: principalID,
+		Role:        role,
+		GrantedBy:   model.ActorFromContext(ctx),
+	}
+	if err := c.daoGrant.SaveGrant(ctx, grant); err != nil {
+		return nil, fmt.Errorf("fail to grant task: %w", err)
+	}
+
+	return model.FactoryTaskGrantPublicDTO(grant), nil
+}
+
+// Revoke removes the role of the principal on the task.
+func (c *TaskController) Revoke(ctx context.Context, taskUUID uuid.UUID, principalID string) error {
+	if err := c.daoGrant.DeleteGrant(ctx, taskUUID, principalID); err != nil {
+		return fmt.Errorf("fail to revoke task grant: %w", err)
+	}
+
+	return nil
+}
+
+// GetDependencies returns the graph of the transitive blockers of the task, each task comes after its blockers.
+func (c *TaskController) GetDependencies(ctx context.Context, taskUUID uuid.UUID) (*model.TaskDependencyGraphPublicDTO, error) {
+	tasks, edges, err := c.daoDependency.ReadDependencyGraph(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get task dependencies: %w", err)
+	}
+
+	graph := &model.TaskDependencyGraphPublicDTO{
+		Tasks: []model.TaskPublicDTO{},
+		Edges: []model.TaskDependencyPublicDTO{},
+	}
+	for _, task := range sortByDependencies(tasks, edges) {
+		graph.Tasks = append(graph.Tasks, *model.FactoryTaskPublicDTO(task))
+	}
+	for _, edge := range edges {
+		graph.Edges = append(graph.Edges, *model.FactoryTaskDependencyPublicDTO(edge))
+	}
+
+	return graph, nil
+}
+
+// AddDependency blocks the task by the blocker, the task can't be closed until the blocker is.
+func (c *TaskController) AddDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) (*model.TaskDependencyPublicDTO, error) {
+	// The blocker must be visible, the DAO refuses the blockers blocked by the task in the same write.
+	_, err := c.daoTask.ReadByUUID(ctx, blockerUUID)
+	var errNoDataFound *repositories.NoDataFoundError
+	if errors.As(err, &errNoDataFound) {
+		return nil, fmt.Errorf("fail to add task dependency: %w", &UnknownBlockerError{UUID: blockerUUID})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fail to add task dependency: %w", err)
+	}
+
+	dependency := &model.TaskDependency{
+		TaskUUID:    taskUUID,
+		BlockerUUID: blockerUUID,
+		CreatedBy:   model.ActorFromContext(ctx),
+	}
+	if err := c.daoDependency.SaveDependency(ctx, dependency); err != nil {
+		return nil, fmt.Errorf("fail to add task dependency: %w", err)
+	}
+
+	return model.FactoryTaskDependencyPublicDTO(dependency), nil
+}
+
+// RemoveDependency removes the edge between the task and its blocker.
+func (c *TaskController) RemoveDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) error {
+	if err := c.daoDependency.DeleteDependency(ctx, taskUUID, blockerUUID); err != nil {
+		return fmt.Errorf("fail to remove task dependency: %w", err)
 	}
 
 	return nil
 }
 
-// factoryTaskController is use to build an TaskController according to the conf.
-func factoryTaskController(c TaskControllerConf) (*TaskController, error) {
+// AddTag puts a tag of the caller on the task, putting it again changes nothing.
+func (c *TaskController) AddTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	_, err := c.daoTag.ReadTag(ctx, tagUUID)
+	var errNoDataFound *repositories.NoDataFoundError
+	if errors.As(err, &errNoDataFound) {
+		return fmt.Errorf("fail to add task tag: %w", &UnknownTagError{UUID: tagUUID})
+	}
+	if err != nil {
+		return fmt.Errorf("fail to add task tag: %w", err)
+	}
+
+	if err := c.daoTask.SaveTaskTag(ctx, taskUUID, tagUUID, version); err != nil {
+		return fmt.Errorf("fail to add task tag: %w", err)
+	}
+	c.publishUpdated(ctx, taskUUID, "")
+
+	return nil
+}
+
+// RemoveTag takes the tag off the task.
+func (c *TaskController) RemoveTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	if err := c.daoTask.DeleteTaskTag(ctx, taskUUID, tagUUID, version); err != nil {
+		return fmt.Errorf("fail to remove task tag: %w", err)
+	}
+	c.publishUpdated(ctx, taskUUID, "")
+
+	return nil
+}
+
+// StopSeries stops the recurrence of the tasks of the series, no occurrence is spawned anymore.
+func (c *TaskController) StopSeries(ctx context.Context, seriesUUID uuid.UUID) error {
+	if _, err := c.daoTask.StopSeries(ctx, seriesUUID); err != nil {
+		return fmt.Errorf("fail to stop series: %w", err)
+	}
+
+	return nil
+}
+
+// spawnNextOccurrence creates the occurrence following the task with the recurrence rule, in the initial status.
+// The occurrence belongs to the owner of the series, the dates without due date repeat from the creation of the task.
+func (c *TaskController) spawnNextOccurrence(ctx context.Context, taskUUID uuid.UUID, recurrence string) error {
+	task, err := c.daoTask.ReadByUUID(ctx, taskUUID)
+	if err != nil {
+		return fmt.Errorf("fail to get task: %w", err)
+	}
+
+	due := task.CreatedAt
+	if task.DueAt != nil {
+		due = *task.DueAt
+	}
+	next, rule, ok := nextOccurrence(recurrence, due, time.Now())
+	if !ok {
+		log.Info("series over", zap.Any("series_uuid", task.SeriesUUID))
+		return nil
+	}
+
+	occurrence := &model.TaskCreateDTO{
+		WhatToDo:   task.WhatToDo,
+		Status:     c.workflow.Initial(),
+		ListUUID:   task.ListUUID,
+		DueAt:      &next,
+		RRule:      rule,
+		SeriesUUID: task.SeriesUUID,
+		OwnerID:    task.OwnerID,
+		ParentUUID: task.ParentUUID,
+	}
+	if task.RemindAt != nil {
+		remindAt := next.Add(task.RemindAt.Sub(due))
+		occurrence.RemindAt = &remindAt
+	}
+
+	created, err := c.daoTask.Create(ctx, occurrence)
+	if err != nil {
+		return fmt.Errorf("fail to create task: %w", err)
+	}
+	c.publish(ctx, model.TaskEventCreated, created, "")
+	return nil
+}
+
+// checkStatusUpdate validates the requested status against the workflow and the current status of the task,
+// then replaces it by its normalized form in the patch and returns the task.
+// A stale version is reported before the transition, the client must first see the current status.
+func (c *TaskController) checkStatusUpdate(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) (*model.Task, error) {
+	status, err := c.workflow.Parse(*patch.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := c.daoTask.ReadByUUID(ctx, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get task: %w", err)
+	}
+	if version != 0 && version != task.Version {
+		return nil, &repositories.VersionMismatchError{Expected: version, Current: task.Version}
+	}
+
+	if err := c.workflow.CheckTransition(task.Status, status); err != nil {
+		return nil, err
+	}
+
+	patch.Status = &status
+	return task, nil
+}
+
+// checkList returns an UnknownListError if the list doesn't exist or isn't visible by the caller.
+func (c *TaskController) checkList(ctx context.Context, listUUID uuid.UUID) error {
+	_, err := c.daoList.ReadByUUID(ctx, listUUID)
+	var errNoDataFound *repositories.NoDataFoundError
+	if errors.As(err, &errNoDataFound) {
+		return &UnknownListError{UUID: listUUID}
+	}
+	if err != nil {
+		return fmt.Errorf("fail to get list: %w", err)
+	}
+	return nil
+}
+
+// checkBlockers returns a BlockedTaskError if some live blockers of the task aren't closed, whoever owns them.
+func (c *TaskController) checkBlockers(ctx context.Context, taskUUID uuid.UUID) error {
+	blockers, err := c.daoDependency.ReadBlockers(ctx, taskUUID)
+	if err != nil {
+		return fmt.Errorf("fail to get task blockers: %w", err)
+	}
+
+	var open []uuid.UUID
+	for _, blocker := range blockers {
+		if !c.workflow.IsClosed(blocker.Status) {
+			open = append(open, blocker.UUID)
+		}
+	}
+	if len(open) > 0 {
+		return &BlockedTaskError{UUID: taskUUID, Blockers: open}
+	}
+	return nil
+}
+
+// checkParent returns an UnknownParentError if the parent task doesn't exist or isn't visible by the caller.
+func (c *TaskController) checkParent(ctx context.Context, parentUUID uuid.UUID) error {
+	_, err := c.daoTask.ReadByUUID(ctx, parentUUID)
+	var errNoDataFound *repositories.NoDataFoundError
+	if errors.As(err, &errNoDataFound) {
+		return &UnknownParentError{UUID: parentUUID}
+	}
+	if err != nil {
+		return fmt.Errorf("fail to get parent task: %w", err)
+	}
+	return nil
+}
+
+// checkCycle returns a ParentCycleError if the task is the parent or one of its ancestors,
+// the ancestors of the other owners count too.
+func (c *TaskController) checkCycle(ctx context.Context, taskUUID, parentUUID uuid.UUID) error {
+	if taskUUID == parentUUID {
+		return &ParentCycleError{UUID: taskUUID, ParentUUID: parentUUID}
+	}
+
+	ancestors, err := c.daoTask.ReadAncestors(ctx, parentUUID)
+	if err != nil {
+		return fmt.Errorf("fail to get task ancestors: %w", err)
+	}
+	if slices.Contains(ancestors, taskUUID) {
+		return &ParentCycleError{UUID: taskUUID, ParentUUID: parentUUID}
+	}
+	return nil
+}
+
+// factoryTaskController is use to build an TaskController according to the conf, the lists are read with listDAO.
+func factoryTaskController(c TaskControllerConf, listDAO, tagDAO repositories.DAOFactoryOptions) (*TaskController, error) {
 	log.Info("loading TaskDAO...")
 	daoTask, err := repositories.ProxyFactoryTaskDAO(c.TaskDAO)
 	if err != nil {
@@ -91,8 +742,53 @@ func factoryTaskController(c TaskControllerConf) (*TaskController, error) {
 	}
 	log.Info("TaskDAO loaded")
 
+	log.Info("loading TaskGrantDAO...")
+	daoGrant, err := repositories.ProxyFactoryTaskGrantDAO(c.TaskGrantDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load TaskGrantDAO: %w", err)
+	}
+	log.Info("TaskGrantDAO loaded")
+
+	log.Info("loading TaskDependencyDAO...")
+	daoDependency, err := repositories.ProxyFactoryTaskDependencyDAO(c.TaskDependencyDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load TaskDependencyDAO: %w", err)
+	}
+	log.Info("TaskDependencyDAO loaded")
+
+	daoList, err := repositories.ProxyFactoryListDAO(listDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load ListDAO: %w", err)
+	}
+
+	daoTag, err := repositories.ProxyFactoryTagDAO(tagDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load TagDAO: %w", err)
+	}
+
+	workflow, err := NewStatusWorkflow(c.StatusWorkflow)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load status workflow: %w", err)
+	}
+
+	if c.Search.DefaultLanguage == "" {
+		c.Search.DefaultLanguage = model.TaskSearchSimple
+	}
+	if !slices.Contains(model.TaskSearchLanguages, c.Search.DefaultLanguage) {
+		return nil, &UnknownLanguageError{Language: c.Search.DefaultLanguage, Known: model.TaskSearchLanguages}
+	}
+
 	controllers := &TaskController{
-		daoTask: daoTask,
+		daoTask:       daoTask,
+		daoGrant:      daoGrant,
+		daoDependency: daoDependency,
+		daoList:       daoList,
+		daoTag:        daoTag,
+		workflow:      workflow,
+		trash:         c.Trash,
+		search:        c.Search,
+		recurrence:    c.Recurrence,
+		events:        newTaskEventBus(c.Events),
 	}
 	return controllers, nil
 }
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// newTestTaskController returns a TaskController over the in-memory DAOs and the context of a principal of its own,
+// the DAOs are shared by the tests of the package so they don't see the tasks of each other.
+func newTestTaskController(t *testing.T, conf TaskControllerConf) (*TaskController, context.Context) {
+	t.Helper()
+
+	conf.TaskDAO = repositories.DAOFactoryOptions{Type: repositories.TypeTaskInMemoryDAO}
+	conf.TaskGrantDAO = repositories.DAOFactoryOptions{Type: repositories.TypeTaskGrantInMemoryDAO}
+	conf.TaskDependencyDAO = repositories.DAOFactoryOptions{Type: repositories.TypeTaskDependencyInMemoryDAO}
+	ctl, err := factoryTaskController(conf,
+		repositories.DAOFactoryOptions{Type: repositories.TypeListInMemoryDAO},
+		repositories.DAOFactoryOptions{Type: repositories.TypeTagInMemoryDAO})
+	if err != nil {
+		t.Fatalf("factoryTaskController() error = %v", err)
+	}
+	t.Cleanup(ctl.events.close)
+
+	return ctl, model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "user-" + uuid.NewString()})
+}
+
+// racingTaskDAO changes the status of the task after the next reads, like concurrent requests
+// between the check of a transition and the update.
+type racingTaskDAO struct {
+	repositories.ITaskDAO
+	races  int
+	status model.TaskStatus
+}
+
+func (dao *racingTaskDAO) ReadByUUID(ctx context.Context, taskUUID uuid.UUID) (*model.Task, error) {
+	task, err := dao.ITaskDAO.ReadByUUID(ctx, taskUUID)
+	if err != nil || dao.races == 0 {
+		return task, err
+	}
+	dao.races--
+	status := dao.status
+	if err := dao.ITaskDAO.Update(ctx, taskUUID, &model.TaskPatch{Status: &status}, 0); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func TestTaskControllerUpdateStatusRace(t *testing.T) {
+	ctl, ctx := newTestTaskController(t, TaskControllerConf{})
+	dao := &racingTaskDAO{ITaskDAO: ctl.daoTask}
+	ctl.daoTask = dao
+
+	createInProgress := func() *model.TaskPublicDTO {
+		t.Helper()
+		task, err := ctl.Create(ctx, &model.TaskCreateDTO{WhatToDo: "ship the release"})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		inProgress := model.TaskStatus("in_progress")
+		if err := ctl.Update(ctx, task.UUID, &model.TaskPatch{Status: &inProgress}, 0); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		return task
+	}
+	status := func(taskUUID uuid.UUID) model.TaskStatus {
+		t.Helper()
+		task, err := ctl.Get(ctx, taskUUID)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		return task.Status
+	}
+	done := model.TaskStatus("done")
+
+	// The task is blocked meanwhile: the transition is checked again and blocked to done isn't allowed.
+	task := createInProgress()
+	dao.races, dao.status = 1, "blocked"
+	var errIllegalTransition *IllegalTransitionError
+	if err := ctl.Update(ctx, task.UUID, &model.TaskPatch{Status: &done}, 0); !errors.As(err, &errIllegalTransition) {
+		t.Errorf("Update() of a task blocked meanwhile error = %v, want an IllegalTransitionError", err)
+	}
+	if got := status(task.UUID); got != "blocked" {
+		t.Errorf("status = %s, want blocked", got)
+	}
+
+	// A change meanwhile still allowing the transition is retried.
+	task = createInProgress()
+	dao.races, dao.status = 1, "todo"
+	if err := ctl.Update(ctx, task.UUID, &model.TaskPatch{Status: &done}, 0); err != nil {
+		t.Errorf("Update() of a task reopened meanwhile error = %v", err)
+	}
+	if got := status(task.UUID); got != done {
+		t.Errorf("status = %s, want done", got)
+	}
+
+	// A status changing at each attempt fails with a conflict.
+	task = createInProgress()
+	dao.races, dao.status = maxStatusUpdateAttempts, "todo"
+	var errConflict *repositories.ConflictError
+	if err := ctl.Update(ctx, task.UUID, &model.TaskPatch{Status: &done}, 0); !errors.As(err, &errConflict) {
+		t.Errorf("Update() of a task changing at each attempt error = %v, want a ConflictError", err)
+	}
+	if got := status(task.UUID); got != "todo" {
+		t.Errorf("status = %s, want todo", got)
+	}
+
+	// The version expected by the caller isn't retried.
+	task = createInProgress()
+	current, err := ctl.Get(ctx, task.UUID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	dao.races, dao.status = 1, "todo"
+	var errVersionMismatch *repositories.VersionMismatchError
+	if err := ctl.Update(ctx, task.UUID, &model.TaskPatch{Status: &done}, current.Version); !errors.As(err, &errVersionMismatch) {
+		t.Errorf("Update() with an expected version changed meanwhile error = %v, want a VersionMismatchError", err)
+	}
+}
+
+// filterTaskDAO keeps the last filter of ReadAll.
+type filterTaskDAO struct {
+	repositories.ITaskDAO
+	filter model.TaskFilterDTO
+}
+
+func (dao *filterTaskDAO) ReadAll(ctx context.Context, filter *model.TaskFilterDTO) ([]*model.Task, string, error) {
+	dao.filter = *filter
+	return dao.ITaskDAO.ReadAll(ctx, filter)
+}
+
+func TestTaskControllerGetAllTags(t *testing.T) {
+	ctl, ctx := newTestTaskController(t, TaskControllerConf{})
+	dao := &filterTaskDAO{ITaskDAO: ctl.daoTask}
+	ctl.daoTask = dao
+
+	// The SQL DAOs count the distinct tags of a task against the requested ones with TagMatchAll.
+	filter := &model.TaskFilterDTO{Tag: []string{" work", "home", "work ", "", "home"}, TagMatch: model.TagMatchAll, Limit: 10}
+	if _, err := ctl.GetAll(ctx, filter); err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if !reflect.DeepEqual(dao.filter.Tag, []string{"home", "work"}) {
+		t.Errorf("GetAll() read the tags %q, want home and work once", dao.filter.Tag)
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// sortByDependencies orders the tasks so that each task comes after its blockers, the tasks without
+// order between them keep their order. The edges may go through tasks missing from tasks.
+func sortByDependencies(tasks []*model.Task, edges []*model.TaskDependency) []*model.Task {
+	blockers := make(map[uuid.UUID][]uuid.UUID)
+	for _, edge := range edges {
+		blockers[edge.TaskUUID] = append(blockers[edge.TaskUUID], edge.BlockerUUID)
+	}
+	listed := make(map[uuid.UUID]*model.Task, len(tasks))
+	for _, task := range tasks {
+		listed[task.UUID] = task
+	}
+
+	// A depth-first walk of the blockers lists each task after them, a cycle is cut where it is found.
+	sorted := make([]*model.Task, 0, len(tasks))
+	visited := make(map[uuid.UUID]bool)
+	var visit func(taskUUID uuid.UUID)
+	visit = func(taskUUID uuid.UUID) {
+		if visited[taskUUID] {
+			return
+		}
+		visited[taskUUID] = true
+		for _, blockerUUID := range blockers[taskUUID] {
+			visit(blockerUUID)
+		}
+		if task, ok := listed[taskUUID]; ok {
+			sorted = append(sorted, task)
+		}
+	}
+	for _, task := range tasks {
+		visit(task.UUID)
+	}
+
+	return sorted
+}
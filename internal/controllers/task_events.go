@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+)
+
+const (
+	// DefaultEventHistorySize is the number of changes kept to resume the feed when none is configured.
+	DefaultEventHistorySize = 1000
+	// DefaultEventSubscriberBuffer is the number of changes waiting for a subscriber when none is configured.
+	DefaultEventSubscriberBuffer = 64
+)
+
+// ErrFeedClosed is returned by TaskFeed.Next when the feed ends, because the subscriber couldn't keep up
+// with the changes or the API is shutting down. The subscriber resumes the feed after the last change it received.
+var ErrFeedClosed = errors.New("the change feed is closed")
+
+// EventsConf configures the change feed of the tasks.
+type EventsConf struct {
+	// HistorySize is the number of changes kept in memory to resume the feed.
+	HistorySize int `mapstructure:"history_size"`
+	// SubscriberBuffer is the number of changes waiting for a subscriber, the feed of a subscriber
+	// falling further behind is closed.
+	SubscriberBuffer int `mapstructure:"subscriber_buffer"`
+}
+
+// taskEventBus publishes the changes of the tasks made by the process to the subscribers of the change feed.
+// It keeps the last changes so a subscriber resumes the feed after a disconnection.
+type taskEventBus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []*model.TaskChange
+	historySize int
+	bufferSize  int
+	subscribers map[*taskSubscription]struct{}
+	// listeners are called with each change once it is published, in the goroutine of the publisher.
+	listeners []func(ctx context.Context, change *model.TaskChange)
+	closed    bool
+}
+
+// taskSubscription receives the changes published once it subscribed.
+// Its channel is closed when it is unsubscribed, when it falls behind or when the bus is closed.
+type taskSubscription struct {
+	changes chan *model.TaskChange
+}
+
+func newTaskEventBus(conf EventsConf) *taskEventBus {
+	if conf.HistorySize <= 0 {
+		conf.HistorySize = DefaultEventHistorySize
+	}
+	if conf.SubscriberBuffer <= 0 {
+		conf.SubscriberBuffer = DefaultEventSubscriberBuffer
+	}
+
+	return &taskEventBus{
+		// The ids start from the start of the bus so the ids of a previous run are older than its history.
+		lastID:      uint64(time.Now().UnixMicro()),
+		historySize: conf.HistorySize,
+		bufferSize:  conf.SubscriberBuffer,
+		subscribers: make(map[*taskSubscription]struct{}),
+	}
+}
+
+// publish gives the next id to the change, sends it to the subscribers then calls the listeners.
+func (b *taskEventBus) publish(ctx context.Context, change *model.TaskChange) {
+	if !b.send(change) {
+		return
+	}
+
+	// The listeners are called without the lock, they may be slow.
+	for _, listener := range b.listeners {
+		listener(ctx, change)
+	}
+}
+
+// send gives the next id to the change and sends it to the subscribers, it returns false once the bus is closed.
+func (b *taskEventBus) send(change *model.TaskChange) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return false
+	}
+
+	b.lastID++
+	change.ID = b.lastID
+	if len(b.history) == b.historySize {
+		b.history = slices.Delete(b.history, 0, 1)
+	}
+	b.history = append(b.history, change)
+
+	for subscription := range b.subscribers {
+		select {
+		case subscription.changes <- change:
+		default:
+			// The subscriber catches up from the history once it resumes the feed.
+			delete(b.subscribers, subscription)
+			close(subscription.changes)
+		}
+	}
+	return true
+}
+
+// listen registers a listener of the changes, it must be called before the first change is published.
+func (b *taskEventBus) listen(listener func(ctx context.Context, change *model.TaskChange)) {
+	b.listeners = append(b.listeners, listener)
+}
+
+// subscribe registers a subscriber. With lastID, it returns the changes of the history published after it,
+// or a reset if the history doesn't hold all of them.
+func (b *taskEventBus) subscribe(lastID *uint64) (*taskSubscription, []*model.TaskChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription := &taskSubscription{changes: make(chan *model.TaskChange, b.bufferSize)}
+	if b.closed {
+		close(subscription.changes)
+		return subscription, nil
+	}
+	b.subscribers[subscription] = struct{}{}
+
+	if lastID == nil || *lastID == b.lastID {
+		return subscription, nil
+	}
+
+	// The ids of the history follow each other, the next change must still be there.
+	next, found := slices.BinarySearchFunc(b.history, *lastID+1, func(change *model.TaskChange, id uint64) int {
+		return cmp.Compare(change.ID, id)
+	})
+	if *lastID > b.lastID || !found {
+		return subscription, []*model.TaskChange{{ID: b.lastID, Type: model.TaskChangeReset, OccurredAt: time.Now()}}
+	}
+	return subscription, slices.Clone(b.history[next:])
+}
+
+// unsubscribe removes the subscriber, it can be called after its channel was closed.
+func (b *taskEventBus) unsubscribe(subscription *taskSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exist := b.subscribers[subscription]; exist {
+		delete(b.subscribers, subscription)
+		close(subscription.changes)
+	}
+}
+
+// close ends the feed of every subscriber, the changes published afterwards are dropped.
+func (b *taskEventBus) close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for subscription := range b.subscribers {
+		close(subscription.changes)
+	}
+	clear(b.subscribers)
+}
+
+// TaskFeed streams the changes of the tasks visible by a subscriber, it must be closed once the subscriber leaves.
+type TaskFeed struct {
+	bus          *taskEventBus
+	subscription *taskSubscription
+	backlog      []*model.TaskChange
+	statuses     []model.TaskStatus
+	daoTask      repositories.ITaskDAO
+}
+
+// Next waits for the next change of a task visible by the principal of ctx and matching the statuses of the feed.
+// It fails with ErrFeedClosed once the feed ends.
+func (f *TaskFeed) Next(ctx context.Context) (*model.TaskChangePublicDTO, error) {
+	for {
+		var change *model.TaskChange
+		if len(f.backlog) > 0 {
+			change, f.backlog = f.backlog[0], f.backlog[1:]
+		} else {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case next, open := <-f.subscription.changes:
+				if !open {
+					return nil, ErrFeedClosed
+				}
+				change = next
+			}
+		}
+
+		if change.Type == model.TaskChangeReset {
+			return model.FactoryTaskChangePublicDTO(change), nil
+		}
+		if !f.matches(change) {
+			continue
+		}
+		visible, err := f.visible(ctx, change.Task)
+		if err != nil {
+			return nil, fmt.Errorf("fail to get next task change: %w", err)
+		}
+		if visible {
+			return model.FactoryTaskChangePublicDTO(change), nil
+		}
+	}
+}
+
+// Close unsubscribes the feed.
+func (f *TaskFeed) Close() {
+	f.bus.unsubscribe(f.subscription)
+}
+
+// matches reports whether the task had one of the statuses of the feed before or after the change.
+func (f *TaskFeed) matches(change *model.TaskChange) bool {
+	return len(f.statuses) == 0 ||
+		slices.Contains(f.statuses, change.Task.Status) ||
+		slices.Contains(f.statuses, change.PreviousStatus)
+}
+
+// visible reports whether the task is owned by or shared with the principal of ctx, in the trash too.
+func (f *TaskFeed) visible(ctx context.Context, task *model.Task) (bool, error) {
+	if owner, scoped := model.OwnerScopeFromContext(ctx); !scoped || owner == task.OwnerID {
+		return true, nil
+	}
+
+	_, err := f.daoTask.ReadRole(ctx, task.UUID)
+	var errNoDataFound *repositories.NoDataFoundError
+	if errors.As(err, &errNoDataFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("fail to get task role: %w", err)
+	}
+	return true, nil
+}
+
+// Subscribe subscribes the caller to the changes of the tasks it sees, published from now on
+// or after the change feed.LastEventID when it resumes the feed.
+func (c *TaskController) Subscribe(ctx context.Context, feed *model.TaskFeedDTO) (*TaskFeed, error) {
+	statuses := make([]model.TaskStatus, 0, len(feed.Status))
+	for _, status := range feed.Status {
+		status, err := c.workflow.Parse(status)
+		if err != nil {
+			return nil, fmt.Errorf("fail to subscribe to task changes: %w", err)
+		}
+		statuses = append(statuses, status)
+	}
+
+	subscription, backlog := c.events.subscribe(feed.LastEventID)
+	return &TaskFeed{
+		bus:          c.events,
+		subscription: subscription,
+		backlog:      backlog,
+		statuses:     statuses,
+		daoTask:      c.daoTask,
+	}, nil
+}
+
+// publish publishes the change of the task made by the caller, previousStatus is only kept if it changed.
+func (c *TaskController) publish(ctx context.Context, changeType model.TaskEventType, task *model.Task, previousStatus model.TaskStatus) {
+	if previousStatus == task.Status {
+		previousStatus = ""
+	}
+
+	c.events.publish(ctx, &model.TaskChange{
+		Type:           changeType,
+		Task:           task,
+		PreviousStatus: previousStatus,
+		Actor:          model.ActorFromContext(ctx),
+		OccurredAt:     time.Now(),
+	})
+}
+
+// publishUpdated reads the task updated by the caller and publishes its change.
+// The update is already done, a failure to read the task is only logged.
+func (c *TaskController) publishUpdated(ctx context.Context, taskUUID uuid.UUID, previousStatus model.TaskStatus) {
+	task, err := c.daoTask.ReadByUUID(systemContext(ctx), taskUUID)
+	if err != nil {
+		log.Error("fail to publish the task change", zap.Any("task_uuid", taskUUID), zap.Error(err))
+		return
+	}
+
+	c.publish(ctx, model.TaskEventUpdated, task, previousStatus)
+}
+
+// readDeleted returns the task deleted with cascade and its live descendants, whoever owns them,
+// as they are before the deletion. There are no descendants when the deletion is restricted.
+func (c *TaskController) readDeleted(ctx context.Context, taskUUID uuid.UUID, cascade model.TaskDeleteCascade) (*model.Task, []*model.Task, error) {
+	task, err := c.daoTask.ReadByUUID(ctx, taskUUID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to get task: %w", err)
+	}
+	if cascade == model.TaskDeleteRestrict {
+		return task, nil, nil
+	}
+
+	subtree, err := c.daoTask.ReadSubtree(systemContext(ctx), taskUUID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fail to get task subtree: %w", err)
+	}
+	descendants := slices.DeleteFunc(subtree, func(descendant *model.Task) bool {
+		return descendant.UUID == taskUUID
+	})
+	return task, descendants, nil
+}
+
+// publishDeleted publishes the deletion of the task and the changes of its descendants according to cascade:
+// the subtasks of the owner of the task are deleted with it, the subtasks of another owner are detached
+// and keep their own subtasks, like the children of the task without the subtree cascade.
+func (c *TaskController) publishDeleted(ctx context.Context, task *model.Task, descendants []*model.Task, cascade model.TaskDeleteCascade) {
+	c.publish(ctx, model.TaskEventDeleted, task, "")
+
+	children := make(map[uuid.UUID][]*model.Task)
+	for _, descendant := range descendants {
+		children[*descendant.ParentUUID] = append(children[*descendant.ParentUUID], descendant)
+	}
+	for deleted := []uuid.UUID{task.UUID}; len(deleted) > 0; deleted = deleted[1:] {
+		for _, child := range children[deleted[0]] {
+			if cascade != model.TaskDeleteSubtree || child.OwnerID != task.OwnerID {
+				c.publishUpdated(ctx, child.UUID, "")
+				continue
+			}
+			c.publish(ctx, model.TaskEventDeleted, child, "")
+			deleted = append(deleted, child.UUID)
+		}
+	}
+}
+
+// systemContext returns a copy of ctx reading the tasks of every owner, like the background jobs.
+func systemContext(ctx context.Context) context.Context {
+	return model.ContextWithPrincipal(ctx, &model.Principal{ID: model.SystemActor, Admin: true})
+}
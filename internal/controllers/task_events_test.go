@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// nextTestChange waits for the next change of the feed and fails the test unless one comes.
+func nextTestChange(t *testing.T, ctx context.Context, feed *TaskFeed) *model.TaskChangePublicDTO {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	change, err := feed.Next(ctx)
+	if err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	return change
+}
+
+// createTestTask creates a task and fails the test unless it is created.
+func createTestTask(t *testing.T, ctl *TaskController, ctx context.Context, task *model.TaskCreateDTO) *model.TaskPublicDTO {
+	t.Helper()
+
+	created, err := ctl.Create(ctx, task)
+	if err != nil {
+		t.Fatalf("Create(%s) error = %v", task.WhatToDo, err)
+	}
+	return created
+}
+
+func TestTaskControllerPublishDeletedSubtree(t *testing.T) {
+	ctl, alice := newTestTaskController(t, TaskControllerConf{})
+	bobID := "user-" + uuid.NewString()
+	bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: bobID})
+	admin := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "root", Admin: true})
+
+	// The release of alice has a subtask of alice and a subtask of bob, each with a subtask of their own.
+	release := createTestTask(t, ctl, alice, &model.TaskCreateDTO{WhatToDo: "release"})
+	if _, err := ctl.Grant(alice, release.UUID, bobID, model.TaskRoleEditor); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	tests := createTestTask(t, ctl, alice, &model.TaskCreateDTO{WhatToDo: "run the tests", ParentUUID: &release.UUID})
+	unit := createTestTask(t, ctl, alice, &model.TaskCreateDTO{WhatToDo: "run the unit tests", ParentUUID: &tests.UUID})
+	review := createTestTask(t, ctl, bob, &model.TaskCreateDTO{WhatToDo: "review the code", ParentUUID: &release.UUID})
+	createTestTask(t, ctl, bob, &model.TaskCreateDTO{WhatToDo: "review the docs", ParentUUID: &review.UUID})
+
+	feed, err := ctl.Subscribe(admin, &model.TaskFeedDTO{})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	defer feed.Close()
+
+	if err := ctl.Delete(alice, release.UUID, 0, model.TaskDeleteSubtree); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	// The change following the deletion marks the end of its changes.
+	end := createTestTask(t, ctl, alice, &model.TaskCreateDTO{WhatToDo: "celebrate"})
+
+	got := make(map[uuid.UUID]model.TaskEventType)
+	for {
+		change := nextTestChange(t, admin, feed)
+		if change.Task.UUID == end.UUID {
+			break
+		}
+		if _, exist := got[change.Task.UUID]; exist {
+			t.Errorf("change %s of %s published twice", change.Type, change.Task.WhatToDo)
+		}
+		got[change.Task.UUID] = change.Type
+		// The subtask of bob is detached from the release, with its own subtask.
+		if change.Task.UUID == review.UUID && change.Task.ParentUUID != nil {
+			t.Errorf("updated review parent = %v, want none", change.Task.ParentUUID)
+		}
+	}
+
+	want := map[uuid.UUID]model.TaskEventType{
+		release.UUID: model.TaskEventDeleted,
+		tests.UUID:   model.TaskEventDeleted,
+		unit.UUID:    model.TaskEventDeleted,
+		review.UUID:  model.TaskEventUpdated,
+	}
+	if len(got) != len(want) {
+		t.Errorf("Delete() published %d changes, want %d", len(got), len(want))
+	}
+	for taskUUID, changeType := range want {
+		if got[taskUUID] != changeType {
+			t.Errorf("change of %v = %q, want %q", taskUUID, got[taskUUID], changeType)
+		}
+	}
+}
+
+// setTestStatus moves the task to the status and fails the test unless it moves.
+func setTestStatus(t *testing.T, ctl *TaskController, ctx context.Context, taskUUID uuid.UUID, status model.TaskStatus) {
+	t.Helper()
+
+	if err := ctl.Update(ctx, taskUUID, &model.TaskPatch{Status: &status}, 0); err != nil {
+		t.Fatalf("Update() to %s error = %v", status, err)
+	}
+}
+
+// subscribeTest subscribes to the changes and closes the feed at the end of the test.
+func subscribeTest(t *testing.T, ctl *TaskController, ctx context.Context, feed *model.TaskFeedDTO) *TaskFeed {
+	t.Helper()
+
+	subscription, err := ctl.Subscribe(ctx, feed)
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	t.Cleanup(subscription.Close)
+	return subscription
+}
+
+func TestTaskControllerSubscribeResume(t *testing.T) {
+	ctl, ctx := newTestTaskController(t, TaskControllerConf{Events: EventsConf{HistorySize: 3}})
+
+	feed := subscribeTest(t, ctl, ctx, &model.TaskFeedDTO{})
+	ids := make([]uint64, 0, 3)
+	for _, whatToDo := range []string{"plan", "build", "ship"} {
+		createTestTask(t, ctl, ctx, &model.TaskCreateDTO{WhatToDo: whatToDo})
+		change := nextTestChange(t, ctx, feed)
+		if change.Type != model.TaskEventCreated || change.Task.WhatToDo != whatToDo {
+			t.Fatalf("Next() = %s of %v, want %s created", change.Type, change.Task, whatToDo)
+		}
+		if len(ids) > 0 && change.ID <= ids[len(ids)-1] {
+			t.Errorf("Next() id = %d, want more than %d", change.ID, ids[len(ids)-1])
+		}
+		ids = append(ids, change.ID)
+	}
+
+	// The feed resumes after the last change received.
+	resumed := subscribeTest(t, ctl, ctx, &model.TaskFeedDTO{LastEventID: &ids[0]})
+	for _, want := range ids[1:] {
+		if change := nextTestChange(t, ctx, resumed); change.ID != want {
+			t.Errorf("Next() of the resumed feed id = %d, want %d", change.ID, want)
+		}
+	}
+	// The feed resumed after the last change goes on with the next one.
+	upToDate := subscribeTest(t, ctl, ctx, &model.TaskFeedDTO{LastEventID: &ids[2]})
+	createTestTask(t, ctl, ctx, &model.TaskCreateDTO{WhatToDo: "celebrate"})
+	last := nextTestChange(t, ctx, upToDate)
+	if last.Task == nil || last.Task.WhatToDo != "celebrate" {
+		t.Errorf("Next() of the feed up to date = %+v, want celebrate created", last)
+	}
+
+	// The history of 3 changes doesn't hold the change of plan anymore, or the id comes from nowhere:
+	// the subscriber starts over from the last change.
+	stale, future := ids[0]-1, last.ID+1000
+	for _, lastID := range []uint64{stale, future} {
+		reset := subscribeTest(t, ctl, ctx, &model.TaskFeedDTO{LastEventID: &lastID})
+		change := nextTestChange(t, ctx, reset)
+		if change.Type != model.TaskChangeReset || change.Task != nil || change.ID != last.ID {
+			t.Errorf("Next() resumed after %d = %s %d, want a reset at %d", lastID, change.Type, change.ID, last.ID)
+		}
+	}
+}
+
+func TestTaskControllerSubscribeStatus(t *testing.T) {
+	ctl, ctx := newTestTaskController(t, TaskControllerConf{})
+
+	var errUnknownStatus *UnknownStatusError
+	if _, err := ctl.Subscribe(ctx, &model.TaskFeedDTO{Status: []model.TaskStatus{"waiting"}}); !errors.As(err, &errUnknownStatus) {
+		t.Errorf("Subscribe() to an unknown status error = %v, want an UnknownStatusError", err)
+	}
+
+	// The changes of the tasks entering or leaving the status are kept, with their previous status.
+	feed := subscribeTest(t, ctl, ctx, &model.TaskFeedDTO{Status: []model.TaskStatus{"In Progress"}})
+	task := createTestTask(t, ctl, ctx, &model.TaskCreateDTO{WhatToDo: "write the report"})
+	setTestStatus(t, ctl, ctx, task.UUID, "in_progress")
+	setTestStatus(t, ctl, ctx, task.UUID, "done")
+	setTestStatus(t, ctl, ctx, task.UUID, "archived")
+	other := createTestTask(t, ctl, ctx, &model.TaskCreateDTO{WhatToDo: "read the report"})
+	setTestStatus(t, ctl, ctx, other.UUID, "in_progress")
+
+	want := []struct {
+		uuid           uuid.UUID
+		status         model.TaskStatus
+		previousStatus model.TaskStatus
+	}{
+		{uuid: task.UUID, status: "in_progress", previousStatus: "todo"},
+		{uuid: task.UUID, status: "done", previousStatus: "in_progress"},
+		{uuid: other.UUID, status: "in_progress", previousStatus: "todo"},
+	}
+	for _, want := range want {
+		change := nextTestChange(t, ctx, feed)
+		if change.Type != model.TaskEventUpdated || change.Task.UUID != want.uuid || change.Task.Status != want.status || change.PreviousStatus != want.previousStatus {
+			t.Errorf("Next() = %s of %s from %q to %s, want %s updated from %s to %s", change.Type, change.Task.WhatToDo,
+				change.PreviousStatus, change.Task.Status, want.uuid, want.previousStatus, want.status)
+		}
+	}
+}
+
+func TestTaskControllerSubscribeVisibility(t *testing.T) {
+	ctl, alice := newTestTaskController(t, TaskControllerConf{})
+	bobID := "user-" + uuid.NewString()
+	bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: bobID})
+	admin := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "root", Admin: true})
+
+	bobFeed := subscribeTest(t, ctl, bob, &model.TaskFeedDTO{})
+	adminFeed := subscribeTest(t, ctl, admin, &model.TaskFeedDTO{})
+
+	// bob only sees the changes of his tasks and of the tasks shared with him.
+	report := createTestTask(t, ctl, alice, &model.TaskCreateDTO{WhatToDo: "write the report"})
+	review := createTestTask(t, ctl, bob, &model.TaskCreateDTO{WhatToDo: "review the report"})
+	if change := nextTestChange(t, bob, bobFeed); change.Task.UUID != review.UUID {
+		t.Errorf("Next() by bob = %s of %s, want the review created", change.Type, change.Task.WhatToDo)
+	}
+
+	if _, err := ctl.Grant(alice, report.UUID, bobID, model.TaskRoleViewer); err != nil {
+		t.Fatalf("Grant() error = %v", err)
+	}
+	setTestStatus(t, ctl, alice, report.UUID, "in_progress")
+	if change := nextTestChange(t, bob, bobFeed); change.Task.UUID != report.UUID || change.Type != model.TaskEventUpdated {
+		t.Errorf("Next() by bob = %s of %s, want the report shared with him updated", change.Type, change.Task.WhatToDo)
+	}
+
+	// An admin sees every change.
+	for _, want := range []uuid.UUID{report.UUID, review.UUID, report.UUID} {
+		if change := nextTestChange(t, admin, adminFeed); change.Task.UUID != want {
+			t.Errorf("Next() by an admin = %s of %s, want %v", change.Type, change.Task.WhatToDo, want)
+		}
+	}
+}
+
+func TestTaskControllerSubscribeSlow(t *testing.T) {
+	ctl, ctx := newTestTaskController(t, TaskControllerConf{Events: EventsConf{SubscriberBuffer: 1}})
+
+	// The feed falling behind is closed once its buffer is full, the subscriber resumes it from the history.
+	feed := subscribeTest(t, ctl, ctx, &model.TaskFeedDTO{})
+	for _, whatToDo := range []string{"plan", "build", "ship"} {
+		createTestTask(t, ctl, ctx, &model.TaskCreateDTO{WhatToDo: whatToDo})
+	}
+	first := nextTestChange(t, ctx, feed)
+	if first.Task.WhatToDo != "plan" {
+		t.Errorf("Next() = %s of %s, want plan created", first.Type, first.Task.WhatToDo)
+	}
+	if _, err := feed.Next(ctx); !errors.Is(err, ErrFeedClosed) {
+		t.Fatalf("Next() of a feed behind error = %v, want ErrFeedClosed", err)
+	}
+
+	resumed := subscribeTest(t, ctl, ctx, &model.TaskFeedDTO{LastEventID: &first.ID})
+	for _, want := range []string{"build", "ship"} {
+		if change := nextTestChange(t, ctx, resumed); change.Task.WhatToDo != want {
+			t.Errorf("Next() of the resumed feed = %s of %s, want %s created", change.Type, change.Task.WhatToDo, want)
+		}
+	}
+
+	// The feeds end with the API.
+	ctl.events.close()
+	if _, err := resumed.Next(ctx); !errors.Is(err, ErrFeedClosed) {
+		t.Errorf("Next() of a closed bus error = %v, want ErrFeedClosed", err)
+	}
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// buildTaskTree returns the node of the first task of subtree, with its subtasks in the order of subtree.
+func (c *TaskController) buildTaskTree(rootUUID uuid.UUID, subtree []*model.Task) *model.TaskNodePublicDTO {
+	var root *model.Task
+	children := make(map[uuid.UUID][]*model.Task)
+	for _, task := range subtree {
+		if task.UUID == rootUUID {
+			root = task
+			continue
+		}
+		if task.ParentUUID != nil {
+			children[*task.ParentUUID] = append(children[*task.ParentUUID], task)
+		}
+	}
+	if root == nil {
+		return nil
+	}
+
+	node, _, _ := c.buildTaskNode(root, children, map[uuid.UUID]bool{})
+	return &node
+}
+
+// buildTaskNode returns the node of the task, and the number of closed leaves and of leaves of its subtree.
+// The completion of a task rolls up the leaves of its subtree, its own status only counts for a leaf.
+func (c *TaskController) buildTaskNode(task *model.Task, children map[uuid.UUID][]*model.Task, seen map[uuid.UUID]bool) (node model.TaskNodePublicDTO, closed, leaves int) {
+	seen[task.UUID] = true
+	node = model.TaskNodePublicDTO{
+		TaskPublicDTO: *model.FactoryTaskPublicDTO(task),
+		Children:      []model.TaskNodePublicDTO{},
+	}
+
+	for _, child := range children[task.UUID] {
+		if seen[child.UUID] {
+			continue
+		}
+		childNode, childClosed, childLeaves := c.buildTaskNode(child, children, seen)
+		node.Children = append(node.Children, childNode)
+		closed += childClosed
+		leaves += childLeaves
+	}
+
+	if leaves == 0 {
+		leaves = 1
+		if c.workflow.IsClosed(task.Status) {
+			closed = 1
+		}
+	}
+	node.Completion = closed * 100 / leaves
+	return node, closed, leaves
+}
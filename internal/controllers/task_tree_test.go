@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"testing"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestBuildTaskTree(t *testing.T) {
+	workflow, err := NewStatusWorkflow(StatusWorkflowConf{})
+	if err != nil {
+		t.Fatalf("NewStatusWorkflow() error = %v", err)
+	}
+	c := &TaskController{workflow: workflow}
+
+	// root has the subtasks a (done), b (todo) with b1 (done) and b2 (in progress), and c (archived).
+	task := func(whatToDo string, status model.TaskStatus, parent *model.Task) *model.Task {
+		task := &model.Task{UUID: uuid.New(), WhatToDo: whatToDo, Status: status}
+		if parent != nil {
+			task.ParentUUID = &parent.UUID
+		}
+		return task
+	}
+	root := task("root", "todo", nil)
+	a := task("a", "done", root)
+	b := task("b", "todo", root)
+	b1 := task("b1", "done", b)
+	b2 := task("b2", "in_progress", b)
+	c2 := task("c", "archived", root)
+	subtree := []*model.Task{root, a, b, b1, b2, c2}
+
+	node := c.buildTaskTree(root.UUID, subtree)
+	if node == nil || node.UUID != root.UUID || len(node.Children) != 3 {
+		t.Fatalf("buildTaskTree() = %+v, want root with 3 children", node)
+	}
+	// The status of root and b doesn't count, only the leaves a, b1, b2 and c do.
+	completions := map[string]int{"root": 75, "a": 100, "b": 50, "b1": 100, "b2": 0, "c": 100}
+	var check func(node model.TaskNodePublicDTO)
+	check = func(node model.TaskNodePublicDTO) {
+		if want := completions[node.WhatToDo]; node.Completion != want {
+			t.Errorf("completion of %s = %d, want %d", node.WhatToDo, node.Completion, want)
+		}
+		for _, child := range node.Children {
+			check(child)
+		}
+	}
+	check(*node)
+	if children := node.Children; children[0].UUID != a.UUID || children[1].UUID != b.UUID || children[2].UUID != c2.UUID {
+		t.Errorf("children of root = %s, %s, %s, want a, b, c in the order of the subtree", children[0].WhatToDo, children[1].WhatToDo, children[2].WhatToDo)
+	}
+
+	// A subtree whose root is missing has no node, and a lone task is complete once closed.
+	if node := c.buildTaskTree(uuid.New(), subtree); node != nil {
+		t.Errorf("buildTaskTree() of a missing root = %+v, want nil", node)
+	}
+	if node := c.buildTaskTree(b1.UUID, []*model.Task{b1}); node == nil || node.Completion != 100 || len(node.Children) != 0 {
+		t.Errorf("buildTaskTree() of a closed leaf = %+v, want 100 without children", node)
+	}
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultTrashPurgeInterval is the number of seconds between two purges of the trash when none is configured.
+const DefaultTrashPurgeInterval = 3600
+
+// TrashConf configures how long the deleted tasks are kept.
+type TrashConf struct {
+	// RetentionDays is the number of days a task stays in the trash before it is purged, 0 keeps it forever.
+	RetentionDays int `mapstructure:"retention_days"`
+	// PurgeInterval is the number of seconds between two purges.
+	PurgeInterval int `mapstructure:"purge_interval"`
+}
+
+// startTrashPurger starts the job running PurgeTrash of a controller at regular intervals,
+// it returns nil if the retention is disabled.
+func startTrashPurger(ctl ITaskController, conf TrashConf) *periodicJob {
+	if conf.RetentionDays <= 0 {
+		return nil
+	}
+
+	interval := conf.PurgeInterval
+	if interval <= 0 {
+		interval = DefaultTrashPurgeInterval
+	}
+
+	return startPeriodicJob(time.Duration(interval)*time.Second, func(ctx context.Context) {
+		count, err := ctl.PurgeTrash(ctx)
+		if err != nil {
+			log.Error("fail to purge the trash", zap.Error(err))
+		} else if count > 0 {
+			log.Info("trash purged", zap.Int64("tasks", count))
+		}
+	})
+}
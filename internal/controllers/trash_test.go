@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+)
+
+// purgeTaskDAO records the dates given to Purge.
+type purgeTaskDAO struct {
+	repositories.ITaskDAO
+	deletedBefore []time.Time
+	err           error
+}
+
+func (dao *purgeTaskDAO) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	dao.deletedBefore = append(dao.deletedBefore, deletedBefore)
+	return 2, dao.err
+}
+
+func TestTaskControllerPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+
+	dao := &purgeTaskDAO{}
+	ctl := &TaskController{daoTask: dao}
+	if count, err := ctl.PurgeTrash(ctx); err != nil || count != 0 || len(dao.deletedBefore) != 0 {
+		t.Errorf("PurgeTrash() without retention = %d, %v, want 0 without purge", count, err)
+	}
+
+	ctl.trash = TrashConf{RetentionDays: 30}
+	before := time.Now().AddDate(0, 0, -30)
+	count, err := ctl.PurgeTrash(ctx)
+	after := time.Now().AddDate(0, 0, -30)
+	if err != nil || count != 2 {
+		t.Fatalf("PurgeTrash() = %d, %v, want 2", count, err)
+	}
+	if cutoff := dao.deletedBefore[0]; cutoff.Before(before) || cutoff.After(after) {
+		t.Errorf("Purge() deletedBefore = %v, want 30 days ago", cutoff)
+	}
+
+	dao.err = errors.New("boom")
+	if _, err := ctl.PurgeTrash(ctx); !errors.Is(err, dao.err) {
+		t.Errorf("PurgeTrash() error = %v, want the error of the DAO", err)
+	}
+}
+
+// purgeTaskController counts the calls to PurgeTrash.
+type purgeTaskController struct {
+	ITaskController
+	purged chan struct{}
+}
+
+func (c *purgeTaskController) PurgeTrash(ctx context.Context) (int64, error) {
+	c.purged <- struct{}{}
+	return 1, nil
+}
+
+func TestStartTrashPurger(t *testing.T) {
+	ctl := &purgeTaskController{purged: make(chan struct{}, 1)}
+
+	if job := startTrashPurger(ctl, TrashConf{}); job != nil {
+		job.stop()
+		t.Fatal("startTrashPurger() without retention started a job")
+	}
+
+	job := startTrashPurger(ctl, TrashConf{RetentionDays: 1, PurgeInterval: 3600})
+	defer job.stop()
+
+	// The trash is purged right away, then every interval.
+	select {
+	case <-ctl.purged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("startTrashPurger() didn't purge the trash")
+	}
+}
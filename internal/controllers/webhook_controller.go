@@ -0,0 +1,382 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+)
+
+var (
+	_ IWebhookController = (*WebhookController)(nil)
+)
+
+// IWebhookController is an interface for WebhookController and WebhookControllerMocking.
+type IWebhookController interface {
+	Create(ctx context.Context, webhookToCreate *model.WebhookCreateDTO) (*model.WebhookPublicDTO, error)
+	Get(ctx context.Context, webhookUUID uuid.UUID) (*model.WebhookPublicDTO, error)
+	GetAll(ctx context.Context) (*model.WebhooksPublicDTO, error)
+	Update(ctx context.Context, webhookUUID uuid.UUID, patch *model.WebhookPatch) error
+	Delete(ctx context.Context, webhookUUID uuid.UUID) error
+	GetDeliveries(ctx context.Context, webhookUUID uuid.UUID, filter *model.WebhookDeliveryFilterDTO) (*model.WebhookDeliveriesPublicDTO, error)
+	RetryDelivery(ctx context.Context, webhookUUID, deliveryUUID uuid.UUID) (*model.WebhookDeliveryPublicDTO, error)
+	Deliver(ctx context.Context) (int64, error)
+}
+
+// WebhookControllerConf is a configuration structure for WebhookController.
+type WebhookControllerConf struct {
+	// WebhookDAO must use the database of the TaskDAO, or its own File connector with a WebhookInMemoryDAO.
+	WebhookDAO repositories.DAOFactoryOptions `mapstructure:"webhook_dao"`
+	Delivery   WebhookDeliveryConf            `mapstructure:"delivery"`
+	// AllowedNetworks are the CIDRs of the loopback, private or link-local addresses the webhooks may target,
+	// e.g. the receivers of the internal network. The other ones are rejected.
+	AllowedNetworks []string `mapstructure:"allowed_networks"`
+}
+
+// WebhookController is an controllers to manage business logic of Webhook and to deliver the changes of the tasks.
+type WebhookController struct {
+	daoWebhook repositories.IWebhookDAO
+	daoTask    repositories.ITaskDAO
+	delivery   WebhookDeliveryConf
+	networks   webhookNetworks
+	client     *http.Client
+}
+
+// Create creates the webhook, its response is the only one showing the secret.
+func (c *WebhookController) Create(ctx context.Context, webhookToCreate *model.WebhookCreateDTO) (*model.WebhookPublicDTO, error) {
+	if err := c.checkURL(ctx, webhookToCreate.URL); err != nil {
+		return nil, fmt.Errorf("fail to create webhook: %w", err)
+	}
+	if webhookToCreate.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, fmt.Errorf("fail to create webhook: %w", err)
+		}
+		webhookToCreate.Secret = secret
+	}
+
+	webhook, err := c.daoWebhook.Create(ctx, webhookToCreate)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create webhook: %w", err)
+	}
+
+	publicWebhook := model.FactoryWebhookPublicDTO(webhook)
+	publicWebhook.Secret = webhook.Secret
+	return publicWebhook, nil
+}
+
+func (c *WebhookController) Get(ctx context.Context, webhookUUID uuid.UUID) (*model.WebhookPublicDTO, error) {
+	webhook, err := c.daoWebhook.ReadByUUID(ctx, webhookUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get webhook: %w", err)
+	}
+
+	return model.FactoryWebhookPublicDTO(webhook), nil
+}
+
+func (c *WebhookController) GetAll(ctx context.Context) (*model.WebhooksPublicDTO, error) {
+	webhooks, err := c.daoWebhook.ReadAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get webhooks: %w", err)
+	}
+
+	publicWebhooks := []model.WebhookPublicDTO{}
+	for _, webhook := range webhooks {
+		publicWebhooks = append(publicWebhooks, *model.FactoryWebhookPublicDTO(webhook))
+	}
+
+	return &model.WebhooksPublicDTO{
+		Webhooks: publicWebhooks,
+	}, nil
+}
+
+func (c *WebhookController) Update(ctx context.Context, webhookUUID uuid.UUID, patch *model.WebhookPatch) error {
+	if patch.URL != nil {
+		if err := c.checkURL(ctx, *patch.URL); err != nil {
+			return fmt.Errorf("fail to update webhook: %w", err)
+		}
+	}
+
+	if err := c.daoWebhook.Update(ctx, webhookUUID, patch); err != nil {
+		return fmt.Errorf("fail to update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the webhook, its pending deliveries are dropped.
+func (c *WebhookController) Delete(ctx context.Context, webhookUUID uuid.UUID) error {
+	if err := c.daoWebhook.Delete(ctx, webhookUUID); err != nil {
+		return fmt.Errorf("fail to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the delivery log of the webhook, newest first. The dead letters have the dead status.
+func (c *WebhookController) GetDeliveries(ctx context.Context, webhookUUID uuid.UUID, filter *model.WebhookDeliveryFilterDTO) (*model.WebhookDeliveriesPublicDTO, error) {
+	deliveries, err := c.daoWebhook.ReadDeliveries(ctx, webhookUUID, filter.Status, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get webhook deliveries: %w", err)
+	}
+
+	publicDeliveries := []model.WebhookDeliveryPublicDTO{}
+	for _, delivery := range deliveries {
+		publicDeliveries = append(publicDeliveries, *model.FactoryWebhookDeliveryPublicDTO(delivery))
+	}
+
+	return &model.WebhookDeliveriesPublicDTO{
+		Deliveries: publicDeliveries,
+	}, nil
+}
+
+// RetryDelivery queues again a dead or delivered delivery with the same payload, its attempts start over.
+// It fails with a DeliveryPendingError if the delivery is still in the queue.
+func (c *WebhookController) RetryDelivery(ctx context.Context, webhookUUID, deliveryUUID uuid.UUID) (*model.WebhookDeliveryPublicDTO, error) {
+	delivery, err := c.daoWebhook.ReadDelivery(ctx, webhookUUID, deliveryUUID)
+	if err != nil {
+		return nil, fmt.Errorf("fail to retry webhook delivery: %w", err)
+	}
+	if delivery.Status == model.WebhookDeliveryPending {
+		return nil, fmt.Errorf("fail to retry webhook delivery: %w", &DeliveryPendingError{UUID: deliveryUUID})
+	}
+
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	if err := c.daoWebhook.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("fail to retry webhook delivery: %w", err)
+	}
+
+	return model.FactoryWebhookDeliveryPublicDTO(delivery), nil
+}
+
+// enqueue queues the deliveries of the change for the webhooks subscribed to it and seeing its task.
+// The change is already done, a failure to queue them is only logged.
+func (c *WebhookController) enqueue(ctx context.Context, change *model.TaskChange) {
+	// The deliveries are queued even if the caller leaves meanwhile.
+	ctx = systemContext(context.WithoutCancel(ctx))
+
+	webhooks, err := c.daoWebhook.ReadAll(ctx)
+	if errors.Is(err, repositories.ErrFeatureNotImplemented) {
+		return
+	}
+	if err != nil {
+		log.Error("fail to queue the webhook deliveries", zap.Any("task_uuid", change.Task.UUID), zap.Error(err))
+		return
+	}
+
+	var payload []byte
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for _, webhook := range webhooks {
+		if !webhook.Subscribed(change.Type) {
+			continue
+		}
+		visible, err := c.visible(ctx, webhook, change.Task)
+		if err != nil {
+			log.Error("fail to queue the webhook delivery", zap.Any("webhook_uuid", webhook.UUID), zap.Error(err))
+			continue
+		}
+		if !visible {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(model.FactoryTaskChangePublicDTO(change)); err != nil {
+				log.Error("fail to encode the webhook payload", zap.Any("task_uuid", change.Task.UUID), zap.Error(err))
+				return
+			}
+		}
+		now := time.Now()
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			UUID:          uuid.New(),
+			WebhookUUID:   webhook.UUID,
+			EventType:     change.Type,
+			Payload:       payload,
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	if err := c.daoWebhook.CreateDeliveries(ctx, deliveries); err != nil {
+		log.Error("fail to queue the webhook deliveries", zap.Any("task_uuid", change.Task.UUID), zap.Error(err))
+	}
+}
+
+// visible reports whether the task is owned by or shared with the owner of the webhook, in the trash too.
+// A webhook created without principal sees every task.
+func (c *WebhookController) visible(ctx context.Context, webhook *model.Webhook, task *model.Task) (bool, error) {
+	if webhook.OwnerID == "" || webhook.OwnerID == task.OwnerID {
+		return true, nil
+	}
+
+	ownerCtx := model.ContextWithPrincipal(ctx, &model.Principal{ID: webhook.OwnerID})
+	_, err := c.daoTask.ReadRole(ownerCtx, task.UUID)
+	var errNoDataFound *repositories.NoDataFoundError
+	if errors.As(err, &errNoDataFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("fail to get task role: %w", err)
+	}
+	return true, nil
+}
+
+// checkURL returns an InvalidWebhookError if the URL isn't an absolute http or https URL,
+// or if its host resolves to an address the webhooks can't target.
+func (c *WebhookController) checkURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return &InvalidWebhookError{URL: rawURL, Reason: err.Error()}
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return &InvalidWebhookError{URL: rawURL, Reason: "the scheme must be http or https"}
+	}
+	if parsed.Hostname() == "" {
+		return &InvalidWebhookError{URL: rawURL, Reason: "the host is missing"}
+	}
+
+	addrs, err := c.networks.resolve(ctx, parsed.Hostname())
+	if err != nil {
+		return &InvalidWebhookError{URL: rawURL, Reason: "the host can't be resolved"}
+	}
+	// Every address is checked, the deliveries may dial any of them.
+	for _, addr := range addrs {
+		if err := c.networks.check(addr); err != nil {
+			return &InvalidWebhookError{URL: rawURL, Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+// webhookNetworks decides which addresses the webhooks can target: the public ones
+// and the loopback, private or link-local ones of the allowed networks.
+type webhookNetworks struct {
+	allowed  []netip.Prefix
+	resolver *net.Resolver
+}
+
+// parseWebhookNetworks returns the webhookNetworks allowing the CIDRs.
+func parseWebhookNetworks(cidrs []string) (webhookNetworks, error) {
+	networks := webhookNetworks{resolver: net.DefaultResolver}
+	for _, cidr := range cidrs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return webhookNetworks{}, fmt.Errorf("invalid allowed network %q: %w", cidr, err)
+		}
+		networks.allowed = append(networks.allowed, prefix.Masked())
+	}
+	return networks, nil
+}
+
+// resolve returns the addresses of the host, the host itself if it is an IP address.
+func (n webhookNetworks) resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+	return n.resolver.LookupNetIP(ctx, "ip", host)
+}
+
+// check returns an error if the address is loopback, private, link-local, multicast or unspecified
+// and none of the allowed networks contains it.
+func (n webhookNetworks) check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() && !addr.IsLinkLocalMulticast() &&
+		!addr.IsInterfaceLocalMulticast() && !addr.IsMulticast() && !addr.IsUnspecified() {
+		return nil
+	}
+	for _, prefix := range n.allowed {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the address %s isn't allowed", addr)
+}
+
+// control is the Control of the dialer of the deliveries: the address is checked once resolved,
+// a host resolving to another address since the webhook was saved can't reach the internal network.
+func (n webhookNetworks) control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	return n.check(addr)
+}
+
+// newWebhookSecret returns a random secret of 256 bits, hex encoded.
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("can't generate the webhook secret: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+// factoryWebhookController is use to build an WebhookController according to the conf, the tasks are read with taskDAO.
+func factoryWebhookController(c WebhookControllerConf, taskDAO repositories.DAOFactoryOptions) (*WebhookController, error) {
+	log.Info("loading WebhookDAO...")
+	daoWebhook, err := repositories.ProxyFactoryWebhookDAO(c.WebhookDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load WebhookDAO: %w", err)
+	}
+	log.Info("WebhookDAO loaded")
+
+	daoTask, err := repositories.ProxyFactoryTaskDAO(taskDAO)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load TaskDAO: %w", err)
+	}
+
+	return newWebhookController(daoWebhook, daoTask, c)
+}
+
+// newWebhookController returns a WebhookController using the DAOs according to the conf.
+func newWebhookController(daoWebhook repositories.IWebhookDAO, daoTask repositories.ITaskDAO, c WebhookControllerConf) (*WebhookController, error) {
+	networks, err := parseWebhookNetworks(c.AllowedNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load WebhookController: %w", err)
+	}
+
+	delivery := c.Delivery.withDefaults()
+	timeout := time.Duration(delivery.Timeout) * time.Second
+	dialer := &net.Dialer{Timeout: timeout, Control: networks.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// The proxies of the environment aren't used, the dialer must see the address of the receiver.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	controllers := &WebhookController{
+		daoWebhook: daoWebhook,
+		daoTask:    daoTask,
+		delivery:   delivery,
+		networks:   networks,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			// A redirection is a failed attempt, the receiver must answer at the URL of the webhook.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	return controllers, nil
+}
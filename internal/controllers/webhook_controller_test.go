@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+// newTestWebhookController returns a WebhookController over new in-memory DAOs.
+func newTestWebhookController(t *testing.T, conf WebhookControllerConf) *WebhookController {
+	t.Helper()
+
+	daoWebhook, err := repositories.FactoryWebhookDAO(repositories.DAOFactoryOptions{Type: repositories.TypeWebhookInMemoryDAO})
+	if err != nil {
+		t.Fatalf("FactoryWebhookDAO() error = %v", err)
+	}
+	daoTask, err := repositories.FactoryTaskDAO(repositories.DAOFactoryOptions{Type: repositories.TypeTaskInMemoryDAO})
+	if err != nil {
+		t.Fatalf("FactoryTaskDAO() error = %v", err)
+	}
+	ctl, err := newWebhookController(daoWebhook, daoTask, conf)
+	if err != nil {
+		t.Fatalf("newWebhookController() error = %v", err)
+	}
+	return ctl
+}
+
+func TestWebhookControllerCheckURL(t *testing.T) {
+	ctl := newTestWebhookController(t, WebhookControllerConf{AllowedNetworks: []string{"10.1.0.0/16", "fd00::1/8"}})
+
+	tests := []struct {
+		name  string
+		url   string
+		valid bool
+	}{
+		{name: "public ipv4", url: "https://93.184.215.14/hooks", valid: true},
+		{name: "public ipv6", url: "http://[2606:4700::1111]:8080/hooks", valid: true},
+		{name: "allowed private", url: "http://10.1.2.3/hooks", valid: true},
+		{name: "allowed unique local", url: "http://[fd12::1]/hooks", valid: true},
+		{name: "ftp", url: "ftp://93.184.215.14/hooks"},
+		{name: "relative", url: "/hooks"},
+		{name: "no host", url: "http:///hooks"},
+		{name: "loopback", url: "http://127.0.0.1:8080/hooks"},
+		{name: "localhost", url: "http://localhost:8080/hooks"},
+		{name: "ipv6 loopback", url: "http://[::1]/hooks"},
+		{name: "ipv4 mapped loopback", url: "http://[::ffff:127.0.0.1]/hooks"},
+		{name: "private", url: "http://10.2.0.1/hooks"},
+		{name: "private outside the allowed network", url: "http://192.168.1.1/hooks"},
+		{name: "link-local metadata", url: "http://169.254.169.254/latest/meta-data"},
+		{name: "ipv6 link-local", url: "http://[fe80::1]/hooks"},
+		{name: "unspecified", url: "http://0.0.0.0:8080/hooks"},
+		{name: "multicast", url: "http://224.0.0.1/hooks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ctl.checkURL(context.Background(), tt.url)
+			if tt.valid {
+				if err != nil {
+					t.Errorf("checkURL(%q) error = %v, want nil", tt.url, err)
+				}
+				return
+			}
+			var errInvalid *InvalidWebhookError
+			if !errors.As(err, &errInvalid) {
+				t.Errorf("checkURL(%q) error = %v, want an InvalidWebhookError", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestWebhookControllerCreateUpdateCheckURL(t *testing.T) {
+	ctl := newTestWebhookController(t, WebhookControllerConf{})
+	ctx := context.Background()
+
+	var errInvalid *InvalidWebhookError
+	if _, err := ctl.Create(ctx, &model.WebhookCreateDTO{URL: "http://127.0.0.1/hooks"}); !errors.As(err, &errInvalid) {
+		t.Fatalf("Create() of a loopback URL error = %v, want an InvalidWebhookError", err)
+	}
+
+	webhook, err := ctl.Create(ctx, &model.WebhookCreateDTO{URL: "https://93.184.215.14/hooks"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	internalURL := "http://169.254.169.254/latest/meta-data"
+	if err := ctl.Update(ctx, webhook.UUID, &model.WebhookPatch{URL: &internalURL}); !errors.As(err, &errInvalid) {
+		t.Errorf("Update() to a link-local URL error = %v, want an InvalidWebhookError", err)
+	}
+}
+
+func TestNewWebhookControllerAllowedNetworks(t *testing.T) {
+	if _, err := newWebhookController(nil, nil, WebhookControllerConf{AllowedNetworks: []string{"10.0.0.1"}}); err == nil {
+		t.Error("newWebhookController() of an allowed network without prefix length error = nil, want an error")
+	}
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/CamilleLange/todolist/internal/repositories"
+)
+
+const (
+	// DefaultWebhookDeliveryInterval is the number of seconds between two runs of the queue when none is configured.
+	DefaultWebhookDeliveryInterval = 5
+	// DefaultWebhookDeliveryTimeout is the number of seconds a receiver has to answer when none is configured.
+	DefaultWebhookDeliveryTimeout = 10
+	// DefaultWebhookMaxAttempts is the number of attempts of a delivery before it is dead when none is configured.
+	DefaultWebhookMaxAttempts = 8
+	// DefaultWebhookBackoffBase is the number of seconds before the first retry when none is configured.
+	DefaultWebhookBackoffBase = 30
+	// DefaultWebhookBackoffMax is the longest number of seconds between two attempts when none is configured.
+	DefaultWebhookBackoffMax = 3600
+	// DefaultWebhookDeliveryBatchSize is the number of deliveries sent at once when none is configured.
+	DefaultWebhookDeliveryBatchSize = 20
+	// DefaultWebhookLogRetentionDays is the number of days the delivered deliveries are kept when none is configured.
+	DefaultWebhookLogRetentionDays = 7
+	// DefaultWebhookQueueSize is the number of changes waiting for their deliveries to be queued when none is configured.
+	DefaultWebhookQueueSize = 1000
+
+	// webhookUserAgent is the User-Agent of the deliveries.
+	webhookUserAgent = "todolist-webhooks/1.0"
+	// webhookMaxErrorLength is the longest error kept in the delivery log.
+	webhookMaxErrorLength = 1024
+	// webhookMaxResponseBody is the number of bytes of the response read to reuse the connection.
+	webhookMaxResponseBody = 64 * 1024
+)
+
+// WebhookDeliveryConf configures the job sending the queued deliveries of the webhooks.
+type WebhookDeliveryConf struct {
+	// Disabled stops the deliveries from being sent, e.g. on the replicas of the API. The changes are still queued.
+	Disabled bool `mapstructure:"disabled"`
+	// Interval is the number of seconds between two runs of the queue.
+	Interval int `mapstructure:"interval"`
+	// Timeout is the number of seconds a receiver has to answer, a slower answer is a failed attempt.
+	Timeout int `mapstructure:"timeout"`
+	// MaxAttempts is the number of attempts before the delivery moves to the dead letters.
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// BackoffBase is the number of seconds before the first retry, it doubles after each failed attempt up to BackoffMax.
+	BackoffBase int `mapstructure:"backoff_base"`
+	BackoffMax  int `mapstructure:"backoff_max"`
+	// BatchSize is the number of deliveries sent at once by a run.
+	BatchSize int `mapstructure:"batch_size"`
+	// LogRetentionDays is the number of days the delivered deliveries stay in the log, the dead letters are kept.
+	LogRetentionDays int `mapstructure:"log_retention_days"`
+	// QueueSize is the number of changes waiting for their deliveries to be queued, the writes of the tasks
+	// wait once it is reached.
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+// withDefaults returns the configuration with the default values in place of the missing ones.
+func (conf WebhookDeliveryConf) withDefaults() WebhookDeliveryConf {
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultWebhookDeliveryInterval
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = DefaultWebhookDeliveryTimeout
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = DefaultWebhookMaxAttempts
+	}
+	if conf.BackoffBase <= 0 {
+		conf.BackoffBase = DefaultWebhookBackoffBase
+	}
+	if conf.BackoffMax < conf.BackoffBase {
+		conf.BackoffMax = max(DefaultWebhookBackoffMax, conf.BackoffBase)
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = DefaultWebhookDeliveryBatchSize
+	}
+	if conf.LogRetentionDays <= 0 {
+		conf.LogRetentionDays = DefaultWebhookLogRetentionDays
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = DefaultWebhookQueueSize
+	}
+	return conf
+}
+
+// Deliver sends the due deliveries of the queue, of every owner, and returns how many were delivered.
+// The deliveries of a run are sent concurrently, their order isn't kept.
+// It also removes the deliveries delivered for longer than the retention of the log.
+func (c *WebhookController) Deliver(ctx context.Context) (int64, error) {
+	ctx = systemContext(ctx)
+	timeout := time.Duration(c.delivery.Timeout) * time.Second
+
+	// The lease outlives the attempts of the run, the deliveries of a replica stopped meanwhile are sent once it ends.
+	now := time.Now()
+	deliveries, err := c.daoWebhook.ClaimDeliveries(ctx, now, now.Add(2*timeout), c.delivery.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("fail to deliver webhooks: %w", err)
+	}
+
+	webhooks := make(map[uuid.UUID]*model.Webhook)
+	var delivered atomic.Int64
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		webhook, read := webhooks[delivery.WebhookUUID]
+		if !read {
+			webhook, err = c.daoWebhook.ReadByUUID(ctx, delivery.WebhookUUID)
+			var errNoDataFound *repositories.NoDataFoundError
+			if err != nil && !errors.As(err, &errNoDataFound) {
+				log.Error("fail to get the webhook of the delivery", zap.Any("delivery_uuid", delivery.UUID), zap.Error(err))
+				continue
+			}
+			webhooks[delivery.WebhookUUID] = webhook
+		}
+		// The deliveries of a deleted webhook are deleted with it.
+		if webhook == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer wg.Done()
+
+			c.attempt(ctx, webhook, delivery)
+			// A delivery interrupted by the shutdown is sent again once its lease expires.
+			if ctx.Err() != nil {
+				return
+			}
+			if err := c.daoWebhook.UpdateDelivery(ctx, delivery); err != nil {
+				log.Error("fail to save the webhook delivery", zap.Any("delivery_uuid", delivery.UUID), zap.Error(err))
+				return
+			}
+			if delivery.Status == model.WebhookDeliveryDelivered {
+				delivered.Add(1)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	retention := time.Duration(c.delivery.LogRetentionDays) * 24 * time.Hour
+	if _, err := c.daoWebhook.PurgeDeliveries(ctx, now.Add(-retention)); err != nil {
+		return delivered.Load(), fmt.Errorf("fail to purge webhook deliveries: %w", err)
+	}
+
+	return delivered.Load(), nil
+}
+
+// attempt sends the delivery to the webhook and records the result on the delivery:
+// it is delivered, retried after a backoff or moved to the dead letters after its last attempt.
+// The deliveries of a paused webhook move to the dead letters without being sent.
+func (c *WebhookController) attempt(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	now := time.Now()
+	if !webhook.Active {
+		delivery.Status = model.WebhookDeliveryDead
+		delivery.LastError = "the webhook is paused"
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	statusCode, err := c.send(ctx, webhook, delivery, now)
+	delivery.LastStatusCode = statusCode
+	if err == nil {
+		delivery.Status = model.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > webhookMaxErrorLength {
+		delivery.LastError = delivery.LastError[:webhookMaxErrorLength]
+	}
+	if delivery.Attempts >= c.delivery.MaxAttempts {
+		delivery.Status = model.WebhookDeliveryDead
+		return
+	}
+	delivery.NextAttemptAt = now.Add(c.backoff(delivery.Attempts))
+}
+
+// send posts the signed payload of the delivery to the webhook and returns the status of the response.
+// It fails unless the receiver answers with a 2xx.
+func (c *WebhookController) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery, now time.Time) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("can't build the request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", webhookUserAgent)
+	request.Header.Set(model.HeaderWebhookEvent, string(delivery.EventType))
+	request.Header.Set(model.HeaderWebhookDelivery, delivery.UUID.String())
+	request.Header.Set(model.HeaderWebhookTimestamp, timestamp)
+	request.Header.Set(model.HeaderWebhookSignature, model.WebhookSignature(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, webhookMaxResponseBody))
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return response.StatusCode, fmt.Errorf("the receiver answered %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// backoff returns the delay before the next attempt of a delivery after its failed attempts:
+// BackoffBase doubled after each of them, up to BackoffMax.
+func (c *WebhookController) backoff(attempts int) time.Duration {
+	delay := c.delivery.BackoffBase
+	for i := 1; i < attempts && delay < c.delivery.BackoffMax; i++ {
+		delay *= 2
+	}
+	return time.Duration(min(delay, c.delivery.BackoffMax)) * time.Second
+}
+
+// startWebhookDispatcher starts the job running Deliver of a controller at regular intervals,
+// it returns nil if the deliveries are disabled.
+func startWebhookDispatcher(ctl IWebhookController, conf WebhookDeliveryConf) *periodicJob {
+	if conf.Disabled {
+		return nil
+	}
+	conf = conf.withDefaults()
+
+	return startPeriodicJob(time.Duration(conf.Interval)*time.Second, func(ctx context.Context) {
+		count, err := ctl.Deliver(ctx)
+		switch {
+		case errors.Is(err, repositories.ErrFeatureNotImplemented):
+			// The webhooks aren't implemented without a WebhookDAO.
+		case err != nil:
+			log.Error("fail to deliver the webhooks", zap.Error(err))
+		case count > 0:
+			log.Info("webhooks delivered", zap.Int64("deliveries", count))
+		}
+	})
+}
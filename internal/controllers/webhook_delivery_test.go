@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// receivedDelivery is a request received by a receiver of newTestReceiver.
+type receivedDelivery struct {
+	path   string
+	header http.Header
+	body   []byte
+}
+
+// newTestReceiver starts a receiver answering with the handler once it recorded the request.
+func newTestReceiver(t *testing.T, answer http.HandlerFunc) (*httptest.Server, chan receivedDelivery) {
+	t.Helper()
+
+	received := make(chan receivedDelivery, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedDelivery{path: r.URL.Path, header: r.Header.Clone(), body: body}
+		answer(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// queueTestDelivery queues a delivery of the payload for the webhook and returns it.
+func queueTestDelivery(t *testing.T, ctl *WebhookController, webhookUUID uuid.UUID, payload string) *model.WebhookDelivery {
+	t.Helper()
+
+	now := time.Now()
+	delivery := &model.WebhookDelivery{
+		UUID:          uuid.New(),
+		WebhookUUID:   webhookUUID,
+		EventType:     model.TaskEventCreated,
+		Payload:       []byte(payload),
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := ctl.daoWebhook.CreateDeliveries(systemContext(context.Background()), []*model.WebhookDelivery{delivery}); err != nil {
+		t.Fatalf("CreateDeliveries() error = %v", err)
+	}
+	return delivery
+}
+
+// readTestDelivery returns the delivery as stored in the queue.
+func readTestDelivery(t *testing.T, ctl *WebhookController, delivery *model.WebhookDelivery) *model.WebhookDelivery {
+	t.Helper()
+
+	stored, err := ctl.daoWebhook.ReadDelivery(systemContext(context.Background()), delivery.WebhookUUID, delivery.UUID)
+	if err != nil {
+		t.Fatalf("ReadDelivery() error = %v", err)
+	}
+	return stored
+}
+
+// newTestDeliveryController returns a WebhookController allowing the loopback receivers, with a webhook to the URL.
+func newTestDeliveryController(t *testing.T, delivery WebhookDeliveryConf, url string) (*WebhookController, *model.WebhookPublicDTO) {
+	t.Helper()
+
+	ctl := newTestWebhookController(t, WebhookControllerConf{Delivery: delivery, AllowedNetworks: []string{"127.0.0.0/8", "::1/128"}})
+	webhook, err := ctl.Create(context.Background(), &model.WebhookCreateDTO{URL: url, Secret: "a secret of the receiver"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	return ctl, webhook
+}
+
+func TestWebhookControllerDeliver(t *testing.T) {
+	ctx := context.Background()
+	server, received := newTestReceiver(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ctl, webhook := newTestDeliveryController(t, WebhookDeliveryConf{}, server.URL+"/hooks")
+	payload := `{"type":"created","task":{"what_to_do":"call the bank"}}`
+	queued := queueTestDelivery(t, ctl, webhook.UUID, payload)
+
+	before := time.Now().Unix()
+	if count, err := ctl.Deliver(ctx); err != nil || count != 1 {
+		t.Fatalf("Deliver() = %d, %v, want 1", count, err)
+	}
+
+	request := <-received
+	if request.path != "/hooks" || string(request.body) != payload {
+		t.Errorf("the receiver got %s %s, want /hooks %s", request.path, request.body, payload)
+	}
+	if got := request.header.Get(model.HeaderWebhookEvent); got != string(model.TaskEventCreated) {
+		t.Errorf("%s = %q, want %q", model.HeaderWebhookEvent, got, model.TaskEventCreated)
+	}
+	if got := request.header.Get(model.HeaderWebhookDelivery); got != queued.UUID.String() {
+		t.Errorf("%s = %q, want %q", model.HeaderWebhookDelivery, got, queued.UUID)
+	}
+	timestamp := request.header.Get(model.HeaderWebhookTimestamp)
+	if unix, err := strconv.ParseInt(timestamp, 10, 64); err != nil || unix < before || unix > time.Now().Unix() {
+		t.Errorf("%s = %q, want the time of the attempt", model.HeaderWebhookTimestamp, timestamp)
+	}
+
+	// The receiver signs the timestamp and the exact body it got with the secret.
+	mac := hmac.New(sha256.New, []byte("a secret of the receiver"))
+	mac.Write([]byte(timestamp + "." + string(request.body)))
+	if got, want := request.header.Get(model.HeaderWebhookSignature), "sha256="+hex.EncodeToString(mac.Sum(nil)); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Errorf("%s = %q, want %q", model.HeaderWebhookSignature, got, want)
+	}
+
+	// The delivery log shows the delivered delivery with the answer of the receiver.
+	deliveries, err := ctl.GetDeliveries(ctx, webhook.UUID, &model.WebhookDeliveryFilterDTO{Status: model.WebhookDeliveryDelivered, Limit: 10})
+	if err != nil || len(deliveries.Deliveries) != 1 {
+		t.Fatalf("GetDeliveries() = %v, %v, want the delivered delivery", deliveries, err)
+	}
+	if got := deliveries.Deliveries[0]; got.UUID != queued.UUID || got.Attempts != 1 || got.LastStatusCode != http.StatusNoContent ||
+		got.LastError != "" || got.DeliveredAt == nil || got.NextAttemptAt != nil {
+		t.Errorf("GetDeliveries() = %+v, want delivered at the first attempt with a 204", got)
+	}
+
+	// A delivered delivery isn't sent again.
+	if count, err := ctl.Deliver(ctx); err != nil || count != 0 {
+		t.Errorf("Deliver() of a delivered queue = %d, %v, want 0", count, err)
+	}
+	if len(received) != 0 {
+		t.Errorf("the receiver got %d requests for a delivered delivery, want 0", len(received))
+	}
+}
+
+func TestWebhookControllerDeliverRetries(t *testing.T) {
+	ctx := context.Background()
+	server, received := newTestReceiver(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	})
+	conf := WebhookDeliveryConf{MaxAttempts: 5, BackoffBase: 10, BackoffMax: 35}
+	ctl, webhook := newTestDeliveryController(t, conf, server.URL+"/hooks")
+	queued := queueTestDelivery(t, ctl, webhook.UUID, `{"type":"created"}`)
+
+	// The backoff doubles after each failed attempt up to BackoffMax, the last one moves the delivery to the dead letters.
+	backoffs := []time.Duration{10 * time.Second, 20 * time.Second, 35 * time.Second, 35 * time.Second}
+	for attempt := 1; attempt <= conf.MaxAttempts; attempt++ {
+		if count, err := ctl.Deliver(ctx); err != nil || count != 0 {
+			t.Fatalf("Deliver() attempt %d = %d, %v, want 0", attempt, count, err)
+		}
+		request := <-received
+		if got := request.header.Get(model.HeaderWebhookDelivery); got != queued.UUID.String() {
+			t.Fatalf("attempt %d %s = %q, want %q", attempt, model.HeaderWebhookDelivery, got, queued.UUID)
+		}
+
+		delivery := readTestDelivery(t, ctl, queued)
+		if delivery.Attempts != attempt || delivery.LastStatusCode != http.StatusServiceUnavailable ||
+			!strings.Contains(delivery.LastError, "503") || delivery.LastAttemptAt == nil {
+			t.Fatalf("attempt %d delivery = %+v, want a failed attempt with a 503", attempt, delivery)
+		}
+		if attempt == conf.MaxAttempts {
+			if delivery.Status != model.WebhookDeliveryDead {
+				t.Fatalf("attempt %d status = %s, want %s", attempt, delivery.Status, model.WebhookDeliveryDead)
+			}
+			break
+		}
+		if delivery.Status != model.WebhookDeliveryPending {
+			t.Fatalf("attempt %d status = %s, want %s", attempt, delivery.Status, model.WebhookDeliveryPending)
+		}
+		if got := delivery.NextAttemptAt.Sub(*delivery.LastAttemptAt); got != backoffs[attempt-1] {
+			t.Errorf("attempt %d backoff = %v, want %v", attempt, got, backoffs[attempt-1])
+		}
+
+		// The delivery isn't due before its backoff.
+		if count, err := ctl.Deliver(ctx); err != nil || count != 0 || len(received) != 0 {
+			t.Fatalf("Deliver() before the backoff = %d, %v with %d requests, want none", count, err, len(received))
+		}
+		delivery.NextAttemptAt = time.Now()
+		if err := ctl.daoWebhook.UpdateDelivery(systemContext(ctx), delivery); err != nil {
+			t.Fatalf("UpdateDelivery() error = %v", err)
+		}
+	}
+
+	// The dead letters aren't sent again until they are retried.
+	if count, err := ctl.Deliver(ctx); err != nil || count != 0 || len(received) != 0 {
+		t.Errorf("Deliver() of a dead letter = %d, %v with %d requests, want none", count, err, len(received))
+	}
+	dead, err := ctl.GetDeliveries(ctx, webhook.UUID, &model.WebhookDeliveryFilterDTO{Status: model.WebhookDeliveryDead, Limit: 10})
+	if err != nil || len(dead.Deliveries) != 1 || dead.Deliveries[0].UUID != queued.UUID || dead.Deliveries[0].Attempts != conf.MaxAttempts {
+		t.Fatalf("GetDeliveries() of the dead letters = %+v, %v, want the delivery after %d attempts", dead, err, conf.MaxAttempts)
+	}
+	if _, err := ctl.RetryDelivery(ctx, webhook.UUID, queued.UUID); err != nil {
+		t.Fatalf("RetryDelivery() error = %v", err)
+	}
+	if count, err := ctl.Deliver(ctx); err != nil || count != 0 || len(received) != 1 {
+		t.Errorf("Deliver() of a retried delivery = %d, %v with %d requests, want 1 request", count, err, len(received))
+	}
+	if delivery := readTestDelivery(t, ctl, queued); delivery.Attempts != 1 || delivery.Status != model.WebhookDeliveryPending {
+		t.Errorf("retried delivery = %+v, want pending after its first attempt", delivery)
+	}
+}
+
+func TestWebhookControllerDeliverRedirect(t *testing.T) {
+	ctx := context.Background()
+	server, received := newTestReceiver(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hooks" {
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		}
+	})
+	ctl, webhook := newTestDeliveryController(t, WebhookDeliveryConf{}, server.URL+"/hooks")
+	queued := queueTestDelivery(t, ctl, webhook.UUID, `{"type":"created"}`)
+
+	if count, err := ctl.Deliver(ctx); err != nil || count != 0 {
+		t.Fatalf("Deliver() = %d, %v, want 0", count, err)
+	}
+	if request := <-received; request.path != "/hooks" {
+		t.Errorf("the receiver got %s, want /hooks", request.path)
+	}
+	if len(received) != 0 {
+		t.Errorf("the redirection was followed, want a failed attempt")
+	}
+	delivery := readTestDelivery(t, ctl, queued)
+	if delivery.Status != model.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.LastStatusCode != http.StatusFound {
+		t.Errorf("delivery = %+v, want a failed attempt with a 302", delivery)
+	}
+}
+
+func TestWebhookControllerDeliverPaused(t *testing.T) {
+	ctx := context.Background()
+	server, received := newTestReceiver(t, func(w http.ResponseWriter, r *http.Request) {})
+	ctl, webhook := newTestDeliveryController(t, WebhookDeliveryConf{}, server.URL+"/hooks")
+	queued := queueTestDelivery(t, ctl, webhook.UUID, `{"type":"created"}`)
+	paused := false
+	if err := ctl.Update(ctx, webhook.UUID, &model.WebhookPatch{Active: &paused}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if count, err := ctl.Deliver(ctx); err != nil || count != 0 {
+		t.Fatalf("Deliver() = %d, %v, want 0", count, err)
+	}
+	if len(received) != 0 {
+		t.Errorf("the receiver of a paused webhook got %d requests, want 0", len(received))
+	}
+	if delivery := readTestDelivery(t, ctl, queued); delivery.Status != model.WebhookDeliveryDead || delivery.Attempts != 0 {
+		t.Errorf("delivery = %+v, want dead without attempt", delivery)
+	}
+}
+
+func TestWebhookControllerDeliverDialedAddress(t *testing.T) {
+	ctx := context.Background()
+	server, received := newTestReceiver(t, func(w http.ResponseWriter, r *http.Request) {})
+	// The webhook was saved while its host resolved to a public address, the receiver is now on the loopback.
+	ctl := newTestWebhookController(t, WebhookControllerConf{})
+	webhook, err := ctl.daoWebhook.Create(ctx, &model.WebhookCreateDTO{URL: server.URL + "/hooks"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	queued := queueTestDelivery(t, ctl, webhook.UUID, `{"type":"created"}`)
+
+	if count, err := ctl.Deliver(ctx); err != nil || count != 0 {
+		t.Fatalf("Deliver() = %d, %v, want 0", count, err)
+	}
+	if len(received) != 0 {
+		t.Errorf("the loopback receiver got %d requests, want 0", len(received))
+	}
+	delivery := readTestDelivery(t, ctl, queued)
+	if delivery.Status != model.WebhookDeliveryPending || delivery.LastStatusCode != 0 || !strings.Contains(delivery.LastError, "isn't allowed") {
+		t.Errorf("delivery = %+v, want a failed attempt refused by the dialer", delivery)
+	}
+}
+
+func TestWebhookControllerBackoff(t *testing.T) {
+	ctl := &WebhookController{delivery: WebhookDeliveryConf{BackoffBase: 30, BackoffMax: 100}.withDefaults()}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: 60 * time.Second},
+		{attempts: 3, want: 100 * time.Second},
+		{attempts: 10, want: 100 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := ctl.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package ginrouters
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderAuthorization = "Authorization"
+	HeaderAPIKey        = "X-API-Key"
+
+	keyPrincipal = "principal"
+
+	// DefaultPrincipalClaim is the JWT claim holding the id of the principal.
+	DefaultPrincipalClaim = "sub"
+	// DefaultRolesClaim is the JWT claim holding the roles of the principal.
+	DefaultRolesClaim = "roles"
+	// DefaultAdminRole is the role of the principals seeing the tasks of every owner.
+	DefaultAdminRole = "admin"
+)
+
+// AuthConf configures the authentication of the API, the routes are public when it isn't enabled.
+type AuthConf struct {
+	Enabled bool         `mapstructure:"enabled"`
+	JWT     JWTConf      `mapstructure:"jwt"`
+	APIKeys []APIKeyConf `mapstructure:"api_keys"`
+}
+
+// JWTConf configures the bearer tokens, they are verified with the keys of a local JWKS file.
+type JWTConf struct {
+	// JWKSFile is the path of the JWKS, the JWT authentication is disabled without it.
+	JWKSFile string `mapstructure:"jwks_file"`
+	Issuer   string `mapstructure:"issuer"`
+	Audience string `mapstructure:"audience"`
+	// Leeway is the number of seconds of clock skew tolerated on the dates of the tokens.
+	Leeway         int    `mapstructure:"leeway"`
+	PrincipalClaim string `mapstructure:"principal_claim"`
+	RolesClaim     string `mapstructure:"roles_claim"`
+}
+
+// APIKeyConf is a static API key, only its SHA-256 is configured.
+type APIKeyConf struct {
+	// ID is the id of the principal authenticated by the key.
+	ID string `mapstructure:"id"`
+	// Hash is the hex encoded SHA-256 of the key.
+	Hash  string   `mapstructure:"hash"`
+	Roles []string `mapstructure:"roles"`
+}
+
+// Authenticator authenticates the requests with a bearer JWT or an API key.
+type Authenticator struct {
+	conf    AuthConf
+	parser  *jwt.Parser
+	keys    []verificationKey
+	apiKeys []apiKey
+}
+
+type apiKey struct {
+	hash      []byte
+	principal model.Principal
+}
+
+// NewAuthenticator loads the keys of the configuration.
+func NewAuthenticator(conf AuthConf) (*Authenticator, error) {
+	if conf.JWT.PrincipalClaim == "" {
+		conf.JWT.PrincipalClaim = DefaultPrincipalClaim
+	}
+	if conf.JWT.RolesClaim == "" {
+		conf.JWT.RolesClaim = DefaultRolesClaim
+	}
+
+	auth := &Authenticator{conf: conf}
+
+	if conf.JWT.JWKSFile != "" {
+		keys, err := loadJWKS(conf.JWT.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("fail to load JWKS: %w", err)
+		}
+		auth.keys = keys
+
+		options := []jwt.ParserOption{
+			jwt.WithValidMethods([]string{"HS256", "HS384", "HS512", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(time.Duration(conf.JWT.Leeway) * time.Second),
+		}
+		if conf.JWT.Issuer != "" {
+			options = append(options, jwt.WithIssuer(conf.JWT.Issuer))
+		}
+		if conf.JWT.Audience != "" {
+			options = append(options, jwt.WithAudience(conf.JWT.Audience))
+		}
+		auth.parser = jwt.NewParser(options...)
+	}
+
+	for i, key := range conf.APIKeys {
+		hash, err := hex.DecodeString(key.Hash)
+		if err != nil || len(hash) != sha256.Size || key.ID == "" {
+			return nil, fmt.Errorf("invalid API key %d: an id and a hex encoded SHA-256 hash are required", i)
+		}
+		auth.apiKeys = append(auth.apiKeys, apiKey{
+			hash:      hash,
+			principal: model.Principal{ID: key.ID, Roles: key.Roles},
+		})
+	}
+
+	if auth.parser == nil && len(auth.apiKeys) == 0 {
+		return nil, fmt.Errorf("the authentication is enabled without JWKS nor API key")
+	}
+
+	return auth, nil
+}
+
+// Middleware rejects the requests without valid credentials with a 401,
+// and puts the principal of the others in the context of the request.
+func (auth *Authenticator) Middleware(c *gin.Context) {
+	principal, err := auth.authenticate(c.Request)
+	if err != nil {
+		log.Info("authentication failed", zap.String("path", c.Request.URL.Path), zap.Error(err))
+		c.Header("WWW-Authenticate", `Bearer realm="todolist"`)
+		AbortWithProblem(c, http.StatusUnauthorized, ProblemTypeUnauthorized, "missing or invalid credentials")
+		return
+	}
+
+	setPrincipal(c, principal)
+	c.Next()
+}
+
+// setPrincipal puts the principal in the gin context and in the context of the request, used by the controllers.
+func setPrincipal(c *gin.Context, principal *model.Principal) {
+	principal.Admin = slices.Contains(principal.Roles, Config.AdminRole)
+	c.Set(keyPrincipal, principal)
+	c.Request = c.Request.WithContext(model.ContextWithPrincipal(c.Request.Context(), principal))
+}
+
+// authenticate returns the principal of the credentials of the request.
+func (auth *Authenticator) authenticate(r *http.Request) (*model.Principal, error) {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		return auth.authenticateAPIKey(key)
+	}
+
+	scheme, token, found := strings.Cut(r.Header.Get(HeaderAuthorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, fmt.Errorf("no credentials")
+	}
+
+	return auth.authenticateJWT(strings.TrimSpace(token))
+}
+
+func (auth *Authenticator) authenticateAPIKey(key string) (*model.Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	for _, candidate := range auth.apiKeys {
+		if subtle.ConstantTimeCompare(hash[:], candidate.hash) == 1 {
+			principal := candidate.principal
+			return &principal, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown API key")
+}
+
+func (auth *Authenticator) authenticateJWT(raw string) (*model.Principal, error) {
+	if auth.parser == nil {
+		return nil, fmt.Errorf("JWT authentication is not configured")
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := auth.parser.ParseWithClaims(raw, claims, auth.keyFunc); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	id, _ := claims[auth.conf.JWT.PrincipalClaim].(string)
+	if id == "" {
+		return nil, fmt.Errorf("no %s claim in the token", auth.conf.JWT.PrincipalClaim)
+	}
+
+	return &model.Principal{ID: id, Roles: stringsClaim(claims[auth.conf.JWT.RolesClaim])}, nil
+}
+
+// keyFunc selects the key of the JWKS matching the kid and the algorithm of the token,
+// so a token can't be verified with a key of another type.
+func (auth *Authenticator) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	alg := token.Method.Alg()
+
+	keys := make([]jwt.VerificationKey, 0, 1)
+	for _, candidate := range auth.keys {
+		if kid != "" && candidate.kid != kid {
+			continue
+		}
+		if candidate.alg != "" && candidate.alg != alg {
+			continue
+		}
+
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if key, ok := candidate.key.([]byte); ok {
+				keys = append(keys, key)
+			}
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			if key, ok := candidate.key.(*rsa.PublicKey); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	switch len(keys) {
+	case 0:
+		return nil, fmt.Errorf("no key for kid %q and alg %s", kid, alg)
+	case 1:
+		return keys[0], nil
+	}
+	return jwt.VerificationKeySet{Keys: keys}, nil
+}
+
+// stringsClaim reads a claim holding a string or a list of strings.
+func stringsClaim(claim any) []string {
+	switch value := claim.(type) {
+	case string:
+		return strings.Fields(value)
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,288 @@
+package ginrouters
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "todolist"
+	testAPIKey   = "the-api-key"
+)
+
+var testHMACSecret = []byte("a secret of more than 32 bytes for HS256")
+
+// writeTestJWKS writes a JWKS file with the keys and returns its path.
+func writeTestJWKS(t *testing.T, keys ...map[string]string) string {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string]any{"keys": keys})
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	return path
+}
+
+// rsaJWK returns the JWK of the public key of an RSA key.
+func rsaJWK(kid string, key *rsa.PrivateKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// apiKeyHash returns the hex encoded SHA-256 of an API key, like it is configured.
+func apiKeyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// signToken signs the claims with the method and the key, under the kid if it isn't empty.
+func signToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return signed
+}
+
+func TestAuthenticator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey() error = %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("x509.MarshalPKIXPublicKey() error = %v", err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	auth, err := NewAuthenticator(AuthConf{
+		Enabled: true,
+		JWT: JWTConf{
+			JWKSFile: writeTestJWKS(t,
+				map[string]string{"kty": "oct", "kid": "hs", "alg": "HS256", "k": base64.RawURLEncoding.EncodeToString(testHMACSecret)},
+				rsaJWK("rs", rsaKey),
+				map[string]string{"kty": "oct", "kid": "enc", "use": "enc", "k": "c2VjcmV0"},
+			),
+			Issuer:   testIssuer,
+			Audience: testAudience,
+			Leeway:   30,
+		},
+		APIKeys: []APIKeyConf{{ID: "ci", Hash: apiKeyHash(testAPIKey), Roles: []string{"admin"}}},
+	})
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	now := time.Now()
+	claims := func(changes jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"sub":   "alice",
+			"roles": []string{"admin", "ops"},
+			"iss":   testIssuer,
+			"aud":   testAudience,
+			"exp":   now.Add(time.Hour).Unix(),
+			"nbf":   now.Add(-time.Minute).Unix(),
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+	hs := func(kid string, changes jwt.MapClaims) string {
+		return "Bearer " + signToken(t, jwt.SigningMethodHS256, testHMACSecret, kid, claims(changes))
+	}
+	rs := func(kid string, changes jwt.MapClaims) string {
+		return "Bearer " + signToken(t, jwt.SigningMethodRS256, rsaKey, kid, claims(changes))
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		apiKey        string
+		want          *model.Principal
+	}{
+		{name: "HS256", authorization: hs("hs", nil), want: &model.Principal{ID: "alice", Roles: []string{"admin", "ops"}}},
+		{name: "RS256", authorization: rs("rs", nil), want: &model.Principal{ID: "alice", Roles: []string{"admin", "ops"}}},
+		{name: "no kid", authorization: rs("", nil), want: &model.Principal{ID: "alice", Roles: []string{"admin", "ops"}}},
+		{name: "lowercase scheme", authorization: "bearer " + signToken(t, jwt.SigningMethodHS256, testHMACSecret, "hs", claims(nil)), want: &model.Principal{ID: "alice", Roles: []string{"admin", "ops"}}},
+		{name: "roles string", authorization: hs("hs", jwt.MapClaims{"roles": "ops dev"}), want: &model.Principal{ID: "alice", Roles: []string{"ops", "dev"}}},
+		{name: "audience list", authorization: hs("hs", jwt.MapClaims{"aud": []string{"other", testAudience}}), want: &model.Principal{ID: "alice", Roles: []string{"admin", "ops"}}},
+		{name: "expired within leeway", authorization: hs("hs", jwt.MapClaims{"exp": now.Add(-10 * time.Second).Unix()}), want: &model.Principal{ID: "alice", Roles: []string{"admin", "ops"}}},
+		{name: "expired", authorization: hs("hs", jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()})},
+		{name: "no expiration", authorization: hs("hs", jwt.MapClaims{"exp": nil})},
+		{name: "not yet valid", authorization: hs("hs", jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()})},
+		{name: "wrong issuer", authorization: hs("hs", jwt.MapClaims{"iss": "https://evil.example.com"})},
+		{name: "no issuer", authorization: hs("hs", jwt.MapClaims{"iss": nil})},
+		{name: "wrong audience", authorization: hs("hs", jwt.MapClaims{"aud": "other"})},
+		{name: "no audience", authorization: hs("hs", jwt.MapClaims{"aud": nil})},
+		{name: "no subject", authorization: hs("hs", jwt.MapClaims{"sub": nil})},
+		{name: "alg none", authorization: "Bearer " + signToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "hs", claims(nil))},
+		// The RSA public key is known by everyone, it must not verify an HMAC.
+		{name: "HS256 with the RSA key", authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, publicPEM, "rs", claims(nil))},
+		{name: "HS256 with the RSA modulus", authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, rsaKey.N.Bytes(), "", claims(nil))},
+		{name: "RS256 under the HMAC kid", authorization: rs("hs", nil)},
+		{name: "algorithm of another key", authorization: "Bearer " + signToken(t, jwt.SigningMethodHS512, testHMACSecret, "hs", claims(nil))},
+		{name: "encryption key", authorization: "Bearer " + signToken(t, jwt.SigningMethodHS256, []byte("secret"), "enc", claims(nil))},
+		{name: "unknown kid", authorization: hs("unknown", nil)},
+		{name: "other RSA key", authorization: "Bearer " + signToken(t, jwt.SigningMethodRS256, otherKey, "rs", claims(nil))},
+		{name: "malformed token", authorization: "Bearer not.a.token"},
+		{name: "other scheme", authorization: "Basic YWxpY2U6c2VjcmV0"},
+		{name: "no credentials"},
+		{name: "API key", apiKey: testAPIKey, want: &model.Principal{ID: "ci", Roles: []string{"admin"}}},
+		// The API key is used before the token.
+		{name: "API key and token", apiKey: testAPIKey, authorization: hs("hs", nil), want: &model.Principal{ID: "ci", Roles: []string{"admin"}}},
+		{name: "unknown API key", apiKey: "another-key", authorization: hs("hs", nil)},
+		{name: "API key hash", apiKey: apiKeyHash(testAPIKey)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.authorization != "" {
+				req.Header.Set(HeaderAuthorization, tt.authorization)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(HeaderAPIKey, tt.apiKey)
+			}
+
+			got, err := auth.authenticate(req)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("authenticate() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("authenticate() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewAuthenticatorInvalid(t *testing.T) {
+	validHash := apiKeyHash(testAPIKey)
+
+	tests := []struct {
+		name string
+		conf AuthConf
+	}{
+		{name: "no credentials", conf: AuthConf{Enabled: true}},
+		{name: "missing JWKS", conf: AuthConf{Enabled: true, JWT: JWTConf{JWKSFile: filepath.Join(t.TempDir(), "missing.json")}}},
+		{name: "only encryption keys", conf: AuthConf{Enabled: true, JWT: JWTConf{JWKSFile: writeTestJWKS(t, map[string]string{"kty": "oct", "use": "enc", "k": "c2VjcmV0"})}}},
+		{name: "unsupported key type", conf: AuthConf{Enabled: true, JWT: JWTConf{JWKSFile: writeTestJWKS(t, map[string]string{"kty": "EC", "crv": "P-256"})}}},
+		{name: "empty secret", conf: AuthConf{Enabled: true, JWT: JWTConf{JWKSFile: writeTestJWKS(t, map[string]string{"kty": "oct", "k": ""})}}},
+		{name: "API key without id", conf: AuthConf{Enabled: true, APIKeys: []APIKeyConf{{Hash: validHash}}}},
+		{name: "API key in clear", conf: AuthConf{Enabled: true, APIKeys: []APIKeyConf{{ID: "ci", Hash: testAPIKey}}}},
+		{name: "API key hash too short", conf: AuthConf{Enabled: true, APIKeys: []APIKeyConf{{ID: "ci", Hash: validHash[:32]}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthenticator(tt.conf); err == nil {
+				t.Error("NewAuthenticator() error = nil, want an error")
+			}
+		})
+	}
+}
+
+// withRouterConfig serves the routes with another configuration until the end of the test.
+func withRouterConfig(t *testing.T, conf Conf) {
+	t.Helper()
+
+	previous := Config
+	t.Cleanup(func() {
+		Config = previous
+		if err := Init(); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+	})
+
+	Config = conf
+	if err := Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	withRouterConfig(t, Conf{
+		GinMode: Config.GinMode,
+		Auth: AuthConf{
+			Enabled: true,
+			APIKeys: []APIKeyConf{{ID: "ci-" + t.Name(), Hash: apiKeyHash(testAPIKey)}},
+		},
+		// The trusted headers are ignored when the authentication is enabled.
+		TrustedHeaders: Config.TrustedHeaders,
+	})
+	tc := newTestCaller(t)
+
+	rec := tc.do(http.MethodGet, "/tasks", nil)
+	expectProblem(t, rec, http.StatusUnauthorized, ProblemTypeUnauthorized)
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("401 without WWW-Authenticate header")
+	}
+	expectProblem(t, tc.do(http.MethodGet, "/tasks", nil, HeaderAPIKey, "revoked-key"), http.StatusUnauthorized, ProblemTypeUnauthorized)
+
+	rec = tc.do(http.MethodPost, "/task", map[string]any{"description": "buy milk"}, HeaderAPIKey, testAPIKey)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /task with an API key = %d %s", rec.Code, rec.Body)
+	}
+	if task := decodeBody[model.TaskPublicDTO](t, rec); task.OwnerID != "ci-"+t.Name() {
+		t.Errorf("created task owner = %q, want the principal of the API key", task.OwnerID)
+	}
+}
+
+func TestTrustedHeadersDisabled(t *testing.T) {
+	withRouterConfig(t, Conf{GinMode: Config.GinMode})
+	tc := newTestCaller(t)
+
+	// Without trusted headers configured, the routes are public and the headers name nobody.
+	task := tc.createTask(map[string]any{"description": "buy milk"})
+	if task.OwnerID != "" {
+		t.Errorf("created task owner = %q, want none", task.OwnerID)
+	}
+	history := tc.getHistory(task.UUID)
+	if len(history) == 0 || history[0].Actor != model.AnonymousActor {
+		t.Errorf("history = %+v, want a creation by %s", history, model.AnonymousActor)
+	}
+}
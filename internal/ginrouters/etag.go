@@ -0,0 +1,98 @@
+package ginrouters
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// TaskETag returns the strong entity tag of a task version.
+func TaskETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseETags returns the task versions listed in an If-Match or If-None-Match header, and whether it is "*".
+// Weak tags are only kept when weak is true, malformed tags are dropped so they never match.
+func parseETags(header string, weak bool) ([]int64, bool) {
+	versions := make([]int64, 0)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, true
+		}
+
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+		if err != nil || version <= 0 {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, false
+}
+
+// notModified reports whether the If-None-Match header of the request matches the version.
+func notModified(c *gin.Context, version int64) bool {
+	header := c.GetHeader(HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	versions, wildcard := parseETags(header, true)
+	return wildcard || slices.Contains(versions, version)
+}
+
+// ifMatchVersion returns the version a write must be conditioned on according to the If-Match header, 0 for any version.
+// current is only called when the header lists several versions. It returns false if the request was aborted.
+func ifMatchVersion(c *gin.Context, current func() (int64, error)) (int64, bool) {
+	header := c.GetHeader(HeaderIfMatch)
+	if header == "" {
+		if Config.RequireIfMatch {
+			AbortWithProblem(c, http.StatusPreconditionRequired, ProblemTypePreconditionRequired, "the If-Match header is required")
+			return 0, false
+		}
+		return 0, true
+	}
+
+	versions, wildcard := parseETags(header, false)
+	switch {
+	case wildcard:
+		return 0, true
+	case len(versions) == 1:
+		return versions[0], true
+	case len(versions) == 0:
+		AbortWithProblem(c, http.StatusPreconditionFailed, ProblemTypePreconditionFailed, "no entity tag of If-Match can match")
+		return 0, false
+	}
+
+	version, err := current()
+	if err != nil {
+		AbortWithError(c, err)
+		return 0, false
+	}
+	if !slices.Contains(versions, version) {
+		AbortWithProblem(c, http.StatusPreconditionFailed, ProblemTypePreconditionFailed, "the task was modified, fetch it again")
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,104 @@
+package ginrouters
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParseETags(t *testing.T) {
+	tests := []struct {
+		header   string
+		weak     bool
+		want     []int64
+		wildcard bool
+	}{
+		{header: `"3"`, want: []int64{3}},
+		{header: ` "3" , "5",W/"7"`, want: []int64{3, 5}},
+		{header: `W/"7"`, weak: true, want: []int64{7}},
+		{header: `*`, wildcard: true},
+		{header: `"3", *`, wildcard: true},
+		{header: `3`, want: []int64{}},
+		{header: `"abc", "0", "-1", ""`, want: []int64{}},
+		{header: `"`, want: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, wildcard := parseETags(tt.header, tt.weak)
+			if wildcard != tt.wildcard || (!tt.wildcard && !reflect.DeepEqual(got, tt.want)) {
+				t.Errorf("parseETags(%q, %v) = %v, %v, want %v, %v", tt.header, tt.weak, got, wildcard, tt.want, tt.wildcard)
+			}
+		})
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	tc := newTestCaller(t)
+	task := tc.createTask(map[string]any{"description": "buy milk"})
+	path := "/task/" + task.UUID.String()
+
+	// GET answers a 304 while the task is unchanged.
+	rec := tc.do(http.MethodGet, path, nil, HeaderIfNoneMatch, `W/"1"`)
+	if rec.Code != http.StatusNotModified || rec.Header().Get(HeaderETag) != `"1"` {
+		t.Errorf("GET If-None-Match: W/\"1\" = %d with ETag %s, want 304 with \"1\"", rec.Code, rec.Header().Get(HeaderETag))
+	}
+
+	// A stale If-Match fails with a 412 and the task is unchanged.
+	if rec := tc.mergePatch(task.UUID, map[string]any{"description": "buy oat milk"}, HeaderIfMatch, `"1"`); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH If-Match: \"1\" = %d %s", rec.Code, rec.Body)
+	}
+	rec = tc.mergePatch(task.UUID, map[string]any{"description": "buy soy milk"}, HeaderIfMatch, `"1"`)
+	expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+	rec = tc.do(http.MethodPut, path, map[string]any{"description": "buy soy milk", "status": "todo"}, HeaderIfMatch, `"1"`)
+	expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+	rec = tc.do(http.MethodDelete, path, nil, HeaderIfMatch, `"1"`)
+	expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+	// Weak tags never match a write.
+	rec = tc.mergePatch(task.UUID, map[string]any{"description": "buy soy milk"}, HeaderIfMatch, `W/"2"`)
+	expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+
+	rec = tc.do(http.MethodGet, path, nil, HeaderIfNoneMatch, `"1"`)
+	if rec.Code != http.StatusOK || rec.Header().Get(HeaderETag) != `"2"` {
+		t.Fatalf("GET If-None-Match: \"1\" = %d with ETag %s, want 200 with \"2\"", rec.Code, rec.Header().Get(HeaderETag))
+	}
+	if got := tc.getTask(task.UUID); got.WhatToDo != "buy oat milk" || got.Version != 2 {
+		t.Errorf("task = %q version %d, want buy oat milk version 2", got.WhatToDo, got.Version)
+	}
+
+	// A list of tags matches any of them, and * matches any version.
+	if rec := tc.mergePatch(task.UUID, map[string]any{"status": "done"}, HeaderIfMatch, `"1", "2"`); rec.Code != http.StatusNoContent {
+		t.Errorf("PATCH If-Match: \"1\", \"2\" = %d %s", rec.Code, rec.Body)
+	}
+	rec = tc.mergePatch(task.UUID, map[string]any{"status": "archived"}, HeaderIfMatch, `"1", "2"`)
+	expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+	if rec := tc.do(http.MethodPut, path, map[string]any{"description": "buy oat milk", "status": "archived"}, HeaderIfMatch, "*"); rec.Code != http.StatusNoContent {
+		t.Errorf("PUT If-Match: * = %d %s", rec.Code, rec.Body)
+	}
+	if rec := tc.do(http.MethodDelete, path, nil, HeaderIfMatch, `"4"`); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE If-Match: \"4\" = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	Config.RequireIfMatch = true
+	defer func() { Config.RequireIfMatch = false }()
+
+	tc := newTestCaller(t)
+	task := tc.createTask(map[string]any{"description": "buy milk"})
+	path := "/task/" + task.UUID.String()
+
+	rec := tc.mergePatch(task.UUID, map[string]any{"description": "buy oat milk"})
+	expectProblem(t, rec, http.StatusPreconditionRequired, ProblemTypePreconditionRequired)
+	rec = tc.do(http.MethodPut, path, map[string]any{"description": "buy oat milk", "status": "todo"})
+	expectProblem(t, rec, http.StatusPreconditionRequired, ProblemTypePreconditionRequired)
+	rec = tc.do(http.MethodDelete, path, nil)
+	expectProblem(t, rec, http.StatusPreconditionRequired, ProblemTypePreconditionRequired)
+
+	if got := tc.getTask(task.UUID); got.Version != 1 {
+		t.Errorf("task version = %d, want 1", got.Version)
+	}
+	if rec := tc.do(http.MethodDelete, path, nil, HeaderIfMatch, `"1"`); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE If-Match: \"1\" = %d %s", rec.Code, rec.Body)
+	}
+}
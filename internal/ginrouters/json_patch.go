@@ -0,0 +1,296 @@
+package ginrouters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentTypeMergePatch is the media type of a JSON Merge Patch (RFC 7396).
+	ContentTypeMergePatch = "application/merge-patch+json"
+	// ContentTypeJSONPatch is the media type of a JSON Patch (RFC 6902).
+	ContentTypeJSONPatch = "application/json-patch+json"
+	// HeaderAcceptPatch advertises the patch media types accepted by PATCH (RFC 5789).
+	HeaderAcceptPatch = "Accept-Patch"
+	// AcceptPatch is the value of the Accept-Patch header of the tasks.
+	AcceptPatch = ContentTypeMergePatch + ", " + ContentTypeJSONPatch
+)
+
+var (
+	// errMalformedPatch is returned for a patch document that isn't a valid JSON Patch.
+	errMalformedPatch = errors.New("malformed patch")
+	// errPatchTestFailed is returned when a test operation doesn't match the document.
+	errPatchTestFailed = errors.New("test failed")
+)
+
+// jsonPatchOperation is an operation of a JSON Patch, Value is nil when the member is absent.
+type jsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch applies the operations of a JSON Patch in order on the decoded JSON document and returns the patched document.
+// The document is modified in place, it must not be used when an operation fails.
+// The errors wrap errMalformedPatch for an invalid operation and errPatchTestFailed for a failed test,
+// the others are operations that can't be applied on the document.
+func applyJSONPatch(doc any, operations []jsonPatchOperation) (any, error) {
+	for i, operation := range operations {
+		var err error
+		doc, err = applyJSONPatchOperation(doc, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyJSONPatchOperation(doc any, operation jsonPatchOperation) (any, error) {
+	path, err := parseJSONPointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%w: %s without value", errMalformedPatch, operation.Op)
+		}
+		var value any
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: invalid value : %v", errMalformedPatch, err)
+		}
+
+		switch operation.Op {
+		case "add":
+			return setJSONValue(doc, path, value, true)
+		case "replace":
+			return setJSONValue(doc, path, value, false)
+		}
+
+		current, err := getJSONValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s doesn't match the value", errPatchTestFailed, operation.Path)
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err := removeJSONValue(doc, path)
+		return doc, err
+	case "move", "copy":
+		if operation.From == nil {
+			return nil, fmt.Errorf("%w: %s without from", errMalformedPatch, operation.Op)
+		}
+		from, err := parseJSONPointer(*operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "copy" {
+			value, err := getJSONValue(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			return setJSONValue(doc, path, copyJSONValue(value), true)
+		}
+
+		if len(from) < len(path) && slices.Equal(path[:len(from)], from) {
+			return nil, fmt.Errorf("%w: can't move %s into one of its children", errMalformedPatch, *operation.From)
+		}
+		doc, value, err := removeJSONValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return setJSONValue(doc, path, value, true)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", errMalformedPatch, operation.Op)
+	}
+}
+
+// parseJSONPointer returns the reference tokens of a JSON Pointer (RFC 6901), none for the whole document.
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: invalid path %q", errMalformedPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// getJSONValue returns the value referenced by the tokens.
+func getJSONValue(doc any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch node := doc.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("the member %q doesn't exist", token)
+			}
+			doc = value
+		case []any:
+			index, err := jsonArrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("the path goes through a value at %q", token)
+		}
+	}
+	return doc, nil
+}
+
+// setJSONValue sets the value referenced by the tokens and returns the document.
+// With add, a member is created if it doesn't exist and a value is inserted in an array, "-" appending it.
+// Otherwise the value must exist and is replaced.
+func setJSONValue(doc any, tokens []string, value any, add bool) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	token := tokens[0]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if len(tokens) == 1 {
+			if !ok && !add {
+				return nil, fmt.Errorf("the member %q doesn't exist", token)
+			}
+			node[token] = value
+			return node, nil
+		}
+		if !ok {
+			return nil, fmt.Errorf("the member %q doesn't exist", token)
+		}
+
+		child, err := setJSONValue(child, tokens[1:], value, add)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []any:
+		if len(tokens) == 1 && add {
+			index := len(node)
+			if token != "-" {
+				var err error
+				if index, err = jsonArrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			return append(node[:index], append([]any{value}, node[index:]...)...), nil
+		}
+
+		index, err := jsonArrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 1 {
+			node[index] = value
+			return node, nil
+		}
+
+		child, err := setJSONValue(node[index], tokens[1:], value, add)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = child
+		return node, nil
+	default:
+		return nil, fmt.Errorf("the path goes through a value at %q", token)
+	}
+}
+
+// removeJSONValue removes the value referenced by the tokens and returns the document and the removed value.
+func removeJSONValue(doc any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("the whole document can't be removed")
+	}
+
+	token := tokens[0]
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("the member %q doesn't exist", token)
+		}
+		if len(tokens) == 1 {
+			delete(node, token)
+			return node, child, nil
+		}
+
+		child, removed, err := removeJSONValue(child, tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+		return node, removed, nil
+	case []any:
+		index, err := jsonArrayIndex(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tokens) == 1 {
+			removed := node[index]
+			return append(node[:index], node[index+1:]...), removed, nil
+		}
+
+		child, removed, err := removeJSONValue(node[index], tokens[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[index] = child
+		return node, removed, nil
+	default:
+		return nil, nil, fmt.Errorf("the path goes through a value at %q", token)
+	}
+}
+
+// jsonArrayIndex parses the index of an array element, it must be between 0 and max.
+func jsonArrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", errMalformedPatch, token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("%w: invalid array index %q", errMalformedPatch, token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("the array index %d is out of bounds", index)
+	}
+	return index, nil
+}
+
+// copyJSONValue returns a deep copy of a decoded JSON value.
+func copyJSONValue(value any) any {
+	switch node := value.(type) {
+	case map[string]any:
+		nodeCopy := make(map[string]any, len(node))
+		for key, child := range node {
+			nodeCopy[key] = copyJSONValue(child)
+		}
+		return nodeCopy
+	case []any:
+		nodeCopy := make([]any, len(node))
+		for i, child := range node {
+			nodeCopy[i] = copyJSONValue(child)
+		}
+		return nodeCopy
+	default:
+		return value
+	}
+}
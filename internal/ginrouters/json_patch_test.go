@@ -0,0 +1,146 @@
+package ginrouters
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+		wantErr bool
+	}{
+		{pointer: "", want: nil},
+		{pointer: "/", want: []string{""}},
+		{pointer: "/a/0/b", want: []string{"a", "0", "b"}},
+		// ~1 is decoded before ~0, so ~01 is a ~ followed by a 1.
+		{pointer: "/a~1b/m~0n/~01", want: []string{"a/b", "m~n", "~1"}},
+		{pointer: "a", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseJSONPointer(tt.pointer)
+		if tt.wantErr {
+			if !errors.Is(err, errMalformedPatch) {
+				t.Errorf("parseJSONPointer(%q) error = %v, want a malformed patch", tt.pointer, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseJSONPointer(%q) = %q, %v, want %q", tt.pointer, got, err, tt.want)
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	const doc = `{"a": 1, "b": {"c": [1, 2, 3]}, "a/b": "slash", "m~n": "tilde"}`
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		// wantErr is errMalformedPatch, errPatchTestFailed or errAny for an operation that can't be applied.
+		wantErr error
+	}{
+		{name: "add a member", patch: `[{"op": "add", "path": "/d", "value": {"e": null}}]`,
+			want: `{"a": 1, "b": {"c": [1, 2, 3]}, "a/b": "slash", "m~n": "tilde", "d": {"e": null}}`},
+		{name: "add replaces a member", patch: `[{"op": "add", "path": "/a", "value": 2}]`,
+			want: `{"a": 2, "b": {"c": [1, 2, 3]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "add inserts in an array", patch: `[{"op": "add", "path": "/b/c/1", "value": 9}]`,
+			want: `{"a": 1, "b": {"c": [1, 9, 2, 3]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "add at the end of an array", patch: `[{"op": "add", "path": "/b/c/3", "value": 9}]`,
+			want: `{"a": 1, "b": {"c": [1, 2, 3, 9]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "add appends with -", patch: `[{"op": "add", "path": "/b/c/-", "value": 9}, {"op": "add", "path": "/b/c/-", "value": 10}]`,
+			want: `{"a": 1, "b": {"c": [1, 2, 3, 9, 10]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "add the whole document", patch: `[{"op": "add", "path": "", "value": {"z": 0}}]`, want: `{"z": 0}`},
+		{name: "add past the end of an array", patch: `[{"op": "add", "path": "/b/c/5", "value": 9}]`, wantErr: errAny},
+		{name: "add under a missing member", patch: `[{"op": "add", "path": "/x/y", "value": 9}]`, wantErr: errAny},
+		{name: "add through a value", patch: `[{"op": "add", "path": "/a/y", "value": 9}]`, wantErr: errAny},
+		{name: "remove a member", patch: `[{"op": "remove", "path": "/a"}]`,
+			want: `{"b": {"c": [1, 2, 3]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "remove an element", patch: `[{"op": "remove", "path": "/b/c/0"}]`,
+			want: `{"a": 1, "b": {"c": [2, 3]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "remove a missing member", patch: `[{"op": "remove", "path": "/x"}]`, wantErr: errAny},
+		{name: "remove with -", patch: `[{"op": "remove", "path": "/b/c/-"}]`, wantErr: errMalformedPatch},
+		{name: "remove the whole document", patch: `[{"op": "remove", "path": ""}]`, wantErr: errAny},
+		{name: "replace a member", patch: `[{"op": "replace", "path": "/b/c", "value": "none"}]`,
+			want: `{"a": 1, "b": {"c": "none"}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "replace an element", patch: `[{"op": "replace", "path": "/b/c/2", "value": 0}]`,
+			want: `{"a": 1, "b": {"c": [1, 2, 0]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "replace a missing member", patch: `[{"op": "replace", "path": "/x", "value": 1}]`, wantErr: errAny},
+		{name: "replace past the end of an array", patch: `[{"op": "replace", "path": "/b/c/3", "value": 1}]`, wantErr: errAny},
+		{name: "replace an escaped member", patch: `[{"op": "replace", "path": "/a~1b", "value": "s"}, {"op": "replace", "path": "/m~0n", "value": "t"}]`,
+			want: `{"a": 1, "b": {"c": [1, 2, 3]}, "a/b": "s", "m~n": "t"}`},
+		{name: "move a member", patch: `[{"op": "move", "from": "/b/c", "path": "/c"}]`,
+			want: `{"a": 1, "b": {}, "c": [1, 2, 3], "a/b": "slash", "m~n": "tilde"}`},
+		{name: "move an element", patch: `[{"op": "move", "from": "/b/c/0", "path": "/b/c/-"}]`,
+			want: `{"a": 1, "b": {"c": [2, 3, 1]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "move an escaped member", patch: `[{"op": "move", "from": "/a~1b", "path": "/m~0n"}]`,
+			want: `{"a": 1, "b": {"c": [1, 2, 3]}, "m~n": "slash"}`},
+		{name: "move into a child", patch: `[{"op": "move", "from": "/b", "path": "/b/d"}]`, wantErr: errMalformedPatch},
+		{name: "move a missing member", patch: `[{"op": "move", "from": "/x", "path": "/y"}]`, wantErr: errAny},
+		{name: "move without from", patch: `[{"op": "move", "path": "/y"}]`, wantErr: errMalformedPatch},
+		{name: "copy a member", patch: `[{"op": "copy", "from": "/b", "path": "/d"}, {"op": "add", "path": "/d/c/-", "value": 4}]`,
+			want: `{"a": 1, "b": {"c": [1, 2, 3]}, "d": {"c": [1, 2, 3, 4]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "copy an element", patch: `[{"op": "copy", "from": "/b/c/2", "path": "/b/c/0"}]`,
+			want: `{"a": 1, "b": {"c": [3, 1, 2, 3]}, "a/b": "slash", "m~n": "tilde"}`},
+		{name: "copy a missing member", patch: `[{"op": "copy", "from": "/x", "path": "/y"}]`, wantErr: errAny},
+		{name: "test", patch: `[{"op": "test", "path": "/b", "value": {"c": [1, 2, 3]}}, {"op": "test", "path": "/a~1b", "value": "slash"}]`,
+			want: doc},
+		{name: "test compares numbers by value", patch: `[{"op": "test", "path": "/a", "value": 1.0}]`, want: doc},
+		{name: "test failed", patch: `[{"op": "test", "path": "/a", "value": "1"}]`, wantErr: errPatchTestFailed},
+		{name: "test failed aborts the patch", patch: `[{"op": "replace", "path": "/a", "value": 2}, {"op": "test", "path": "/b/c/0", "value": 2}, {"op": "remove", "path": "/b"}]`,
+			wantErr: errPatchTestFailed},
+		{name: "test a missing member", patch: `[{"op": "test", "path": "/x", "value": null}]`, wantErr: errAny},
+		{name: "invalid path", patch: `[{"op": "add", "path": "a", "value": 1}]`, wantErr: errMalformedPatch},
+		{name: "leading zero index", patch: `[{"op": "replace", "path": "/b/c/01", "value": 1}]`, wantErr: errMalformedPatch},
+		{name: "negative index", patch: `[{"op": "replace", "path": "/b/c/-1", "value": 1}]`, wantErr: errMalformedPatch},
+		{name: "unknown op", patch: `[{"op": "merge", "path": "/a", "value": 1}]`, wantErr: errMalformedPatch},
+		{name: "without value", patch: `[{"op": "add", "path": "/a"}]`, wantErr: errMalformedPatch},
+		{name: "null value", patch: `[{"op": "replace", "path": "/a", "value": null}]`,
+			want: `{"a": null, "b": {"c": [1, 2, 3]}, "a/b": "slash", "m~n": "tilde"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var operations []jsonPatchOperation
+			if err := json.Unmarshal([]byte(tt.patch), &operations); err != nil {
+				t.Fatalf("fail to decode the patch: %v", err)
+			}
+
+			got, err := applyJSONPatch(decodeJSON(t, doc), operations)
+			if tt.wantErr != nil {
+				if err == nil || got != nil {
+					t.Fatalf("applyJSONPatch() = %v, %v, want an error and no document", got, err)
+				}
+				if tt.wantErr != errAny && !errors.Is(err, tt.wantErr) {
+					t.Errorf("applyJSONPatch() error = %v, want %v", err, tt.wantErr)
+				}
+				if tt.wantErr == errAny && (errors.Is(err, errMalformedPatch) || errors.Is(err, errPatchTestFailed)) {
+					t.Errorf("applyJSONPatch() error = %v, want an operation that can't be applied", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyJSONPatch() error = %v", err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("applyJSONPatch() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// errAny stands for the errors of the operations that can't be applied on the document.
+var errAny = errors.New("any error")
+
+// decodeJSON decodes a JSON document like the documents patched by applyJSONPatch.
+func decodeJSON(t *testing.T, raw string) any {
+	t.Helper()
+
+	var doc any
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("fail to decode %s: %v", raw, err)
+	}
+	return doc
+}
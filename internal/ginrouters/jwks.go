@@ -0,0 +1,95 @@
+package ginrouters
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+// jwk is a JSON Web Key, only the symmetric (oct) and RSA public keys are supported.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// verificationKey is a key of the JWKS ready to verify signatures.
+type verificationKey struct {
+	kid string
+	alg string
+	// key is a []byte for HMAC keys and a *rsa.PublicKey for RSA keys.
+	key any
+}
+
+// loadJWKS reads the keys of a local JWKS file.
+func loadJWKS(path string) ([]verificationKey, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s : %w", path, err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("can't unmarshal %s : %w", path, err)
+	}
+
+	keys := make([]verificationKey, 0, len(set.Keys))
+	for i, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		verification, err := key.verificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %d of %s : %w", i, path, err)
+		}
+		keys = append(keys, verification)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no signature key in %s", path)
+	}
+	return keys, nil
+}
+
+func (key jwk) verificationKey() (verificationKey, error) {
+	verification := verificationKey{kid: key.Kid, alg: key.Alg}
+
+	switch key.Kty {
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(key.K)
+		if err != nil || len(secret) == 0 {
+			return verification, fmt.Errorf("invalid k")
+		}
+		verification.key = secret
+
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil || len(n) == 0 {
+			return verification, fmt.Errorf("invalid n")
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			return verification, fmt.Errorf("invalid e")
+		}
+		verification.key = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+
+	default:
+		return verification, fmt.Errorf("unsupported key type %q", key.Kty)
+	}
+
+	return verification, nil
+}
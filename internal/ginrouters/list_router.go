@@ -0,0 +1,212 @@
+package ginrouters
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/CamilleLange/todolist/internal/controllers"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	ginparamsmapper "gitlab.com/Zandraz/gin-params-mapper"
+	"go.uber.org/zap"
+)
+
+var (
+	onceInitListRouter sync.Once
+	// singletonListRouter is a singleton instance of ListRouter.
+	singletonListRouter *ListRouter
+)
+
+// ListRouter groups a set of handlers to manage entrypoints of List and of the tasks of a list.
+type ListRouter struct {
+	ctlList controllers.IListController
+	ctlTask controllers.ITaskController
+}
+
+func (r *ListRouter) Post(c *gin.Context) {
+	list := new(model.ListCreateDTO)
+	if err := c.ShouldBindJSON(list); err != nil {
+		log.Error("ListRouter.Post fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	createdList, err := r.ctlList.Create(c, list)
+	if err != nil {
+		log.Error("ListRouter.Post fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdList)
+}
+
+func (r *ListRouter) GetAll(c *gin.Context) {
+	lists, err := r.ctlList.GetAll(c)
+	if err != nil {
+		log.Error("ListRouter.GetAll fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+func (r *ListRouter) Get(c *gin.Context) {
+	listUUID, ok := listUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	list, err := r.ctlList.Get(c, listUUID)
+	if err != nil {
+		log.Error("ListRouter.Get fail",
+			zap.Any("list_uuid", listUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (r *ListRouter) Put(c *gin.Context) {
+	listUUID, ok := listUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	listUpdateDTO := new(model.ListUpdateDTO)
+	if err := c.ShouldBindJSON(listUpdateDTO); err != nil {
+		log.Error("ListRouter.Put fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	if err := r.ctlList.Update(c, listUUID, listUpdateDTO.ReversePatch()); err != nil {
+		log.Error("ListRouter.Put fail", zap.Error(err))
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "List updated.")
+}
+
+func (r *ListRouter) Delete(c *gin.Context) {
+	listUUID, ok := listUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.ctlList.Delete(c, listUUID); err != nil {
+		log.Error("ListRouter.Delete fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "List deleted.")
+}
+
+// GetTasks lists the tasks of the list, with the filters, sort and pagination of /tasks.
+func (r *ListRouter) GetTasks(c *gin.Context) {
+	listUUID, ok := listUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	filter := new(model.TaskFilterDTO)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		log.Error("ListRouter.GetTasks fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	filter.SetDefaults()
+	filter.ListUUID = &listUUID
+
+	// A missing list is a 404, not an empty page.
+	if _, err := r.ctlList.Get(c, listUUID); err != nil {
+		log.Error("ListRouter.GetTasks fail", zap.Any("list_uuid", listUUID), zap.Error(err))
+		AbortWithError(c, err)
+		return
+	}
+
+	tasks, err := r.ctlTask.GetAll(c, filter)
+	if err != nil {
+		log.Error("ListRouter.GetTasks fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// PostTask creates a task in the list.
+func (r *ListRouter) PostTask(c *gin.Context) {
+	listUUID, ok := listUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	task := new(model.TaskCreateDTO)
+	if err := c.ShouldBindJSON(task); err != nil {
+		log.Error("ListRouter.PostTask fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	task.ListUUID = &listUUID
+
+	if _, err := r.ctlList.Get(c, listUUID); err != nil {
+		log.Error("ListRouter.PostTask fail", zap.Any("list_uuid", listUUID), zap.Error(err))
+		AbortWithError(c, err)
+		return
+	}
+
+	createdTask, err := r.ctlTask.Create(c, task)
+	if err != nil {
+		log.Error("ListRouter.PostTask fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.Header(HeaderETag, TaskETag(createdTask.Version))
+	c.JSON(http.StatusCreated, createdTask)
+}
+
+// listUUIDParam reads the list_uuid path parameter, it aborts the request with a 400 if it isn't an UUID.
+func listUUIDParam(c *gin.Context) (uuid.UUID, bool) {
+	var listUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("list_uuid", c, &listUUID); err != nil {
+		log.Error("ListRouter fail", zap.String("list_uuid", c.Param("list_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid list_uuid")
+		return uuid.Nil, false
+	}
+	return listUUID, true
+}
+
+// GetInstanceListRouter get singleton instance of ListRouter.
+func GetInstanceListRouter() *ListRouter {
+	if singletonListRouter == nil {
+		onceInitListRouter.Do(
+			func() {
+				singletonListRouter = &ListRouter{
+					ctlList: controllers.ListInstance,
+					ctlTask: controllers.TaskInstance,
+				}
+			},
+		)
+	}
+
+	return singletonListRouter
+}
@@ -0,0 +1,214 @@
+package ginrouters
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// createList creates a list and fails the test unless it is created.
+func (tc *testCaller) createList(name string) *model.ListPublicDTO {
+	tc.t.Helper()
+
+	rec := tc.do(http.MethodPost, "/lists", map[string]any{"name": name})
+	if rec.Code != http.StatusCreated {
+		tc.t.Fatalf("POST /lists = %d %s", rec.Code, rec.Body)
+	}
+	return decodeBody[model.ListPublicDTO](tc.t, rec)
+}
+
+func TestListCRUD(t *testing.T) {
+	tc := newTestCaller(t)
+
+	rec := tc.do(http.MethodPost, "/lists", map[string]any{"name": "sprint 42", "description": "the backlog of the sprint"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /lists = %d %s", rec.Code, rec.Body)
+	}
+	list := decodeBody[model.ListPublicDTO](t, rec)
+	if list.Name != "sprint 42" || list.Description != "the backlog of the sprint" || list.OwnerID != tc.principal || list.UUID == uuid.Nil {
+		t.Errorf("POST /lists = %+v, want the list owned by the caller", list)
+	}
+	other := tc.createList("ops checklist")
+	path := "/lists/" + list.UUID.String()
+
+	rec = tc.do(http.MethodGet, path, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d %s", path, rec.Code, rec.Body)
+	}
+	if got := decodeBody[model.ListPublicDTO](t, rec); *got != *list {
+		t.Errorf("GET %s = %+v, want %+v", path, got, list)
+	}
+
+	// The lists are listed oldest first.
+	rec = tc.do(http.MethodGet, "/lists", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /lists = %d %s", rec.Code, rec.Body)
+	}
+	if got := decodeBody[model.ListsPublicDTO](t, rec).Lists; len(got) != 2 || got[0].UUID != list.UUID || got[1].UUID != other.UUID {
+		t.Errorf("GET /lists = %+v, want sprint 42 and ops checklist", got)
+	}
+
+	// The fields left out are unchanged.
+	if rec := tc.do(http.MethodPut, path, map[string]any{"name": "sprint 43"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PUT %s = %d %s", path, rec.Code, rec.Body)
+	}
+	rec = tc.do(http.MethodGet, path, nil)
+	if got := decodeBody[model.ListPublicDTO](t, rec); got.Name != "sprint 43" || got.Description != list.Description || got.LastUpdated.Before(list.LastUpdated) {
+		t.Errorf("GET %s after PUT = %+v, want the new name and the same description", path, got)
+	}
+
+	expectProblem(t, tc.do(http.MethodPost, "/lists", map[string]any{"description": "no name"}), http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	expectProblem(t, tc.do(http.MethodPut, path, map[string]any{"name": ""}), http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	expectProblem(t, tc.do(http.MethodGet, "/lists/not-an-uuid", nil), http.StatusBadRequest, ProblemTypeBadRequest)
+	expectProblem(t, tc.do(http.MethodGet, "/lists/"+uuid.NewString(), nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, tc.do(http.MethodPut, "/lists/"+uuid.NewString(), map[string]any{"name": "ghost"}), http.StatusNotFound, ProblemTypeNotFound)
+
+	// The lists are personal, another principal doesn't see them.
+	stranger := newTestCaller(t)
+	expectProblem(t, stranger.do(http.MethodGet, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, stranger.do(http.MethodPut, path, map[string]any{"name": "mine"}), http.StatusNotFound, ProblemTypeNotFound)
+	rec = stranger.do(http.MethodGet, "/lists", nil)
+	if got := decodeBody[model.ListsPublicDTO](t, rec).Lists; rec.Code != http.StatusOK || len(got) != 0 {
+		t.Errorf("GET /lists by another principal = %d %+v, want no list", rec.Code, got)
+	}
+
+	if rec := tc.do(http.MethodDelete, "/lists/"+other.UUID.String(), nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /lists/%s = %d %s", other.UUID, rec.Code, rec.Body)
+	}
+	rec = tc.do(http.MethodGet, "/lists", nil)
+	if got := decodeBody[model.ListsPublicDTO](t, rec).Lists; len(got) != 1 || got[0].UUID != list.UUID {
+		t.Errorf("GET /lists after DELETE = %+v, want sprint 43 only", got)
+	}
+}
+
+func TestListTasks(t *testing.T) {
+	tc := newTestCaller(t)
+	list := tc.createList("sprint 42")
+	other := tc.createList("ops checklist")
+	path := "/lists/" + list.UUID.String() + "/tasks"
+
+	rec := tc.do(http.MethodPost, path, map[string]any{"description": "ship it"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST %s = %d %s", path, rec.Code, rec.Body)
+	}
+	shipped := decodeBody[model.TaskPublicDTO](t, rec)
+	if shipped.ListUUID == nil || *shipped.ListUUID != list.UUID || rec.Header().Get(HeaderETag) != TaskETag(shipped.Version) {
+		t.Errorf("POST %s = %+v with ETag %q, want a task of the list", path, shipped, rec.Header().Get(HeaderETag))
+	}
+	// The list of the path wins over the one of the body.
+	rec = tc.do(http.MethodPost, path, map[string]any{"description": "test it", "list_uuid": other.UUID})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST %s = %d %s", path, rec.Code, rec.Body)
+	}
+	tested := decodeBody[model.TaskPublicDTO](t, rec)
+	moved := tc.createTask(map[string]any{"description": "review it"})
+	if rec := tc.mergePatch(moved.UUID, map[string]any{"list_uuid": list.UUID}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH /task/%s = %d %s", moved.UUID, rec.Code, rec.Body)
+	}
+	trashed := tc.createTask(map[string]any{"description": "forget it", "list_uuid": list.UUID})
+	if rec := tc.do(http.MethodDelete, "/task/"+trashed.UUID.String(), nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /task/%s = %d %s", trashed.UUID, rec.Code, rec.Body)
+	}
+	tc.createTask(map[string]any{"description": "restart the server", "list_uuid": other.UUID})
+	tc.createTask(map[string]any{"description": "water the plants"})
+
+	// The live tasks of the list only, with the filters and the pagination of /tasks.
+	getTasks := func(query url.Values) []model.TaskPublicDTO {
+		t.Helper()
+		rec := tc.do(http.MethodGet, path+"?"+query.Encode(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s?%s = %d %s", path, query.Encode(), rec.Code, rec.Body)
+		}
+		return decodeBody[model.TaskListPublicDTO](t, rec).Tasks
+	}
+	got := getTasks(url.Values{"sort": {"created_at"}, "order": {"asc"}})
+	if len(got) != 3 || got[0].UUID != shipped.UUID || got[1].UUID != tested.UUID || got[2].UUID != moved.UUID {
+		t.Errorf("GET %s = %+v, want ship it, test it and review it", path, got)
+	}
+	if got := getTasks(url.Values{"q": {"review"}}); len(got) != 1 || got[0].UUID != moved.UUID {
+		t.Errorf("GET %s?q=review = %+v, want review it", path, got)
+	}
+	if got := getTasks(url.Values{"limit": {"1"}}); len(got) != 1 {
+		t.Errorf("GET %s?limit=1 = %d tasks, want 1", path, len(got))
+	}
+
+	// The task moved out of the list isn't listed anymore.
+	if rec := tc.mergePatch(moved.UUID, map[string]any{"list_uuid": nil}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH /task/%s = %d %s", moved.UUID, rec.Code, rec.Body)
+	}
+	if got := getTasks(url.Values{}); len(got) != 2 {
+		t.Errorf("GET %s after moving a task out = %d tasks, want 2", path, len(got))
+	}
+
+	// A task can't be put in an unknown list, nor in the list of another principal.
+	expectProblem(t, tc.do(http.MethodGet, "/lists/"+uuid.NewString()+"/tasks", nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, tc.do(http.MethodPost, "/task", map[string]any{"description": "lost", "list_uuid": uuid.New()}), http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	stranger := newTestCaller(t)
+	expectProblem(t, stranger.do(http.MethodGet, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, stranger.do(http.MethodPost, path, map[string]any{"description": "intrude"}), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, stranger.do(http.MethodPost, "/task", map[string]any{"description": "intrude", "list_uuid": list.UUID}), http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+
+	// A task shared with a teammate keeps its list, which the teammate doesn't see.
+	if rec := tc.do(http.MethodPut, "/task/"+shipped.UUID.String()+"/grants/"+stranger.principal, map[string]any{"role": "editor"}); rec.Code != http.StatusOK {
+		t.Fatalf("PUT /task/%s/grants/%s = %d %s", shipped.UUID, stranger.principal, rec.Code, rec.Body)
+	}
+	if got := stranger.getTask(shipped.UUID); got.ListUUID == nil || *got.ListUUID != list.UUID {
+		t.Errorf("shared task list = %v, want %s", got.ListUUID, list.UUID)
+	}
+	expectProblem(t, stranger.do(http.MethodGet, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+}
+
+func TestListDelete(t *testing.T) {
+	tc := newTestCaller(t)
+	list := tc.createList("sprint 42")
+	path := "/lists/" + list.UUID.String()
+
+	rec := tc.do(http.MethodPost, path+"/tasks", map[string]any{"description": "ship it"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST %s/tasks = %d %s", path, rec.Code, rec.Body)
+	}
+	task := decodeBody[model.TaskPublicDTO](t, rec)
+
+	// The list isn't deleted while it holds a live task, nor a task of the trash which could be restored in it.
+	expectProblem(t, tc.do(http.MethodDelete, path, nil), http.StatusConflict, ProblemTypeConflict)
+	if rec := tc.do(http.MethodDelete, "/task/"+task.UUID.String(), nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE /task/%s = %d %s", task.UUID, rec.Code, rec.Body)
+	}
+	expectProblem(t, tc.do(http.MethodDelete, path, nil), http.StatusConflict, ProblemTypeConflict)
+	if rec := tc.do(http.MethodPost, "/task/"+task.UUID.String()+"/restore", nil); rec.Code != http.StatusOK {
+		t.Fatalf("POST /task/%s/restore = %d %s", task.UUID, rec.Code, rec.Body)
+	}
+
+	// Another principal doesn't see the list.
+	expectProblem(t, newTestCaller(t).do(http.MethodDelete, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+
+	// Nor the task an admin put in the list, it still holds the list.
+	admin := newTestCaller(t)
+	admin.roles = DefaultAdminRole
+	rec = admin.do(http.MethodPost, path+"/tasks", map[string]any{"description": "audit it"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST %s/tasks by an admin = %d %s", path, rec.Code, rec.Body)
+	}
+	adminTask := decodeBody[model.TaskPublicDTO](t, rec)
+
+	if rec := tc.mergePatch(task.UUID, map[string]any{"list_uuid": nil}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH /task/%s = %d %s", task.UUID, rec.Code, rec.Body)
+	}
+	expectProblem(t, tc.do(http.MethodDelete, path, nil), http.StatusConflict, ProblemTypeConflict)
+
+	if rec := admin.mergePatch(adminTask.UUID, map[string]any{"list_uuid": nil}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH /task/%s by an admin = %d %s", adminTask.UUID, rec.Code, rec.Body)
+	}
+	if rec := tc.do(http.MethodDelete, path, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s of an empty list = %d %s", path, rec.Code, rec.Body)
+	}
+	expectProblem(t, tc.do(http.MethodGet, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, tc.do(http.MethodDelete, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, tc.do(http.MethodPost, path+"/tasks", map[string]any{"description": "too late"}), http.StatusNotFound, ProblemTypeNotFound)
+	if got := tc.getTask(task.UUID); got.ListUUID != nil {
+		t.Errorf("task list = %v, want none", got.ListUUID)
+	}
+}
@@ -0,0 +1,200 @@
+package ginrouters
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/CamilleLange/todolist/internal/controllers"
+	"github.com/CamilleLange/todolist/internal/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const (
+	// ContentTypeProblem is the media type of the RFC 7807 error bodies.
+	ContentTypeProblem = "application/problem+json"
+	// HeaderRequestID carries the id of a request, generated when the client doesn't send one.
+	HeaderRequestID = "X-Request-ID"
+
+	keyRequestID = "request_id"
+
+	ProblemTypeBadRequest           = "urn:todolist:problem:bad-request"
+	ProblemTypeUnauthorized         = "urn:todolist:problem:unauthorized"
+	ProblemTypeForbidden            = "urn:todolist:problem:forbidden"
+	ProblemTypeNotFound             = "urn:todolist:problem:not-found"
+	ProblemTypeNotAllowed           = "urn:todolist:problem:method-not-allowed"
+	ProblemTypeConflict             = "urn:todolist:problem:conflict"
+	ProblemTypeUnsupportedMediaType = "urn:todolist:problem:unsupported-media-type"
+	ProblemTypeUnprocessable        = "urn:todolist:problem:unprocessable-entity"
+	ProblemTypePreconditionFailed   = "urn:todolist:problem:precondition-failed"
+	ProblemTypePreconditionRequired = "urn:todolist:problem:precondition-required"
+	ProblemTypeUpgradeRequired      = "urn:todolist:problem:upgrade-required"
+	ProblemTypeUnavailable          = "urn:todolist:problem:service-unavailable"
+	ProblemTypeNotImplemented       = "urn:todolist:problem:not-implemented"
+	ProblemTypeInternal             = "urn:todolist:problem:internal-error"
+)
+
+// Problem is an RFC 7807 problem details body.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// RequestIDMiddleware reuses the X-Request-ID header of the request or generates one,
+// and sends it back in the response.
+func RequestIDMiddleware(c *gin.Context) {
+	requestID := c.GetHeader(HeaderRequestID)
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+
+	c.Set(keyRequestID, requestID)
+	c.Header(HeaderRequestID, requestID)
+	c.Next()
+}
+
+// AbortWithProblem aborts the request with a problem+json body.
+func AbortWithProblem(c *gin.Context, status int, problemType, detail string) {
+	problem := Problem{
+		Type:      problemType,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: c.GetString(keyRequestID),
+	}
+
+	c.Header("Content-Type", ContentTypeProblem)
+	c.AbortWithStatusJSON(status, problem)
+}
+
+// AbortWithError aborts the request with the problem matching the error returned by a controller.
+// The detail of unexpected errors is not sent to the client.
+func AbortWithError(c *gin.Context, err error) {
+	status, problemType, detail := problemOfError(err)
+
+	// The failed operation of a batch is named in the detail.
+	var errBatchOperation *repositories.BatchOperationError
+	if errors.As(err, &errBatchOperation) {
+		detail = fmt.Sprintf("operation %d: %s", errBatchOperation.Index, detail)
+	}
+
+	AbortWithProblem(c, status, problemType, detail)
+}
+
+// problemOfError returns the status, the type and the detail of the problem matching the error returned by a controller.
+func problemOfError(err error) (int, string, string) {
+	var (
+		errNoDataFound       *repositories.NoDataFoundError
+		errConflict          *repositories.ConflictError
+		errUnavailable       *repositories.UnavailableError
+		errInvalidCursor     *repositories.InvalidCursorError
+		errVersionMismatch   *repositories.VersionMismatchError
+		errUnknownStatus     *controllers.UnknownStatusError
+		errIllegalTransition *controllers.IllegalTransitionError
+		errUnknownList       *controllers.UnknownListError
+		errListNotEmpty      *controllers.ListNotEmptyError
+		errForbidden         *controllers.ForbiddenError
+		errInvalidGrant      *controllers.InvalidGrantError
+		errInvalidRecurrence *controllers.InvalidRecurrenceError
+		errUnknownParent     *controllers.UnknownParentError
+		errParentCycle       *controllers.ParentCycleError
+		errHasSubtasks       *repositories.HasSubtasksError
+		errUnknownBlocker    *controllers.UnknownBlockerError
+		errDependencyCycle   *repositories.DependencyCycleError
+		errBlockedTask       *controllers.BlockedTaskError
+		errInvalidTag        *controllers.InvalidTagError
+		errUnknownTag        *controllers.UnknownTagError
+		errUnknownLanguage   *controllers.UnknownLanguageError
+		errInvalidWebhook    *controllers.InvalidWebhookError
+		errDeliveryPending   *controllers.DeliveryPendingError
+	)
+
+	switch {
+	case errors.As(err, &errNoDataFound), errors.Is(err, repositories.ErrNoRowAffected):
+		return http.StatusNotFound, ProblemTypeNotFound, "the requested resource doesn't exist"
+	case errors.As(err, &errForbidden):
+		return http.StatusForbidden, ProblemTypeForbidden, errForbidden.Error()
+	case errors.As(err, &errVersionMismatch):
+		return http.StatusPreconditionFailed, ProblemTypePreconditionFailed, errVersionMismatch.Error()
+	case errors.As(err, &errListNotEmpty):
+		return http.StatusConflict, ProblemTypeConflict, errListNotEmpty.Error()
+	case errors.As(err, &errBlockedTask):
+		return http.StatusConflict, ProblemTypeConflict, errBlockedTask.Error()
+	case errors.As(err, &errDeliveryPending):
+		return http.StatusConflict, ProblemTypeConflict, errDeliveryPending.Error()
+	case errors.As(err, &errHasSubtasks):
+		return http.StatusConflict, ProblemTypeConflict, errHasSubtasks.Error()
+	case errors.As(err, &errConflict):
+		return http.StatusConflict, ProblemTypeConflict, "the request conflicts with the current state of the resource"
+	case errors.As(err, &errIllegalTransition):
+		return http.StatusConflict, ProblemTypeConflict, errIllegalTransition.Error()
+	case errors.As(err, &errUnknownStatus):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errUnknownStatus.Error()
+	case errors.As(err, &errUnknownList):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errUnknownList.Error()
+	case errors.As(err, &errInvalidGrant):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errInvalidGrant.Error()
+	case errors.As(err, &errInvalidRecurrence):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errInvalidRecurrence.Error()
+	case errors.As(err, &errUnknownParent):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errUnknownParent.Error()
+	case errors.As(err, &errParentCycle):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errParentCycle.Error()
+	case errors.As(err, &errUnknownBlocker):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errUnknownBlocker.Error()
+	case errors.As(err, &errDependencyCycle):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errDependencyCycle.Error()
+	case errors.As(err, &errInvalidTag):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errInvalidTag.Error()
+	case errors.As(err, &errUnknownTag):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errUnknownTag.Error()
+	case errors.As(err, &errUnknownLanguage):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errUnknownLanguage.Error()
+	case errors.As(err, &errInvalidWebhook):
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errInvalidWebhook.Error()
+	case errors.As(err, &errInvalidCursor):
+		return http.StatusBadRequest, ProblemTypeBadRequest, errInvalidCursor.Error()
+	case errors.As(err, &errUnavailable):
+		return http.StatusServiceUnavailable, ProblemTypeUnavailable, "the data source is unavailable, retry later"
+	case errors.Is(err, repositories.ErrFeatureNotImplemented):
+		return http.StatusNotImplemented, ProblemTypeNotImplemented, repositories.ErrFeatureNotImplemented.Error()
+	default:
+		return http.StatusInternalServerError, ProblemTypeInternal, "unexpected error"
+	}
+}
+
+// AbortWithBindingError aborts the request with a 422 if the input breaks a validation rule, or a 400 if it can't be decoded.
+func AbortWithBindingError(c *gin.Context, err error) {
+	status, problemType, detail := problemOfBindingError(err)
+	AbortWithProblem(c, status, problemType, detail)
+}
+
+// AbortWithPatchError aborts the request with a 400 for a malformed patch, a 409 if a test operation failed,
+// or a 422 if the patch can't be applied on the task.
+func AbortWithPatchError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errMalformedPatch):
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, err.Error())
+	case errors.Is(err, errPatchTestFailed):
+		AbortWithProblem(c, http.StatusConflict, ProblemTypeConflict, err.Error())
+	default:
+		AbortWithProblem(c, http.StatusUnprocessableEntity, ProblemTypeUnprocessable, err.Error())
+	}
+}
+
+// problemOfBindingError returns the status, the type and the detail of the problem matching a binding error.
+func problemOfBindingError(err error) (int, string, string) {
+	var errValidation validator.ValidationErrors
+	if errors.As(err, &errValidation) {
+		return http.StatusUnprocessableEntity, ProblemTypeUnprocessable, errValidation.Error()
+	}
+
+	return http.StatusBadRequest, ProblemTypeBadRequest, err.Error()
+}
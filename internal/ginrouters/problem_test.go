@@ -0,0 +1,144 @@
+package ginrouters
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/CamilleLange/todolist/internal/controllers"
+	"github.com/CamilleLange/todolist/internal/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestProblemOfError(t *testing.T) {
+	tests := []struct {
+		err         error
+		status      int
+		problemType string
+	}{
+		{err: &repositories.NoDataFoundError{}, status: http.StatusNotFound, problemType: ProblemTypeNotFound},
+		{err: repositories.ErrNoRowAffected, status: http.StatusNotFound, problemType: ProblemTypeNotFound},
+		{err: &controllers.ForbiddenError{Role: "viewer", Required: "editor"}, status: http.StatusForbidden, problemType: ProblemTypeForbidden},
+		{err: &repositories.VersionMismatchError{Expected: 1, Current: 2}, status: http.StatusPreconditionFailed, problemType: ProblemTypePreconditionFailed},
+		{err: &controllers.ListNotEmptyError{UUID: uuid.New()}, status: http.StatusConflict, problemType: ProblemTypeConflict},
+		{err: &controllers.BlockedTaskError{UUID: uuid.New()}, status: http.StatusConflict, problemType: ProblemTypeConflict},
+		{err: &controllers.DeliveryPendingError{UUID: uuid.New()}, status: http.StatusConflict, problemType: ProblemTypeConflict},
+		{err: &repositories.HasSubtasksError{UUID: uuid.New(), Count: 2}, status: http.StatusConflict, problemType: ProblemTypeConflict},
+		{err: &repositories.ConflictError{Err: errors.New("duplicate key")}, status: http.StatusConflict, problemType: ProblemTypeConflict},
+		{err: &controllers.IllegalTransitionError{From: "done", To: "todo"}, status: http.StatusConflict, problemType: ProblemTypeConflict},
+		{err: &controllers.UnknownStatusError{Status: "waiting"}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.UnknownListError{UUID: uuid.New()}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.InvalidGrantError{PrincipalID: "me"}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.InvalidRecurrenceError{Rule: "FREQ=NEVER"}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.UnknownParentError{UUID: uuid.New()}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.ParentCycleError{UUID: uuid.New()}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.UnknownBlockerError{UUID: uuid.New()}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &repositories.DependencyCycleError{UUID: uuid.New()}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.InvalidTagError{Name: ""}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.UnknownTagError{UUID: uuid.New()}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.UnknownLanguageError{Language: "klingon"}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &controllers.InvalidWebhookError{URL: "ftp://example.com"}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{err: &repositories.InvalidCursorError{Cursor: "garbage"}, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{err: &repositories.UnavailableError{Err: sql.ErrConnDone}, status: http.StatusServiceUnavailable, problemType: ProblemTypeUnavailable},
+		{err: repositories.ErrFeatureNotImplemented, status: http.StatusNotImplemented, problemType: ProblemTypeNotImplemented},
+		{err: errors.New("boom"), status: http.StatusInternalServerError, problemType: ProblemTypeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%T", tt.err), func(t *testing.T) {
+			// The controllers and the DAOs wrap the errors they return.
+			err := fmt.Errorf("fail to do something: %w", fmt.Errorf("fail to do it: %w", tt.err))
+
+			status, problemType, detail := problemOfError(err)
+			if status != tt.status || problemType != tt.problemType {
+				t.Errorf("problemOfError() = %d %s, want %d %s", status, problemType, tt.status, tt.problemType)
+			}
+			if detail == "" || strings.Contains(detail, "fail to do") {
+				t.Errorf("problemOfError() detail = %q, want the detail of the error alone", detail)
+			}
+		})
+	}
+}
+
+func TestProblemOfErrorHidesUnexpectedErrors(t *testing.T) {
+	_, _, detail := problemOfError(errors.New(`pq: password authentication failed for user "todolist"`))
+	if strings.Contains(detail, "password") {
+		t.Errorf("problemOfError() detail = %q, want the cause hidden", detail)
+	}
+}
+
+func TestAbortWithError(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/tasks:action", nil)
+	c.Set(keyRequestID, "request-1")
+
+	err := &repositories.BatchOperationError{Index: 3, Err: &repositories.VersionMismatchError{Expected: 1, Current: 2}}
+	AbortWithError(c, fmt.Errorf("fail to run the batch: %w", err))
+
+	problem := expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+	if !strings.HasPrefix(problem.Detail, "operation 3: ") {
+		t.Errorf("detail = %q, want the failed operation named", problem.Detail)
+	}
+	if problem.Title != "Precondition Failed" || problem.Instance != "/tasks:action" || problem.RequestID != "request-1" {
+		t.Errorf("problem = %+v, want its title, instance and request id", problem)
+	}
+	if !c.IsAborted() {
+		t.Error("AbortWithError() didn't abort the request")
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tc := newTestCaller(t)
+
+	rec := tc.do(http.MethodGet, "/task/"+uuid.NewString(), nil, HeaderRequestID, "my-request")
+	problem := expectProblem(t, rec, http.StatusNotFound, ProblemTypeNotFound)
+	if rec.Header().Get(HeaderRequestID) != "my-request" || problem.RequestID != "my-request" {
+		t.Errorf("request id = %q in the header and %q in the problem, want my-request", rec.Header().Get(HeaderRequestID), problem.RequestID)
+	}
+
+	// A request without id gets one.
+	rec = tc.do(http.MethodGet, "/task/"+uuid.NewString(), nil)
+	problem = expectProblem(t, rec, http.StatusNotFound, ProblemTypeNotFound)
+	if _, err := uuid.Parse(problem.RequestID); err != nil || rec.Header().Get(HeaderRequestID) != problem.RequestID {
+		t.Errorf("request id = %q in the header and %q in the problem, want the same generated UUID", rec.Header().Get(HeaderRequestID), problem.RequestID)
+	}
+}
+
+func TestRouterProblems(t *testing.T) {
+	tc := newTestCaller(t)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		body        any
+		status      int
+		problemType string
+	}{
+		{name: "no route", method: http.MethodGet, path: "/nowhere", status: http.StatusNotFound, problemType: ProblemTypeNotFound},
+		{name: "malformed body", method: http.MethodPost, path: "/task", body: `{"description":`, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{name: "wrong type", method: http.MethodPost, path: "/task", body: `{"description":42}`, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{name: "missing field", method: http.MethodPost, path: "/task", body: `{"status":"todo"}`, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{name: "unknown list", method: http.MethodPost, path: "/task", body: map[string]any{"description": "a", "list_uuid": uuid.NewString()}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{name: "bad uuid", method: http.MethodDelete, path: "/task/42", status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{name: "unknown task", method: http.MethodDelete, path: "/task/" + uuid.NewString(), status: http.StatusNotFound, problemType: ProblemTypeNotFound},
+		{name: "patch media type", method: http.MethodPatch, path: "/task/" + uuid.NewString(), body: `{}`, status: http.StatusUnsupportedMediaType, problemType: ProblemTypeUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc.t = t
+			rec := tc.do(tt.method, tt.path, tt.body)
+			problem := expectProblem(t, rec, tt.status, tt.problemType)
+			if problem.Instance != strings.SplitN(tt.path, "?", 2)[0] {
+				t.Errorf("instance = %q, want %q", problem.Instance, tt.path)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package ginrouters
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
@@ -33,11 +34,25 @@ type Conf struct {
 	Addr            string `mapstructure:"addr"`
 	Port            int    `mapstructure:"port"`
 	ShutdownTimeout int    `mapstructure:"shutdown_timeout"`
+	// RequireIfMatch rejects the task updates and deletions without If-Match header with a 428.
+	RequireIfMatch bool     `mapstructure:"require_if_match"`
+	Auth           AuthConf `mapstructure:"auth"`
+	// TrustedHeaders takes the caller from headers set by a gateway, only when Auth isn't enabled.
+	TrustedHeaders TrustedHeadersConf `mapstructure:"trusted_headers"`
+	// AdminRole is the role of the callers seeing the tasks of every owner, DefaultAdminRole if empty.
+	AdminRole string `mapstructure:"admin_role"`
+	// EventsKeepAlive is the number of seconds between two pings of an idle change feed, DefaultEventsKeepAlive if 0.
+	EventsKeepAlive int `mapstructure:"events_keep_alive"`
 }
 
 // Init create a gin.Engine and define multiplexer of the Engine.
-func Init() {
+func Init() error {
 	ValidateInstance = validator.New()
+	// The DTOs hold their validation rules in the binding tag read by gin.
+	ValidateInstance.SetTagName("binding")
+	if Config.AdminRole == "" {
+		Config.AdminRole = DefaultAdminRole
+	}
 
 	log.Info("init ginrouters package...")
 	gin.SetMode(Config.GinMode)
@@ -45,24 +60,89 @@ func Init() {
 	// set up the routing.
 	log.Info("instantiate gin engine...")
 	Router = gin.New()
+	// The handlers pass the gin.Context to the controllers, let it expose the values of the request context like the principal.
+	Router.ContextWithFallback = true
 	log.Info("gin engine instantiate")
 
 	// Middleware.
 	log.Info("load middlewares...")
+	Router.Use(RequestIDMiddleware)
 	Router.Use(ginzap.RecoveryWithZap(log, true))
 	Router.Use(ginzap.Ginzap(log, time.RFC3339, true))
-	Router.Use(cors.Middleware(new(cors.Builder).New().WithOrigins("http://localhost:8080").Build()))
+	corsConfig := new(cors.Builder).New().WithOrigins("http://localhost:8080").Build()
+	// Let browsers send conditional requests, patches, API keys, resume the change feed
+	// and read the ETag and the Accept-Patch of the tasks.
+	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, HeaderIfMatch, HeaderIfNoneMatch, HeaderAPIKey, HeaderLastEventID)
+	corsConfig.ExposeHeaders = append(corsConfig.ExposeHeaders, HeaderETag, HeaderAcceptPatch)
+	corsConfig.AllowMethods = append(corsConfig.AllowMethods, http.MethodPatch)
+	Router.Use(cors.Middleware(corsConfig))
+
+	// The task routes are only served to authenticated callers when the authentication is enabled,
+	// or to the callers identified by the gateway with the trusted headers.
+	api := Router.Group("")
+	switch {
+	case Config.Auth.Enabled:
+		auth, err := NewAuthenticator(Config.Auth)
+		if err != nil {
+			return fmt.Errorf("fail to init authentication: %w", err)
+		}
+		api.Use(auth.Middleware)
+	case Config.TrustedHeaders.Principal != "":
+		api.Use(Config.TrustedHeaders.Middleware)
+	}
 	log.Info("middlewares loaded")
 
 	// Add your handler below this log.
 	log.Info("load handlers...")
 
-	Router.GET("/tasks", GetInstanceTaskRouter().GetAll)
-	Router.Group("/task").
+	api.GET("/events", GetInstanceTaskRouter().Events)
+	api.GET("/ws", GetInstanceTaskRouter().WebSocket)
+	api.GET("/tasks", GetInstanceTaskRouter().GetAll)
+	api.POST("/tasks:action", GetInstanceTaskRouter().Batch)
+	api.GET("/tasks/search", GetInstanceTaskRouter().Search)
+	api.GET("/tasks/trash", GetInstanceTaskRouter().GetTrash)
+	api.GET("/tasks/series/:series_uuid", GetInstanceTaskRouter().GetSeries)
+	api.POST("/tasks/series/:series_uuid/stop", GetInstanceTaskRouter().StopSeries)
+	api.Group("/task").
 		POST("", GetInstanceTaskRouter().Post).
 		GET("/:task_uuid", GetInstanceTaskRouter().Get).
 		PUT("/:task_uuid", GetInstanceTaskRouter().Put).
-		DELETE("/:task_uuid", GetInstanceTaskRouter().Delete)
+		PATCH("/:task_uuid", GetInstanceTaskRouter().Patch).
+		DELETE("/:task_uuid", GetInstanceTaskRouter().Delete).
+		POST("/:task_uuid/restore", GetInstanceTaskRouter().Restore).
+		GET("/:task_uuid/history", GetInstanceTaskRouter().GetHistory).
+		GET("/:task_uuid/children", GetInstanceTaskRouter().GetChildren).
+		GET("/:task_uuid/subtree", GetInstanceTaskRouter().GetSubtree).
+		GET("/:task_uuid/grants", GetInstanceTaskRouter().GetGrants).
+		PUT("/:task_uuid/grants/:principal_id", GetInstanceTaskRouter().PutGrant).
+		DELETE("/:task_uuid/grants/:principal_id", GetInstanceTaskRouter().DeleteGrant).
+		GET("/:task_uuid/dependencies", GetInstanceTaskRouter().GetDependencies).
+		PUT("/:task_uuid/dependencies/:blocker_uuid", GetInstanceTaskRouter().PutDependency).
+		DELETE("/:task_uuid/dependencies/:blocker_uuid", GetInstanceTaskRouter().DeleteDependency).
+		PUT("/:task_uuid/tags/:tag_uuid", GetInstanceTaskRouter().PutTag).
+		DELETE("/:task_uuid/tags/:tag_uuid", GetInstanceTaskRouter().DeleteTag)
+	api.Group("/lists").
+		GET("", GetInstanceListRouter().GetAll).
+		POST("", GetInstanceListRouter().Post).
+		GET("/:list_uuid", GetInstanceListRouter().Get).
+		PUT("/:list_uuid", GetInstanceListRouter().Put).
+		DELETE("/:list_uuid", GetInstanceListRouter().Delete).
+		GET("/:list_uuid/tasks", GetInstanceListRouter().GetTasks).
+		POST("/:list_uuid/tasks", GetInstanceListRouter().PostTask)
+	api.Group("/tags").
+		GET("", GetInstanceTagRouter().GetAll).
+		POST("", GetInstanceTagRouter().Post).
+		GET("/:tag_uuid", GetInstanceTagRouter().Get).
+		PUT("/:tag_uuid", GetInstanceTagRouter().Put).
+		DELETE("/:tag_uuid", GetInstanceTagRouter().Delete)
+	api.Group("/webhooks").
+		GET("", GetInstanceWebhookRouter().GetAll).
+		POST("", GetInstanceWebhookRouter().Post).
+		GET("/:webhook_uuid", GetInstanceWebhookRouter().Get).
+		PUT("/:webhook_uuid", GetInstanceWebhookRouter().Put).
+		DELETE("/:webhook_uuid", GetInstanceWebhookRouter().Delete).
+		GET("/:webhook_uuid/deliveries", GetInstanceWebhookRouter().GetDeliveries).
+		POST("/:webhook_uuid/deliveries/:delivery_uuid/retry", GetInstanceWebhookRouter().RetryDelivery)
 
 	// Specific handler
 	log.Info("load specific handlers...")
@@ -74,15 +154,16 @@ func Init() {
 	})
 
 	Router.NoRoute(func(c *gin.Context) {
-		c.JSON(http.StatusNotFound, "Not Found")
+		AbortWithProblem(c, http.StatusNotFound, ProblemTypeNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
 	})
 
 	Router.NoMethod(func(c *gin.Context) {
-		c.JSON(http.StatusMethodNotAllowed, "Not Allowed")
+		AbortWithProblem(c, http.StatusMethodNotAllowed, ProblemTypeNotAllowed, c.Request.Method+" is not allowed on "+c.Request.URL.Path)
 	})
 	log.Info("specific handlers loaded")
 
 	log.Info("handlers loaded")
 
 	log.Info("ginrouters package ready")
+	return nil
 }
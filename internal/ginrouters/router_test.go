@@ -0,0 +1,157 @@
+package ginrouters
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Aloe-Corporation/logs"
+	"github.com/CamilleLange/todolist/internal/controllers"
+	"github.com/CamilleLange/todolist/internal/repositories"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	testHeaderPrincipal = "X-Test-Principal"
+	testHeaderRoles     = "X-Test-Roles"
+)
+
+// TestMain serves the routes over the in-memory DAOs, the callers are named by the trusted headers.
+func TestMain(m *testing.M) {
+	logs.Config = logs.Conf{Level: logs.ERROR, Output: []string{os.DevNull}}
+	if err := logs.Init(); err != nil {
+		fmt.Println(fmt.Errorf("fail to init logs: %w", err))
+		os.Exit(1)
+	}
+
+	controllers.Config = controllers.Conf{
+		TaskController: controllers.TaskControllerConf{
+			TaskDAO:   repositories.DAOFactoryOptions{Type: repositories.TypeTaskInMemoryDAO},
+			Reminders: controllers.RemindersConf{Disabled: true},
+		},
+		ListController: controllers.ListControllerConf{
+			ListDAO: repositories.DAOFactoryOptions{Type: repositories.TypeListInMemoryDAO},
+		},
+		WebhookController: controllers.WebhookControllerConf{
+			WebhookDAO: repositories.DAOFactoryOptions{Type: repositories.TypeWebhookInMemoryDAO},
+			Delivery:   controllers.WebhookDeliveryConf{Disabled: true},
+		},
+	}
+	if err := controllers.Init(); err != nil {
+		fmt.Println(fmt.Errorf("fail to init controllers: %w", err))
+		os.Exit(1)
+	}
+
+	Config = Conf{
+		GinMode:        gin.TestMode,
+		TrustedHeaders: TrustedHeadersConf{Principal: testHeaderPrincipal, Roles: testHeaderRoles},
+	}
+	if err := Init(); err != nil {
+		fmt.Println(fmt.Errorf("fail to init ginrouters: %w", err))
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	controllers.Close()
+	os.Exit(code)
+}
+
+// testCaller sends requests to the Router as a principal of its own, so the tests don't see the tasks of each other.
+type testCaller struct {
+	t         *testing.T
+	principal string
+	roles     string
+}
+
+func newTestCaller(t *testing.T) *testCaller {
+	return &testCaller{t: t, principal: "user-" + uuid.NewString()}
+}
+
+// do serves the request, body is sent as JSON unless it is a string or nil.
+// headers are pairs of names and values.
+func (tc *testCaller) do(method, path string, body any, headers ...string) *httptest.ResponseRecorder {
+	tc.t.Helper()
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewBufferString(body)
+	default:
+		raw, err := json.Marshal(body)
+		if err != nil {
+			tc.t.Fatalf("fail to marshal the body: %v", err)
+		}
+		reader = bytes.NewBuffer(raw)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(testHeaderPrincipal, tc.principal)
+	if tc.roles != "" {
+		req.Header.Set(testHeaderRoles, tc.roles)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	Router.ServeHTTP(rec, req)
+	return rec
+}
+
+// createTask creates a task and fails the test unless it is created.
+func (tc *testCaller) createTask(task map[string]any) *model.TaskPublicDTO {
+	tc.t.Helper()
+
+	rec := tc.do(http.MethodPost, "/task", task)
+	if rec.Code != http.StatusOK {
+		tc.t.Fatalf("POST /task = %d %s", rec.Code, rec.Body)
+	}
+	return decodeBody[model.TaskPublicDTO](tc.t, rec)
+}
+
+// decodeBody decodes the JSON body of a response.
+func decodeBody[T any](t *testing.T, rec *httptest.ResponseRecorder) *T {
+	t.Helper()
+
+	value := new(T)
+	if err := json.Unmarshal(rec.Body.Bytes(), value); err != nil {
+		t.Fatalf("fail to decode %s: %v", rec.Body, err)
+	}
+	return value
+}
+
+// expectProblem fails the test unless the response is a problem+json body with the status and the type.
+func expectProblem(t *testing.T, rec *httptest.ResponseRecorder, status int, problemType string) *Problem {
+	t.Helper()
+
+	if rec.Code != status {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, status, rec.Body)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != ContentTypeProblem {
+		t.Errorf("Content-Type = %q, want %q", contentType, ContentTypeProblem)
+	}
+	problem := decodeBody[Problem](t, rec)
+	if problem.Type != problemType || problem.Status != status {
+		t.Errorf("problem = %+v, want type %s and status %d", problem, problemType, status)
+	}
+	return problem
+}
+
+func TestTrustedHeadersRequired(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	rec := httptest.NewRecorder()
+	Router.ServeHTTP(rec, req)
+
+	expectProblem(t, rec, http.StatusUnauthorized, ProblemTypeUnauthorized)
+}
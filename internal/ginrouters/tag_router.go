@@ -0,0 +1,142 @@
+package ginrouters
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/CamilleLange/todolist/internal/controllers"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	ginparamsmapper "gitlab.com/Zandraz/gin-params-mapper"
+	"go.uber.org/zap"
+)
+
+var (
+	onceInitTagRouter sync.Once
+	// singletonTagRouter is a singleton instance of TagRouter.
+	singletonTagRouter *TagRouter
+)
+
+// TagRouter groups a set of handlers to manage entrypoints of Tag.
+type TagRouter struct {
+	ctlTag controllers.ITagController
+}
+
+func (r *TagRouter) Post(c *gin.Context) {
+	tag := new(model.TagCreateDTO)
+	if err := c.ShouldBindJSON(tag); err != nil {
+		log.Error("TagRouter.Post fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	createdTag, err := r.ctlTag.Create(c, tag)
+	if err != nil {
+		log.Error("TagRouter.Post fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdTag)
+}
+
+func (r *TagRouter) GetAll(c *gin.Context) {
+	tags, err := r.ctlTag.GetAll(c)
+	if err != nil {
+		log.Error("TagRouter.GetAll fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+func (r *TagRouter) Get(c *gin.Context) {
+	tagUUID, ok := tagUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	tag, err := r.ctlTag.Get(c, tagUUID)
+	if err != nil {
+		log.Error("TagRouter.Get fail",
+			zap.Any("tag_uuid", tagUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// Put renames the tag.
+func (r *TagRouter) Put(c *gin.Context) {
+	tagUUID, ok := tagUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	tagUpdateDTO := new(model.TagUpdateDTO)
+	if err := c.ShouldBindJSON(tagUpdateDTO); err != nil {
+		log.Error("TagRouter.Put fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	if err := r.ctlTag.Rename(c, tagUUID, tagUpdateDTO.Name); err != nil {
+		log.Error("TagRouter.Put fail", zap.Error(err))
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Tag updated.")
+}
+
+func (r *TagRouter) Delete(c *gin.Context) {
+	tagUUID, ok := tagUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.ctlTag.Delete(c, tagUUID); err != nil {
+		log.Error("TagRouter.Delete fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Tag deleted.")
+}
+
+// tagUUIDParam reads the tag_uuid path parameter, it aborts the request with a 400 if it isn't an UUID.
+func tagUUIDParam(c *gin.Context) (uuid.UUID, bool) {
+	var tagUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("tag_uuid", c, &tagUUID); err != nil {
+		log.Error("TagRouter fail", zap.String("tag_uuid", c.Param("tag_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid tag_uuid")
+		return uuid.Nil, false
+	}
+	return tagUUID, true
+}
+
+// GetInstanceTagRouter get singleton instance of TagRouter.
+func GetInstanceTagRouter() *TagRouter {
+	if singletonTagRouter == nil {
+		onceInitTagRouter.Do(
+			func() {
+				singletonTagRouter = &TagRouter{
+					ctlTag: controllers.TagInstance,
+				}
+			},
+		)
+	}
+
+	return singletonTagRouter
+}
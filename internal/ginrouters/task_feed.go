@@ -0,0 +1,70 @@
+package ginrouters
+
+import (
+	"context"
+	"time"
+
+	"github.com/CamilleLange/todolist/internal/controllers"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+const (
+	// HeaderLastEventID is sent by a reconnecting EventSource with the id of the last change it received.
+	HeaderLastEventID = "Last-Event-ID"
+	// ContentTypeEventStream is the media type of Server-Sent Events.
+	ContentTypeEventStream = "text/event-stream"
+
+	// DefaultEventsKeepAlive is the number of seconds between two pings of an idle feed when none is configured.
+	DefaultEventsKeepAlive = 30
+)
+
+// streamTaskFeed sends the changes of the feed to the client with send until ctx is done or the feed ends,
+// and pings the client with ping when no change was sent for keepAlive, so the proxies keep the connection open.
+func streamTaskFeed(ctx context.Context, feed *controllers.TaskFeed, keepAlive time.Duration, send func(*model.TaskChangePublicDTO) error, ping func() error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	changes := make(chan *model.TaskChangePublicDTO)
+	failure := make(chan error, 1)
+	go func() {
+		for {
+			change, err := feed.Next(ctx)
+			if err != nil {
+				failure <- err
+				return
+			}
+
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case change := <-changes:
+			if err := send(change); err != nil {
+				return err
+			}
+			ticker.Reset(keepAlive)
+		case <-ticker.C:
+			if err := ping(); err != nil {
+				return err
+			}
+		case err := <-failure:
+			return err
+		}
+	}
+}
+
+// eventsKeepAlive returns the configured interval between two pings of an idle feed.
+func eventsKeepAlive() time.Duration {
+	if Config.EventsKeepAlive <= 0 {
+		return DefaultEventsKeepAlive * time.Second
+	}
+	return time.Duration(Config.EventsKeepAlive) * time.Second
+}
@@ -0,0 +1,130 @@
+package ginrouters
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// testEvent is an event of a text/event-stream.
+type testEvent struct {
+	id     string
+	event  string
+	change model.TaskChangePublicDTO
+}
+
+// openTestEvents subscribes to GET /events of the server as the caller, resuming after lastEventID unless it is empty.
+// The stream is closed at the end of the test.
+func (tc *testCaller) openTestEvents(server *httptest.Server, lastEventID string) *bufio.Reader {
+	tc.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	tc.t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	if err != nil {
+		tc.t.Fatalf("http.NewRequest() error = %v", err)
+	}
+	req.Header.Set(testHeaderPrincipal, tc.principal)
+	if lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, lastEventID)
+	}
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		tc.t.Fatalf("GET /events error = %v", err)
+	}
+	tc.t.Cleanup(func() { _ = resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != ContentTypeEventStream {
+		tc.t.Fatalf("GET /events = %d %s, want 200 %s", resp.StatusCode, resp.Header.Get("Content-Type"), ContentTypeEventStream)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+// readTestEvent reads the next event of the stream, the comments keeping the stream alive are skipped.
+func readTestEvent(t *testing.T, reader *bufio.Reader) testEvent {
+	t.Helper()
+
+	var event testEvent
+	var data string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("fail to read the event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if data == "" {
+				continue
+			}
+			break
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			data += value
+		}
+	}
+
+	if err := json.Unmarshal([]byte(data), &event.change); err != nil {
+		t.Fatalf("fail to decode the data %s: %v", data, err)
+	}
+	return event
+}
+
+func TestEvents(t *testing.T) {
+	tc := newTestCaller(t)
+	// The server is closed after the streams of the test.
+	server := httptest.NewServer(Router)
+	t.Cleanup(server.Close)
+
+	rec := tc.do(http.MethodGet, "/events", nil, HeaderLastEventID, "yesterday")
+	expectProblem(t, rec, http.StatusBadRequest, ProblemTypeBadRequest)
+	rec = tc.do(http.MethodGet, "/events?status=waiting", nil)
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+
+	// The caller receives the changes of its tasks only, with their id.
+	stream := tc.openTestEvents(server, "")
+	newTestCaller(t).createTask(map[string]any{"description": "a task of someone else"})
+	plan := tc.createTask(map[string]any{"description": "plan the release"})
+	build := tc.createTask(map[string]any{"description": "build the release"})
+	events := make([]testEvent, 0, 2)
+	for _, want := range []uuid.UUID{plan.UUID, build.UUID} {
+		event := readTestEvent(t, stream)
+		if event.event != string(model.TaskEventCreated) || event.change.Task == nil || event.change.Task.UUID != want {
+			t.Fatalf("event = %s %+v, want %v created", event.event, event.change.Task, want)
+		}
+		if event.id != strconv.FormatUint(event.change.ID, 10) {
+			t.Errorf("event id = %s, want the id of the change %d", event.id, event.change.ID)
+		}
+		events = append(events, event)
+	}
+
+	// The caller reconnects with the id of a change it received and gets the changes published after it.
+	ship := tc.createTask(map[string]any{"description": "ship the release"})
+	stream = tc.openTestEvents(server, events[0].id)
+	for _, want := range []uuid.UUID{build.UUID, ship.UUID} {
+		if event := readTestEvent(t, stream); event.change.Task == nil || event.change.Task.UUID != want {
+			t.Fatalf("event after %s = %s %+v, want %v", events[0].id, event.event, event.change.Task, want)
+		}
+	}
+
+	// An id the feed doesn't know starts the caller over with a reset.
+	stream = tc.openTestEvents(server, "1")
+	if event := readTestEvent(t, stream); event.event != string(model.TaskChangeReset) || event.change.Task != nil {
+		t.Errorf("event after an unknown id = %s %+v, want a reset", event.event, event.change.Task)
+	}
+}
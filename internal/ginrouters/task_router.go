@@ -2,12 +2,22 @@ package ginrouters
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
 	"sync"
 
 	"github.com/CamilleLange/todolist/internal/controllers"
+	"github.com/CamilleLange/todolist/internal/repositories"
 	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 	ginparamsmapper "gitlab.com/Zandraz/gin-params-mapper"
@@ -20,6 +30,22 @@ var (
 	singletonTaskRouter *TaskRouter
 )
 
+var (
+	// taskPatchableFields are the fields of the public representation of a task changed by PUT and PATCH.
+	taskPatchableFields = []string{"description", "status", "list_uuid", "due_at", "remind_at", "rrule", "parent_uuid"}
+	// taskReadOnlyFields are the other fields of the public representation, managed by the API.
+	taskReadOnlyFields = []string{"task_uuid", "created_at", "last_updated", "version", "deleted_at", "owner_id", "reminded_at", "series_uuid", "tags"}
+	// taskUpdateStructFields maps the fields of a task update to the names of the fields of TaskUpdateDTO.
+	taskUpdateStructFields = func() map[string]string {
+		structFields := map[string]string{}
+		dtoType := reflect.TypeOf(model.TaskUpdateDTO{})
+		for i := 0; i < dtoType.NumField(); i++ {
+			structFields[dtoType.Field(i).Tag.Get("mapstructure")] = dtoType.Field(i).Name
+		}
+		return structFields
+	}()
+)
+
 // TaskRouter groups a set of handlers to manage entrypoints of Task.
 type TaskRouter struct {
 	ctlTask controllers.ITaskController
@@ -28,128 +54,232 @@ type TaskRouter struct {
 func (r *TaskRouter) Post(c *gin.Context) {
 	task := new(model.TaskCreateDTO)
 	if err := c.ShouldBindJSON(task); err != nil {
-		log.Error("TaskRouter.Post fail : %w", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, "Invalid request body")
+		log.Error("TaskRouter.Post fail", zap.Error(err))
+		AbortWithBindingError(c, err)
 		return
 	}
 
 	if err := ValidateInstance.Struct(task); err != nil {
-		log.Error("TaskRouter.Post fail : %w", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, "Invalid request body")
+		log.Error("TaskRouter.Post fail", zap.Error(err))
+		AbortWithBindingError(c, err)
 		return
 	}
 
-	ctx := context.WithValue(c, "create_task", task)
-
-	createdTask, err := r.ctlTask.Create(ctx)
+	createdTask, err := r.ctlTask.Create(c, task)
 	if err != nil {
 		log.Error("TaskRouter.Post fail",
 			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, "Bad Request")
+		AbortWithError(c, err)
 		return
 	}
 
+	c.Header(HeaderETag, TaskETag(createdTask.Version))
 	c.JSON(http.StatusOK, createdTask)
 }
 
 func (r *TaskRouter) GetAll(c *gin.Context) {
-	ctx := context.WithValue(c, "", "")
+	filter := new(model.TaskFilterDTO)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		log.Error("TaskRouter.GetAll fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	filter.SetDefaults()
 
-	tasks, err := r.ctlTask.GetAll(ctx)
+	tasks, err := r.ctlTask.GetAll(c, filter)
 	if err != nil {
 		log.Error("TaskRouter.GetAll fail",
 			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, "Bad Request")
+		AbortWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, tasks)
 }
 
+// Search answers the live tasks matching a full-text query, best ranked first.
+func (r *TaskRouter) Search(c *gin.Context) {
+	search := new(model.TaskSearchDTO)
+	if err := c.ShouldBindQuery(search); err != nil {
+		log.Error("TaskRouter.Search fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	results, err := r.ctlTask.Search(c, search)
+	if err != nil {
+		log.Error("TaskRouter.Search fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
 func (r *TaskRouter) Get(c *gin.Context) {
 	var taskUUID uuid.UUID
 	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
-		log.Error("TaskRouter.Get fail to get path param task_uuid: ", zap.Error(err))
-		c.JSON(http.StatusBadRequest, "Bad Request")
+		log.Error("TaskRouter.Get fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
 		return
 	}
 
-	ctx := context.WithValue(c, "task_uuid", &taskUUID)
-
-	task, err := r.ctlTask.Get(ctx)
+	task, err := r.ctlTask.Get(c, taskUUID)
 	if err != nil {
 		log.Error("TaskController.GetByUUID fail",
 			zap.Any("task_uuid", taskUUID),
 			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, "Bad Request")
+		AbortWithError(c, err)
+		return
+	}
+
+	c.Header(HeaderETag, TaskETag(task.Version))
+	c.Header(HeaderAcceptPatch, AcceptPatch)
+	if notModified(c, task.Version) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
 	c.JSON(http.StatusOK, task)
 }
 
+// Put replaces the patchable fields of the task by the ones of the body, the fields left out are removed.
+// The read-only fields of the public representation are ignored so a task read by GET can be sent back.
 func (r *TaskRouter) Put(c *gin.Context) {
 	var taskUUID uuid.UUID
 	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
-		log.Error("TaskRouter.Get fail to get path param task_uuid: ", zap.Error(err))
-		c.JSON(http.StatusBadRequest, "Bad Request")
+		log.Error("TaskRouter.Put fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
 		return
 	}
 
-	taskFieldsToUpdate := map[string]any{}
-	if err := c.ShouldBindJSON(&taskFieldsToUpdate); err != nil {
-		log.Error("TaskRouter.Put fail : %w", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, "bad request")
+	version, ok := ifMatchVersion(c, r.currentVersion(c, taskUUID))
+	if !ok {
 		return
 	}
 
-	if len(taskFieldsToUpdate) == 0 {
-		c.JSON(http.StatusNoContent, "no fields to update")
+	taskRepresentation := map[string]any{}
+	if err := c.ShouldBindJSON(&taskRepresentation); err != nil {
+		log.Error("TaskRouter.Put fail", zap.Error(err))
+		AbortWithBindingError(c, err)
 		return
 	}
 
-	taskUpdateDTO := new(model.TaskUpdateDTO)
-	config := &mapstructure.DecoderConfig{
-		ErrorUnused: true, // Extra fields throw an error.
-		Result:      &taskUpdateDTO,
+	for fieldName := range taskRepresentation {
+		if !slices.Contains(taskPatchableFields, fieldName) && !slices.Contains(taskReadOnlyFields, fieldName) {
+			AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, fmt.Sprintf("unknown field %q", fieldName))
+			return
+		}
 	}
 
-	decoder, err := mapstructure.NewDecoder(config)
+	taskFieldsToUpdate := make(map[string]any, len(taskPatchableFields))
+	for _, fieldName := range taskPatchableFields {
+		taskFieldsToUpdate[fieldName] = taskRepresentation[fieldName]
+	}
+
+	patch, err := decodeTaskPatch(taskFieldsToUpdate)
 	if err != nil {
-		log.Error("TaskRouter.Put fail : %w", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, "Internal server error")
+		log.Error("TaskRouter.Put fail", zap.String("reason", "invalid request body fields"), zap.Error(err))
+		AbortWithBindingError(c, err)
 		return
 	}
 
-	if err := decoder.Decode(taskFieldsToUpdate); err != nil {
-		log.Error("TaskRouter.Put fail : %w", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, "Can't decode request body")
+	err = r.ctlTask.Update(c, taskUUID, patch, version)
+	if err != nil {
+		log.Error("TaskRouter.Put fail", zap.Error(err))
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Task updated.")
+}
+
+// Patch changes the task with a JSON Merge Patch or a JSON Patch of its public representation,
+// conditioned on the If-Match header like Put. Only the patchable fields can be changed.
+func (r *TaskRouter) Patch(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.Patch fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	contentType := c.ContentType()
+	if contentType != ContentTypeMergePatch && contentType != ContentTypeJSONPatch {
+		c.Header(HeaderAcceptPatch, AcceptPatch)
+		AbortWithProblem(c, http.StatusUnsupportedMediaType, ProblemTypeUnsupportedMediaType,
+			fmt.Sprintf("the patch must be %s or %s", ContentTypeMergePatch, ContentTypeJSONPatch))
+		return
+	}
+
+	version, ok := ifMatchVersion(c, r.currentVersion(c, taskUUID))
+	if !ok {
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		log.Error("TaskRouter.Patch fail", zap.String("reason", "can't read the body"), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "can't read the body")
+		return
+	}
+
+	var taskFieldsToUpdate map[string]any
+	if contentType == ContentTypeMergePatch {
+		taskFieldsToUpdate, err = taskMergePatchFields(body)
+	} else {
+		task, errGet := r.ctlTask.Get(c, taskUUID)
+		if errGet != nil {
+			log.Error("TaskRouter.Patch fail",
+				zap.Any("task_uuid", taskUUID),
+				zap.Error(errGet),
+			)
+			AbortWithError(c, errGet)
+			return
+		}
+
+		// The patch is applied on the task as read, the update fails if the task changes meanwhile.
+		if version != 0 && version != task.Version {
+			AbortWithError(c, &repositories.VersionMismatchError{Expected: version, Current: task.Version})
+			return
+		}
+		version = task.Version
+		taskFieldsToUpdate, err = taskJSONPatchFields(task, body)
+	}
+	if err != nil {
+		log.Error("TaskRouter.Patch fail", zap.String("reason", "invalid patch"), zap.Error(err))
+		AbortWithPatchError(c, err)
 		return
 	}
 
-	fieldsNameToUpdate := make([]string, 0)
 	for fieldName := range taskFieldsToUpdate {
-		fieldsNameToUpdate = append(fieldsNameToUpdate, fieldName)
+		if !slices.Contains(taskPatchableFields, fieldName) {
+			AbortWithProblem(c, http.StatusUnprocessableEntity, ProblemTypeUnprocessable, fmt.Sprintf("the field %q can't be patched", fieldName))
+			return
+		}
 	}
 
-	// Only validate the fields that appears in request body
-	if err := ValidateInstance.StructPartial(taskUpdateDTO, fieldsNameToUpdate...); err != nil {
-		log.Error("TaskRouter.Put fail : invalid request body fields: %w", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, "Can't decode request body")
+	if len(taskFieldsToUpdate) == 0 {
+		c.JSON(http.StatusNoContent, "no fields to update")
 		return
 	}
 
-	ctx := context.WithValue(c, "task_fields_name", fieldsNameToUpdate)
-	ctx = context.WithValue(ctx, "task_values_to_update", taskFieldsToUpdate)
-	ctx = context.WithValue(ctx, "task_uuid", &taskUUID)
+	patch, err := decodeTaskPatch(taskFieldsToUpdate)
+	if err != nil {
+		log.Error("TaskRouter.Patch fail", zap.String("reason", "invalid patched fields"), zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
 
-	err = r.ctlTask.Update(ctx)
+	err = r.ctlTask.Update(c, taskUUID, patch, version)
 	if err != nil {
-		log.Error("TaskRouter.Put fail : %w", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, "can't update the resource")
+		log.Error("TaskRouter.Patch fail", zap.Error(err))
+		AbortWithError(c, err)
 		return
 	}
 
@@ -160,25 +290,769 @@ func (r *TaskRouter) Delete(c *gin.Context) {
 	var taskUUID uuid.UUID
 
 	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
-		log.Error("TaskRouter.Delete fail to get path param task_uuid: ", zap.Error(err))
-		c.JSON(http.StatusBadRequest, "Bad Request")
+		log.Error("TaskRouter.Delete fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	params := new(model.TaskDeleteDTO)
+	if err := c.ShouldBindQuery(params); err != nil {
+		log.Error("TaskRouter.Delete fail", zap.Error(err))
+		AbortWithBindingError(c, err)
 		return
 	}
 
-	ctx := context.WithValue(c, "task_uuid", &taskUUID)
+	version, ok := ifMatchVersion(c, r.currentVersion(c, taskUUID))
+	if !ok {
+		return
+	}
 
-	err := r.ctlTask.Delete(ctx)
+	err := r.ctlTask.Delete(c, taskUUID, version, params.Cascade)
 	if err != nil {
 		log.Error("TaskRouter.Delete fail",
 			zap.Error(err),
 		)
-		c.JSON(http.StatusBadRequest, "Bad Request")
+		AbortWithError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, "Task deleted.")
 }
 
+// GetChildren lists the subtasks of a task, with the filters, sort and pagination of /tasks.
+func (r *TaskRouter) GetChildren(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.GetChildren fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	filter := new(model.TaskFilterDTO)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		log.Error("TaskRouter.GetChildren fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	filter.SetDefaults()
+
+	tasks, err := r.ctlTask.GetChildren(c, taskUUID, filter)
+	if err != nil {
+		log.Error("TaskRouter.GetChildren fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// GetSubtree returns the task with its subtasks at any depth and their completion.
+func (r *TaskRouter) GetSubtree(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.GetSubtree fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	subtree, err := r.ctlTask.GetSubtree(c, taskUUID)
+	if err != nil {
+		log.Error("TaskRouter.GetSubtree fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, subtree)
+}
+
+func (r *TaskRouter) GetTrash(c *gin.Context) {
+	filter := new(model.TaskFilterDTO)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		log.Error("TaskRouter.GetTrash fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	filter.SetDefaults()
+
+	tasks, err := r.ctlTask.GetTrash(c, filter)
+	if err != nil {
+		log.Error("TaskRouter.GetTrash fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// GetSeries lists the occurrences of a recurring task, with the filters, sort and pagination of /tasks.
+func (r *TaskRouter) GetSeries(c *gin.Context) {
+	var seriesUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("series_uuid", c, &seriesUUID); err != nil {
+		log.Error("TaskRouter.GetSeries fail", zap.String("series_uuid", c.Param("series_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid series_uuid")
+		return
+	}
+
+	filter := new(model.TaskFilterDTO)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		log.Error("TaskRouter.GetSeries fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	filter.SetDefaults()
+	filter.SeriesUUID = &seriesUUID
+
+	tasks, err := r.ctlTask.GetAll(c, filter)
+	if err != nil {
+		log.Error("TaskRouter.GetSeries fail",
+			zap.Any("series_uuid", seriesUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// StopSeries stops the recurrence of every occurrence of the series.
+func (r *TaskRouter) StopSeries(c *gin.Context) {
+	var seriesUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("series_uuid", c, &seriesUUID); err != nil {
+		log.Error("TaskRouter.StopSeries fail", zap.String("series_uuid", c.Param("series_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid series_uuid")
+		return
+	}
+
+	if err := r.ctlTask.StopSeries(c, seriesUUID); err != nil {
+		log.Error("TaskRouter.StopSeries fail",
+			zap.Any("series_uuid", seriesUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Series stopped.")
+}
+
+func (r *TaskRouter) Restore(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.Restore fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	task, err := r.ctlTask.Restore(c, taskUUID)
+	if err != nil {
+		log.Error("TaskRouter.Restore fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.Header(HeaderETag, TaskETag(task.Version))
+	c.JSON(http.StatusOK, task)
+}
+
+func (r *TaskRouter) GetHistory(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.GetHistory fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	history, err := r.ctlTask.GetHistory(c, taskUUID)
+	if err != nil {
+		log.Error("TaskRouter.GetHistory fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (r *TaskRouter) GetGrants(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.GetGrants fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	grants, err := r.ctlTask.GetGrants(c, taskUUID)
+	if err != nil {
+		log.Error("TaskRouter.GetGrants fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grants)
+}
+
+func (r *TaskRouter) PutGrant(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.PutGrant fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+	principalID := c.Param("principal_id")
+
+	grant := new(model.TaskGrantDTO)
+	if err := c.ShouldBindJSON(grant); err != nil {
+		log.Error("TaskRouter.PutGrant fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	savedGrant, err := r.ctlTask.Grant(c, taskUUID, principalID, grant.Role)
+	if err != nil {
+		log.Error("TaskRouter.PutGrant fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.String("principal_id", principalID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, savedGrant)
+}
+
+func (r *TaskRouter) DeleteGrant(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.DeleteGrant fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+	principalID := c.Param("principal_id")
+
+	if err := r.ctlTask.Revoke(c, taskUUID, principalID); err != nil {
+		log.Error("TaskRouter.DeleteGrant fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.String("principal_id", principalID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Grant revoked.")
+}
+
+func (r *TaskRouter) GetDependencies(c *gin.Context) {
+	var taskUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.GetDependencies fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+
+	graph, err := r.ctlTask.GetDependencies(c, taskUUID)
+	if err != nil {
+		log.Error("TaskRouter.GetDependencies fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, graph)
+}
+
+func (r *TaskRouter) PutDependency(c *gin.Context) {
+	var taskUUID, blockerUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.PutDependency fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+	if err := ginparamsmapper.GetPathParamFromContext("blocker_uuid", c, &blockerUUID); err != nil {
+		log.Error("TaskRouter.PutDependency fail", zap.String("blocker_uuid", c.Param("blocker_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid blocker_uuid")
+		return
+	}
+
+	dependency, err := r.ctlTask.AddDependency(c, taskUUID, blockerUUID)
+	if err != nil {
+		log.Error("TaskRouter.PutDependency fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Any("blocker_uuid", blockerUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dependency)
+}
+
+func (r *TaskRouter) DeleteDependency(c *gin.Context) {
+	var taskUUID, blockerUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.DeleteDependency fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+	if err := ginparamsmapper.GetPathParamFromContext("blocker_uuid", c, &blockerUUID); err != nil {
+		log.Error("TaskRouter.DeleteDependency fail", zap.String("blocker_uuid", c.Param("blocker_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid blocker_uuid")
+		return
+	}
+
+	if err := r.ctlTask.RemoveDependency(c, taskUUID, blockerUUID); err != nil {
+		log.Error("TaskRouter.DeleteDependency fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Any("blocker_uuid", blockerUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Dependency removed.")
+}
+
+// PutTag puts a tag on the task, conditioned on the If-Match header like Put.
+func (r *TaskRouter) PutTag(c *gin.Context) {
+	var taskUUID, tagUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.PutTag fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+	if err := ginparamsmapper.GetPathParamFromContext("tag_uuid", c, &tagUUID); err != nil {
+		log.Error("TaskRouter.PutTag fail", zap.String("tag_uuid", c.Param("tag_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid tag_uuid")
+		return
+	}
+
+	version, ok := ifMatchVersion(c, r.currentVersion(c, taskUUID))
+	if !ok {
+		return
+	}
+
+	if err := r.ctlTask.AddTag(c, taskUUID, tagUUID, version); err != nil {
+		log.Error("TaskRouter.PutTag fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Any("tag_uuid", tagUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Tag added.")
+}
+
+// DeleteTag takes a tag off the task, conditioned on the If-Match header like Put.
+func (r *TaskRouter) DeleteTag(c *gin.Context) {
+	var taskUUID, tagUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("task_uuid", c, &taskUUID); err != nil {
+		log.Error("TaskRouter.DeleteTag fail", zap.String("task_uuid", c.Param("task_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid task_uuid")
+		return
+	}
+	if err := ginparamsmapper.GetPathParamFromContext("tag_uuid", c, &tagUUID); err != nil {
+		log.Error("TaskRouter.DeleteTag fail", zap.String("tag_uuid", c.Param("tag_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid tag_uuid")
+		return
+	}
+
+	version, ok := ifMatchVersion(c, r.currentVersion(c, taskUUID))
+	if !ok {
+		return
+	}
+
+	if err := r.ctlTask.RemoveTag(c, taskUUID, tagUUID, version); err != nil {
+		log.Error("TaskRouter.DeleteTag fail",
+			zap.Any("task_uuid", taskUUID),
+			zap.Any("tag_uuid", tagUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Tag removed.")
+}
+
+// Batch runs the operations of POST /tasks:batch. Gin can't route a path with a colon,
+// so the route is /tasks:action and the action must be :batch.
+func (r *TaskRouter) Batch(c *gin.Context) {
+	if c.Param("action") != ":batch" {
+		AbortWithProblem(c, http.StatusNotFound, ProblemTypeNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path)
+		return
+	}
+
+	batch := new(model.TaskBatchDTO)
+	if err := c.ShouldBindJSON(batch); err != nil {
+		log.Error("TaskRouter.Batch fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	if batch.Mode == "" {
+		batch.Mode = model.TaskBatchAllOrNothing
+	}
+
+	// A malformed operation rejects the whole batch, whatever its mode.
+	operations := make([]*model.TaskBatchOperation, 0, len(batch.Operations))
+	for i := range batch.Operations {
+		operation, err := decodeTaskBatchOperation(&batch.Operations[i])
+		if err != nil {
+			log.Error("TaskRouter.Batch fail : invalid operation", zap.Int("index", i), zap.Error(err))
+			status, problemType, detail := problemOfBindingError(err)
+			AbortWithProblem(c, status, problemType, fmt.Sprintf("operation %d: %s", i, detail))
+			return
+		}
+		// The version stands for the If-Match header, required like it on the updates and deletions.
+		if Config.RequireIfMatch && operation.Op != model.TaskBatchCreate && operation.Version == 0 {
+			AbortWithProblem(c, http.StatusPreconditionRequired, ProblemTypePreconditionRequired, fmt.Sprintf("operation %d: the version is required", i))
+			return
+		}
+		operations = append(operations, operation)
+	}
+
+	results, err := r.ctlTask.Batch(c, operations, batch.Mode)
+	if err != nil {
+		log.Error("TaskRouter.Batch fail",
+			zap.String("mode", string(batch.Mode)),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	publicBatch := &model.TaskBatchPublicDTO{
+		Mode:    batch.Mode,
+		Results: make([]model.TaskBatchResultPublicDTO, 0, len(results)),
+	}
+	for i, result := range results {
+		publicResult := model.TaskBatchResultPublicDTO{
+			Index:  i,
+			Op:     operations[i].Op,
+			Status: taskBatchStatus[operations[i].Op],
+		}
+		if result.Err != nil {
+			publicResult.Status, publicResult.Type, publicResult.Detail = problemOfError(result.Err)
+		} else if result.Task != nil {
+			publicResult.Task = model.FactoryTaskPublicDTO(result.Task)
+		}
+		publicBatch.Results = append(publicBatch.Results, publicResult)
+	}
+
+	// The results of a best-effort batch may differ from one operation to another.
+	status := http.StatusOK
+	if batch.Mode == model.TaskBatchBestEffort {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, publicBatch)
+}
+
+// Events streams the changes of the tasks visible by the caller as Server-Sent Events until the client leaves.
+// Each change is an event named after its type, its id is sent back in Last-Event-ID by a reconnecting EventSource.
+func (r *TaskRouter) Events(c *gin.Context) {
+	feed, ok := r.subscribe(c)
+	if !ok {
+		return
+	}
+	defer feed.Close()
+
+	c.Header("Content-Type", ContentTypeEventStream)
+	c.Header("Cache-Control", "no-cache")
+	// Stops the proxies like nginx from buffering the stream.
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	err := streamTaskFeed(c, feed, eventsKeepAlive(),
+		func(change *model.TaskChangePublicDTO) error {
+			event := sse.Event{
+				Id:    strconv.FormatUint(change.ID, 10),
+				Event: string(change.Type),
+				Data:  change,
+			}
+			if err := sse.Encode(c.Writer, event); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		},
+		func() error {
+			if _, err := c.Writer.WriteString(": ping\n\n"); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		},
+	)
+	logFeedEnd("TaskRouter.Events", err)
+}
+
+// WebSocket streams the changes of the tasks visible by the caller over a WebSocket, each change is a JSON text message.
+// The browsers can't send headers on a WebSocket, the feed is resumed with the last_event_id query parameter.
+func (r *TaskRouter) WebSocket(c *gin.Context) {
+	feed, ok := r.subscribe(c)
+	if !ok {
+		return
+	}
+	defer feed.Close()
+
+	ws := upgradeWebSocket(c)
+	if ws == nil {
+		return
+	}
+	defer ws.Release()
+
+	// The request context isn't canceled once the connection is taken over, the client leaving ends the feed.
+	// The reader is always waited for, the gin.Context is reused once the handler returns.
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+	readDone := make(chan error, 1)
+	go func() {
+		err := ws.ReadLoop()
+		cancel()
+		readDone <- err
+	}()
+
+	err := streamTaskFeed(ctx, feed, eventsKeepAlive(),
+		func(change *model.TaskChangePublicDTO) error {
+			return ws.WriteJSON(change)
+		},
+		ws.Ping,
+	)
+
+	select {
+	case readErr := <-readDone:
+		// The client closed the WebSocket or broke the protocol.
+		switch {
+		case errors.Is(readErr, errWebSocketProtocol):
+			_ = ws.Close(websocketCloseProtocolError, readErr.Error())
+		case errors.Is(readErr, errWebSocketTooBig):
+			_ = ws.Close(websocketCloseTooBig, readErr.Error())
+		}
+		logFeedEnd("TaskRouter.WebSocket", readErr)
+	default:
+		code, reason := uint16(websocketCloseInternalError), "unexpected error"
+		if errors.Is(err, controllers.ErrFeedClosed) {
+			code, reason = websocketCloseTryAgainLater, "the feed ended, resume it after the last change"
+		}
+		// The client answers the close, its connection is dropped if it doesn't in time.
+		if ws.Close(code, reason) != nil {
+			_ = ws.Release()
+		}
+		<-readDone
+		logFeedEnd("TaskRouter.WebSocket", err)
+	}
+}
+
+// subscribe subscribes the caller to the change feed with the query parameters of the request,
+// the Last-Event-ID header taking precedence over last_event_id. It returns false once the request is aborted.
+func (r *TaskRouter) subscribe(c *gin.Context) (*controllers.TaskFeed, bool) {
+	feedToSubscribe := new(model.TaskFeedDTO)
+	if err := c.ShouldBindQuery(feedToSubscribe); err != nil {
+		log.Error("TaskRouter.subscribe fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return nil, false
+	}
+	if header := c.GetHeader(HeaderLastEventID); header != "" {
+		lastEventID, err := strconv.ParseUint(header, 10, 64)
+		if err != nil {
+			AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid "+HeaderLastEventID)
+			return nil, false
+		}
+		feedToSubscribe.LastEventID = &lastEventID
+	}
+
+	feed, err := r.ctlTask.Subscribe(c, feedToSubscribe)
+	if err != nil {
+		log.Error("TaskRouter.subscribe fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return nil, false
+	}
+	return feed, true
+}
+
+// logFeedEnd logs why the feed of a client ended, the client leaving and the end of the feed are expected.
+func logFeedEnd(handler string, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, controllers.ErrFeedClosed) || errors.Is(err, io.EOF) {
+		log.Info(handler+" feed ended", zap.Error(err))
+		return
+	}
+	log.Error(handler+" feed fail", zap.Error(err))
+}
+
+// taskBatchStatus is the status of the successful operations of a batch, the status of the same single request.
+var taskBatchStatus = map[model.TaskBatchOp]int{
+	model.TaskBatchCreate: http.StatusCreated,
+	model.TaskBatchUpdate: http.StatusOK,
+	model.TaskBatchDelete: http.StatusNoContent,
+}
+
+// decodeTaskBatchOperation decodes and validates the task of an operation of a batch like POST /task and a merge patch of PATCH /task/{task_uuid}.
+func decodeTaskBatchOperation(dto *model.TaskBatchOperationDTO) (*model.TaskBatchOperation, error) {
+	operation := &model.TaskBatchOperation{
+		Op:      dto.Op,
+		Version: dto.Version,
+		Cascade: dto.Cascade,
+	}
+	if dto.TaskUUID != nil {
+		operation.TaskUUID = *dto.TaskUUID
+	}
+
+	switch dto.Op {
+	case model.TaskBatchCreate:
+		operation.Create = new(model.TaskCreateDTO)
+		if err := json.Unmarshal(dto.Task, operation.Create); err != nil {
+			return nil, fmt.Errorf("invalid task : %w", err)
+		}
+		if err := binding.Validator.ValidateStruct(operation.Create); err != nil {
+			return nil, err
+		}
+	case model.TaskBatchUpdate:
+		taskFieldsToUpdate := map[string]any{}
+		if err := json.Unmarshal(dto.Task, &taskFieldsToUpdate); err != nil {
+			return nil, fmt.Errorf("invalid task : %w", err)
+		}
+		if len(taskFieldsToUpdate) == 0 {
+			return nil, errors.New("no fields to update")
+		}
+
+		patch, err := decodeTaskPatch(taskFieldsToUpdate)
+		if err != nil {
+			return nil, err
+		}
+		operation.Patch = patch
+	}
+
+	return operation, nil
+}
+
+// taskMergePatchFields returns the fields changed by a JSON Merge Patch of a task, a null removes the field.
+// The fields of a task aren't objects so the merge patch only has to be a JSON object.
+func taskMergePatchFields(body []byte) (map[string]any, error) {
+	var mergePatch any
+	if err := json.Unmarshal(body, &mergePatch); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformedPatch, err)
+	}
+
+	taskFieldsToUpdate, ok := mergePatch.(map[string]any)
+	if !ok {
+		return nil, errors.New("the merge patch of a task must be an object")
+	}
+	return taskFieldsToUpdate, nil
+}
+
+// taskJSONPatchFields applies a JSON Patch on the public representation of the task
+// and returns the fields it changed, a removed field is null.
+func taskJSONPatchFields(task *model.TaskPublicDTO, body []byte) (map[string]any, error) {
+	var operations []jsonPatchOperation
+	if err := json.Unmarshal(body, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", errMalformedPatch, err)
+	}
+
+	representation, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var before, doc map[string]any
+	if err := json.Unmarshal(representation, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(representation, &doc); err != nil {
+		return nil, err
+	}
+
+	patched, err := applyJSONPatch(doc, operations)
+	if err != nil {
+		return nil, err
+	}
+	after, ok := patched.(map[string]any)
+	if !ok {
+		return nil, errors.New("the patched task must be an object")
+	}
+
+	taskFieldsToUpdate := map[string]any{}
+	for fieldName, value := range after {
+		if !reflect.DeepEqual(before[fieldName], value) {
+			taskFieldsToUpdate[fieldName] = value
+		}
+	}
+	for fieldName := range before {
+		if _, ok := after[fieldName]; !ok {
+			taskFieldsToUpdate[fieldName] = nil
+		}
+	}
+	return taskFieldsToUpdate, nil
+}
+
+// decodeTaskPatch decodes the fields of a task update into a TaskPatch, only the fields present are validated.
+func decodeTaskPatch(taskFieldsToUpdate map[string]any) (*model.TaskPatch, error) {
+	taskUpdateDTO := new(model.TaskUpdateDTO)
+	config := &mapstructure.DecoderConfig{
+		ErrorUnused: true, // Extra fields throw an error.
+		DecodeHook:  mapstructure.TextUnmarshallerHookFunc(),
+		Result:      &taskUpdateDTO,
+	}
+
+	decoder, err := mapstructure.NewDecoder(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := decoder.Decode(taskFieldsToUpdate); err != nil {
+		return nil, err
+	}
+
+	fieldsNameToUpdate := make([]string, 0)
+	structFieldsToValidate := make([]string, 0)
+	for fieldName := range taskFieldsToUpdate {
+		fieldsNameToUpdate = append(fieldsNameToUpdate, fieldName)
+		structFieldsToValidate = append(structFieldsToValidate, taskUpdateStructFields[fieldName])
+	}
+
+	// Only validate the fields that appears in request body, the validator knows them by their struct field name.
+	if err := ValidateInstance.StructPartial(taskUpdateDTO, structFieldsToValidate...); err != nil {
+		return nil, err
+	}
+
+	return taskUpdateDTO.ReversePatch(fieldsNameToUpdate), nil
+}
+
+// currentVersion returns a function reading the current version of the task.
+func (r *TaskRouter) currentVersion(c *gin.Context, taskUUID uuid.UUID) func() (int64, error) {
+	return func() (int64, error) {
+		task, err := r.ctlTask.Get(c, taskUUID)
+		if err != nil {
+			return 0, err
+		}
+		return task.Version, nil
+	}
+}
+
 // GetInstanceTaskRouter get singleton instance of TaskRouter.
 func GetInstanceTaskRouter() *TaskRouter {
 	if singletonTaskRouter == nil {
@@ -0,0 +1,883 @@
+package ginrouters
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// getTasks requests a page of GET /tasks and fails the test unless it is served.
+func (tc *testCaller) getTasks(query url.Values) *model.TaskListPublicDTO {
+	tc.t.Helper()
+
+	rec := tc.do(http.MethodGet, "/tasks?"+query.Encode(), nil)
+	if rec.Code != http.StatusOK {
+		tc.t.Fatalf("GET /tasks?%s = %d %s", query.Encode(), rec.Code, rec.Body)
+	}
+	return decodeBody[model.TaskListPublicDTO](tc.t, rec)
+}
+
+// taskUUIDs returns the UUIDs of the tasks, in order.
+func taskUUIDs(tasks []model.TaskPublicDTO) []uuid.UUID {
+	uuids := make([]uuid.UUID, 0, len(tasks))
+	for _, task := range tasks {
+		uuids = append(uuids, task.UUID)
+	}
+	return uuids
+}
+
+func TestGetAllEnvelope(t *testing.T) {
+	tc := newTestCaller(t)
+
+	// The tasks are wrapped in an object, not sent as a bare array.
+	rec := tc.do(http.MethodGet, "/tasks", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /tasks = %d %s", rec.Code, rec.Body)
+	}
+	if got, want := rec.Body.String(), `{"tasks":[]}`; got != want {
+		t.Errorf("GET /tasks = %s, want %s", got, want)
+	}
+
+	created := tc.createTask(map[string]any{"description": "buy milk"})
+
+	var envelope map[string]json.RawMessage
+	rec = tc.do(http.MethodGet, "/tasks?limit=1", nil)
+	if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("GET /tasks = %s, want an object: %v", rec.Body, err)
+	}
+	if _, ok := envelope["next_cursor"]; ok {
+		t.Errorf("GET /tasks = %s, want no next_cursor on the last page", rec.Body)
+	}
+
+	page := decodeBody[model.TaskListPublicDTO](t, rec)
+	if len(page.Tasks) != 1 || page.Tasks[0].UUID != created.UUID {
+		t.Errorf("GET /tasks = %s, want the created task", rec.Body)
+	}
+}
+
+func TestGetAllPagination(t *testing.T) {
+	tc := newTestCaller(t)
+
+	created := make([]uuid.UUID, 0)
+	for _, description := range []string{"a", "b", "c", "d", "e"} {
+		created = append(created, tc.createTask(map[string]any{"description": description}).UUID)
+	}
+
+	query := url.Values{"limit": {"2"}}
+	listed := make([]uuid.UUID, 0)
+	pages := 0
+	for {
+		page := tc.getTasks(query)
+		pages++
+		listed = append(listed, taskUUIDs(page.Tasks)...)
+		if page.NextCursor == "" {
+			break
+		}
+		if pages > len(created) {
+			t.Fatal("GET /tasks never returns the last page")
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+
+	if pages != 3 {
+		t.Errorf("GET /tasks served %d pages of 2 tasks, want 3", pages)
+	}
+	if len(listed) != len(created) {
+		t.Fatalf("GET /tasks listed %v, want %v", listed, created)
+	}
+	for i := range created {
+		if listed[i] != created[i] {
+			t.Fatalf("GET /tasks listed %v, want %v", listed, created)
+		}
+	}
+}
+
+func TestGetAllSortAndFilter(t *testing.T) {
+	tc := newTestCaller(t)
+
+	water := tc.createTask(map[string]any{"description": "water the plants"})
+	call := tc.createTask(map[string]any{"description": "call mum", "status": "in_progress"})
+	buy := tc.createTask(map[string]any{"description": "buy milk", "status": "done"})
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []uuid.UUID
+	}{
+		{name: "default", query: url.Values{}, want: []uuid.UUID{water.UUID, call.UUID, buy.UUID}},
+		{name: "created desc", query: url.Values{"order": {"desc"}}, want: []uuid.UUID{buy.UUID, call.UUID, water.UUID}},
+		{name: "description", query: url.Values{"sort": {"description"}}, want: []uuid.UUID{buy.UUID, call.UUID, water.UUID}},
+		{name: "status", query: url.Values{"status": {"todo", "done"}}, want: []uuid.UUID{water.UUID, buy.UUID}},
+		{name: "search", query: url.Values{"q": {"MILK"}}, want: []uuid.UUID{buy.UUID}},
+		{name: "overdue", query: url.Values{"overdue": {"true"}}, want: []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc.t = t
+			page := tc.getTasks(tt.query)
+			got := taskUUIDs(page.Tasks)
+			if len(got) != len(tt.want) {
+				t.Fatalf("GET /tasks?%s = %v, want %v", tt.query.Encode(), got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("GET /tasks?%s = %v, want %v", tt.query.Encode(), got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAllTags(t *testing.T) {
+	tc := newTestCaller(t)
+
+	tags := map[string]uuid.UUID{}
+	for _, name := range []string{"work", "home"} {
+		rec := tc.do(http.MethodPost, "/tags", map[string]any{"name": name})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("POST /tags = %d %s", rec.Code, rec.Body)
+		}
+		tags[name] = decodeBody[model.TagPublicDTO](t, rec).UUID
+	}
+	createTagged := func(description string, names ...string) *model.TaskPublicDTO {
+		t.Helper()
+		task := tc.createTask(map[string]any{"description": description})
+		for _, name := range names {
+			path := "/task/" + task.UUID.String() + "/tags/" + tags[name].String()
+			if rec := tc.do(http.MethodPut, path, nil); rec.Code != http.StatusNoContent {
+				t.Fatalf("PUT %s = %d %s", path, rec.Code, rec.Body)
+			}
+		}
+		return task
+	}
+	report := createTagged("write the report", "work", "home")
+	call := createTagged("call the client", "work")
+	garden := createTagged("mow the lawn", "home")
+	createTagged("read a book")
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  []uuid.UUID
+	}{
+		{name: "any", query: url.Values{"tag": {"work", "home"}}, want: []uuid.UUID{report.UUID, call.UUID, garden.UUID}},
+		{name: "all", query: url.Values{"tag": {"work", "home"}, "tag_match": {"all"}}, want: []uuid.UUID{report.UUID}},
+		// The duplicates are counted once, the blank tags are ignored.
+		{name: "all with a duplicate", query: url.Values{"tag": {"work", " work", ""}, "tag_match": {"all"}}, want: []uuid.UUID{report.UUID, call.UUID}},
+		{name: "all with a duplicate of two", query: url.Values{"tag": {"home", "work", "home"}, "tag_match": {"all"}}, want: []uuid.UUID{report.UUID}},
+		{name: "all with an unknown tag", query: url.Values{"tag": {"work", "urgent"}, "tag_match": {"all"}}, want: []uuid.UUID{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc.t = t
+			got := taskUUIDs(tc.getTasks(tt.query).Tasks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GET /tasks?%s = %v, want %v", tt.query.Encode(), got, tt.want)
+			}
+		})
+	}
+
+	rec := tc.do(http.MethodGet, "/tasks?tag=work&tag_match=some", nil)
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+}
+
+func TestGetAllInvalidQuery(t *testing.T) {
+	tc := newTestCaller(t)
+	tc.createTask(map[string]any{"description": "a"})
+	tc.createTask(map[string]any{"description": "b"})
+	cursor := tc.getTasks(url.Values{"limit": {"1"}}).NextCursor
+	if cursor == "" {
+		t.Fatal("GET /tasks?limit=1 has no next_cursor")
+	}
+
+	tests := []struct {
+		name        string
+		query       url.Values
+		status      int
+		problemType string
+	}{
+		{name: "limit too big", query: url.Values{"limit": {"501"}}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{name: "limit not a number", query: url.Values{"limit": {"ten"}}, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{name: "unknown sort", query: url.Values{"sort": {"owner_id"}}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{name: "unknown order", query: url.Values{"order": {"up"}}, status: http.StatusUnprocessableEntity, problemType: ProblemTypeUnprocessable},
+		{name: "bad date", query: url.Values{"created_after": {"yesterday"}}, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{name: "garbage cursor", query: url.Values{"cursor": {"garbage"}}, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{name: "cursor of another sort", query: url.Values{"cursor": {cursor}, "sort": {"description"}}, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+		{name: "cursor of another order", query: url.Values{"cursor": {cursor}, "order": {"desc"}}, status: http.StatusBadRequest, problemType: ProblemTypeBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc.t = t
+			rec := tc.do(http.MethodGet, "/tasks?"+tt.query.Encode(), nil)
+			expectProblem(t, rec, tt.status, tt.problemType)
+		})
+	}
+}
+
+// getTask reads a task and fails the test unless it is found.
+func (tc *testCaller) getTask(taskUUID uuid.UUID) *model.TaskPublicDTO {
+	tc.t.Helper()
+
+	rec := tc.do(http.MethodGet, "/task/"+taskUUID.String(), nil)
+	if rec.Code != http.StatusOK {
+		tc.t.Fatalf("GET /task/%s = %d %s", taskUUID, rec.Code, rec.Body)
+	}
+	return decodeBody[model.TaskPublicDTO](tc.t, rec)
+}
+
+// mergePatch sends a JSON Merge Patch of the task, headers are pairs of names and values.
+func (tc *testCaller) mergePatch(taskUUID uuid.UUID, patch any, headers ...string) *httptest.ResponseRecorder {
+	tc.t.Helper()
+
+	headers = append([]string{"Content-Type", ContentTypeMergePatch}, headers...)
+	return tc.do(http.MethodPatch, "/task/"+taskUUID.String(), patch, headers...)
+}
+
+func TestStatusWorkflow(t *testing.T) {
+	tc := newTestCaller(t)
+
+	// The initial status is used without status, and the statuses are normalized.
+	if task := tc.createTask(map[string]any{"description": "a"}); task.Status != "todo" {
+		t.Errorf("created status = %q, want todo", task.Status)
+	}
+	task := tc.createTask(map[string]any{"description": "b", "status": "In Progress "})
+	if task.Status != "in_progress" {
+		t.Errorf("created status = %q, want in_progress", task.Status)
+	}
+
+	rec := tc.do(http.MethodPost, "/task", map[string]any{"description": "c", "status": "waiting"})
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+
+	if rec := tc.mergePatch(task.UUID, map[string]any{"status": "done"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH in_progress to done = %d %s", rec.Code, rec.Body)
+	}
+
+	// done can't go back to todo, whichever route changes it.
+	rec = tc.mergePatch(task.UUID, map[string]any{"status": "todo"})
+	expectProblem(t, rec, http.StatusConflict, ProblemTypeConflict)
+	rec = tc.do(http.MethodPut, "/task/"+task.UUID.String(), map[string]any{"description": "b", "status": "todo"})
+	expectProblem(t, rec, http.StatusConflict, ProblemTypeConflict)
+	rec = tc.mergePatch(task.UUID, map[string]any{"status": "waiting"})
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+
+	if got := tc.getTask(task.UUID); got.Status != "done" || got.Version != 2 {
+		t.Errorf("task = %s version %d, want done version 2", got.Status, got.Version)
+	}
+
+	// Staying in the same status is allowed.
+	if rec := tc.mergePatch(task.UUID, map[string]any{"status": "Done"}); rec.Code != http.StatusNoContent {
+		t.Errorf("PATCH done to done = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestGetTask(t *testing.T) {
+	tc := newTestCaller(t)
+	created := tc.createTask(map[string]any{"description": "buy milk", "due_at": "2030-01-02T15:04:05Z"})
+
+	rec := tc.do(http.MethodGet, "/task/"+created.UUID.String(), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /task = %d %s", rec.Code, rec.Body)
+	}
+	if etag := rec.Header().Get(HeaderETag); etag != `"1"` {
+		t.Errorf("ETag = %s, want \"1\"", etag)
+	}
+
+	// The task is sent with the documented field names.
+	var fields map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatalf("fail to decode %s: %v", rec.Body, err)
+	}
+	want := map[string]any{
+		"task_uuid":   created.UUID.String(),
+		"description": "buy milk",
+		"status":      "todo",
+		"version":     float64(1),
+		"owner_id":    tc.principal,
+		"due_at":      "2030-01-02T15:04:05Z",
+	}
+	for name, value := range want {
+		if fields[name] != value {
+			t.Errorf("GET /task field %s = %v, want %v", name, fields[name], value)
+		}
+	}
+	for _, name := range []string{"created_at", "last_updated"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("GET /task = %s, want a %s field", rec.Body, name)
+		}
+	}
+
+	rec = tc.do(http.MethodGet, "/task/not-a-uuid", nil)
+	expectProblem(t, rec, http.StatusBadRequest, ProblemTypeBadRequest)
+	rec = tc.do(http.MethodGet, "/task/"+uuid.NewString(), nil)
+	expectProblem(t, rec, http.StatusNotFound, ProblemTypeNotFound)
+}
+
+// jsonPatch sends a JSON Patch of the task, headers are pairs of names and values.
+func (tc *testCaller) jsonPatch(taskUUID uuid.UUID, patch string, headers ...string) *httptest.ResponseRecorder {
+	tc.t.Helper()
+
+	headers = append([]string{"Content-Type", ContentTypeJSONPatch}, headers...)
+	return tc.do(http.MethodPatch, "/task/"+taskUUID.String(), patch, headers...)
+}
+
+func TestPatchJSONPatch(t *testing.T) {
+	tc := newTestCaller(t)
+	parent := tc.createTask(map[string]any{"description": "move out"})
+	task := tc.createTask(map[string]any{"description": "pack", "due_at": "2030-01-02T15:04:05Z", "parent_uuid": parent.UUID})
+
+	rec := tc.jsonPatch(task.UUID, `[
+		{"op": "test", "path": "/status", "value": "todo"},
+		{"op": "replace", "path": "/description", "value": "pack the books"},
+		{"op": "move", "from": "/due_at", "path": "/remind_at"},
+		{"op": "remove", "path": "/parent_uuid"}
+	]`, HeaderIfMatch, `"1"`)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH = %d %s", rec.Code, rec.Body)
+	}
+	got := tc.getTask(task.UUID)
+	if got.WhatToDo != "pack the books" || got.DueAt != nil || got.RemindAt == nil || got.RemindAt.Format(time.RFC3339) != "2030-01-02T15:04:05Z" || got.ParentUUID != nil || got.Version != 2 {
+		t.Errorf("patched task = %+v, want pack the books reminded at the former due date without parent at version 2", got)
+	}
+
+	// A failing operation aborts the whole patch.
+	invalid := []struct {
+		patch   string
+		status  int
+		problem string
+	}{
+		{patch: `[{"op": "replace", "path": "/description", "value": "pack"}, {"op": "test", "path": "/status", "value": "done"}]`,
+			status: http.StatusConflict, problem: ProblemTypeConflict},
+		{patch: `[{"op": "replace", "path": "/description", "value": "pack"}, {"op": "remove", "path": "/due_at"}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+		{patch: `[{"op": "replace", "path": "/description", "value": "pack"}, {"op": "copy", "path": "/status"}]`,
+			status: http.StatusBadRequest, problem: ProblemTypeBadRequest},
+		{patch: `{"op": "replace", "path": "/description", "value": "pack"}`,
+			status: http.StatusBadRequest, problem: ProblemTypeBadRequest},
+		// The read-only and unknown fields can't be patched.
+		{patch: `[{"op": "replace", "path": "/owner_id", "value": "someone"}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+		{patch: `[{"op": "replace", "path": "/version", "value": 7}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+		{patch: `[{"op": "add", "path": "/tags/-", "value": "urgent"}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+		{patch: `[{"op": "add", "path": "/color", "value": "red"}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+		{patch: `[{"op": "replace", "path": "/color", "value": "red"}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+		{patch: `[{"op": "remove", "path": ""}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+		{patch: `[{"op": "replace", "path": "", "value": []}]`,
+			status: http.StatusUnprocessableEntity, problem: ProblemTypeUnprocessable},
+	}
+	for _, tt := range invalid {
+		rec := tc.jsonPatch(task.UUID, tt.patch)
+		expectProblem(t, rec, tt.status, tt.problem)
+	}
+	if got := tc.getTask(task.UUID); got.WhatToDo != "pack the books" || got.Version != 2 {
+		t.Errorf("task after the failed patches = %s version %d, want pack the books version 2", got.WhatToDo, got.Version)
+	}
+
+	// The patch is applied on the version of If-Match.
+	rec = tc.jsonPatch(task.UUID, `[{"op": "replace", "path": "/status", "value": "done"}]`, HeaderIfMatch, `"1"`)
+	expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+	rec = tc.do(http.MethodPatch, "/task/"+task.UUID.String(), `[]`, "Content-Type", "application/json")
+	expectProblem(t, rec, http.StatusUnsupportedMediaType, ProblemTypeUnsupportedMediaType)
+	if accept := rec.Header().Get(HeaderAcceptPatch); accept != AcceptPatch {
+		t.Errorf("Accept-Patch = %q, want %q", accept, AcceptPatch)
+	}
+}
+
+func TestPatchMergePatchNull(t *testing.T) {
+	tc := newTestCaller(t)
+	parent := tc.createTask(map[string]any{"description": "move out"})
+	due := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	task := tc.createTask(map[string]any{"description": "water the plants", "due_at": due, "rrule": "FREQ=WEEKLY", "parent_uuid": parent.UUID})
+	if task.DueAt == nil || task.RRule == "" || task.ParentUUID == nil {
+		t.Fatalf("created task = %+v, want a due date, a rule and a parent", task)
+	}
+
+	// A null removes the field, the absent fields are left unchanged.
+	if rec := tc.mergePatch(task.UUID, map[string]any{"due_at": nil, "rrule": nil, "parent_uuid": nil}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH = %d %s", rec.Code, rec.Body)
+	}
+	got := tc.getTask(task.UUID)
+	if got.DueAt != nil || got.RRule != "" || got.ParentUUID != nil {
+		t.Errorf("patched task = due %v, rule %q, parent %v, want none", got.DueAt, got.RRule, got.ParentUUID)
+	}
+	if got.WhatToDo != task.WhatToDo || got.Status != task.Status || got.Version != 2 {
+		t.Errorf("patched task = %s %s version %d, want %s %s version 2", got.WhatToDo, got.Status, got.Version, task.WhatToDo, task.Status)
+	}
+
+	// The required fields can't be removed and the read-only ones can't be patched.
+	rec := tc.mergePatch(task.UUID, map[string]any{"description": nil})
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	rec = tc.mergePatch(task.UUID, map[string]any{"owner_id": nil})
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	rec = tc.mergePatch(task.UUID, `[{"op": "remove", "path": "/due_at"}]`)
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+}
+
+func TestDecodeTaskPatch(t *testing.T) {
+	listUUID := uuid.New()
+	patch, err := decodeTaskPatch(map[string]any{
+		"description": "call mum",
+		"list_uuid":   listUUID.String(),
+		"due_at":      "2030-01-02T15:04:05Z",
+		"remind_at":   nil,
+	})
+	if err != nil {
+		t.Fatalf("decodeTaskPatch() error = %v", err)
+	}
+
+	if patch.WhatToDo == nil || *patch.WhatToDo != "call mum" {
+		t.Errorf("WhatToDo = %v, want call mum", patch.WhatToDo)
+	}
+	if patch.ListUUID == nil || *patch.ListUUID != listUUID {
+		t.Errorf("ListUUID = %v, want %v", patch.ListUUID, listUUID)
+	}
+	if patch.DueAt == nil || patch.DueAt.Format(time.RFC3339) != "2030-01-02T15:04:05Z" {
+		t.Errorf("DueAt = %v, want 2030-01-02T15:04:05Z", patch.DueAt)
+	}
+	if patch.RemindAt == nil || !patch.RemindAt.IsZero() {
+		t.Errorf("RemindAt = %v, want the zero time removing the reminder", patch.RemindAt)
+	}
+	if patch.Status != nil || patch.RRule != nil || patch.ParentUUID != nil {
+		t.Errorf("decodeTaskPatch() = %+v, want only the fields of the body", patch)
+	}
+
+	invalid := []map[string]any{
+		{"description": 42},
+		{"description": ""},
+		{"status": nil},
+		{"list_uuid": "not-a-uuid"},
+		{"due_at": "tomorrow"},
+		{"owner_id": "someone"},
+	}
+	for _, fields := range invalid {
+		if _, err := decodeTaskPatch(fields); err == nil {
+			t.Errorf("decodeTaskPatch(%v) error = nil, want an error", fields)
+		}
+	}
+}
+
+// getTrash requests a page of GET /tasks/trash and fails the test unless it is served.
+func (tc *testCaller) getTrash(query url.Values) *model.TaskListPublicDTO {
+	tc.t.Helper()
+
+	rec := tc.do(http.MethodGet, "/tasks/trash?"+query.Encode(), nil)
+	if rec.Code != http.StatusOK {
+		tc.t.Fatalf("GET /tasks/trash?%s = %d %s", query.Encode(), rec.Code, rec.Body)
+	}
+	return decodeBody[model.TaskListPublicDTO](tc.t, rec)
+}
+
+func TestTrash(t *testing.T) {
+	tc := newTestCaller(t)
+	kept := tc.createTask(map[string]any{"description": "kept"})
+	task := tc.createTask(map[string]any{"description": "trashed"})
+	path := "/task/" + task.UUID.String()
+
+	if rec := tc.do(http.MethodDelete, path, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s = %d %s", path, rec.Code, rec.Body)
+	}
+
+	// The deleted task leaves the live routes for the trash.
+	expectProblem(t, tc.do(http.MethodGet, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, tc.mergePatch(task.UUID, map[string]any{"status": "done"}), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, tc.do(http.MethodDelete, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+	if got := taskUUIDs(tc.getTasks(nil).Tasks); len(got) != 1 || got[0] != kept.UUID {
+		t.Errorf("GET /tasks = %v, want only %v", got, kept.UUID)
+	}
+	trash := tc.getTrash(nil).Tasks
+	if len(trash) != 1 || trash[0].UUID != task.UUID || trash[0].DeletedAt == nil || trash[0].Version != 2 {
+		t.Fatalf("GET /tasks/trash = %+v, want the deleted task at version 2", trash)
+	}
+
+	// The trash is filtered like /tasks, and only the owner sees it.
+	if got := tc.getTrash(url.Values{"q": {"kept"}}).Tasks; len(got) != 0 {
+		t.Errorf("GET /tasks/trash?q=kept = %v, want none", taskUUIDs(got))
+	}
+	other := newTestCaller(t)
+	if got := other.getTrash(nil).Tasks; len(got) != 0 {
+		t.Errorf("GET /tasks/trash of another principal = %v, want none", taskUUIDs(got))
+	}
+	expectProblem(t, other.do(http.MethodPost, path+"/restore", nil), http.StatusNotFound, ProblemTypeNotFound)
+
+	rec := tc.do(http.MethodPost, path+"/restore", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST %s/restore = %d %s", path, rec.Code, rec.Body)
+	}
+	restored := decodeBody[model.TaskPublicDTO](t, rec)
+	if restored.DeletedAt != nil || restored.Version != 3 || rec.Header().Get(HeaderETag) != `"3"` {
+		t.Errorf("restored task = %+v with ETag %s, want a live task at version 3", restored, rec.Header().Get(HeaderETag))
+	}
+	if got := tc.getTask(task.UUID); got.WhatToDo != "trashed" {
+		t.Errorf("GET %s = %q, want the restored task", path, got.WhatToDo)
+	}
+	if got := tc.getTrash(nil).Tasks; len(got) != 0 {
+		t.Errorf("GET /tasks/trash after the restore = %v, want none", taskUUIDs(got))
+	}
+
+	// A live task isn't in the trash.
+	expectProblem(t, tc.do(http.MethodPost, path+"/restore", nil), http.StatusNotFound, ProblemTypeNotFound)
+}
+
+// getHistory reads the history of a task and fails the test unless it is found.
+func (tc *testCaller) getHistory(taskUUID uuid.UUID) []model.TaskEventPublicDTO {
+	tc.t.Helper()
+
+	rec := tc.do(http.MethodGet, "/task/"+taskUUID.String()+"/history", nil)
+	if rec.Code != http.StatusOK {
+		tc.t.Fatalf("GET /task/%s/history = %d %s", taskUUID, rec.Code, rec.Body)
+	}
+	return decodeBody[model.TaskHistoryPublicDTO](tc.t, rec).Events
+}
+
+func TestGetHistory(t *testing.T) {
+	tc := newTestCaller(t)
+	task := tc.createTask(map[string]any{"description": "buy milk"})
+	path := "/task/" + task.UUID.String()
+
+	editor := newTestCaller(t)
+	if rec := tc.do(http.MethodPut, path+"/grants/"+editor.principal, map[string]any{"role": "editor"}); rec.Code != http.StatusOK {
+		t.Fatalf("PUT %s/grants/%s = %d %s", path, editor.principal, rec.Code, rec.Body)
+	}
+	if rec := editor.mergePatch(task.UUID, map[string]any{"status": "done"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH %s = %d %s", path, rec.Code, rec.Body)
+	}
+	if rec := tc.do(http.MethodDelete, path, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE %s = %d %s", path, rec.Code, rec.Body)
+	}
+	if rec := tc.do(http.MethodPost, path+"/restore", nil); rec.Code != http.StatusOK {
+		t.Fatalf("POST %s/restore = %d %s", path, rec.Code, rec.Body)
+	}
+
+	events := tc.getHistory(task.UUID)
+	want := []struct {
+		eventType model.TaskEventType
+		actor     string
+	}{
+		{eventType: model.TaskEventCreated, actor: tc.principal},
+		{eventType: model.TaskEventUpdated, actor: editor.principal},
+		{eventType: model.TaskEventDeleted, actor: tc.principal},
+		{eventType: model.TaskEventRestored, actor: tc.principal},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("GET %s/history = %d events, want %d", path, len(events), len(want))
+	}
+	for i, event := range events {
+		if event.TaskUUID != task.UUID || event.Version != int64(i+1) || event.Type != want[i].eventType || event.Actor != want[i].actor {
+			t.Errorf("event %d = %s of version %d by %s, want %s of version %d by %s", i, event.Type, event.Version, event.Actor, want[i].eventType, i+1, want[i].actor)
+		}
+		if i > 0 && event.OccurredAt.Before(events[i-1].OccurredAt) {
+			t.Errorf("event %d occurred at %v, before the previous one", i, event.OccurredAt)
+		}
+	}
+
+	// The update records the old and new values of the changed fields only.
+	changes := events[1].Changes
+	if len(changes) != 1 || changes[0].Field != "status" || *changes[0].Old != "todo" || *changes[0].New != "done" {
+		t.Errorf("changes of the update = %+v, want the status from todo to done", changes)
+	}
+	if changes := events[2].Changes; len(changes) != 1 || changes[0].Field != "deleted_at" || changes[0].Old != nil || changes[0].New == nil {
+		t.Errorf("changes of the deletion = %+v, want the deleted_at set", changes)
+	}
+
+	// The history is visible like the task.
+	if got := editor.getHistory(task.UUID); len(got) != len(want) {
+		t.Errorf("GET %s/history by the editor = %d events, want %d", path, len(got), len(want))
+	}
+	expectProblem(t, newTestCaller(t).do(http.MethodGet, path+"/history", nil), http.StatusNotFound, ProblemTypeNotFound)
+	expectProblem(t, tc.do(http.MethodGet, "/task/"+uuid.NewString()+"/history", nil), http.StatusNotFound, ProblemTypeNotFound)
+}
+
+func TestOwnerScoping(t *testing.T) {
+	owner := newTestCaller(t)
+	task := owner.createTask(map[string]any{"description": "scoped task"})
+	if task.OwnerID != owner.principal {
+		t.Fatalf("created task owner = %q, want %q", task.OwnerID, owner.principal)
+	}
+	path := "/task/" + task.UUID.String()
+
+	// The tasks of another principal are never found, whatever the route.
+	other := newTestCaller(t)
+	other.createTask(map[string]any{"description": "scoped task of the other"})
+	for _, request := range []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{method: http.MethodGet, path: path},
+		{method: http.MethodPut, path: path, body: map[string]any{"description": "stolen", "status": "todo"}},
+		{method: http.MethodDelete, path: path},
+		{method: http.MethodPost, path: path + "/restore"},
+		{method: http.MethodGet, path: path + "/history"},
+		{method: http.MethodGet, path: path + "/grants"},
+		{method: http.MethodGet, path: path + "/children"},
+	} {
+		expectProblem(t, other.do(request.method, request.path, request.body), http.StatusNotFound, ProblemTypeNotFound)
+	}
+	expectProblem(t, other.mergePatch(task.UUID, map[string]any{"description": "stolen"}), http.StatusNotFound, ProblemTypeNotFound)
+	if got := taskUUIDs(other.getTasks(url.Values{"q": {"scoped"}}).Tasks); len(got) != 1 || got[0] == task.UUID {
+		t.Errorf("GET /tasks by another principal = %v, want only its own task", got)
+	}
+	rec := other.do(http.MethodGet, "/tasks/search?q=scoped", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /tasks/search = %d %s", rec.Code, rec.Body)
+	}
+	if results := decodeBody[model.TaskSearchPublicDTO](t, rec).Results; len(results) != 1 || results[0].Task.UUID == task.UUID {
+		t.Errorf("GET /tasks/search by another principal = %+v, want only its own task", results)
+	}
+	if got := owner.getTask(task.UUID); got.WhatToDo != "scoped task" || got.Version != 1 {
+		t.Errorf("task = %q version %d, want unchanged", got.WhatToDo, got.Version)
+	}
+
+	// An admin sees and changes the tasks of every owner, the owner is kept.
+	admin := newTestCaller(t)
+	admin.roles = "ops, " + DefaultAdminRole
+	if got := admin.getTask(task.UUID); got.OwnerID != owner.principal {
+		t.Errorf("task read by an admin = %+v, want the task of %s", got, owner.principal)
+	}
+	if got := taskUUIDs(admin.getTasks(url.Values{"q": {"scoped"}, "limit": {"500"}}).Tasks); len(got) < 2 {
+		t.Errorf("GET /tasks by an admin = %v, want the tasks of both principals", got)
+	}
+	if rec := admin.mergePatch(task.UUID, map[string]any{"status": "done"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH by an admin = %d %s", rec.Code, rec.Body)
+	}
+	if got := owner.getTask(task.UUID); got.Status != "done" || got.OwnerID != owner.principal {
+		t.Errorf("task updated by an admin = %s owned by %s, want done owned by %s", got.Status, got.OwnerID, owner.principal)
+	}
+	if history := owner.getHistory(task.UUID); history[len(history)-1].Actor != admin.principal {
+		t.Errorf("last event by %s, want the admin %s", history[len(history)-1].Actor, admin.principal)
+	}
+
+	// The admin role is matched as a whole.
+	notAdmin := newTestCaller(t)
+	notAdmin.roles = DefaultAdminRole + "s"
+	expectProblem(t, notAdmin.do(http.MethodGet, path, nil), http.StatusNotFound, ProblemTypeNotFound)
+}
+
+func TestRecurringTask(t *testing.T) {
+	tc := newTestCaller(t)
+
+	for _, rule := range []string{"FREQ=SECONDLY", "FREQ=MINUTELY;INTERVAL=30", "FREQ=HOURLY", "FREQ=DAILY;DTSTART=20240101T000000Z"} {
+		rec := tc.do(http.MethodPost, "/task", map[string]any{"description": "check the mail", "rrule": rule})
+		expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	}
+
+	due := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second)
+	first := tc.createTask(map[string]any{"description": "water the plants", "due_at": due, "rrule": "rrule:freq=daily;count=2"})
+	if first.RRule != "FREQ=DAILY;COUNT=2" || first.SeriesUUID == nil || *first.SeriesUUID != first.UUID {
+		t.Fatalf("created task = %q in series %v, want FREQ=DAILY;COUNT=2 in its own series", first.RRule, first.SeriesUUID)
+	}
+	rec := tc.mergePatch(first.UUID, map[string]any{"rrule": "FREQ=MINUTELY"})
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+
+	series := func() []model.TaskPublicDTO {
+		t.Helper()
+		rec := tc.do(http.MethodGet, "/tasks/series/"+first.UUID.String(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /tasks/series = %d %s", rec.Code, rec.Body)
+		}
+		return decodeBody[model.TaskListPublicDTO](t, rec).Tasks
+	}
+
+	// Closing an occurrence spawns the next one with one less COUNT.
+	if rec := tc.mergePatch(first.UUID, map[string]any{"status": "done"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH status done = %d %s", rec.Code, rec.Body)
+	}
+	occurrences := series()
+	if len(occurrences) != 2 {
+		t.Fatalf("series = %d tasks, want 2", len(occurrences))
+	}
+	var next model.TaskPublicDTO
+	for _, occurrence := range occurrences {
+		if occurrence.UUID != first.UUID {
+			next = occurrence
+		}
+	}
+	if next.Status != "todo" || next.RRule != "FREQ=DAILY;COUNT=1" || next.DueAt == nil || !next.DueAt.Equal(due.AddDate(0, 0, 1)) {
+		t.Errorf("next occurrence = %s %q due %v, want todo FREQ=DAILY;COUNT=1 due %v", next.Status, next.RRule, next.DueAt, due.AddDate(0, 0, 1))
+	}
+	if got := tc.getTask(first.UUID); got.RRule != "" {
+		t.Errorf("closed occurrence rule = %q, want it moved to the next one", got.RRule)
+	}
+
+	// The COUNT is exhausted by the last occurrence.
+	if rec := tc.mergePatch(next.UUID, map[string]any{"status": "done"}); rec.Code != http.StatusNoContent {
+		t.Fatalf("PATCH status done = %d %s", rec.Code, rec.Body)
+	}
+	if occurrences := series(); len(occurrences) != 2 {
+		t.Errorf("series after the last occurrence = %d tasks, want 2", len(occurrences))
+	}
+}
+
+func TestDeleteCascade(t *testing.T) {
+	tc := newTestCaller(t)
+
+	// root has the subtasks a, with a1, and b.
+	root := tc.createTask(map[string]any{"description": "move out"})
+	a := tc.createTask(map[string]any{"description": "pack", "parent_uuid": root.UUID})
+	a1 := tc.createTask(map[string]any{"description": "buy boxes", "parent_uuid": a.UUID, "status": "done"})
+	b := tc.createTask(map[string]any{"description": "clean", "parent_uuid": root.UUID})
+	path := func(task *model.TaskPublicDTO) string { return "/task/" + task.UUID.String() }
+	expectStatus := func(task *model.TaskPublicDTO, status int) {
+		t.Helper()
+		if rec := tc.do(http.MethodGet, path(task), nil); rec.Code != status {
+			t.Errorf("GET %s = %d, want %d", task.WhatToDo, rec.Code, status)
+		}
+	}
+
+	rec := tc.do(http.MethodGet, path(root)+"/subtree", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET subtree = %d %s", rec.Code, rec.Body)
+	}
+	if node := decodeBody[model.TaskNodePublicDTO](t, rec); node.Completion != 50 || len(node.Children) != 2 {
+		t.Errorf("subtree = completion %d with %d children, want 50 with 2", node.Completion, len(node.Children))
+	}
+
+	// The restrict mode, the default, refuses to delete a task with subtasks.
+	rec = tc.do(http.MethodDelete, path(root), nil)
+	expectProblem(t, rec, http.StatusConflict, ProblemTypeConflict)
+	rec = tc.do(http.MethodDelete, path(root)+"?cascade=restrict", nil)
+	expectProblem(t, rec, http.StatusConflict, ProblemTypeConflict)
+	rec = tc.do(http.MethodDelete, path(root)+"?cascade=orphan", nil)
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	expectStatus(root, http.StatusOK)
+
+	// The delete mode trashes the subtree, restoring the task brings it back.
+	if rec := tc.do(http.MethodDelete, path(root)+"?cascade=delete", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE ?cascade=delete = %d %s", rec.Code, rec.Body)
+	}
+	for _, task := range []*model.TaskPublicDTO{root, a, a1, b} {
+		expectStatus(task, http.StatusNotFound)
+	}
+	if rec := tc.do(http.MethodPost, path(root)+"/restore", nil); rec.Code != http.StatusOK {
+		t.Fatalf("POST restore = %d %s", rec.Code, rec.Body)
+	}
+	for _, task := range []*model.TaskPublicDTO{root, a, a1, b} {
+		expectStatus(task, http.StatusOK)
+	}
+	if got := tc.getTask(a1.UUID); got.ParentUUID == nil || *got.ParentUUID != a.UUID {
+		t.Errorf("restored a1 parent = %v, want a", got.ParentUUID)
+	}
+
+	// The detach mode trashes the task alone and makes its children top-level tasks.
+	if rec := tc.do(http.MethodDelete, path(a)+"?cascade=detach", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE ?cascade=detach = %d %s", rec.Code, rec.Body)
+	}
+	expectStatus(a, http.StatusNotFound)
+	if got := tc.getTask(a1.UUID); got.ParentUUID != nil {
+		t.Errorf("detached a1 parent = %v, want none", got.ParentUUID)
+	}
+	rec = tc.do(http.MethodGet, path(root)+"/subtree", nil)
+	if node := decodeBody[model.TaskNodePublicDTO](t, rec); node.Completion != 0 || len(node.Children) != 1 {
+		t.Errorf("subtree after the detach = completion %d with %d children, want 0 with 1", node.Completion, len(node.Children))
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tc := newTestCaller(t)
+
+	a := tc.createTask(map[string]any{"description": "pack"})
+	b := tc.createTask(map[string]any{"description": "clean"})
+	batch := func(mode string, operations ...map[string]any) *httptest.ResponseRecorder {
+		t.Helper()
+		return tc.do(http.MethodPost, "/tasks:batch", map[string]any{"mode": mode, "operations": operations})
+	}
+	// versions returns the versions of the tasks of the caller by description.
+	versions := func() map[string]int64 {
+		t.Helper()
+		versions := map[string]int64{}
+		for _, task := range tc.getTasks(url.Values{}).Tasks {
+			versions[task.WhatToDo] = task.Version
+		}
+		return versions
+	}
+	expectTasks := func(want map[string]int64) {
+		t.Helper()
+		if got := versions(); !reflect.DeepEqual(got, want) {
+			t.Errorf("tasks = %v, want %v", got, want)
+		}
+	}
+
+	// The first failing operation of an all-or-nothing batch answers its problem and no task is changed.
+	rec := batch("",
+		map[string]any{"op": "create", "task": map[string]any{"description": "label the boxes"}},
+		map[string]any{"op": "update", "task_uuid": a.UUID, "version": 1, "task": map[string]any{"status": "done"}},
+		map[string]any{"op": "delete", "task_uuid": b.UUID, "version": 7},
+	)
+	problem := expectProblem(t, rec, http.StatusPreconditionFailed, ProblemTypePreconditionFailed)
+	if !strings.HasPrefix(problem.Detail, "operation 2: ") {
+		t.Errorf("detail = %q, want the operation 2 named", problem.Detail)
+	}
+	expectTasks(map[string]int64{"pack": 1, "clean": 1})
+
+	// A malformed operation rejects the whole batch, whatever its mode.
+	rec = batch("best_effort",
+		map[string]any{"op": "create", "task": map[string]any{"description": "label the boxes"}},
+		map[string]any{"op": "update", "task_uuid": a.UUID, "task": map[string]any{}},
+	)
+	problem = expectProblem(t, rec, http.StatusBadRequest, ProblemTypeBadRequest)
+	if !strings.HasPrefix(problem.Detail, "operation 1: ") {
+		t.Errorf("detail = %q, want the operation 1 named", problem.Detail)
+	}
+	rec = batch("all_at_once", map[string]any{"op": "create", "task": map[string]any{"description": "label the boxes"}})
+	expectProblem(t, rec, http.StatusUnprocessableEntity, ProblemTypeUnprocessable)
+	expectTasks(map[string]int64{"pack": 1, "clean": 1})
+
+	// A best-effort batch answers the status of each operation and keeps the successful ones.
+	rec = batch("best_effort",
+		map[string]any{"op": "update", "task_uuid": a.UUID, "version": 1, "task": map[string]any{"status": "done"}},
+		map[string]any{"op": "create", "task": map[string]any{"description": "label the boxes"}},
+		map[string]any{"op": "delete", "task_uuid": b.UUID, "version": 7},
+		map[string]any{"op": "delete", "task_uuid": uuid.New()},
+	)
+	if rec.Code != http.StatusMultiStatus {
+		t.Fatalf("POST /tasks:batch best_effort = %d %s", rec.Code, rec.Body)
+	}
+	results := decodeBody[model.TaskBatchPublicDTO](t, rec).Results
+	if len(results) != 4 {
+		t.Fatalf("results = %+v, want 4", results)
+	}
+	wantStatuses := []int{http.StatusOK, http.StatusCreated, http.StatusPreconditionFailed, http.StatusNotFound}
+	for i, result := range results {
+		if result.Index != i || result.Status != wantStatuses[i] {
+			t.Errorf("result %d = %+v, want status %d", i, result, wantStatuses[i])
+		}
+	}
+	if results[0].Task == nil || results[0].Task.Status != "done" || results[1].Task == nil || results[1].Task.WhatToDo != "label the boxes" {
+		t.Errorf("results = %+v %+v, want pack done and label the boxes created", results[0], results[1])
+	}
+	if results[2].Type != ProblemTypePreconditionFailed || results[2].Task != nil {
+		t.Errorf("result 2 = %+v, want a precondition failed problem", results[2])
+	}
+	expectTasks(map[string]int64{"pack": 2, "clean": 1, "label the boxes": 1})
+
+	// An all-or-nothing batch without failure runs every operation.
+	rec = batch("all_or_nothing",
+		map[string]any{"op": "delete", "task_uuid": b.UUID, "version": 1},
+		map[string]any{"op": "update", "task_uuid": a.UUID, "version": 2, "task": map[string]any{"description": "pack the books"}},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /tasks:batch all_or_nothing = %d %s", rec.Code, rec.Body)
+	}
+	if results := decodeBody[model.TaskBatchPublicDTO](t, rec).Results; len(results) != 2 || results[0].Status != http.StatusNoContent || results[1].Task == nil || results[1].Task.Version != 3 {
+		t.Errorf("results = %+v, want clean deleted and pack the books version 3", results)
+	}
+	expectTasks(map[string]int64{"pack the books": 3, "label the boxes": 1})
+
+	// The route only runs the batch action.
+	rec = tc.do(http.MethodPost, "/tasks:purge", map[string]any{})
+	expectProblem(t, rec, http.StatusNotFound, ProblemTypeNotFound)
+}
@@ -0,0 +1,40 @@
+package ginrouters
+
+import (
+	"net/http"
+	"strings"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/gin-gonic/gin"
+)
+
+// TrustedHeadersConf names the headers carrying the caller of the requests authenticated by a gateway in front of the API.
+// The API must only be reachable through the gateway, which must remove these headers from the client requests.
+type TrustedHeadersConf struct {
+	// Principal is the header holding the id of the caller, like X-User-ID.
+	Principal string `mapstructure:"principal"`
+	// Roles is the header holding the comma separated roles of the caller, optional.
+	Roles string `mapstructure:"roles"`
+}
+
+// Middleware rejects the requests without principal header with a 401,
+// and puts the principal of the others in the context of the request.
+func (conf TrustedHeadersConf) Middleware(c *gin.Context) {
+	id := strings.TrimSpace(c.GetHeader(conf.Principal))
+	if id == "" {
+		AbortWithProblem(c, http.StatusUnauthorized, ProblemTypeUnauthorized, "missing "+conf.Principal+" header")
+		return
+	}
+
+	principal := &model.Principal{ID: id}
+	if conf.Roles != "" {
+		for _, role := range strings.Split(c.GetHeader(conf.Roles), ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				principal.Roles = append(principal.Roles, role)
+			}
+		}
+	}
+
+	setPrincipal(c, principal)
+	c.Next()
+}
@@ -0,0 +1,197 @@
+package ginrouters
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/CamilleLange/todolist/internal/controllers"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	ginparamsmapper "gitlab.com/Zandraz/gin-params-mapper"
+	"go.uber.org/zap"
+)
+
+var (
+	onceInitWebhookRouter sync.Once
+	// singletonWebhookRouter is a singleton instance of WebhookRouter.
+	singletonWebhookRouter *WebhookRouter
+)
+
+// WebhookRouter groups a set of handlers to manage entrypoints of Webhook and of their deliveries.
+type WebhookRouter struct {
+	ctlWebhook controllers.IWebhookController
+}
+
+func (r *WebhookRouter) Post(c *gin.Context) {
+	webhook := new(model.WebhookCreateDTO)
+	if err := c.ShouldBindJSON(webhook); err != nil {
+		log.Error("WebhookRouter.Post fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	createdWebhook, err := r.ctlWebhook.Create(c, webhook)
+	if err != nil {
+		log.Error("WebhookRouter.Post fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdWebhook)
+}
+
+func (r *WebhookRouter) GetAll(c *gin.Context) {
+	webhooks, err := r.ctlWebhook.GetAll(c)
+	if err != nil {
+		log.Error("WebhookRouter.GetAll fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (r *WebhookRouter) Get(c *gin.Context) {
+	webhookUUID, ok := webhookUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := r.ctlWebhook.Get(c, webhookUUID)
+	if err != nil {
+		log.Error("WebhookRouter.Get fail",
+			zap.Any("webhook_uuid", webhookUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (r *WebhookRouter) Put(c *gin.Context) {
+	webhookUUID, ok := webhookUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	webhookUpdateDTO := new(model.WebhookUpdateDTO)
+	if err := c.ShouldBindJSON(webhookUpdateDTO); err != nil {
+		log.Error("WebhookRouter.Put fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+
+	if err := r.ctlWebhook.Update(c, webhookUUID, webhookUpdateDTO.ReversePatch()); err != nil {
+		log.Error("WebhookRouter.Put fail", zap.Error(err))
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Webhook updated.")
+}
+
+func (r *WebhookRouter) Delete(c *gin.Context) {
+	webhookUUID, ok := webhookUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.ctlWebhook.Delete(c, webhookUUID); err != nil {
+		log.Error("WebhookRouter.Delete fail",
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, "Webhook deleted.")
+}
+
+// GetDeliveries lists the delivery log of the webhook, ?status=dead lists its dead letters.
+func (r *WebhookRouter) GetDeliveries(c *gin.Context) {
+	webhookUUID, ok := webhookUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	filter := new(model.WebhookDeliveryFilterDTO)
+	if err := c.ShouldBindQuery(filter); err != nil {
+		log.Error("WebhookRouter.GetDeliveries fail", zap.Error(err))
+		AbortWithBindingError(c, err)
+		return
+	}
+	filter.SetDefaults()
+
+	deliveries, err := r.ctlWebhook.GetDeliveries(c, webhookUUID, filter)
+	if err != nil {
+		log.Error("WebhookRouter.GetDeliveries fail",
+			zap.Any("webhook_uuid", webhookUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// RetryDelivery queues a dead letter, or a delivered delivery, again.
+func (r *WebhookRouter) RetryDelivery(c *gin.Context) {
+	webhookUUID, ok := webhookUUIDParam(c)
+	if !ok {
+		return
+	}
+
+	var deliveryUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("delivery_uuid", c, &deliveryUUID); err != nil {
+		log.Error("WebhookRouter.RetryDelivery fail", zap.String("delivery_uuid", c.Param("delivery_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid delivery_uuid")
+		return
+	}
+
+	delivery, err := r.ctlWebhook.RetryDelivery(c, webhookUUID, deliveryUUID)
+	if err != nil {
+		log.Error("WebhookRouter.RetryDelivery fail",
+			zap.Any("webhook_uuid", webhookUUID),
+			zap.Any("delivery_uuid", deliveryUUID),
+			zap.Error(err),
+		)
+		AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// webhookUUIDParam reads the webhook_uuid path parameter, it aborts the request with a 400 if it isn't an UUID.
+func webhookUUIDParam(c *gin.Context) (uuid.UUID, bool) {
+	var webhookUUID uuid.UUID
+	if err := ginparamsmapper.GetPathParamFromContext("webhook_uuid", c, &webhookUUID); err != nil {
+		log.Error("WebhookRouter fail", zap.String("webhook_uuid", c.Param("webhook_uuid")), zap.Error(err))
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid webhook_uuid")
+		return uuid.Nil, false
+	}
+	return webhookUUID, true
+}
+
+// GetInstanceWebhookRouter get singleton instance of WebhookRouter.
+func GetInstanceWebhookRouter() *WebhookRouter {
+	if singletonWebhookRouter == nil {
+		onceInitWebhookRouter.Do(
+			func() {
+				singletonWebhookRouter = &WebhookRouter{
+					ctlWebhook: controllers.WebhookInstance,
+				}
+			},
+		)
+	}
+
+	return singletonWebhookRouter
+}
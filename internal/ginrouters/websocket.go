@@ -0,0 +1,264 @@
+package ginrouters
+
+import (
+	"bufio"
+	"crypto/sha1" // #nosec G505 -- SHA-1 is mandated by the WebSocket handshake, it doesn't protect anything.
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// websocketGUID is appended to the key of the client to compute the accept key of the handshake (RFC 6455).
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// websocketVersion is the only version of the protocol supported.
+	websocketVersion = "13"
+	// websocketMaxPayload is the biggest frame accepted from a client, the API doesn't expect messages from it.
+	websocketMaxPayload = 4096
+	// websocketWriteTimeout is the time a client has to read a frame before its connection is dropped.
+	websocketWriteTimeout = 10 * time.Second
+	// websocketCloseTimeout is the time a client has to answer the close of the connection.
+	websocketCloseTimeout = 5 * time.Second
+
+	websocketOpContinuation = 0x0
+	websocketOpText         = 0x1
+	websocketOpBinary       = 0x2
+	websocketOpClose        = 0x8
+	websocketOpPing         = 0x9
+	websocketOpPong         = 0xA
+
+	websocketCloseProtocolError = 1002
+	websocketCloseTooBig        = 1009
+	websocketCloseInternalError = 1011
+	websocketCloseTryAgainLater = 1013
+)
+
+var (
+	// errWebSocketProtocol is returned for a frame breaking the protocol.
+	errWebSocketProtocol = errors.New("websocket protocol error")
+	// errWebSocketTooBig is returned for a frame bigger than websocketMaxPayload.
+	errWebSocketTooBig = errors.New("websocket frame too big")
+	// errWebSocketClosed is returned when a frame is written after the close of the connection.
+	errWebSocketClosed = errors.New("websocket closed")
+)
+
+// websocketConn is the server side of a WebSocket (RFC 6455) sending text messages to the client.
+// The data messages of the client are discarded.
+type websocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	// mu serializes the frames sent by the server and the answers to the control frames of the client.
+	mu     sync.Mutex
+	closed bool
+}
+
+// upgradeWebSocket answers the WebSocket handshake of the request and takes over its connection.
+// It returns nil after aborting the request with a problem if the request isn't a valid handshake.
+func upgradeWebSocket(c *gin.Context) *websocketConn {
+	if !headerHasToken(c.Request.Header, "Connection", "upgrade") || !headerHasToken(c.Request.Header, "Upgrade", "websocket") {
+		c.Header("Upgrade", "websocket")
+		AbortWithProblem(c, http.StatusUpgradeRequired, ProblemTypeUpgradeRequired, "the request must upgrade to a websocket")
+		return nil
+	}
+	if c.GetHeader("Sec-WebSocket-Version") != websocketVersion {
+		c.Header("Sec-WebSocket-Version", websocketVersion)
+		AbortWithProblem(c, http.StatusUpgradeRequired, ProblemTypeUpgradeRequired, "the websocket version must be "+websocketVersion)
+		return nil
+	}
+	key := c.GetHeader("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		AbortWithProblem(c, http.StatusBadRequest, ProblemTypeBadRequest, "invalid Sec-WebSocket-Key")
+		return nil
+	}
+
+	// The status is only recorded for the logs, the response is written on the connection.
+	c.Writer.WriteHeader(http.StatusSwitchingProtocols)
+	conn, buffer, err := c.Writer.Hijack()
+	if err != nil {
+		log.Error("fail to hijack the websocket connection", zap.Error(err))
+		AbortWithProblem(c, http.StatusInternalServerError, ProblemTypeInternal, "unexpected error")
+		return nil
+	}
+
+	accept := sha1.Sum([]byte(key + websocketGUID)) // #nosec G401
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) + "\r\n\r\n"
+	_ = conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	if _, err := conn.Write([]byte(response)); err != nil {
+		log.Error("fail to answer the websocket handshake", zap.Error(err))
+		_ = conn.Close()
+		return nil
+	}
+
+	return &websocketConn{conn: conn, reader: buffer.Reader}
+}
+
+// headerHasToken reports whether one of the comma separated values of the header is the token, ignoring case.
+func headerHasToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteJSON sends the value encoded in JSON as a text message.
+func (ws *websocketConn) WriteJSON(value any) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("fail to encode websocket message: %w", err)
+	}
+	return ws.writeFrame(websocketOpText, payload)
+}
+
+// Ping sends a ping, the client answers it with a pong.
+func (ws *websocketConn) Ping() error {
+	return ws.writeFrame(websocketOpPing, nil)
+}
+
+// Close sends a close frame with the code and the reason, then gives the client websocketCloseTimeout to answer it.
+// The connection itself is closed by Release.
+func (ws *websocketConn) Close(code uint16, reason string) error {
+	payload := binary.BigEndian.AppendUint16(nil, code)
+	payload = append(payload, reason...)
+	if err := ws.writeFrame(websocketOpClose, payload); err != nil {
+		return err
+	}
+	return ws.conn.SetReadDeadline(time.Now().Add(websocketCloseTimeout))
+}
+
+// Release closes the connection.
+func (ws *websocketConn) Release() error {
+	return ws.conn.Close()
+}
+
+// ReadLoop reads the frames of the client until the connection ends, answering its pings and its close.
+// It returns io.EOF once the client closed the WebSocket, errWebSocketProtocol or errWebSocketTooBig
+// for an invalid frame, or the error of the connection.
+func (ws *websocketConn) ReadLoop() error {
+	for {
+		opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return err
+		}
+
+		switch opcode {
+		case websocketOpPing:
+			if err := ws.writeFrame(websocketOpPong, payload); err != nil && !errors.Is(err, errWebSocketClosed) {
+				return err
+			}
+		case websocketOpClose:
+			// The close is echoed with the code of the client, unless the server closed the WebSocket first.
+			code := payload[:min(len(payload), 2)]
+			if err := ws.writeFrame(websocketOpClose, code); err != nil && !errors.Is(err, errWebSocketClosed) {
+				return err
+			}
+			return io.EOF
+		case websocketOpContinuation, websocketOpText, websocketOpBinary, websocketOpPong:
+		default:
+			return fmt.Errorf("%w: unknown opcode %d", errWebSocketProtocol, opcode)
+		}
+	}
+}
+
+// readFrame reads a frame of the client and returns its opcode and its unmasked payload.
+func (ws *websocketConn) readFrame() (byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	final := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return 0, nil, fmt.Errorf("%w: reserved bits set", errWebSocketProtocol)
+	}
+	if header[1]&0x80 == 0 {
+		return 0, nil, fmt.Errorf("%w: unmasked frame", errWebSocketProtocol)
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(ws.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if opcode >= websocketOpClose && (!final || length > 125) {
+		return 0, nil, fmt.Errorf("%w: invalid control frame", errWebSocketProtocol)
+	}
+	if length > websocketMaxPayload {
+		return 0, nil, errWebSocketTooBig
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(ws.reader, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// writeFrame sends an unfragmented frame, nothing is sent after a close frame.
+func (ws *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.closed {
+		return errWebSocketClosed
+	}
+
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	frame = append(frame, payload...)
+
+	if err := ws.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout)); err != nil {
+		return err
+	}
+	if _, err := ws.conn.Write(frame); err != nil {
+		return err
+	}
+	if opcode == websocketOpClose {
+		ws.closed = true
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package ginrouters
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+// clientFrame returns a frame of a client masked with a fixed key, first is its first byte: FIN, RSV and opcode.
+func clientFrame(first byte, payload []byte) []byte {
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame := []byte{first}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, 0x80|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, 0x80|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	return frame
+}
+
+// closePayload returns the payload of a close frame.
+func closePayload(code uint16, reason string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, code), reason...)
+}
+
+// readServerFrame reads a frame of the server, which must be final and unmasked, and returns its opcode and payload.
+func readServerFrame(t *testing.T, reader io.Reader) (byte, []byte) {
+	t.Helper()
+
+	var header [2]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		t.Fatalf("fail to read the frame header: %v", err)
+	}
+	if header[0]&0xF0 != 0x80 || header[1]&0x80 != 0 {
+		t.Fatalf("frame header = %08b %08b, want a final unmasked frame", header[0], header[1])
+	}
+
+	length := uint64(header[1])
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			t.Fatalf("fail to read the frame length: %v", err)
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(reader, extended[:]); err != nil {
+			t.Fatalf("fail to read the frame length: %v", err)
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatalf("fail to read the frame payload: %v", err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+// newTestWebSocketConn returns the server side of a WebSocket over a pipe and the client side of the pipe.
+func newTestWebSocketConn(t *testing.T) (*websocketConn, net.Conn) {
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		_ = server.Close()
+		_ = client.Close()
+	})
+	_ = client.SetDeadline(time.Now().Add(5 * time.Second))
+	return &websocketConn{conn: server, reader: bufio.NewReader(server)}, client
+}
+
+func TestWebSocketReadFrame(t *testing.T) {
+	long := bytes.Repeat([]byte("a"), 300)
+	tests := []struct {
+		name    string
+		frame   []byte
+		opcode  byte
+		payload []byte
+		wantErr error
+	}{
+		{name: "text", frame: clientFrame(0x81, []byte("hello")), opcode: websocketOpText, payload: []byte("hello")},
+		{name: "empty", frame: clientFrame(0x82, nil), opcode: websocketOpBinary, payload: []byte{}},
+		{name: "16-bit length", frame: clientFrame(0x81, long), opcode: websocketOpText, payload: long},
+		{name: "64-bit length", frame: append([]byte{0x81, 0x80 | 127, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0}, "abc"...),
+			opcode: websocketOpText, payload: []byte("abc")},
+		{name: "fragment", frame: clientFrame(0x01, []byte("hel")), opcode: websocketOpText, payload: []byte("hel")},
+		{name: "continuation", frame: clientFrame(0x80, []byte("lo")), opcode: websocketOpContinuation, payload: []byte("lo")},
+		{name: "ping", frame: clientFrame(0x89, []byte("ping")), opcode: websocketOpPing, payload: []byte("ping")},
+		{name: "unmasked", frame: []byte{0x81, 0x02, 'h', 'i'}, wantErr: errWebSocketProtocol},
+		{name: "reserved bits", frame: clientFrame(0xC1, []byte("hi")), wantErr: errWebSocketProtocol},
+		{name: "fragmented control frame", frame: clientFrame(0x09, []byte("ping")), wantErr: errWebSocketProtocol},
+		{name: "long control frame", frame: clientFrame(0x89, long[:126]), wantErr: errWebSocketProtocol},
+		{name: "oversized", frame: clientFrame(0x81, bytes.Repeat([]byte("a"), websocketMaxPayload+1)), wantErr: errWebSocketTooBig},
+		{name: "oversized 64-bit length", frame: []byte{0x82, 0x80 | 127, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, wantErr: errWebSocketTooBig},
+		{name: "truncated header", frame: []byte{0x81}, wantErr: io.ErrUnexpectedEOF},
+		{name: "truncated payload", frame: clientFrame(0x81, []byte("hello"))[:8], wantErr: io.ErrUnexpectedEOF},
+		{name: "no frame", frame: nil, wantErr: io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws := &websocketConn{reader: bufio.NewReader(bytes.NewReader(tt.frame))}
+			opcode, payload, err := ws.readFrame()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("readFrame() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || opcode != tt.opcode || !bytes.Equal(payload, tt.payload) {
+				t.Errorf("readFrame() = %d %q, %v, want %d %q", opcode, payload, err, tt.opcode, tt.payload)
+			}
+		})
+	}
+}
+
+func TestWebSocketWriteFrame(t *testing.T) {
+	ws, client := newTestWebSocketConn(t)
+
+	// The frames of the server are unmasked, their length is written on 7, 16 or 64 bits.
+	for _, length := range []int{0, 125, 126, 0xFFFF, 0x10000} {
+		message := strings.Repeat("a", length)
+		errc := make(chan error, 1)
+		go func() { errc <- ws.WriteJSON(message) }()
+
+		opcode, payload := readServerFrame(t, client)
+		if err := <-errc; err != nil {
+			t.Fatalf("WriteJSON() error = %v", err)
+		}
+		var got string
+		if err := json.Unmarshal(payload, &got); opcode != websocketOpText || err != nil || got != message {
+			t.Errorf("frame of %d bytes = opcode %d, %d bytes, %v, want the text message", length, opcode, len(got), err)
+		}
+	}
+
+	// Nothing is sent after the close of the server.
+	errc := make(chan error, 1)
+	go func() { errc <- ws.Close(websocketCloseTryAgainLater, "resume later") }()
+	opcode, payload := readServerFrame(t, client)
+	if err := <-errc; err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if opcode != websocketOpClose || !bytes.Equal(payload, closePayload(websocketCloseTryAgainLater, "resume later")) {
+		t.Errorf("close frame = opcode %d %q, want the code and the reason", opcode, payload)
+	}
+	if err := ws.WriteJSON("late"); !errors.Is(err, errWebSocketClosed) {
+		t.Errorf("WriteJSON() after the close error = %v, want errWebSocketClosed", err)
+	}
+	if err := ws.Ping(); !errors.Is(err, errWebSocketClosed) {
+		t.Errorf("Ping() after the close error = %v, want errWebSocketClosed", err)
+	}
+}
+
+func TestWebSocketReadLoop(t *testing.T) {
+	ws, client := newTestWebSocketConn(t)
+	readDone := make(chan error, 1)
+	go func() { readDone <- ws.ReadLoop() }()
+	send := func(frame []byte) {
+		t.Helper()
+		if _, err := client.Write(frame); err != nil {
+			t.Fatalf("fail to send the frame: %v", err)
+		}
+	}
+
+	// The messages of the client, even fragmented, and its pongs are discarded, its pings are answered.
+	send(clientFrame(0x01, []byte("hel")))
+	send(clientFrame(0x89, []byte("between the fragments")))
+	if opcode, payload := readServerFrame(t, client); opcode != websocketOpPong || string(payload) != "between the fragments" {
+		t.Errorf("answer to the ping = opcode %d %q, want a pong with its payload", opcode, payload)
+	}
+	send(clientFrame(0x80, []byte("lo")))
+	send(clientFrame(0x82, []byte{0, 1, 2}))
+	send(clientFrame(0x8A, nil))
+
+	// The close of the client is echoed with its code and ends the loop.
+	send(clientFrame(0x88, closePayload(1000, "bye")))
+	if opcode, payload := readServerFrame(t, client); opcode != websocketOpClose || !bytes.Equal(payload, closePayload(1000, "")) {
+		t.Errorf("answer to the close = opcode %d %q, want a close with its code", opcode, payload)
+	}
+	if err := <-readDone; !errors.Is(err, io.EOF) {
+		t.Errorf("ReadLoop() error = %v, want io.EOF", err)
+	}
+	if err := ws.WriteJSON("late"); !errors.Is(err, errWebSocketClosed) {
+		t.Errorf("WriteJSON() after the close error = %v, want errWebSocketClosed", err)
+	}
+}
+
+func TestWebSocketReadLoopErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   []byte
+		wantErr error
+	}{
+		{name: "unknown opcode", frame: clientFrame(0x83, nil), wantErr: errWebSocketProtocol},
+		{name: "unmasked", frame: []byte{0x81, 0x00}, wantErr: errWebSocketProtocol},
+		{name: "oversized", frame: []byte{0x81, 0x80 | 126, 0xFF, 0xFF}, wantErr: errWebSocketTooBig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws, client := newTestWebSocketConn(t)
+			readDone := make(chan error, 1)
+			go func() { readDone <- ws.ReadLoop() }()
+
+			if _, err := client.Write(tt.frame); err != nil {
+				t.Fatalf("fail to send the frame: %v", err)
+			}
+			if err := <-readDone; !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadLoop() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// The close of the client answering the close of the server ends the loop without an echo.
+	ws, client := newTestWebSocketConn(t)
+	go func() { _ = ws.Close(websocketCloseInternalError, "unexpected error") }()
+	readServerFrame(t, client)
+	readDone := make(chan error, 1)
+	go func() { readDone <- ws.ReadLoop() }()
+	if _, err := client.Write(clientFrame(0x88, closePayload(websocketCloseInternalError, ""))); err != nil {
+		t.Fatalf("fail to send the frame: %v", err)
+	}
+	if err := <-readDone; !errors.Is(err, io.EOF) {
+		t.Errorf("ReadLoop() error = %v, want io.EOF", err)
+	}
+}
+
+// dialTestWebSocket opens a WebSocket on the path of the server as the caller and returns its connection.
+func (tc *testCaller) dialTestWebSocket(server *httptest.Server, path string) (net.Conn, *bufio.Reader) {
+	tc.t.Helper()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		tc.t.Fatalf("net.Dial() error = %v", err)
+	}
+	tc.t.Cleanup(func() { _ = conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// The key and the accept key of the handshake are the example of RFC 6455.
+	handshake := "GET " + path + " HTTP/1.1\r\nHost: todolist\r\nConnection: keep-alive, Upgrade\r\nUpgrade: websocket\r\n" +
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" + testHeaderPrincipal + ": " + tc.principal + "\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		tc.t.Fatalf("fail to send the handshake: %v", err)
+	}
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		tc.t.Fatalf("fail to read the handshake answer: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		tc.t.Fatalf("handshake answer = %d %v, want 101 with the accept key", resp.StatusCode, resp.Header)
+	}
+	return conn, reader
+}
+
+func TestWebSocket(t *testing.T) {
+	tc := newTestCaller(t)
+	server := httptest.NewServer(Router)
+	defer server.Close()
+
+	// The requests that aren't a valid handshake are refused.
+	rec := tc.do(http.MethodGet, "/ws", nil)
+	expectProblem(t, rec, http.StatusUpgradeRequired, ProblemTypeUpgradeRequired)
+	if upgrade := rec.Header().Get("Upgrade"); upgrade != "websocket" {
+		t.Errorf("Upgrade = %q, want websocket", upgrade)
+	}
+	upgrade := []string{"Connection", "Upgrade", "Upgrade", "websocket"}
+	rec = tc.do(http.MethodGet, "/ws", nil, append(upgrade, "Sec-WebSocket-Version", "8")...)
+	expectProblem(t, rec, http.StatusUpgradeRequired, ProblemTypeUpgradeRequired)
+	if version := rec.Header().Get("Sec-WebSocket-Version"); version != websocketVersion {
+		t.Errorf("Sec-WebSocket-Version = %q, want %s", version, websocketVersion)
+	}
+	rec = tc.do(http.MethodGet, "/ws", nil, append(upgrade, "Sec-WebSocket-Version", "13", "Sec-WebSocket-Key", "short")...)
+	expectProblem(t, rec, http.StatusBadRequest, ProblemTypeBadRequest)
+
+	// The changes are text messages, the client closing the WebSocket ends the feed.
+	conn, reader := tc.dialTestWebSocket(server, "/ws")
+	task := tc.createTask(map[string]any{"description": "water the plants"})
+	opcode, payload := readServerFrame(t, reader)
+	var change model.TaskChangePublicDTO
+	if err := json.Unmarshal(payload, &change); opcode != websocketOpText || err != nil {
+		t.Fatalf("message = opcode %d %s, %v, want a text change", opcode, payload, err)
+	}
+	if change.Type != model.TaskEventCreated || change.Task == nil || change.Task.UUID != task.UUID {
+		t.Errorf("change = %+v, want the creation of the task", change)
+	}
+	if _, err := conn.Write(clientFrame(0x88, closePayload(1000, ""))); err != nil {
+		t.Fatalf("fail to send the close: %v", err)
+	}
+	if opcode, payload := readServerFrame(t, reader); opcode != websocketOpClose || !bytes.Equal(payload, closePayload(1000, "")) {
+		t.Errorf("answer to the close = opcode %d %q, want a close with its code", opcode, payload)
+	}
+	if _, err := reader.ReadByte(); !errors.Is(err, io.EOF) {
+		t.Errorf("read after the close error = %v, want io.EOF", err)
+	}
+
+	// An oversized frame closes the WebSocket with 1009, a protocol error with 1002.
+	frames := map[uint16][]byte{
+		websocketCloseTooBig:        {0x81, 0x80 | 126, 0xFF, 0xFF},
+		websocketCloseProtocolError: {0x81, 0x05, 'h', 'e', 'l', 'l', 'o'},
+	}
+	for code, frame := range frames {
+		conn, reader := tc.dialTestWebSocket(server, "/ws")
+		if _, err := conn.Write(frame); err != nil {
+			t.Fatalf("fail to send the frame: %v", err)
+		}
+		opcode, payload := readServerFrame(t, reader)
+		if opcode != websocketOpClose || len(payload) < 2 || binary.BigEndian.Uint16(payload) != code {
+			t.Errorf("answer to the frame %x = opcode %d %q, want a close with %d", frame, opcode, payload, code)
+		}
+		if _, err := reader.ReadByte(); !errors.Is(err, io.EOF) {
+			t.Errorf("read after the close error = %v, want io.EOF", err)
+		}
+	}
+}
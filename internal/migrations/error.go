@@ -0,0 +1,43 @@
+package migrations
+
+import "fmt"
+
+var (
+	ErrIrreversibleMigration = fmt.Errorf("migration has no down script")
+	ErrMissingUpScript       = fmt.Errorf("migration has no up script")
+	ErrLockNotAcquired       = fmt.Errorf("the migration lock is held by another replica")
+
+	ErrMigration            *MigrationError
+	ErrInvalidMigrationName *InvalidMigrationNameError
+	ErrDuplicateMigration   *DuplicateMigrationError
+)
+
+type MigrationError struct {
+	Version int64
+	Name    string
+	Err     error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migration %d_%s: %v", e.Version, e.Name, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+type InvalidMigrationNameError struct {
+	FileName string
+}
+
+func (e *InvalidMigrationNameError) Error() string {
+	return fmt.Sprintf("invalid migration file name %v, expected <version>_<name>.up.sql or <version>_<name>.down.sql", e.FileName)
+}
+
+type DuplicateMigrationError struct {
+	Version int64
+}
+
+func (e *DuplicateMigrationError) Error() string {
+	return fmt.Sprintf("several migrations use the version %d", e.Version)
+}
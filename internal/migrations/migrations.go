@@ -0,0 +1,482 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Aloe-Corporation/logs"
+	"go.uber.org/zap"
+)
+
+const (
+	// TableName is the name of the table used to track applied migrations.
+	TableName = "schema_migrations"
+
+	// LockName is the name of the advisory lock taken while the migrations run, LockKey its key on Postgres.
+	LockName = "todolist_schema_migrations"
+	LockKey  = 7340178253401782
+	// LockTimeout is the number of seconds MySQL waits for the lock held by another replica.
+	LockTimeout = 600
+
+	suffixUp   = ".up.sql"
+	suffixDown = ".down.sql"
+)
+
+var (
+	log = logs.Get()
+
+	//go:embed postgres/*.sql mysql/*.sql sqlite/*.sql
+	embedded embed.FS
+)
+
+// Dialect describes the SQL flavour spoken by a database and where its migrations are stored.
+type Dialect struct {
+	// Name is also the directory holding the migrations of the dialect.
+	Name string
+	// CreateTableQuery creates the schema_migrations table if it doesn't exist.
+	CreateTableQuery string
+	// Placeholder returns the bind parameter of the n-th argument of a query (1-based).
+	Placeholder func(n int) string
+	// SplitStatements runs the scripts one statement at a time, for drivers that can't execute several statements at once.
+	SplitStatements bool
+	// LockQuery takes the advisory lock serializing the migrations of the replicas sharing the database,
+	// it returns 1 once the lock is held. The database has no such lock if it is empty.
+	LockQuery string
+	// UnlockQuery releases the lock taken by LockQuery on the same connection.
+	UnlockQuery string
+}
+
+// DialectPostgres is the Dialect of Postgres databases.
+var DialectPostgres = Dialect{
+	Name: "postgres",
+	CreateTableQuery: "CREATE TABLE IF NOT EXISTS " + TableName + ` (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	Placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+	// pg_advisory_lock waits until the lock is released, or until the context is cancelled.
+	LockQuery:   "SELECT 1 FROM pg_advisory_lock(" + strconv.Itoa(LockKey) + ");",
+	UnlockQuery: "SELECT pg_advisory_unlock(" + strconv.Itoa(LockKey) + ");",
+}
+
+// DialectMySQL is the Dialect of MySQL and MariaDB databases.
+// Their DDL statements commit implicitly, so a failing script may be partially applied.
+var DialectMySQL = Dialect{
+	Name: "mysql",
+	CreateTableQuery: "CREATE TABLE IF NOT EXISTS " + TableName + ` (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+	);`,
+	Placeholder:     func(int) string { return "?" },
+	SplitStatements: true,
+	// GET_LOCK returns 0 when the lock is still held by another replica after LockTimeout.
+	LockQuery:   "SELECT GET_LOCK('" + LockName + "', " + strconv.Itoa(LockTimeout) + ");",
+	UnlockQuery: "DO RELEASE_LOCK('" + LockName + "');",
+}
+
+// DialectSQLite is the Dialect of SQLite databases.
+// SQLite has no advisory lock: a migration applied meanwhile by another process makes the insertion of its version fail,
+// which rolls its script back.
+var DialectSQLite = Dialect{
+	Name: "sqlite",
+	CreateTableQuery: "CREATE TABLE IF NOT EXISTS " + TableName + ` (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+	Placeholder: func(n int) string { return "?" + strconv.Itoa(n) },
+}
+
+// Migration is a versioned schema change with its up and down scripts.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies and reverts the embedded migrations of a dialect on a database.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// Up applies all pending migrations in version order and returns how many were applied.
+// The replicas sharing the database apply them one at a time.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.migrations {
+		if _, done := applied[migration.Version]; done {
+			continue
+		}
+
+		log.Info("apply migration",
+			zap.String("dialect", m.dialect.Name),
+			zap.Int64("version", migration.Version),
+			zap.String("name", migration.Name),
+		)
+
+		query := fmt.Sprintf("INSERT INTO %s (version, name) VALUES (%s, %s);",
+			TableName, m.dialect.Placeholder(1), m.dialect.Placeholder(2))
+		if err := m.exec(ctx, conn, migration.Up, query, migration.Version, migration.Name); err != nil {
+			return count, &MigrationError{Version: migration.Version, Name: migration.Name, Err: err}
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Down reverts the last steps applied migrations in reverse version order and returns how many were reverted.
+// It waits for the migrations run by the other replicas like Up.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	conn, unlock, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.migrations[i]
+		if _, done := applied[migration.Version]; !done {
+			continue
+		}
+
+		if migration.Down == "" {
+			return count, &MigrationError{Version: migration.Version, Name: migration.Name, Err: ErrIrreversibleMigration}
+		}
+
+		log.Info("revert migration",
+			zap.String("dialect", m.dialect.Name),
+			zap.Int64("version", migration.Version),
+			zap.String("name", migration.Name),
+		)
+
+		query := fmt.Sprintf("DELETE FROM %s WHERE version = %s;", TableName, m.dialect.Placeholder(1))
+		if err := m.exec(ctx, conn, migration.Down, query, migration.Version); err != nil {
+			return count, &MigrationError{Version: migration.Version, Name: migration.Name, Err: err}
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Version returns the highest applied migration version, 0 if none was applied.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+
+	return version, nil
+}
+
+// session is implemented by *sql.DB and *sql.Conn.
+type session interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// lock takes the advisory lock of the dialect and returns the connection holding it, on which the migrations run,
+// and the function releasing it. Without lock, the migrations run on the pool.
+// The applied migrations must be read once the lock is held, another replica may have applied them meanwhile.
+func (m *Migrator) lock(ctx context.Context) (session, func(), error) {
+	if m.dialect.LockQuery == "" {
+		return m.db, func() {}, nil
+	}
+
+	// The lock belongs to the session, it is taken and released on the same connection.
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't get a connection : %w", err)
+	}
+
+	var held sql.NullInt64
+	if err := conn.QueryRowContext(ctx, m.dialect.LockQuery).Scan(&held); err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("can't take the migration lock : %w", err)
+	}
+	if held.Int64 != 1 {
+		_ = conn.Close()
+		return nil, nil, ErrLockNotAcquired
+	}
+
+	return conn, func() {
+		// The lock is released even if the migrations were cancelled.
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), m.dialect.UnlockQuery); err != nil {
+			log.Error("fail to release the migration lock", zap.String("dialect", m.dialect.Name), zap.Error(err))
+			// Drop the connection instead of giving it back to the pool with the lock, the session ends with it.
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}, nil
+}
+
+// applied creates the tracking table if needed and returns the set of applied versions.
+func (m *Migrator) applied(ctx context.Context, s session) (map[int64]struct{}, error) {
+	if _, err := s.ExecContext(ctx, m.dialect.CreateTableQuery); err != nil {
+		return nil, fmt.Errorf("can't create the %s table : %w", TableName, err)
+	}
+
+	rows, err := s.QueryContext(ctx, "SELECT version FROM "+TableName+";")
+	if err != nil {
+		return nil, fmt.Errorf("can't query the applied migrations : %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]struct{})
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		applied[version] = struct{}{}
+	}
+
+	return applied, rows.Err()
+}
+
+// exec runs a migration script and its bookkeeping query in a single transaction.
+func (m *Migrator) exec(ctx context.Context, s session, script, query string, args ...any) error {
+	tx, err := s.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin the transaction : %w", err)
+	}
+
+	statements := []string{script}
+	if m.dialect.SplitStatements {
+		statements = splitStatements(script)
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			if err := tx.Rollback(); err != nil {
+				return fmt.Errorf("can't rollback the tx : %w", err)
+			}
+			return fmt.Errorf("can't execute the script : %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		if err := tx.Rollback(); err != nil {
+			return fmt.Errorf("can't rollback the tx : %w", err)
+		}
+		return fmt.Errorf("can't update the %s table : %w", TableName, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("can't commit the transaction : %w", err)
+	}
+	return nil
+}
+
+// splitStatements splits a script on the semicolons ending its statements. The semicolons of the quoted strings
+// and identifiers and of the comments don't end a statement, and the statements made of comments only are dropped.
+// It follows the MySQL syntax: a backslash escapes the next character of a string and # starts a comment.
+func splitStatements(script string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		// empty is true while the current statement only has spaces and comments.
+		empty = true
+	)
+	endStatement := func() {
+		if !empty {
+			statements = append(statements, strings.TrimSpace(current.String()))
+		}
+		current.Reset()
+		empty = true
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == ';':
+			endStatement()
+			continue
+
+		case c == '\'' || c == '"' || c == '`':
+			end := quoteEnd(script, i)
+			current.WriteString(script[i:end])
+			empty = false
+			i = end - 1
+			continue
+
+		case c == '#' || (c == '-' && strings.HasPrefix(script[i:], "--") && (i+2 == len(script) || isSpace(script[i+2]))):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			current.WriteString(script[i : i+end])
+			i += end - 1
+			continue
+
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i
+			} else {
+				end += 4
+			}
+			// The executable comments of MySQL hold a statement.
+			if strings.HasPrefix(script[i:], "/*!") {
+				empty = false
+			}
+			current.WriteString(script[i : i+end])
+			i += end - 1
+			continue
+		}
+
+		current.WriteByte(c)
+		if !isSpace(c) {
+			empty = false
+		}
+	}
+	endStatement()
+
+	return statements
+}
+
+// quoteEnd returns the index following the closing quote of the string or identifier opened at start,
+// or the length of the script if it isn't closed. A doubled quote doesn't close it, and neither does
+// a quote escaped by a backslash in a string.
+func quoteEnd(script string, start int) int {
+	quote := script[start]
+	for i := start + 1; i < len(script); i++ {
+		switch script[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(script)
+}
+
+// isSpace reports whether c is an ASCII white space.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// NewMigrator builds a Migrator for the embedded migrations of the dialect.
+func NewMigrator(db *sql.DB, dialect Dialect) (*Migrator, error) {
+	migrations, err := Load(embedded, dialect.Name)
+	if err != nil {
+		return nil, fmt.Errorf("fail to load %s migrations: %w", dialect.Name, err)
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads the migrations stored in dir, named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// and returns them sorted by version.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("can't read migrations directory %s : %w", dir, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		var base string
+		var up bool
+		switch {
+		case strings.HasSuffix(fileName, suffixUp):
+			base, up = strings.TrimSuffix(fileName, suffixUp), true
+		case strings.HasSuffix(fileName, suffixDown):
+			base = strings.TrimSuffix(fileName, suffixDown)
+		default:
+			return nil, &InvalidMigrationNameError{FileName: fileName}
+		}
+
+		strVersion, name, found := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(strVersion, 10, 64)
+		if !found || err != nil || version <= 0 {
+			return nil, &InvalidMigrationNameError{FileName: fileName}
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("can't read migration %s : %w", fileName, err)
+		}
+
+		migration, exist := byVersion[version]
+		if !exist {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, &DuplicateMigrationError{Version: version}
+		}
+
+		if up {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, &MigrationError{Version: migration.Version, Name: migration.Name, Err: ErrMissingUpScript}
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,456 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"db/0002_add_column.up.sql":    {Data: []byte("ALTER TABLE t ADD c INT;")},
+		"db/0002_add_column.down.sql":  {Data: []byte("ALTER TABLE t DROP c;")},
+		"db/0001_create_table.up.sql":  {Data: []byte("CREATE TABLE t (id INT);")},
+		"db/0010_irreversible.up.sql":  {Data: []byte("DELETE FROM t;")},
+		"db/README.md":                 {Data: []byte("ignored")},
+		"db/0001_create_table.down.sq": {Data: []byte("ignored")},
+	}
+
+	migrations, err := Load(fsys, "db")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	want := []Migration{
+		{Version: 1, Name: "create_table", Up: "CREATE TABLE t (id INT);"},
+		{Version: 2, Name: "add_column", Up: "ALTER TABLE t ADD c INT;", Down: "ALTER TABLE t DROP c;"},
+		{Version: 10, Name: "irreversible", Up: "DELETE FROM t;"},
+	}
+	if !reflect.DeepEqual(migrations, want) {
+		t.Errorf("Load() = %+v, want %+v", migrations, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  any
+	}{
+		{name: "no suffix", files: []string{"0001_create.sql"}, want: &ErrInvalidMigrationName},
+		{name: "no version", files: []string{"create.up.sql"}, want: &ErrInvalidMigrationName},
+		{name: "no name", files: []string{"0001.up.sql"}, want: &ErrInvalidMigrationName},
+		{name: "version zero", files: []string{"0000_create.up.sql"}, want: &ErrInvalidMigrationName},
+		{name: "duplicate version", files: []string{"0001_create.up.sql", "0001_other.up.sql"}, want: &ErrDuplicateMigration},
+		{name: "down only", files: []string{"0001_create.down.sql"}, want: ErrMissingUpScript},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, file := range tt.files {
+				fsys["db/"+file] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+
+			_, err := Load(fsys, "db")
+			switch want := tt.want.(type) {
+			case error:
+				if !errors.Is(err, want) {
+					t.Errorf("Load() error = %v, want %v", err, want)
+				}
+			default:
+				if err == nil || !errors.As(err, want) {
+					t.Errorf("Load() error = %v, want a %T", err, want)
+				}
+			}
+		})
+	}
+}
+
+// TestLoadEmbedded checks the migrations of each dialect are numbered without gap and can all be reverted.
+func TestLoadEmbedded(t *testing.T) {
+	for _, dialect := range []Dialect{DialectPostgres, DialectMySQL, DialectSQLite} {
+		t.Run(dialect.Name, func(t *testing.T) {
+			migrations, err := Load(embedded, dialect.Name)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if len(migrations) == 0 {
+				t.Fatal("Load() returned no migration")
+			}
+
+			for i, migration := range migrations {
+				if migration.Version != int64(i+1) {
+					t.Errorf("migration %d has the version %d", i+1, migration.Version)
+				}
+				if migration.Down == "" {
+					t.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "statements on several lines",
+			script: "CREATE TABLE t (\n  id INT\n);\nCREATE INDEX i ON t (id);\n",
+			want:   []string{"CREATE TABLE t (\n  id INT\n)", "CREATE INDEX i ON t (id)"},
+		},
+		{
+			name:   "statements on the same line",
+			script: "DELETE FROM a; DELETE FROM b;",
+			want:   []string{"DELETE FROM a", "DELETE FROM b"},
+		},
+		{
+			name:   "last statement without semicolon",
+			script: "DELETE FROM a;\nDELETE FROM b",
+			want:   []string{"DELETE FROM a", "DELETE FROM b"},
+		},
+		{
+			name:   "semicolon in a string",
+			script: "INSERT INTO t VALUES ('a;b');\nINSERT INTO t VALUES ('c');",
+			want:   []string{"INSERT INTO t VALUES ('a;b')", "INSERT INTO t VALUES ('c')"},
+		},
+		{
+			name:   "doubled and escaped quotes in a string",
+			script: `INSERT INTO t VALUES ('it''s;', 'a\';b', "c"";d");`,
+			want:   []string{`INSERT INTO t VALUES ('it''s;', 'a\';b', "c"";d")`},
+		},
+		{
+			name:   "semicolon in an identifier",
+			script: "CREATE TABLE `a;b` (id INT);",
+			want:   []string{"CREATE TABLE `a;b` (id INT)"},
+		},
+		{
+			name:   "semicolon in comments",
+			script: "-- first; comment\nDELETE FROM a; # second; comment\n/* third; comment */ DELETE FROM b;",
+			want:   []string{"-- first; comment\nDELETE FROM a", "# second; comment\n/* third; comment */ DELETE FROM b"},
+		},
+		{
+			name:   "comment only statements are dropped",
+			script: "DELETE FROM a;\n-- trailing comment\n/* another one */\n",
+			want:   []string{"DELETE FROM a"},
+		},
+		{
+			name:   "double dash without space is an operator",
+			script: "SELECT 1--1;",
+			want:   []string{"SELECT 1--1"},
+		},
+		{
+			name:   "executable comment",
+			script: "/*!40101 SET NAMES utf8mb4 */;",
+			want:   []string{"/*!40101 SET NAMES utf8mb4 */"},
+		},
+		{
+			name:   "empty script",
+			script: "\n  \n",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSplitStatementsEmbedded checks the MySQL scripts are split into statements without semicolon.
+func TestSplitStatementsEmbedded(t *testing.T) {
+	migrations, err := Load(embedded, DialectMySQL.Name)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, migration := range migrations {
+		for _, script := range []string{migration.Up, migration.Down} {
+			statements := splitStatements(script)
+			if len(statements) == 0 {
+				t.Errorf("migration %d_%s has an empty script", migration.Version, migration.Name)
+			}
+			for _, statement := range statements {
+				if statement == "" || statement[len(statement)-1] == ';' {
+					t.Errorf("migration %d_%s has the statement %q", migration.Version, migration.Name, statement)
+				}
+			}
+		}
+	}
+}
+
+// openSQLite opens a SQLite database in the temporary directory of the test.
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// TestMigratorRoundTrip applies and reverts the migrations of SQLite, and of Postgres and MySQL on the databases
+// of TEST_POSTGRES_DSN and TEST_MYSQL_DSN when they are set. The migrations of these databases are reverted
+// before and after the test.
+func TestMigratorRoundTrip(t *testing.T) {
+	t.Run(DialectSQLite.Name, func(t *testing.T) {
+		testMigratorRoundTrip(t, openSQLite(t), DialectSQLite)
+	})
+
+	databases := []struct {
+		env     string
+		driver  string
+		dialect Dialect
+	}{
+		{env: "TEST_POSTGRES_DSN", driver: "postgres", dialect: DialectPostgres},
+		{env: "TEST_MYSQL_DSN", driver: "mysql", dialect: DialectMySQL},
+	}
+	for _, database := range databases {
+		t.Run(database.dialect.Name, func(t *testing.T) {
+			dsn := os.Getenv(database.env)
+			if dsn == "" {
+				t.Skipf("%s isn't set", database.env)
+			}
+
+			db, err := sql.Open(database.driver, dsn)
+			if err != nil {
+				t.Fatalf("sql.Open() error = %v", err)
+			}
+			defer db.Close()
+
+			migrator, err := NewMigrator(db, database.dialect)
+			if err != nil {
+				t.Fatalf("NewMigrator() error = %v", err)
+			}
+			revert := func() {
+				if _, err := migrator.Down(context.Background(), len(migrator.migrations)); err != nil {
+					t.Fatalf("Down() error = %v", err)
+				}
+			}
+			revert()
+			defer revert()
+
+			testMigratorRoundTrip(t, db, database.dialect)
+		})
+	}
+}
+
+// testMigratorRoundTrip applies every migration of the dialect on the database without any, reverts them and applies them again.
+func testMigratorRoundTrip(t *testing.T, db *sql.DB, dialect Dialect) {
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(db, dialect)
+	if err != nil {
+		t.Fatalf("NewMigrator() error = %v", err)
+	}
+	last := migrator.migrations[len(migrator.migrations)-1].Version
+
+	assertVersion := func(want int64) {
+		t.Helper()
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			t.Fatalf("Version() error = %v", err)
+		}
+		if version != want {
+			t.Fatalf("Version() = %d, want %d", version, want)
+		}
+	}
+
+	assertVersion(0)
+
+	count, err := migrator.Up(ctx)
+	if err != nil || count != len(migrator.migrations) {
+		t.Fatalf("Up() = %d, %v, want %d", count, err, len(migrator.migrations))
+	}
+	assertVersion(last)
+	if _, err := db.ExecContext(ctx, "SELECT task_uuid, version, deleted_at, owner_id FROM tasks;"); err != nil {
+		t.Fatalf("the tasks table isn't migrated: %v", err)
+	}
+
+	count, err = migrator.Up(ctx)
+	if err != nil || count != 0 {
+		t.Fatalf("Up() again = %d, %v, want 0", count, err)
+	}
+
+	count, err = migrator.Down(ctx, 2)
+	if err != nil || count != 2 {
+		t.Fatalf("Down(2) = %d, %v, want 2", count, err)
+	}
+	assertVersion(last - 2)
+
+	count, err = migrator.Down(ctx, len(migrator.migrations))
+	if err != nil || count != int(last-2) {
+		t.Fatalf("Down(all) = %d, %v, want %d", count, err, last-2)
+	}
+	assertVersion(0)
+	if _, err := db.ExecContext(ctx, "SELECT 1 FROM tasks;"); err == nil {
+		t.Fatal("the tasks table is still there once every migration is reverted")
+	}
+
+	count, err = migrator.Up(ctx)
+	if err != nil || count != len(migrator.migrations) {
+		t.Fatalf("Up() after Down = %d, %v, want %d", count, err, len(migrator.migrations))
+	}
+	assertVersion(last)
+}
+
+func TestMigratorFailingScript(t *testing.T) {
+	ctx := context.Background()
+	migrator := &Migrator{
+		db:      openSQLite(t),
+		dialect: DialectSQLite,
+		migrations: []Migration{
+			{Version: 1, Name: "create", Up: "CREATE TABLE t (id INTEGER);", Down: "DROP TABLE t;"},
+			{Version: 2, Name: "broken", Up: "CREATE TABLE u (id INTEGER); INSERT INTO missing VALUES (1);"},
+		},
+	}
+
+	count, err := migrator.Up(ctx)
+	if count != 1 || !errors.As(err, &ErrMigration) || ErrMigration.Version != 2 {
+		t.Fatalf("Up() = %d, %v, want 1 and a MigrationError of the version 2", count, err)
+	}
+	if version, _ := migrator.Version(ctx); version != 1 {
+		t.Errorf("Version() = %d, want 1", version)
+	}
+	// The script of the failed migration is rolled back with its version.
+	if _, err := migrator.db.ExecContext(ctx, "SELECT 1 FROM u;"); err == nil {
+		t.Error("the table of the failed migration exists")
+	}
+
+	migrator.migrations[1].Up = "CREATE TABLE u (id INTEGER);"
+	if _, err := migrator.Down(ctx, 1); err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if count, err := migrator.Up(ctx); err != nil || count != 2 {
+		t.Fatalf("Up() = %d, %v, want 2", count, err)
+	}
+	if _, err := migrator.Down(ctx, 1); !errors.Is(err, ErrIrreversibleMigration) {
+		t.Errorf("Down() of a migration without down script error = %v, want %v", err, ErrIrreversibleMigration)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	ctx := context.Background()
+	migrations := []Migration{{Version: 1, Name: "create", Up: "CREATE TABLE t (id INTEGER);", Down: "DROP TABLE t;"}}
+
+	t.Run("held", func(t *testing.T) {
+		dialect := DialectSQLite
+		dialect.LockQuery, dialect.UnlockQuery = "SELECT 1;", "SELECT 1;"
+		db := openSQLite(t)
+		// The connection holding the lock must go back to the pool once the migrations ran.
+		db.SetMaxOpenConns(1)
+		migrator := &Migrator{db: db, dialect: dialect, migrations: migrations}
+
+		if count, err := migrator.Up(ctx); err != nil || count != 1 {
+			t.Fatalf("Up() = %d, %v, want 1", count, err)
+		}
+		if count, err := migrator.Down(ctx, 1); err != nil || count != 1 {
+			t.Fatalf("Down() = %d, %v, want 1", count, err)
+		}
+	})
+
+	t.Run("not acquired", func(t *testing.T) {
+		dialect := DialectSQLite
+		dialect.LockQuery, dialect.UnlockQuery = "SELECT 0;", "SELECT 1;"
+		migrator := &Migrator{db: openSQLite(t), dialect: dialect, migrations: migrations}
+
+		if _, err := migrator.Up(ctx); !errors.Is(err, ErrLockNotAcquired) {
+			t.Fatalf("Up() error = %v, want %v", err, ErrLockNotAcquired)
+		}
+		if _, err := migrator.Down(ctx, 1); !errors.Is(err, ErrLockNotAcquired) {
+			t.Fatalf("Down() error = %v, want %v", err, ErrLockNotAcquired)
+		}
+		if version, err := migrator.Version(ctx); err != nil || version != 0 {
+			t.Fatalf("Version() = %d, %v, want 0", version, err)
+		}
+	})
+}
+
+// TestMigratorConcurrentUp runs Up from several replicas at once on the databases of the environment:
+// TEST_POSTGRES_DSN and TEST_MYSQL_DSN, the MySQL DSN must allow multiStatements.
+// Every migration must be applied once. The migrations of the database are reverted before and after the test.
+func TestMigratorConcurrentUp(t *testing.T) {
+	databases := []struct {
+		env     string
+		driver  string
+		dialect Dialect
+	}{
+		{env: "TEST_POSTGRES_DSN", driver: "postgres", dialect: DialectPostgres},
+		{env: "TEST_MYSQL_DSN", driver: "mysql", dialect: DialectMySQL},
+	}
+
+	for _, database := range databases {
+		t.Run(database.dialect.Name, func(t *testing.T) {
+			dsn := os.Getenv(database.env)
+			if dsn == "" {
+				t.Skipf("%s isn't set", database.env)
+			}
+
+			ctx := context.Background()
+			db, err := sql.Open(database.driver, dsn)
+			if err != nil {
+				t.Fatalf("sql.Open() error = %v", err)
+			}
+			defer db.Close()
+
+			migrator, err := NewMigrator(db, database.dialect)
+			if err != nil {
+				t.Fatalf("NewMigrator() error = %v", err)
+			}
+			revert := func() {
+				if _, err := migrator.Down(ctx, len(migrator.migrations)); err != nil {
+					t.Fatalf("Down() error = %v", err)
+				}
+			}
+			revert()
+			defer revert()
+
+			const replicas = 4
+			var wg sync.WaitGroup
+			counts := make([]int, replicas)
+			errs := make([]error, replicas)
+			for i := 0; i < replicas; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					replica, err := NewMigrator(db, database.dialect)
+					if err != nil {
+						errs[i] = err
+						return
+					}
+					counts[i], errs[i] = replica.Up(ctx)
+				}(i)
+			}
+			wg.Wait()
+
+			total := 0
+			for i := range counts {
+				if errs[i] != nil {
+					t.Errorf("replica %d: Up() error = %v", i, errs[i])
+				}
+				total += counts[i]
+			}
+			if total != len(migrator.migrations) {
+				t.Errorf("the replicas applied %d migrations, want %d", total, len(migrator.migrations))
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+-- Texts are compared byte-wise, as in the other DAOs.
+CREATE TABLE IF NOT EXISTS tasks (
+    task_uuid CHAR(36) NOT NULL PRIMARY KEY,
+    description VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL,
+    created_at DATETIME(6) NOT NULL,
+    last_updated DATETIME(6) NOT NULL
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
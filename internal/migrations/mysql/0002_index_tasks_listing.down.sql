@@ -0,0 +1,4 @@
+DROP INDEX tasks_status_idx ON tasks;
+DROP INDEX tasks_description_idx ON tasks;
+DROP INDEX tasks_last_updated_idx ON tasks;
+DROP INDEX tasks_created_at_idx ON tasks;
@@ -0,0 +1,5 @@
+-- Keyset pagination indexes, one per sort field.
+CREATE INDEX tasks_created_at_idx ON tasks (created_at, task_uuid);
+CREATE INDEX tasks_last_updated_idx ON tasks (last_updated, task_uuid);
+CREATE INDEX tasks_description_idx ON tasks (description, task_uuid);
+CREATE INDEX tasks_status_idx ON tasks (status, task_uuid);
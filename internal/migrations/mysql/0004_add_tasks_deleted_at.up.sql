@@ -0,0 +1,5 @@
+-- Soft delete, the deleted tasks stay in the trash until they are restored or purged.
+ALTER TABLE tasks ADD COLUMN deleted_at DATETIME(6) NULL;
+
+-- Purge of the trash.
+CREATE INDEX tasks_deleted_at_idx ON tasks (deleted_at);
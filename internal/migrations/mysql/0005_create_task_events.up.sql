@@ -0,0 +1,11 @@
+-- Append-only history of the tasks, kept after the task is purged.
+CREATE TABLE IF NOT EXISTS task_events (
+    event_uuid CHAR(36) NOT NULL PRIMARY KEY,
+    task_uuid CHAR(36) NOT NULL,
+    version BIGINT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    occurred_at DATETIME(6) NOT NULL,
+    changes JSON NOT NULL,
+    UNIQUE KEY task_events_task_version_key (task_uuid, version)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
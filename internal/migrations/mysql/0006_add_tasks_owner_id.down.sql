@@ -0,0 +1,3 @@
+DROP INDEX tasks_owner_id_idx ON tasks;
+ALTER TABLE task_events DROP COLUMN owner_id;
+ALTER TABLE tasks DROP COLUMN owner_id;
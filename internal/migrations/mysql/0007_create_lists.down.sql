@@ -0,0 +1,4 @@
+ALTER TABLE tasks DROP FOREIGN KEY tasks_list_uuid_fk;
+DROP INDEX tasks_list_uuid_idx ON tasks;
+ALTER TABLE tasks DROP COLUMN list_uuid;
+DROP TABLE IF EXISTS lists;
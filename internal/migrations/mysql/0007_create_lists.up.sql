@@ -0,0 +1,15 @@
+-- Lists (projects) grouping the tasks.
+CREATE TABLE IF NOT EXISTS lists (
+    list_uuid CHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description VARCHAR(1024) NOT NULL DEFAULT '',
+    owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    last_updated DATETIME(6) NOT NULL,
+    KEY lists_owner_id_idx (owner_id, created_at, list_uuid)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- A list can't be deleted while it has tasks.
+ALTER TABLE tasks ADD COLUMN list_uuid CHAR(36) NULL;
+CREATE INDEX tasks_list_uuid_idx ON tasks (list_uuid, created_at, task_uuid);
+ALTER TABLE tasks ADD CONSTRAINT tasks_list_uuid_fk FOREIGN KEY (list_uuid) REFERENCES lists (list_uuid);
@@ -0,0 +1,11 @@
+-- Roles granted on a task to the principals other than its owner.
+CREATE TABLE IF NOT EXISTS task_grants (
+    task_uuid CHAR(36) NOT NULL,
+    principal_id VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    granted_by VARCHAR(255) NOT NULL DEFAULT '',
+    granted_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (task_uuid, principal_id),
+    KEY task_grants_principal_id_idx (principal_id, task_uuid),
+    CONSTRAINT task_grants_task_uuid_fk FOREIGN KEY (task_uuid) REFERENCES tasks (task_uuid) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
@@ -0,0 +1,5 @@
+DROP INDEX tasks_remind_at_idx ON tasks;
+DROP INDEX tasks_due_at_idx ON tasks;
+ALTER TABLE tasks DROP COLUMN reminded_at;
+ALTER TABLE tasks DROP COLUMN remind_at;
+ALTER TABLE tasks DROP COLUMN due_at;
@@ -0,0 +1,9 @@
+-- Optional deadline and reminder of the tasks, reminded_at is set once the reminder fired.
+ALTER TABLE tasks ADD COLUMN due_at DATETIME(6) NULL;
+ALTER TABLE tasks ADD COLUMN remind_at DATETIME(6) NULL;
+ALTER TABLE tasks ADD COLUMN reminded_at DATETIME(6) NULL;
+
+-- Overdue tasks.
+CREATE INDEX tasks_due_at_idx ON tasks (due_at);
+-- Reminders waiting to fire.
+CREATE INDEX tasks_remind_at_idx ON tasks (remind_at);
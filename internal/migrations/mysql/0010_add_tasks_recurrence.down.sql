@@ -0,0 +1,3 @@
+DROP INDEX tasks_series_uuid_idx ON tasks;
+ALTER TABLE tasks DROP COLUMN series_uuid;
+ALTER TABLE tasks DROP COLUMN rrule;
@@ -0,0 +1,6 @@
+-- RFC 5545 recurrence rule of the tasks, and the series grouping the occurrences of a recurring task.
+ALTER TABLE tasks ADD COLUMN rrule VARCHAR(1024) NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN series_uuid CHAR(36) NULL;
+
+-- Occurrences of a series.
+CREATE INDEX tasks_series_uuid_idx ON tasks (series_uuid, created_at, task_uuid);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP FOREIGN KEY tasks_parent_uuid_fk;
+DROP INDEX tasks_parent_uuid_idx ON tasks;
+ALTER TABLE tasks DROP COLUMN parent_uuid;
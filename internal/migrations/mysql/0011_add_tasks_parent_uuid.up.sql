@@ -0,0 +1,4 @@
+-- Parent of the subtasks, the purge of a task detaches its subtasks.
+ALTER TABLE tasks ADD COLUMN parent_uuid CHAR(36) NULL;
+CREATE INDEX tasks_parent_uuid_idx ON tasks (parent_uuid, created_at, task_uuid);
+ALTER TABLE tasks ADD CONSTRAINT tasks_parent_uuid_fk FOREIGN KEY (parent_uuid) REFERENCES tasks (task_uuid) ON DELETE SET NULL;
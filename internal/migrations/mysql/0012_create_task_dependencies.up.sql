@@ -0,0 +1,11 @@
+-- Blocked-by edges between the tasks, the task can't be closed while its blockers are open.
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_uuid CHAR(36) NOT NULL,
+    blocker_uuid CHAR(36) NOT NULL,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    PRIMARY KEY (task_uuid, blocker_uuid),
+    KEY task_dependencies_blocker_uuid_idx (blocker_uuid, task_uuid),
+    CONSTRAINT task_dependencies_task_uuid_fk FOREIGN KEY (task_uuid) REFERENCES tasks (task_uuid) ON DELETE CASCADE,
+    CONSTRAINT task_dependencies_blocker_uuid_fk FOREIGN KEY (blocker_uuid) REFERENCES tasks (task_uuid) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
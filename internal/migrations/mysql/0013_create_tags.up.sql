@@ -0,0 +1,19 @@
+-- Labels categorizing the tasks, the names of the tags of an owner are unique.
+CREATE TABLE IF NOT EXISTS tags (
+    tag_uuid CHAR(36) NOT NULL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    last_updated DATETIME(6) NOT NULL,
+    UNIQUE KEY tags_owner_id_name_idx (owner_id, name)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- The tags put on the tasks.
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_uuid CHAR(36) NOT NULL,
+    tag_uuid CHAR(36) NOT NULL,
+    PRIMARY KEY (task_uuid, tag_uuid),
+    KEY task_tags_tag_uuid_idx (tag_uuid, task_uuid),
+    CONSTRAINT task_tags_task_uuid_fk FOREIGN KEY (task_uuid) REFERENCES tasks (task_uuid) ON DELETE CASCADE,
+    CONSTRAINT task_tags_tag_uuid_fk FOREIGN KEY (tag_uuid) REFERENCES tags (tag_uuid) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
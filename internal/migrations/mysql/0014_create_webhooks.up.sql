@@ -0,0 +1,31 @@
+-- Subscriptions of the receivers to the changes of the tasks, events holds the types separated by commas.
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_uuid CHAR(36) NOT NULL PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    last_updated DATETIME(6) NOT NULL,
+    KEY webhooks_owner_id_idx (owner_id, created_at, webhook_uuid)
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
+
+-- Queue and log of the deliveries, the payload is kept as sent since it is signed: it isn't a JSON column.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_uuid CHAR(36) NOT NULL PRIMARY KEY,
+    webhook_uuid CHAR(36) NOT NULL,
+    event_type VARCHAR(32) NOT NULL,
+    payload MEDIUMTEXT NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME(6) NOT NULL,
+    last_attempt_at DATETIME(6) NULL,
+    last_status_code INT NOT NULL DEFAULT 0,
+    last_error VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at DATETIME(6) NOT NULL,
+    delivered_at DATETIME(6) NULL,
+    KEY webhook_deliveries_queue_idx (status, next_attempt_at),
+    KEY webhook_deliveries_webhook_uuid_idx (webhook_uuid, created_at, delivery_uuid),
+    CONSTRAINT webhook_deliveries_webhook_uuid_fk FOREIGN KEY (webhook_uuid) REFERENCES webhooks (webhook_uuid) ON DELETE CASCADE
+) DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin;
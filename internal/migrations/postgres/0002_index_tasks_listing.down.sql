@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS tasks_description_trgm_idx;
+DROP INDEX IF EXISTS tasks_status_idx;
+DROP INDEX IF EXISTS tasks_description_idx;
+DROP INDEX IF EXISTS tasks_last_updated_idx;
+DROP INDEX IF EXISTS tasks_created_at_idx;
@@ -0,0 +1,10 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Keyset pagination indexes, one per sort field.
+CREATE INDEX IF NOT EXISTS tasks_created_at_idx ON tasks (created_at, task_uuid);
+CREATE INDEX IF NOT EXISTS tasks_last_updated_idx ON tasks (last_updated, task_uuid);
+CREATE INDEX IF NOT EXISTS tasks_description_idx ON tasks ((description COLLATE "C"), task_uuid);
+CREATE INDEX IF NOT EXISTS tasks_status_idx ON tasks ((status COLLATE "C"), task_uuid);
+
+-- Free-text match on description.
+CREATE INDEX IF NOT EXISTS tasks_description_trgm_idx ON tasks USING GIN (description gin_trgm_ops);
@@ -0,0 +1,2 @@
+-- Optimistic concurrency control, the version is incremented by each update.
+ALTER TABLE tasks ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
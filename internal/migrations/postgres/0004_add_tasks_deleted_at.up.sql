@@ -0,0 +1,5 @@
+-- Soft delete, the deleted tasks stay in the trash until they are restored or purged.
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMPTZ NULL;
+
+-- Purge of the trash.
+CREATE INDEX IF NOT EXISTS tasks_deleted_at_idx ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,11 @@
+-- Append-only history of the tasks, kept after the task is purged.
+CREATE TABLE IF NOT EXISTS task_events (
+    event_uuid UUID PRIMARY KEY,
+    task_uuid UUID NOT NULL,
+    version BIGINT NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    changes JSONB NOT NULL,
+    UNIQUE (task_uuid, version)
+);
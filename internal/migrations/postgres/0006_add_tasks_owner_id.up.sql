@@ -0,0 +1,6 @@
+-- Owner of the tasks, empty for the tasks created without principal.
+ALTER TABLE tasks ADD COLUMN owner_id VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE task_events ADD COLUMN owner_id VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Listing of the tasks of an owner.
+CREATE INDEX IF NOT EXISTS tasks_owner_id_idx ON tasks (owner_id, created_at, task_uuid);
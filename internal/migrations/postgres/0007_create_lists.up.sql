@@ -0,0 +1,14 @@
+-- Lists (projects) grouping the tasks.
+CREATE TABLE IF NOT EXISTS lists (
+    list_uuid UUID PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description VARCHAR(1024) NOT NULL DEFAULT '',
+    owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    last_updated TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS lists_owner_id_idx ON lists (owner_id, created_at, list_uuid);
+
+-- A list can't be deleted while it has tasks.
+ALTER TABLE tasks ADD COLUMN list_uuid UUID NULL REFERENCES lists (list_uuid);
+CREATE INDEX IF NOT EXISTS tasks_list_uuid_idx ON tasks (list_uuid, created_at, task_uuid);
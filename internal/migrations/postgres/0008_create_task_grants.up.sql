@@ -0,0 +1,10 @@
+-- Roles granted on a task to the principals other than its owner.
+CREATE TABLE IF NOT EXISTS task_grants (
+    task_uuid UUID NOT NULL REFERENCES tasks (task_uuid) ON DELETE CASCADE,
+    principal_id VARCHAR(255) NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    granted_by VARCHAR(255) NOT NULL DEFAULT '',
+    granted_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (task_uuid, principal_id)
+);
+CREATE INDEX IF NOT EXISTS task_grants_principal_id_idx ON task_grants (principal_id, task_uuid);
@@ -0,0 +1,9 @@
+-- Optional deadline and reminder of the tasks, reminded_at is set once the reminder fired.
+ALTER TABLE tasks ADD COLUMN due_at TIMESTAMPTZ NULL;
+ALTER TABLE tasks ADD COLUMN remind_at TIMESTAMPTZ NULL;
+ALTER TABLE tasks ADD COLUMN reminded_at TIMESTAMPTZ NULL;
+
+-- Overdue tasks.
+CREATE INDEX IF NOT EXISTS tasks_due_at_idx ON tasks (due_at) WHERE due_at IS NOT NULL;
+-- Reminders waiting to fire.
+CREATE INDEX IF NOT EXISTS tasks_remind_at_idx ON tasks (remind_at) WHERE reminded_at IS NULL;
@@ -0,0 +1,17 @@
+-- Labels categorizing the tasks, the names of the tags of an owner are unique.
+CREATE TABLE IF NOT EXISTS tags (
+    tag_uuid UUID PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    last_updated TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS tags_owner_id_name_idx ON tags (owner_id, name);
+
+-- The tags put on the tasks.
+CREATE TABLE IF NOT EXISTS task_tags (
+    task_uuid UUID NOT NULL REFERENCES tasks (task_uuid) ON DELETE CASCADE,
+    tag_uuid UUID NOT NULL REFERENCES tags (tag_uuid) ON DELETE CASCADE,
+    PRIMARY KEY (task_uuid, tag_uuid)
+);
+CREATE INDEX IF NOT EXISTS task_tags_tag_uuid_idx ON task_tags (tag_uuid, task_uuid);
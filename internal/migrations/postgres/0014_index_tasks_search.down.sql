@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_search_french_idx;
+DROP INDEX IF EXISTS tasks_search_english_idx;
+DROP INDEX IF EXISTS tasks_search_simple_idx;
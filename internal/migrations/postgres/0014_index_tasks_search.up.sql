@@ -0,0 +1,4 @@
+-- Full-text search on description, one index per language of the search.
+CREATE INDEX IF NOT EXISTS tasks_search_simple_idx ON tasks USING GIN (to_tsvector('simple', description));
+CREATE INDEX IF NOT EXISTS tasks_search_english_idx ON tasks USING GIN (to_tsvector('english', description));
+CREATE INDEX IF NOT EXISTS tasks_search_french_idx ON tasks USING GIN (to_tsvector('french', description));
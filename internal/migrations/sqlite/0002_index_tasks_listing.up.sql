@@ -0,0 +1,5 @@
+-- Keyset pagination indexes, one per sort field.
+CREATE INDEX IF NOT EXISTS tasks_created_at_idx ON tasks (created_at, task_uuid);
+CREATE INDEX IF NOT EXISTS tasks_last_updated_idx ON tasks (last_updated, task_uuid);
+CREATE INDEX IF NOT EXISTS tasks_description_idx ON tasks (description, task_uuid);
+CREATE INDEX IF NOT EXISTS tasks_status_idx ON tasks (status, task_uuid);
@@ -0,0 +1,11 @@
+-- Append-only history of the tasks, kept after the task is purged.
+CREATE TABLE IF NOT EXISTS task_events (
+    event_uuid TEXT PRIMARY KEY,
+    task_uuid TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    type VARCHAR(32) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    changes TEXT NOT NULL,
+    UNIQUE (task_uuid, version)
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_list_uuid_idx;
+ALTER TABLE tasks DROP COLUMN list_uuid;
+DROP TABLE IF EXISTS lists;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS tasks_series_uuid_idx;
+ALTER TABLE tasks DROP COLUMN series_uuid;
+ALTER TABLE tasks DROP COLUMN rrule;
@@ -0,0 +1,3 @@
+-- Parent of the subtasks, the purge of a task detaches its subtasks.
+ALTER TABLE tasks ADD COLUMN parent_uuid TEXT NULL;
+CREATE INDEX IF NOT EXISTS tasks_parent_uuid_idx ON tasks (parent_uuid, created_at, task_uuid);
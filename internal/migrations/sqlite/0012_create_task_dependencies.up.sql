@@ -0,0 +1,9 @@
+-- Blocked-by edges between the tasks, the task can't be closed while its blockers are open.
+CREATE TABLE IF NOT EXISTS task_dependencies (
+    task_uuid TEXT NOT NULL REFERENCES tasks (task_uuid) ON DELETE CASCADE,
+    blocker_uuid TEXT NOT NULL REFERENCES tasks (task_uuid) ON DELETE CASCADE,
+    created_by VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (task_uuid, blocker_uuid)
+);
+CREATE INDEX IF NOT EXISTS task_dependencies_blocker_uuid_idx ON task_dependencies (blocker_uuid, task_uuid);
@@ -0,0 +1,30 @@
+-- Subscriptions of the receivers to the changes of the tasks, events holds the types separated by commas.
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_uuid TEXT PRIMARY KEY,
+    url VARCHAR(2048) NOT NULL,
+    secret VARCHAR(255) NOT NULL,
+    events VARCHAR(255) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    owner_id VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    last_updated TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS webhooks_owner_id_idx ON webhooks (owner_id, created_at, webhook_uuid);
+
+-- Queue and log of the deliveries, the payload is kept as sent since it is signed.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_uuid TEXT PRIMARY KEY,
+    webhook_uuid TEXT NOT NULL REFERENCES webhooks (webhook_uuid) ON DELETE CASCADE,
+    event_type VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_attempt_at TIMESTAMP NULL,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error VARCHAR(1024) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP NULL
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_queue_idx ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_uuid_idx ON webhook_deliveries (webhook_uuid, created_at, delivery_uuid);
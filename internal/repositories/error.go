@@ -1,6 +1,10 @@
 package repositories
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
 
 var (
 	ErrFeatureNotImplemented = fmt.Errorf("feature not implemented")
@@ -8,6 +12,13 @@ var (
 
 	ErrDAOTypeNotFound *DAOTypeNotFoundError
 	ErrNoDataFound     *NoDataFoundError
+	ErrInvalidCursor   *InvalidCursorError
+	ErrConflict        *ConflictError
+	ErrUnavailable     *UnavailableError
+	ErrVersionMismatch *VersionMismatchError
+	ErrHasSubtasks     *HasSubtasksError
+	ErrDependencyCycle *DependencyCycleError
+	ErrBatchOperation  *BatchOperationError
 )
 
 type DAOTypeNotFoundError struct {
@@ -29,10 +40,82 @@ func (e *NoDataFoundError) Error() string {
 	return "no data found"
 }
 
-type InvalidContextError struct {
-	Key string
+type InvalidCursorError struct {
+	Cursor string
+}
+
+func (e *InvalidCursorError) Error() string {
+	return "invalid cursor " + e.Cursor + " for the requested sort"
+}
+
+// ConflictError is returned when the data can't be written because of the current state of the data source,
+// like a unique constraint violation or a concurrent transaction.
+type ConflictError struct {
+	Err error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflict with the current state of the data : %v", e.Err)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// UnavailableError is returned when the data source can't be reached.
+type UnavailableError struct {
+	Err error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("data source unavailable : %v", e.Err)
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// VersionMismatchError is returned when a task was changed since the version expected by the caller.
+type VersionMismatchError struct {
+	Expected int64
+	Current  int64
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("the task is at version %d, not %d", e.Current, e.Expected)
+}
+
+// HasSubtasksError is returned when a task with live subtasks is deleted without cascade.
+type HasSubtasksError struct {
+	UUID  uuid.UUID
+	Count int
+}
+
+func (e *HasSubtasksError) Error() string {
+	return fmt.Sprintf("the task %v still has %d subtasks, delete it with a cascade", e.UUID, e.Count)
+}
+
+// DependencyCycleError is returned when a task is blocked by itself or a task it blocks, even transitively.
+type DependencyCycleError struct {
+	UUID        uuid.UUID
+	BlockerUUID uuid.UUID
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("the task %v can't be blocked by %v, it already blocks it", e.UUID, e.BlockerUUID)
+}
+
+// BatchOperationError is returned when an operation of an all-or-nothing batch fails, the batch is rolled back.
+type BatchOperationError struct {
+	// Index is the position of the failed operation in the batch.
+	Index int
+	Err   error
+}
+
+func (e *BatchOperationError) Error() string {
+	return fmt.Sprintf("operation %d : %v", e.Index, e.Err)
 }
 
-func (e *InvalidContextError) Error() string {
-	return "requested object " + e.Key + " is not present or is not castable to destination struct"
+func (e *BatchOperationError) Unwrap() error {
+	return e.Err
 }
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// mapListDAO is used by ProxyFactoryListDAO to store ListDAO.
+var mapListDAO = make(map[string]map[string]IListDAO)
+
+// IListDAO is a DAO interface to manage List.
+// The lists of another owner than the principal of ctx aren't found, unless it is an admin.
+type IListDAO interface {
+	Create(ctx context.Context, listToCreate *model.ListCreateDTO) (*model.List, error)
+	ReadByUUID(ctx context.Context, listUUID uuid.UUID) (*model.List, error)
+	// ReadAll returns the lists, oldest first.
+	ReadAll(ctx context.Context) ([]*model.List, error)
+	Update(ctx context.Context, listUUID uuid.UUID, patch *model.ListPatch) error
+	// Delete fails with a ConflictError when the database still has tasks in the list.
+	Delete(ctx context.Context, listUUID uuid.UUID) error
+}
+
+// ProxyFactoryListDAO uses FactoryListDAO if the ListDAO don't exist, and returns ListDAO.
+func ProxyFactoryListDAO(opt DAOFactoryOptions) (IListDAO, error) {
+	// Test if exist
+	mapConnector, mapExist := mapListDAO[opt.Type]
+	if mapExist {
+		daoList, present := mapConnector[opt.Connector]
+		if present {
+			return daoList, nil
+		}
+	}
+
+	// Build new ListDAO
+	daoList, err := FactoryListDAO(opt)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build new ListDAO: %w", err)
+	}
+
+	// Save new ListDAO
+	if !mapExist {
+		mapListDAO[opt.Type] = make(map[string]IListDAO)
+	}
+	mapListDAO[opt.Type][opt.Connector] = daoList
+
+	return daoList, nil
+}
+
+// FactoryListDAO builds a new ListDAO according to the typename.
+func FactoryListDAO(opt DAOFactoryOptions) (IListDAO, error) {
+	var dao IListDAO
+	var err error
+
+	switch opt.Type {
+	case TypeListVoidDAO:
+		dao, err = factoryListVoidDAO(opt)
+	case TypeListInMemoryDAO:
+		dao, err = factoryListInMemoryDAO(opt)
+	case TypeListPostgresDAO:
+		dao, err = factoryListPostgresDAO(opt)
+	case TypeListMySQLDAO:
+		dao, err = factoryListMySQLDAO(opt)
+	case TypeListSQLiteDAO:
+		dao, err = factoryListSQLiteDAO(opt)
+	default:
+		return nil, &DAOTypeNotFoundError{Type: opt.Type}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to build %v: %w", opt.Type, err)
+	}
+
+	return dao, nil
+}
@@ -0,0 +1,247 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// TypeListInMemoryDAO is an identifier to build ListInMemoryDAO.
+	TypeListInMemoryDAO = "ListInMemoryDAO"
+)
+
+var _ IListDAO = (*ListInMemoryDAO)(nil)
+
+// ListInMemoryDAO is a ListDAO storing the lists in a map, safe for concurrent use.
+// With a File connector, each change is appended to the file and the lists are reloaded from it at startup.
+// It doesn't see the tasks, the ListController checks the list is empty before deleting it.
+type ListInMemoryDAO struct {
+	connectorName string
+	file          *connectors.FileConnector
+
+	mu    sync.RWMutex
+	lists map[uuid.UUID]*model.List
+}
+
+// listRecord is a line of the log file of a ListInMemoryDAO.
+type listRecord struct {
+	Op   string      `json:"op"`
+	List *model.List `json:"list,omitempty"`
+	UUID uuid.UUID   `json:"uuid"`
+}
+
+func (dao *ListInMemoryDAO) Create(ctx context.Context, listToCreate *model.ListCreateDTO) (*model.List, error) {
+	list := listToCreate.ReverseCreateDTO()
+	list.OwnerID = model.OwnerFromContext(ctx)
+	list.CreatedAt = time.Now()
+	list.LastUpdated = list.CreatedAt
+
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if err := dao.apply(listRecord{Op: recordOpPut, List: list, UUID: list.UUID}); err != nil {
+		return nil, err
+	}
+
+	listCopy := *list
+	return &listCopy, nil
+}
+
+func (dao *ListInMemoryDAO) ReadByUUID(ctx context.Context, listUUID uuid.UUID) (*model.List, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	list, err := dao.visibleList(ctx, listUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	listCopy := *list
+	return &listCopy, nil
+}
+
+func (dao *ListInMemoryDAO) ReadAll(ctx context.Context) ([]*model.List, error) {
+	dao.mu.RLock()
+	lists := make([]*model.List, 0, len(dao.lists))
+	for _, list := range dao.lists {
+		if visibleOwner(ctx, list.OwnerID) {
+			listCopy := *list
+			lists = append(lists, &listCopy)
+		}
+	}
+	dao.mu.RUnlock()
+
+	sort.Slice(lists, func(i, j int) bool {
+		if cmp := lists[i].CreatedAt.Compare(lists[j].CreatedAt); cmp != 0 {
+			return cmp < 0
+		}
+		return strings.Compare(lists[i].UUID.String(), lists[j].UUID.String()) < 0
+	})
+
+	return lists, nil
+}
+
+func (dao *ListInMemoryDAO) Update(ctx context.Context, listUUID uuid.UUID, patch *model.ListPatch) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	storedList, err := dao.visibleList(ctx, listUUID)
+	if err != nil {
+		return err
+	}
+
+	// Work on a copy so a failure to persist leaves the stored list untouched.
+	listToUpdate := *storedList
+	if patch.Name != nil {
+		listToUpdate.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		listToUpdate.Description = *patch.Description
+	}
+	listToUpdate.LastUpdated = time.Now()
+
+	return dao.apply(listRecord{Op: recordOpPut, List: &listToUpdate, UUID: listUUID})
+}
+
+func (dao *ListInMemoryDAO) Delete(ctx context.Context, listUUID uuid.UUID) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if _, err := dao.visibleList(ctx, listUUID); err != nil {
+		return err
+	}
+
+	return dao.apply(listRecord{Op: recordOpDelete, UUID: listUUID})
+}
+
+// visibleList returns the stored list if it is visible by the principal of ctx.
+// It must be called with the lock held.
+func (dao *ListInMemoryDAO) visibleList(ctx context.Context, listUUID uuid.UUID) (*model.List, error) {
+	list, exist := dao.lists[listUUID]
+	if !exist || !visibleOwner(ctx, list.OwnerID) {
+		return nil, fmt.Errorf("no list with this UUID (%s) exist : %w", listUUID.String(), &NoDataFoundError{})
+	}
+	return list, nil
+}
+
+// apply persists the record then applies it on the lists.
+// It must be called with the write lock held.
+func (dao *ListInMemoryDAO) apply(record listRecord) error {
+	if err := dao.persist(record); err != nil {
+		return err
+	}
+
+	applyListRecord(dao.lists, record)
+	return nil
+}
+
+// persist appends the record to the file, and compacts the file when it grew too much.
+// It must be called with the write lock held.
+func (dao *ListInMemoryDAO) persist(record listRecord) error {
+	if dao.file == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("can't marshal the record : %w", err)
+	}
+
+	if err := dao.file.Append(raw); err != nil {
+		return fmt.Errorf("can't persist the record : %w", err)
+	}
+
+	if dao.file.NeedCompaction() {
+		// The record is already safe in the file, a failed compaction is retried on the next change.
+		snapshot := make(map[uuid.UUID]*model.List, len(dao.lists)+1)
+		for listUUID, list := range dao.lists {
+			snapshot[listUUID] = list
+		}
+		applyListRecord(snapshot, record)
+
+		if err := dao.compact(snapshot); err != nil {
+			log.Error("fail to compact the lists file", zap.String("connector", dao.connectorName), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// compact replaces the file content by one put record per list.
+func (dao *ListInMemoryDAO) compact(lists map[uuid.UUID]*model.List) error {
+	records := make([][]byte, 0, len(lists))
+	for listUUID, list := range lists {
+		raw, err := json.Marshal(listRecord{Op: recordOpPut, List: list, UUID: listUUID})
+		if err != nil {
+			return fmt.Errorf("can't marshal the record : %w", err)
+		}
+		records = append(records, raw)
+	}
+
+	return dao.file.Compact(records)
+}
+
+// load replays the records of the file then compacts it.
+func (dao *ListInMemoryDAO) load() error {
+	records, err := dao.file.Records()
+	if err != nil {
+		return fmt.Errorf("can't read the lists file : %w", err)
+	}
+
+	for i, raw := range records {
+		var record listRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("can't unmarshal the record %d : %w", i+1, err)
+		}
+		applyListRecord(dao.lists, record)
+	}
+
+	return dao.compact(dao.lists)
+}
+
+// applyListRecord applies a record of the log file on the lists.
+func applyListRecord(lists map[uuid.UUID]*model.List, record listRecord) {
+	switch record.Op {
+	case recordOpPut:
+		if record.List != nil {
+			lists[record.UUID] = record.List
+		}
+	case recordOpDelete:
+		delete(lists, record.UUID)
+	}
+}
+
+// factoryListInMemoryDAO build ListInMemoryDAO, the lists are persisted if opt.Connector names a File connector.
+// The File connector can't be shared with a TaskInMemoryDAO.
+func factoryListInMemoryDAO(opt DAOFactoryOptions) (*ListInMemoryDAO, error) {
+	dao := &ListInMemoryDAO{
+		connectorName: opt.Connector,
+		lists:         make(map[uuid.UUID]*model.List),
+	}
+
+	if opt.Connector == "" {
+		return dao, nil
+	}
+
+	file, err := connectors.GetConnectorFile(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+	dao.file = file
+
+	if err := dao.load(); err != nil {
+		return nil, fmt.Errorf("fail to load lists: %w", err)
+	}
+
+	return dao, nil
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeListMySQLDAO is an identifier to build ListMySQLDAO.
+	TypeListMySQLDAO = "ListMySQLDAO"
+)
+
+var _ IListDAO = (*ListMySQLDAO)(nil)
+
+// ListMySQLDAO is a ListDAO storing the lists in the database of TaskMySQLDAO.
+type ListMySQLDAO struct {
+	listSQLDAO
+}
+
+// factoryListMySQLDAO build ListMySQLDAO.
+func factoryListMySQLDAO(opt DAOFactoryOptions) (*ListMySQLDAO, error) {
+	connector, err := connectors.GetConnectorMySQL(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &ListMySQLDAO{
+		listSQLDAO: listSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       mysqlDialect,
+			},
+		},
+	}, nil
+}
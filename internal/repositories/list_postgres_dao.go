@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeListPostgresDAO is an identifier to build ListPostgresDAO.
+	TypeListPostgresDAO = "ListPostgresDAO"
+)
+
+var _ IListDAO = (*ListPostgresDAO)(nil)
+
+// ListPostgresDAO is a ListDAO storing the lists in the database of TaskPostgresDAO.
+type ListPostgresDAO struct {
+	listSQLDAO
+}
+
+// factoryListPostgresDAO build ListPostgresDAO.
+func factoryListPostgresDAO(opt DAOFactoryOptions) (*ListPostgresDAO, error) {
+	connector, err := connectors.GetConnectorPostgres(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &ListPostgresDAO{
+		listSQLDAO: listSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       postgresDialect,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// listColumns are the columns read by scanList, in order.
+const listColumns = "list_uuid, name, description, owner_id, created_at, last_updated"
+
+// listSQLDAO implements IListDAO with portable SQL, the specificities of each database are in its dialect.
+type listSQLDAO struct {
+	sqlDAO
+}
+
+func (dao *listSQLDAO) Create(ctx context.Context, listToCreate *model.ListCreateDTO) (*model.List, error) {
+	list := listToCreate.ReverseCreateDTO()
+	list.OwnerID = model.OwnerFromContext(ctx)
+	list.CreatedAt = sqlNow()
+	list.LastUpdated = list.CreatedAt
+
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("INSERT INTO lists (%s) VALUES (%s, %s, %s, %s, %s, %s);", listColumns,
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5), dao.bind(6))
+		return dao.execOne(tx, query, list.UUID, list.Name, list.Description, list.OwnerID, list.CreatedAt, list.LastUpdated)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't insert the list : %w", err)
+	}
+
+	return list, nil
+}
+
+func (dao *listSQLDAO) ReadByUUID(ctx context.Context, listUUID uuid.UUID) (*model.List, error) {
+	ownerCondition, params := dao.ownerCondition(ctx, []any{listUUID})
+	query := fmt.Sprintf("SELECT %s FROM lists WHERE list_uuid = %s%s;", listColumns, dao.bind(1), ownerCondition)
+	list, err := scanList(dao.connector.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no list with this UUID (%s) exist : %w", listUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+	return list, nil
+}
+
+func (dao *listSQLDAO) ReadAll(ctx context.Context) ([]*model.List, error) {
+	query := fmt.Sprintf("SELECT %s FROM lists", listColumns)
+	var params []any
+	if owner, scoped := model.OwnerScopeFromContext(ctx); scoped {
+		params = append(params, owner)
+		query += " WHERE owner_id = " + dao.bind(1)
+	}
+	query += " ORDER BY created_at, list_uuid;"
+
+	rows, err := dao.connector.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query all lists : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	lists := make([]*model.List, 0)
+	for rows.Next() {
+		list, err := scanList(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		lists = append(lists, list)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	return lists, nil
+}
+
+func (dao *listSQLDAO) Update(ctx context.Context, listUUID uuid.UUID, patch *model.ListPatch) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		list, err := dao.lockList(ctx, tx, listUUID)
+		if err != nil {
+			return err
+		}
+
+		if patch.Name != nil {
+			list.Name = *patch.Name
+		}
+		if patch.Description != nil {
+			list.Description = *patch.Description
+		}
+		list.LastUpdated = sqlNow()
+
+		query := fmt.Sprintf("UPDATE lists SET name = %s, description = %s, last_updated = %s WHERE list_uuid = %s;",
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4))
+		return dao.execOne(tx, query, list.Name, list.Description, list.LastUpdated, list.UUID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't update the list : %w", err)
+	}
+	return nil
+}
+
+func (dao *listSQLDAO) Delete(ctx context.Context, listUUID uuid.UUID) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := dao.lockList(ctx, tx, listUUID); err != nil {
+			return err
+		}
+
+		// Postgres and MySQL also have a foreign key on the list of the tasks, SQLite only relies on this check.
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM tasks WHERE list_uuid = %s;", dao.bind(1))
+		if err := tx.QueryRowContext(ctx, query, listUUID).Scan(&count); err != nil {
+			return dao.dialect.wrapError(err)
+		}
+		if count > 0 {
+			return &ConflictError{Err: fmt.Errorf("%d tasks are in the list", count)}
+		}
+
+		query = fmt.Sprintf("DELETE FROM lists WHERE list_uuid = %s;", dao.bind(1))
+		return dao.execOne(tx, query, listUUID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't delete the list : %w", err)
+	}
+	return nil
+}
+
+// lockList reads the list in the transaction and locks its row until the end of the transaction.
+func (dao *listSQLDAO) lockList(ctx context.Context, tx *sql.Tx, listUUID uuid.UUID) (*model.List, error) {
+	ownerCondition, params := dao.ownerCondition(ctx, []any{listUUID})
+	query := fmt.Sprintf("SELECT %s FROM lists WHERE list_uuid = %s%s%s;", listColumns, dao.bind(1), ownerCondition, dao.dialect.lockClause)
+	list, err := scanList(tx.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no list with this UUID (%s) exist : %w", listUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+	return list, nil
+}
+
+// scanList scans the listColumns of a row.
+func scanList(row rowScanner) (*model.List, error) {
+	list := new(model.List)
+	if err := row.Scan(
+		&list.UUID,
+		&list.Name,
+		&list.Description,
+		&list.OwnerID,
+		&list.CreatedAt,
+		&list.LastUpdated,
+	); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
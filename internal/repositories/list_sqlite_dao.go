@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeListSQLiteDAO is an identifier to build ListSQLiteDAO.
+	TypeListSQLiteDAO = "ListSQLiteDAO"
+)
+
+var _ IListDAO = (*ListSQLiteDAO)(nil)
+
+// ListSQLiteDAO is a ListDAO storing the lists in the database of TaskSQLiteDAO.
+type ListSQLiteDAO struct {
+	listSQLDAO
+}
+
+// factoryListSQLiteDAO build ListSQLiteDAO.
+func factoryListSQLiteDAO(opt DAOFactoryOptions) (*ListSQLiteDAO, error) {
+	connector, err := connectors.GetConnectorSQLite(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &ListSQLiteDAO{
+		listSQLDAO: listSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       sqliteDialect,
+			},
+		},
+	}, nil
+}
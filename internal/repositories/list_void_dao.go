@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeListVoidDAO is an identifier to build ListVoidDAO.
+	TypeListVoidDAO = "ListVoidDAO"
+)
+
+var _ IListDAO = (*ListVoidDAO)(nil)
+
+// ListVoidDAO is a ListDAO with not implemented features.
+type ListVoidDAO struct {
+	connectorName string
+}
+
+func (dao *ListVoidDAO) Create(ctx context.Context, listToCreate *model.ListCreateDTO) (*model.List, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *ListVoidDAO) ReadByUUID(ctx context.Context, listUUID uuid.UUID) (*model.List, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *ListVoidDAO) ReadAll(ctx context.Context) ([]*model.List, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *ListVoidDAO) Update(ctx context.Context, listUUID uuid.UUID, patch *model.ListPatch) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *ListVoidDAO) Delete(ctx context.Context, listUUID uuid.UUID) error {
+	return ErrFeatureNotImplemented
+}
+
+// factoryListVoidDAO build ListVoidDAO.
+func factoryListVoidDAO(opt DAOFactoryOptions) (*ListVoidDAO, error) {
+	return &ListVoidDAO{
+		connectorName: opt.Connector,
+	}, nil
+}
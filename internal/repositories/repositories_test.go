@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Aloe-Corporation/logs"
+	"github.com/Aloe-Corporation/sqldb"
+	"github.com/CamilleLange/todolist/internal/connectors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) {
+	logs.Config = logs.Conf{Level: logs.ERROR, Output: []string{os.DevNull}}
+	if err := logs.Init(); err != nil {
+		fmt.Println(fmt.Errorf("fail to init logs: %w", err))
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestFileConnector opens a File connector on a new file and returns its name.
+func newTestFileConnector(t *testing.T, compactEvery int) string {
+	t.Helper()
+
+	name := "test-" + uuid.NewString()
+	connectors.Config = connectors.Conf{
+		File: map[string]connectors.FileConf{
+			name: {Path: filepath.Join(t.TempDir(), "records.log"), CompactEvery: compactEvery},
+		},
+	}
+	if err := connectors.Init(); err != nil {
+		t.Fatalf("connectors.Init() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = connectors.Files[name].Close()
+	})
+
+	return name
+}
+
+// reopenTestFileConnector closes the File connector and opens its file again, like a restart.
+func reopenTestFileConnector(t *testing.T, name string) {
+	t.Helper()
+
+	if err := connectors.Files[name].Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := connectors.Init(); err != nil {
+		t.Fatalf("connectors.Init() error = %v", err)
+	}
+}
+
+// newTestSQLiteConnector opens a SQLite connector on a new migrated database and returns its name.
+func newTestSQLiteConnector(t *testing.T) string {
+	t.Helper()
+
+	name := "test-" + uuid.NewString()
+	connectors.Config = connectors.Conf{
+		SQLite: map[string]sqldb.Conf{
+			name: {DSN: "file:" + filepath.Join(t.TempDir(), "todolist.db") + "?_busy_timeout=5000&_foreign_keys=on"},
+		},
+		AutoMigrate: true,
+	}
+	if err := connectors.Init(); err != nil {
+		t.Fatalf("connectors.Init() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = connectors.SQLite[name].Close()
+		delete(connectors.SQLite, name)
+	})
+
+	return name
+}
+
+// newTestPostgresConnector opens a Postgres connector on a new migrated schema of the database of the DSN
+// and returns its name. The schema is dropped once the test ends.
+func newTestPostgresConnector(t *testing.T, dsn string) string {
+	t.Helper()
+
+	schema := newTestDatabaseName(t, "postgres", dsn, "SCHEMA")
+	// The extensions of the migrations may be installed in the public schema.
+	searchPath := schema + ",public"
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatalf("url.Parse() error = %v", err)
+		}
+		query := parsed.Query()
+		query.Set("search_path", searchPath)
+		parsed.RawQuery = query.Encode()
+		dsn = parsed.String()
+	} else {
+		dsn += " search_path=" + searchPath
+	}
+
+	name := "test-" + uuid.NewString()
+	connectors.Config = connectors.Conf{
+		Postgres: map[string]sqldb.Conf{
+			name: {Driver: "postgres", DSN: dsn},
+		},
+		AutoMigrate: true,
+	}
+	if err := connectors.Init(); err != nil {
+		t.Fatalf("connectors.Init() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = connectors.Postgres[name].Close()
+		delete(connectors.Postgres, name)
+	})
+
+	return name
+}
+
+// newTestMySQLConnector opens a MySQL connector on a new migrated database of the server of the DSN
+// and returns its name. The database is dropped once the test ends.
+func newTestMySQLConnector(t *testing.T, dsn string) string {
+	t.Helper()
+
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("mysql.ParseDSN() error = %v", err)
+	}
+	cfg.DBName = newTestDatabaseName(t, "mysql", dsn, "DATABASE")
+
+	name := "test-" + uuid.NewString()
+	connectors.Config = connectors.Conf{
+		MySQL: map[string]sqldb.Conf{
+			name: {DSN: cfg.FormatDSN()},
+		},
+		AutoMigrate: true,
+	}
+	if err := connectors.Init(); err != nil {
+		t.Fatalf("connectors.Init() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = connectors.MySQL[name].Close()
+		delete(connectors.MySQL, name)
+	})
+
+	return name
+}
+
+// newTestDatabaseName creates a new schema or database, according to kind, on the server of the DSN
+// and returns its name. It is dropped with its tables once the test ends.
+func newTestDatabaseName(t *testing.T, driver, dsn, kind string) string {
+	t.Helper()
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	name := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := db.Exec("CREATE " + kind + " " + name); err != nil {
+		_ = db.Close()
+		t.Fatalf("CREATE %s error = %v", kind, err)
+	}
+	t.Cleanup(func() {
+		defer db.Close()
+		drop := "DROP " + kind + " " + name
+		if kind == "SCHEMA" {
+			drop += " CASCADE"
+		}
+		if _, err := db.Exec(drop); err != nil {
+			t.Errorf("DROP %s error = %v", kind, err)
+		}
+	})
+
+	return name
+}
+
+// testTaskDAOBackend is a TaskDAO type tested on a connector of its own.
+type testTaskDAOBackend struct {
+	name    string
+	taskDAO DAOFactoryOptions
+}
+
+// newTestTaskDAOBackends returns the in-memory and SQLite TaskDAOs on new connectors, and the Postgres
+// and MySQL ones on new schemas of the databases of TEST_POSTGRES_DSN and TEST_MYSQL_DSN when they are set.
+func newTestTaskDAOBackends(t *testing.T) []testTaskDAOBackend {
+	t.Helper()
+
+	backends := []testTaskDAOBackend{
+		{name: "memory", taskDAO: DAOFactoryOptions{Type: TypeTaskInMemoryDAO, Connector: newTestFileConnector(t, 1000)}},
+		{name: "sqlite", taskDAO: DAOFactoryOptions{Type: TypeTaskSQLiteDAO, Connector: newTestSQLiteConnector(t)}},
+	}
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		backends = append(backends, testTaskDAOBackend{name: "postgres", taskDAO: DAOFactoryOptions{Type: TypeTaskPostgresDAO, Connector: newTestPostgresConnector(t, dsn)}})
+	}
+	if dsn := os.Getenv("TEST_MYSQL_DSN"); dsn != "" {
+		backends = append(backends, testTaskDAOBackend{name: "mysql", taskDAO: DAOFactoryOptions{Type: TypeTaskMySQLDAO, Connector: newTestMySQLConnector(t, dsn)}})
+	}
+	return backends
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Aloe-Corporation/sqldb"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+// sqlDialect holds what differs between the SQL databases used by the SQL DAOs.
+type sqlDialect struct {
+	// placeholder returns the bind parameter of the n-th argument of a query (1-based).
+	placeholder func(n int) string
+	// sortColumns maps the sort fields to their column, texts must be compared byte-wise to match TaskInMemoryDAO.
+	sortColumns map[string]string
+	// containsCondition is the case insensitive match on the description, %s is the placeholder of the LIKE pattern.
+	containsCondition string
+	// wrapError wraps the driver errors into the error types of the repositories package.
+	wrapError func(err error) error
+	// lockClause ends the SELECT locking the rows read in a transaction, empty if the database locks it all.
+	lockClause string
+	// textSearch tells the database searches the tasks with its text search functions and indexes,
+	// otherwise the tasks having the words of the query are ranked by the DAO.
+	textSearch bool
+}
+
+// sqlDAO holds the connection of the SQL DAOs and their helpers.
+type sqlDAO struct {
+	connector     *sqldb.Connector
+	connectorName string
+	dialect       sqlDialect
+}
+
+// withTx runs fn in a transaction, committed if fn succeeds and rolled back otherwise.
+func (dao *sqlDAO) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	// Open a transaction.
+	tx, err := dao.connector.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("can't begin the transaction :%w", dao.dialect.wrapError(err))
+	}
+
+	if err := fn(tx); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			return fmt.Errorf("can't rollback the tx : %w", errRollback)
+		}
+		return err
+	}
+
+	// Commit the transaction.
+	if err := dao.connector.Commit(tx); err != nil {
+		return fmt.Errorf("can't commit the transaction : %w", dao.dialect.wrapError(err))
+	}
+	return nil
+}
+
+// execOne executes a query that must affect exactly one row in the transaction.
+// It returns ErrNoRowAffected if no row matched.
+func (dao *sqlDAO) execOne(tx *sql.Tx, query string, params ...any) error {
+	result, err := dao.connector.Exec(tx, query, params...)
+	if err != nil {
+		return dao.dialect.wrapError(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("can't get the number of affected rows : %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNoRowAffected
+	}
+	if rowsAffected != 1 {
+		return fmt.Errorf("we affect more than one row")
+	}
+	return nil
+}
+
+// bind returns the placeholder of the n-th parameter of a query.
+func (dao *sqlDAO) bind(n int) string {
+	return dao.dialect.placeholder(n)
+}
+
+// ownerCondition returns the condition restricting a query to the owner of the principal of ctx, empty if it sees every task.
+// Its parameter is appended to params.
+func (dao *sqlDAO) ownerCondition(ctx context.Context, params []any) (string, []any) {
+	owner, scoped := model.OwnerScopeFromContext(ctx)
+	if !scoped {
+		return "", params
+	}
+
+	params = append(params, owner)
+	return " AND owner_id = " + dao.bind(len(params)), params
+}
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// sqlNow returns the current time with the precision stored by every supported database.
+func sqlNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// sqlTime returns t with the precision stored by every supported database, nil for nil.
+func sqlTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := t.UTC().Truncate(time.Microsecond)
+	return &stored
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// mapTagDAO is used by ProxyFactoryTagDAO to store TagDAO.
+var mapTagDAO = make(map[string]map[string]ITagDAO)
+
+// tagDAOTypes maps the types of TaskDAO to the type of TagDAO storing the tags next to the tasks they are put on.
+var tagDAOTypes = map[string]string{
+	TypeTaskVoidDAO:     TypeTagVoidDAO,
+	TypeTaskInMemoryDAO: TypeTagInMemoryDAO,
+	TypeTaskPostgresDAO: TypeTagPostgresDAO,
+	TypeTaskMySQLDAO:    TypeTagMySQLDAO,
+	TypeTaskSQLiteDAO:   TypeTagSQLiteDAO,
+}
+
+// ITagDAO is a DAO interface to manage the Tag of the owners.
+// The tags are stored next to the tasks of the TaskDAO of the same connector, which puts them on the tasks and takes them off.
+type ITagDAO interface {
+	// ReadTags returns the tags owned by the principal of ctx, ordered by name, or every tag for an admin.
+	ReadTags(ctx context.Context) ([]*model.Tag, error)
+	// ReadTag returns the tag if it is owned by the principal of ctx.
+	ReadTag(ctx context.Context, tagUUID uuid.UUID) (*model.Tag, error)
+	// CreateTag and RenameTag fail with a ConflictError if the owner already has a tag with this name.
+	CreateTag(ctx context.Context, tagToCreate *model.TagCreateDTO) (*model.Tag, error)
+	// RenameTag changes the name of the tag on every task, their version is unchanged.
+	RenameTag(ctx context.Context, tagUUID uuid.UUID, name string) error
+	// DeleteTag removes the tag and takes it off its tasks, their version is unchanged.
+	DeleteTag(ctx context.Context, tagUUID uuid.UUID) error
+}
+
+// TagDAOOptions returns the options of the TagDAO storing the tags next to the tasks of the TaskDAO of taskDAO.
+func TagDAOOptions(taskDAO DAOFactoryOptions) DAOFactoryOptions {
+	return DAOFactoryOptions{Type: tagDAOTypes[taskDAO.Type], Connector: taskDAO.Connector}
+}
+
+// ProxyFactoryTagDAO uses FactoryTagDAO if the TagDAO don't exist, and returns TagDAO.
+func ProxyFactoryTagDAO(opt DAOFactoryOptions) (ITagDAO, error) {
+	// Test if exist
+	mapConnector, mapExist := mapTagDAO[opt.Type]
+	if mapExist {
+		daoTag, present := mapConnector[opt.Connector]
+		if present {
+			return daoTag, nil
+		}
+	}
+
+	// Build new TagDAO
+	daoTag, err := FactoryTagDAO(opt)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build new TagDAO: %w", err)
+	}
+
+	// Save new TagDAO
+	if !mapExist {
+		mapTagDAO[opt.Type] = make(map[string]ITagDAO)
+	}
+	mapTagDAO[opt.Type][opt.Connector] = daoTag
+
+	return daoTag, nil
+}
+
+// FactoryTagDAO builds a new TagDAO according to the typename.
+func FactoryTagDAO(opt DAOFactoryOptions) (ITagDAO, error) {
+	var dao ITagDAO
+	var err error
+
+	switch opt.Type {
+	case TypeTagVoidDAO:
+		dao, err = factoryTagVoidDAO(opt)
+	case TypeTagInMemoryDAO:
+		dao, err = factoryTagInMemoryDAO(opt)
+	case TypeTagPostgresDAO:
+		dao, err = factoryTagPostgresDAO(opt)
+	case TypeTagMySQLDAO:
+		dao, err = factoryTagMySQLDAO(opt)
+	case TypeTagSQLiteDAO:
+		dao, err = factoryTagSQLiteDAO(opt)
+	default:
+		return nil, &DAOTypeNotFoundError{Type: opt.Type}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to build %v: %w", opt.Type, err)
+	}
+
+	return dao, nil
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestTagDAOOptions(t *testing.T) {
+	for taskType, tagType := range tagDAOTypes {
+		got := TagDAOOptions(DAOFactoryOptions{Type: taskType, Connector: "db"})
+		if got.Type != tagType || got.Connector != "db" {
+			t.Errorf("TagDAOOptions(%s) = %+v, want %s on db", taskType, got, tagType)
+		}
+	}
+
+	var errDAOTypeNotFound *DAOTypeNotFoundError
+	if _, err := FactoryTagDAO(TagDAOOptions(DAOFactoryOptions{Type: "TaskRedisDAO"})); !errors.As(err, &errDAOTypeNotFound) {
+		t.Errorf("FactoryTagDAO() of an unknown type error = %v, want a DAOTypeNotFoundError", err)
+	}
+}
+
+func TestTagDAO(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			daoTask, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+			daoTag, err := ProxyFactoryTagDAO(TagDAOOptions(backend.taskDAO))
+			if err != nil {
+				t.Fatalf("ProxyFactoryTagDAO() error = %v", err)
+			}
+
+			alice := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "bob"})
+			admin := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "root", Admin: true})
+			createTag := func(ctx context.Context, name string) *model.Tag {
+				t.Helper()
+				tag, err := daoTag.CreateTag(ctx, &model.TagCreateDTO{Name: name})
+				if err != nil {
+					t.Fatalf("CreateTag(%s) error = %v", name, err)
+				}
+				return tag
+			}
+			names := func(tags []*model.Tag) []string {
+				names := make([]string, 0, len(tags))
+				for _, tag := range tags {
+					names = append(names, tag.Name)
+				}
+				return names
+			}
+
+			work := createTag(alice, "work")
+			home := createTag(alice, "home")
+			createTag(bob, "work")
+			if work.OwnerID != "alice" || work.CreatedAt.IsZero() || !work.LastUpdated.Equal(work.CreatedAt) {
+				t.Errorf("CreateTag() = %+v, want owned by alice and created now", work)
+			}
+
+			// An owner can't have two tags of the same name.
+			var errConflict *ConflictError
+			if _, err := daoTag.CreateTag(alice, &model.TagCreateDTO{Name: "work"}); !errors.As(err, &errConflict) {
+				t.Errorf("CreateTag() of a duplicate error = %v, want a ConflictError", err)
+			}
+			if err := daoTag.RenameTag(alice, home.UUID, "work"); !errors.As(err, &errConflict) {
+				t.Errorf("RenameTag() to a duplicate error = %v, want a ConflictError", err)
+			}
+
+			// The tags are read by their owner, sorted by name, and by an admin.
+			if tags, err := daoTag.ReadTags(alice); err != nil || !reflect.DeepEqual(names(tags), []string{"home", "work"}) {
+				t.Errorf("ReadTags() by alice = %v, %v, want home and work", names(tags), err)
+			}
+			if tags, err := daoTag.ReadTags(admin); err != nil || !reflect.DeepEqual(names(tags), []string{"home", "work", "work"}) {
+				t.Errorf("ReadTags() by an admin = %v, %v, want every tag", names(tags), err)
+			}
+			if tag, err := daoTag.ReadTag(alice, work.UUID); err != nil || tag.Name != "work" {
+				t.Errorf("ReadTag() = %+v, %v, want work", tag, err)
+			}
+			if _, err := daoTag.ReadTag(bob, work.UUID); !isNotFound(err) {
+				t.Errorf("ReadTag() by bob error = %v, want not found", err)
+			}
+			if err := daoTag.RenameTag(bob, work.UUID, "job"); !isNotFound(err) {
+				t.Errorf("RenameTag() by bob error = %v, want not found", err)
+			}
+			if err := daoTag.DeleteTag(bob, work.UUID); !isNotFound(err) {
+				t.Errorf("DeleteTag() by bob error = %v, want not found", err)
+			}
+
+			// The TaskDAO puts the tags on its tasks, which follow the renaming and the removal of the tag.
+			task, err := daoTask.Create(alice, &model.TaskCreateDTO{WhatToDo: "write the report", Status: "todo"})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			for _, tag := range []*model.Tag{work, home} {
+				if err := daoTask.SaveTaskTag(alice, task.UUID, tag.UUID, 0); err != nil {
+					t.Fatalf("SaveTaskTag(%s) error = %v", tag.Name, err)
+				}
+			}
+			if err := daoTask.SaveTaskTag(bob, task.UUID, work.UUID, 0); !isNotFound(err) {
+				t.Errorf("SaveTaskTag() by bob error = %v, want not found", err)
+			}
+			readTask := func() *model.Task {
+				t.Helper()
+				task, err := daoTask.ReadByUUID(alice, task.UUID)
+				if err != nil {
+					t.Fatalf("ReadByUUID() error = %v", err)
+				}
+				return task
+			}
+			if got := readTask(); !reflect.DeepEqual(got.Tags, []string{"home", "work"}) || got.Version != 3 {
+				t.Fatalf("tagged task = %v version %d, want home and work version 3", got.Tags, got.Version)
+			}
+
+			if err := daoTag.RenameTag(alice, work.UUID, "job"); err != nil {
+				t.Fatalf("RenameTag() error = %v", err)
+			}
+			if tag, err := daoTag.ReadTag(alice, work.UUID); err != nil || tag.Name != "job" || !tag.LastUpdated.After(tag.CreatedAt) {
+				t.Errorf("ReadTag() after the renaming = %+v, %v, want job updated after its creation", tag, err)
+			}
+			if got := readTask(); !reflect.DeepEqual(got.Tags, []string{"home", "job"}) || got.Version != 3 {
+				t.Errorf("task after the renaming = %v version %d, want home and job version 3", got.Tags, got.Version)
+			}
+
+			if err := daoTag.DeleteTag(alice, home.UUID); err != nil {
+				t.Fatalf("DeleteTag() error = %v", err)
+			}
+			if _, err := daoTag.ReadTag(alice, home.UUID); !isNotFound(err) {
+				t.Errorf("ReadTag() of a deleted tag error = %v, want not found", err)
+			}
+			if got := readTask(); !reflect.DeepEqual(got.Tags, []string{"job"}) || got.Version != 3 {
+				t.Errorf("task after the removal = %v version %d, want job version 3", got.Tags, got.Version)
+			}
+			if err := daoTag.DeleteTag(alice, uuid.New()); !isNotFound(err) {
+				t.Errorf("DeleteTag() of an unknown tag error = %v, want not found", err)
+			}
+		})
+	}
+}
+
+func TestTaskDAOReadAllTags(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			daoTask, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+			daoTag, err := ProxyFactoryTagDAO(TagDAOOptions(backend.taskDAO))
+			if err != nil {
+				t.Fatalf("ProxyFactoryTagDAO() error = %v", err)
+			}
+
+			ctx := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			tags := map[string]*model.Tag{}
+			for _, name := range []string{"work", "home"} {
+				tag, err := daoTag.CreateTag(ctx, &model.TagCreateDTO{Name: name})
+				if err != nil {
+					t.Fatalf("CreateTag(%s) error = %v", name, err)
+				}
+				tags[name] = tag
+			}
+			create := func(whatToDo string, names ...string) *model.Task {
+				t.Helper()
+				task, err := daoTask.Create(ctx, &model.TaskCreateDTO{WhatToDo: whatToDo, Status: "todo"})
+				if err != nil {
+					t.Fatalf("Create(%s) error = %v", whatToDo, err)
+				}
+				for _, name := range names {
+					if err := daoTask.SaveTaskTag(ctx, task.UUID, tags[name].UUID, 0); err != nil {
+						t.Fatalf("SaveTaskTag(%s) error = %v", name, err)
+					}
+				}
+				return task
+			}
+
+			report := create("write the report", "work", "home")
+			call := create("call the client", "work")
+			garden := create("mow the lawn", "home")
+			create("read a book")
+
+			tests := []struct {
+				name  string
+				tag   []string
+				match string
+				want  []uuid.UUID
+			}{
+				{name: "any of one", tag: []string{"work"}, match: model.TagMatchAny, want: []uuid.UUID{report.UUID, call.UUID}},
+				{name: "any of two", tag: []string{"work", "home"}, match: model.TagMatchAny, want: []uuid.UUID{report.UUID, call.UUID, garden.UUID}},
+				{name: "all of one", tag: []string{"home"}, match: model.TagMatchAll, want: []uuid.UUID{report.UUID, garden.UUID}},
+				{name: "all of two", tag: []string{"home", "work"}, match: model.TagMatchAll, want: []uuid.UUID{report.UUID}},
+				{name: "all with an unknown tag", tag: []string{"work", "urgent"}, match: model.TagMatchAll, want: []uuid.UUID{}},
+				{name: "any with an unknown tag", tag: []string{"urgent", "home"}, match: model.TagMatchAny, want: []uuid.UUID{report.UUID, garden.UUID}},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					filter := &model.TaskFilterDTO{Tag: tt.tag, TagMatch: tt.match, Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Limit: 100}
+					tasks, _, err := daoTask.ReadAll(ctx, filter)
+					if err != nil {
+						t.Fatalf("ReadAll() error = %v", err)
+					}
+					got := make([]uuid.UUID, 0, len(tasks))
+					for _, task := range tasks {
+						got = append(got, task.UUID)
+					}
+					if !reflect.DeepEqual(got, tt.want) {
+						t.Errorf("ReadAll() of the tags %v matching %s = %v, want %v", tt.tag, tt.match, got, tt.want)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeTagInMemoryDAO is an identifier to build TagInMemoryDAO.
+	TypeTagInMemoryDAO = "TagInMemoryDAO"
+)
+
+var _ ITagDAO = (*TagInMemoryDAO)(nil)
+
+// TagInMemoryDAO is a TagDAO keeping the tags in the TaskInMemoryDAO of the same connector,
+// which puts them on its tasks. The tags are appended to its log file.
+type TagInMemoryDAO struct {
+	tasks *TaskInMemoryDAO
+}
+
+func (dao *TagInMemoryDAO) ReadTags(ctx context.Context) ([]*model.Tag, error) {
+	dao.tasks.mu.RLock()
+	tags := make([]*model.Tag, 0)
+	for _, tag := range dao.tasks.tags {
+		if visibleOwner(ctx, tag.OwnerID) {
+			tagCopy := *tag
+			tags = append(tags, &tagCopy)
+		}
+	}
+	dao.tasks.mu.RUnlock()
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Name != tags[j].Name {
+			return tags[i].Name < tags[j].Name
+		}
+		return tags[i].UUID.String() < tags[j].UUID.String()
+	})
+	return tags, nil
+}
+
+func (dao *TagInMemoryDAO) ReadTag(ctx context.Context, tagUUID uuid.UUID) (*model.Tag, error) {
+	dao.tasks.mu.RLock()
+	defer dao.tasks.mu.RUnlock()
+
+	tag, err := dao.tasks.visibleTag(ctx, tagUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	tagCopy := *tag
+	return &tagCopy, nil
+}
+
+func (dao *TagInMemoryDAO) CreateTag(ctx context.Context, tagToCreate *model.TagCreateDTO) (*model.Tag, error) {
+	tag := tagToCreate.ReverseCreateDTO()
+	tag.OwnerID = model.OwnerFromContext(ctx)
+	tag.CreatedAt = time.Now()
+	tag.LastUpdated = tag.CreatedAt
+
+	dao.tasks.mu.Lock()
+	defer dao.tasks.mu.Unlock()
+
+	if err := dao.checkTagName(tag); err != nil {
+		return nil, err
+	}
+	if err := dao.tasks.apply(taskRecord{Op: recordOpPutTag, UUID: tag.UUID, Tag: tag}); err != nil {
+		return nil, err
+	}
+
+	tagCopy := *tag
+	return &tagCopy, nil
+}
+
+func (dao *TagInMemoryDAO) RenameTag(ctx context.Context, tagUUID uuid.UUID, name string) error {
+	dao.tasks.mu.Lock()
+	defer dao.tasks.mu.Unlock()
+
+	storedTag, err := dao.tasks.visibleTag(ctx, tagUUID)
+	if err != nil {
+		return err
+	}
+
+	tagToRename := *storedTag
+	tagToRename.Name = name
+	tagToRename.LastUpdated = time.Now()
+	if err := dao.checkTagName(&tagToRename); err != nil {
+		return err
+	}
+
+	return dao.tasks.apply(taskRecord{Op: recordOpPutTag, UUID: tagUUID, Tag: &tagToRename})
+}
+
+func (dao *TagInMemoryDAO) DeleteTag(ctx context.Context, tagUUID uuid.UUID) error {
+	dao.tasks.mu.Lock()
+	defer dao.tasks.mu.Unlock()
+
+	tag, err := dao.tasks.visibleTag(ctx, tagUUID)
+	if err != nil {
+		return err
+	}
+
+	return dao.tasks.apply(taskRecord{Op: recordOpDeleteTag, UUID: tagUUID, Tag: tag})
+}
+
+// checkTagName returns a ConflictError if another tag of the owner of the tag has its name.
+// It must be called with the lock held.
+func (dao *TagInMemoryDAO) checkTagName(tag *model.Tag) error {
+	for _, other := range dao.tasks.tags {
+		if other.UUID != tag.UUID && other.OwnerID == tag.OwnerID && other.Name == tag.Name {
+			return &ConflictError{Err: fmt.Errorf("the tag %q already exists", tag.Name)}
+		}
+	}
+	return nil
+}
+
+// factoryTagInMemoryDAO build TagInMemoryDAO on the TaskInMemoryDAO of the connector.
+func factoryTagInMemoryDAO(opt DAOFactoryOptions) (*TagInMemoryDAO, error) {
+	daoTask, err := ProxyFactoryTaskDAO(DAOFactoryOptions{Type: TypeTaskInMemoryDAO, Connector: opt.Connector})
+	if err != nil {
+		return nil, fmt.Errorf("fail to get TaskInMemoryDAO: %w", err)
+	}
+
+	return &TagInMemoryDAO{
+		tasks: daoTask.(*TaskInMemoryDAO),
+	}, nil
+}
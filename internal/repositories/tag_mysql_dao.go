@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTagMySQLDAO is an identifier to build TagMySQLDAO.
+	TypeTagMySQLDAO = "TagMySQLDAO"
+)
+
+var _ ITagDAO = (*TagMySQLDAO)(nil)
+
+// TagMySQLDAO is a TagDAO storing the tags in the database of TaskMySQLDAO.
+type TagMySQLDAO struct {
+	tagSQLDAO
+}
+
+// factoryTagMySQLDAO build TagMySQLDAO.
+func factoryTagMySQLDAO(opt DAOFactoryOptions) (*TagMySQLDAO, error) {
+	connector, err := connectors.GetConnectorMySQL(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TagMySQLDAO{
+		tagSQLDAO: newTagSQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       mysqlDialect,
+		}),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTagPostgresDAO is an identifier to build TagPostgresDAO.
+	TypeTagPostgresDAO = "TagPostgresDAO"
+)
+
+var _ ITagDAO = (*TagPostgresDAO)(nil)
+
+// TagPostgresDAO is a TagDAO storing the tags in the database of TaskPostgresDAO.
+type TagPostgresDAO struct {
+	tagSQLDAO
+}
+
+// factoryTagPostgresDAO build TagPostgresDAO.
+func factoryTagPostgresDAO(opt DAOFactoryOptions) (*TagPostgresDAO, error) {
+	connector, err := connectors.GetConnectorPostgres(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TagPostgresDAO{
+		tagSQLDAO: newTagSQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       postgresDialect,
+		}),
+	}, nil
+}
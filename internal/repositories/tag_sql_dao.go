@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// tagSQLDAO implements ITagDAO with portable SQL, in the tags table of the database of the taskSQLDAO.
+type tagSQLDAO struct {
+	sqlDAO
+	// tasks locks the tag in the transaction changing it, like when it is put on a task.
+	tasks taskSQLDAO
+}
+
+// newTagSQLDAO returns a tagSQLDAO on the connection of dao.
+func newTagSQLDAO(dao sqlDAO) tagSQLDAO {
+	return tagSQLDAO{
+		sqlDAO: dao,
+		tasks:  taskSQLDAO{sqlDAO: dao},
+	}
+}
+
+func (dao *tagSQLDAO) ReadTags(ctx context.Context) ([]*model.Tag, error) {
+	query := fmt.Sprintf("SELECT %s FROM tags", tagColumns)
+	var params []any
+	if owner, scoped := model.OwnerScopeFromContext(ctx); scoped {
+		params = append(params, owner)
+		query += " WHERE owner_id = " + dao.bind(1)
+	}
+	query += " ORDER BY name, tag_uuid;"
+
+	rows, err := dao.connector.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query all tags : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	tags := make([]*model.Tag, 0)
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	// The collation of the database may not compare the names byte-wise.
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Name < tags[j].Name
+	})
+	return tags, nil
+}
+
+func (dao *tagSQLDAO) ReadTag(ctx context.Context, tagUUID uuid.UUID) (*model.Tag, error) {
+	ownerCondition, params := dao.ownerCondition(ctx, []any{tagUUID})
+	query := fmt.Sprintf("SELECT %s FROM tags WHERE tag_uuid = %s%s;", tagColumns, dao.bind(1), ownerCondition)
+	tag, err := scanTag(dao.connector.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no tag with this UUID (%s) exist : %w", tagUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+	return tag, nil
+}
+
+func (dao *tagSQLDAO) CreateTag(ctx context.Context, tagToCreate *model.TagCreateDTO) (*model.Tag, error) {
+	tag := tagToCreate.ReverseCreateDTO()
+	tag.OwnerID = model.OwnerFromContext(ctx)
+	tag.CreatedAt = sqlNow()
+	tag.LastUpdated = tag.CreatedAt
+
+	// The unique index on the owner and the name rejects a duplicate with a ConflictError.
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("INSERT INTO tags (%s) VALUES (%s, %s, %s, %s, %s);", tagColumns,
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5))
+		return dao.execOne(tx, query, tag.UUID, tag.Name, tag.OwnerID, tag.CreatedAt, tag.LastUpdated)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't insert the tag : %w", err)
+	}
+
+	return tag, nil
+}
+
+func (dao *tagSQLDAO) RenameTag(ctx context.Context, tagUUID uuid.UUID, name string) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := dao.tasks.lockTag(ctx, tx, tagUUID); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("UPDATE tags SET name = %s, last_updated = %s WHERE tag_uuid = %s;", dao.bind(1), dao.bind(2), dao.bind(3))
+		return dao.execOne(tx, query, name, sqlNow(), tagUUID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't rename the tag : %w", err)
+	}
+	return nil
+}
+
+func (dao *tagSQLDAO) DeleteTag(ctx context.Context, tagUUID uuid.UUID) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := dao.tasks.lockTag(ctx, tx, tagUUID); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("DELETE FROM task_tags WHERE tag_uuid = %s;", dao.bind(1))
+		if _, err := dao.connector.Exec(tx, query, tagUUID); err != nil {
+			return dao.dialect.wrapError(err)
+		}
+
+		query = fmt.Sprintf("DELETE FROM tags WHERE tag_uuid = %s;", dao.bind(1))
+		return dao.execOne(tx, query, tagUUID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't delete the tag : %w", err)
+	}
+	return nil
+}
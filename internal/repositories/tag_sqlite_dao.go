@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTagSQLiteDAO is an identifier to build TagSQLiteDAO.
+	TypeTagSQLiteDAO = "TagSQLiteDAO"
+)
+
+var _ ITagDAO = (*TagSQLiteDAO)(nil)
+
+// TagSQLiteDAO is a TagDAO storing the tags in the database of TaskSQLiteDAO.
+type TagSQLiteDAO struct {
+	tagSQLDAO
+}
+
+// factoryTagSQLiteDAO build TagSQLiteDAO.
+func factoryTagSQLiteDAO(opt DAOFactoryOptions) (*TagSQLiteDAO, error) {
+	connector, err := connectors.GetConnectorSQLite(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TagSQLiteDAO{
+		tagSQLDAO: newTagSQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       sqliteDialect,
+		}),
+	}, nil
+}
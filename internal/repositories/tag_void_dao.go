@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeTagVoidDAO is an identifier to build TagVoidDAO.
+	TypeTagVoidDAO = "TagVoidDAO"
+)
+
+var _ ITagDAO = (*TagVoidDAO)(nil)
+
+// TagVoidDAO is a TagDAO with not implemented features.
+type TagVoidDAO struct {
+	connectorName string
+}
+
+func (dao *TagVoidDAO) ReadTags(ctx context.Context) ([]*model.Tag, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TagVoidDAO) ReadTag(ctx context.Context, tagUUID uuid.UUID) (*model.Tag, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TagVoidDAO) CreateTag(ctx context.Context, tagToCreate *model.TagCreateDTO) (*model.Tag, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TagVoidDAO) RenameTag(ctx context.Context, tagUUID uuid.UUID, name string) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *TagVoidDAO) DeleteTag(ctx context.Context, tagUUID uuid.UUID) error {
+	return ErrFeatureNotImplemented
+}
+
+// factoryTagVoidDAO build TagVoidDAO.
+func factoryTagVoidDAO(opt DAOFactoryOptions) (*TagVoidDAO, error) {
+	return &TagVoidDAO{
+		connectorName: opt.Connector,
+	}, nil
+}
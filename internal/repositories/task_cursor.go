@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// taskCursor is the keyset position of the last task of a page.
+type taskCursor struct {
+	Sort  string    `json:"s"`
+	Order string    `json:"o"`
+	Value string    `json:"v"`
+	UUID  uuid.UUID `json:"id"`
+}
+
+// sortValue returns the value of the task for the sort field as stored in a cursor.
+func sortValue(task *model.Task, field string) string {
+	switch field {
+	case model.TaskSortLastUpdated:
+		return task.LastUpdated.UTC().Format(time.RFC3339Nano)
+	case model.TaskSortDescription:
+		return task.WhatToDo
+	case model.TaskSortStatus:
+		return string(task.Status)
+	default:
+		return task.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+// encodeTaskCursor builds the opaque cursor pointing after the task.
+func encodeTaskCursor(task *model.Task, filter *model.TaskFilterDTO) string {
+	raw, _ := json.Marshal(taskCursor{
+		Sort:  filter.Sort,
+		Order: filter.Order,
+		Value: sortValue(task, filter.Sort),
+		UUID:  task.UUID,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeTaskCursor parses the cursor of the filter, it returns nil if the filter has no cursor.
+// A cursor is only valid with the sort field and order it was built for.
+func decodeTaskCursor(filter *model.TaskFilterDTO) (*taskCursor, error) {
+	if filter.Cursor == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(filter.Cursor)
+	if err != nil {
+		return nil, &InvalidCursorError{Cursor: filter.Cursor}
+	}
+
+	cursor := new(taskCursor)
+	if err := json.Unmarshal(raw, cursor); err != nil {
+		return nil, &InvalidCursorError{Cursor: filter.Cursor}
+	}
+
+	if cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+		return nil, &InvalidCursorError{Cursor: filter.Cursor}
+	}
+
+	if isTimeSort(cursor.Sort) {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, &InvalidCursorError{Cursor: filter.Cursor}
+		}
+	}
+
+	return cursor, nil
+}
+
+// isTimeSort reports whether the sort field is a timestamp.
+func isTimeSort(field string) bool {
+	return field == model.TaskSortCreatedAt || field == model.TaskSortLastUpdated
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with a backslash.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestTaskCursorRoundTrip(t *testing.T) {
+	task := &model.Task{
+		UUID:        uuid.New(),
+		WhatToDo:    "water the plants",
+		Status:      "todo",
+		CreatedAt:   time.Date(2024, 3, 1, 10, 0, 0, 123456789, time.FixedZone("CET", 3600)),
+		LastUpdated: time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		sort  string
+		value string
+	}{
+		{sort: model.TaskSortCreatedAt, value: "2024-03-01T09:00:00.123456789Z"},
+		{sort: model.TaskSortLastUpdated, value: "2024-03-02T10:00:00Z"},
+		{sort: model.TaskSortDescription, value: "water the plants"},
+		{sort: model.TaskSortStatus, value: "todo"},
+	}
+
+	for _, tt := range tests {
+		for _, order := range []string{model.SortOrderAsc, model.SortOrderDesc} {
+			t.Run(tt.sort+" "+order, func(t *testing.T) {
+				filter := &model.TaskFilterDTO{Sort: tt.sort, Order: order}
+				filter.Cursor = encodeTaskCursor(task, filter)
+
+				cursor, err := decodeTaskCursor(filter)
+				if err != nil {
+					t.Fatalf("decodeTaskCursor() error = %v", err)
+				}
+				want := taskCursor{Sort: tt.sort, Order: order, Value: tt.value, UUID: task.UUID}
+				if *cursor != want {
+					t.Errorf("decodeTaskCursor() = %+v, want %+v", *cursor, want)
+				}
+			})
+		}
+	}
+}
+
+func TestDecodeTaskCursorEmpty(t *testing.T) {
+	cursor, err := decodeTaskCursor(&model.TaskFilterDTO{Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc})
+	if cursor != nil || err != nil {
+		t.Errorf("decodeTaskCursor() = %v, %v, want nil, nil", cursor, err)
+	}
+}
+
+func TestDecodeTaskCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	id := uuid.NewString()
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "not json", cursor: encode("created_at|asc")},
+		{name: "bad uuid", cursor: encode(`{"s":"created_at","o":"asc","v":"2024-03-01T09:00:00Z","id":"42"}`)},
+		{name: "other sort", cursor: encode(`{"s":"description","o":"asc","v":"a","id":"` + id + `"}`)},
+		{name: "other order", cursor: encode(`{"s":"created_at","o":"desc","v":"2024-03-01T09:00:00Z","id":"` + id + `"}`)},
+		{name: "bad time", cursor: encode(`{"s":"created_at","o":"asc","v":"yesterday","id":"` + id + `"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := &model.TaskFilterDTO{Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Cursor: tt.cursor}
+			_, err := decodeTaskCursor(filter)
+			var errInvalidCursor *InvalidCursorError
+			if !errors.As(err, &errInvalidCursor) {
+				t.Errorf("decodeTaskCursor() error = %v, want an InvalidCursorError", err)
+			}
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := escapeLike(`100%_done\`), `100\%\_done\\`; got != want {
+		t.Errorf("escapeLike() = %q, want %q", got, want)
+	}
+}
+
+// TestTaskInMemoryDAOReadAllPages walks the pages of every sort and checks they hold each task once, in order.
+func TestTaskInMemoryDAOReadAllPages(t *testing.T) {
+	dao, err := factoryTaskInMemoryDAO(DAOFactoryOptions{Type: TypeTaskInMemoryDAO})
+	if err != nil {
+		t.Fatalf("factoryTaskInMemoryDAO() error = %v", err)
+	}
+
+	ctx := context.Background()
+	// Several tasks share a description and a status, the UUID breaks the ties.
+	for i := 0; i < 11; i++ {
+		_, err := dao.Create(ctx, &model.TaskCreateDTO{
+			WhatToDo: fmt.Sprintf("task %d", i%4),
+			Status:   model.TaskStatus([]string{"todo", "done"}[i%2]),
+		})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	sorts := []string{model.TaskSortCreatedAt, model.TaskSortLastUpdated, model.TaskSortDescription, model.TaskSortStatus}
+	for _, sortField := range sorts {
+		for _, order := range []string{model.SortOrderAsc, model.SortOrderDesc} {
+			t.Run(sortField+" "+order, func(t *testing.T) {
+				all, _, err := dao.ReadAll(ctx, &model.TaskFilterDTO{Sort: sortField, Order: order, Limit: 100})
+				if err != nil {
+					t.Fatalf("ReadAll() error = %v", err)
+				}
+
+				filter := &model.TaskFilterDTO{Sort: sortField, Order: order, Limit: 3}
+				paged := make([]*model.Task, 0)
+				for pages := 0; ; pages++ {
+					if pages > len(all) {
+						t.Fatal("ReadAll() never returns the last page")
+					}
+					tasks, next, err := dao.ReadAll(ctx, filter)
+					if err != nil {
+						t.Fatalf("ReadAll() error = %v", err)
+					}
+					if next != "" && len(tasks) != filter.Limit {
+						t.Fatalf("ReadAll() returned %d tasks with a next cursor, want %d", len(tasks), filter.Limit)
+					}
+					paged = append(paged, tasks...)
+					if next == "" {
+						break
+					}
+					filter.Cursor = next
+				}
+
+				if len(paged) != len(all) {
+					t.Fatalf("pages hold %d tasks, want %d", len(paged), len(all))
+				}
+				for i := range all {
+					if paged[i].UUID != all[i].UUID {
+						t.Fatalf("task %d of the pages is %v, want %v", i, paged[i].UUID, all[i].UUID)
+					}
+				}
+			})
+		}
+	}
+}
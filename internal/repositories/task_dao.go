@@ -3,20 +3,60 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
 )
 
 // mapTaskDAO is used by ProxyFactoryTaskDAO to store TaskDAO.
 var mapTaskDAO = make(map[string]map[string]ITaskDAO)
 
 // ITaskDAO is a DAO interface to manage Task.
+// The tasks neither owned by nor shared with the principal of ctx aren't found, unless it is an admin.
+// The tasks read are returned with the names of their tags.
 type ITaskDAO interface {
-	Create(ctx context.Context) (*model.Task, error)
-	ReadByUUID(ctx context.Context) (*model.Task, error)
-	ReadAll(ctx context.Context) ([]*model.Task, error)
-	Update(ctx context.Context) error
-	Delete(ctx context.Context) error
+	Create(ctx context.Context, taskToCreate *model.TaskCreateDTO) (*model.Task, error)
+	ReadByUUID(ctx context.Context, taskUUID uuid.UUID) (*model.Task, error)
+	ReadAll(ctx context.Context, filter *model.TaskFilterDTO) ([]*model.Task, string, error)
+	// Search returns the live tasks visible by the principal of ctx whose description matches the full-text query,
+	// best ranked first. The language and the limit of the search must be set.
+	Search(ctx context.Context, search *model.TaskSearchDTO) ([]*model.TaskSearchResult, error)
+	// Update and Delete fail with a VersionMismatchError if version isn't the current version of the task, 0 skips the check.
+	Update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) error
+	// Delete moves the task to the trash, the tasks of the trash are only read by ReadAll with filter.Deleted.
+	// cascade tells what happens to its live subtasks, it fails with a HasSubtasksError if it restricts the deletion.
+	Delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error
+	// Restore moves a task out of the trash, with the subtasks deleted with it by a cascade delete,
+	// and returns the restored tasks, the task first.
+	Restore(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error)
+	// Purge definitively removes the tasks moved to the trash before deletedBefore and returns how many were removed.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// Remind marks the reminders of the live tasks due at remindBefore as fired, records them in the history
+	// and returns the reminded tasks. A reminder fires once until the remind date of the task changes.
+	Remind(ctx context.Context, remindBefore time.Time) ([]*model.Task, error)
+	// ReadHistory returns the events recorded by the changes of the task, oldest first, even once the task is purged.
+	// The actor of the events is taken from the principal of ctx.
+	ReadHistory(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskEvent, error)
+	// StopSeries removes the recurrence rule of the tasks of the series, in the trash too, and returns how many were stopped.
+	// Only the owner of the tasks stops a series.
+	StopSeries(ctx context.Context, seriesUUID uuid.UUID) (int64, error)
+	// ReadSubtree returns the live task and its live descendants visible by the principal of ctx, oldest first.
+	// The descendants of a task that isn't visible aren't returned.
+	ReadSubtree(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error)
+	// ReadAncestors returns the UUIDs of the ancestors of the task, in the trash too and whoever owns them.
+	ReadAncestors(ctx context.Context, taskUUID uuid.UUID) ([]uuid.UUID, error)
+	// ReadRole returns the role of the principal of ctx on the task, in the trash too.
+	// It is TaskRoleOwner for the owner of the task, an admin or without principal.
+	ReadRole(ctx context.Context, taskUUID uuid.UUID) (model.TaskRole, error)
+	// SaveTaskTag puts a tag of the principal of ctx on a live task, and DeleteTaskTag takes a tag off a live task.
+	// Both are updates of the task recorded in its history, they fail with a VersionMismatchError like Update.
+	SaveTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error
+	DeleteTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error
+	// Batch runs the creates, updates and deletes of the batch in order, each like Create, Update and Delete.
+	// With atomic, they succeed or fail together and the failure is a BatchOperationError,
+	// otherwise each operation has its own outcome in the result of the same index.
+	Batch(ctx context.Context, operations []*model.TaskBatchOperation, atomic bool) ([]*model.TaskBatchResult, error)
 }
 
 // ProxyFactoryTaskDAO uses FactoryTaskDAO if the TaskDAO don't exist, and returns TaskDAO.
@@ -57,6 +97,10 @@ func FactoryTaskDAO(opt DAOFactoryOptions) (ITaskDAO, error) {
 		dao, err = factoryTaskInMemoryDAO(opt)
 	case TypeTaskPostgresDAO:
 		dao, err = factoryTaskPostgresDAO(opt)
+	case TypeTaskMySQLDAO:
+		dao, err = factoryTaskMySQLDAO(opt)
+	case TypeTaskSQLiteDAO:
+		dao, err = factoryTaskSQLiteDAO(opt)
 	default:
 		return nil, &DAOTypeNotFoundError{Type: opt.Type}
 	}
@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+)
+
+func TestTaskDAORemind(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			dao, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+
+			alice := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "bob"})
+			now := time.Now().UTC().Truncate(time.Second)
+			past, future := now.Add(-time.Hour), now.Add(time.Hour)
+			create := func(ctx context.Context, whatToDo string, remindAt *time.Time) *model.Task {
+				t.Helper()
+				task, err := dao.Create(ctx, &model.TaskCreateDTO{WhatToDo: whatToDo, Status: "todo", RemindAt: remindAt})
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				return task
+			}
+
+			due := create(alice, "call the bank", &past)
+			dueOfBob := create(bob, "water the plants", &now)
+			create(alice, "book the flight", &future)
+			create(alice, "read a book", nil)
+			trashed := create(alice, "pay the rent", &past)
+			if err := dao.Delete(alice, trashed.UUID, trashed.Version, model.TaskDeleteRestrict); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+
+			// The reminders due of every owner fire, not the future, missing or trashed ones.
+			reminded, err := dao.Remind(context.Background(), now)
+			if err != nil || len(reminded) != 2 {
+				t.Fatalf("Remind() = %d tasks, %v, want 2", len(reminded), err)
+			}
+			for _, task := range reminded {
+				if task.UUID != due.UUID && task.UUID != dueOfBob.UUID {
+					t.Errorf("Remind() fired %q, want call the bank and water the plants", task.WhatToDo)
+				}
+				if task.RemindedAt == nil || task.RemindedAt.Before(now) || task.Version != 2 {
+					t.Errorf("reminded task = %+v, want RemindedAt now and version 2", task)
+				}
+			}
+			task, err := dao.ReadByUUID(alice, due.UUID)
+			if err != nil || task.RemindedAt == nil || task.Version != 2 {
+				t.Fatalf("ReadByUUID() = %+v, %v, want the reminder fired", task, err)
+			}
+			history, err := dao.ReadHistory(alice, due.UUID)
+			if err != nil || len(history) != 2 {
+				t.Fatalf("ReadHistory() = %d events, %v, want 2", len(history), err)
+			}
+			if event := history[1]; event.Type != model.TaskEventReminded || event.Actor != model.SystemActor || event.Version != 2 {
+				t.Errorf("event = %s by %s version %d, want reminded by %s version 2", event.Type, event.Actor, event.Version, model.SystemActor)
+			}
+
+			// A reminder fires once.
+			if reminded, err := dao.Remind(context.Background(), future.Add(time.Hour)); err != nil || len(reminded) != 1 || reminded[0].WhatToDo != "book the flight" {
+				t.Fatalf("Remind() after the first one = %d tasks, %v, want book the flight alone", len(reminded), err)
+			}
+
+			// Keeping the remind date leaves the reminder fired, changing it rearms the reminder.
+			if err := dao.Update(alice, due.UUID, &model.TaskPatch{RemindAt: &past}, 2); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if reminded, err := dao.Remind(context.Background(), now); err != nil || len(reminded) != 0 {
+				t.Fatalf("Remind() after the same date = %d tasks, %v, want 0", len(reminded), err)
+			}
+			later := past.Add(time.Minute)
+			if err := dao.Update(alice, due.UUID, &model.TaskPatch{RemindAt: &later}, 3); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if task, err := dao.ReadByUUID(alice, due.UUID); err != nil || task.RemindedAt != nil {
+				t.Fatalf("ReadByUUID() after a new date = %+v, %v, want the reminder rearmed", task, err)
+			}
+			if reminded, err := dao.Remind(context.Background(), now); err != nil || len(reminded) != 1 || reminded[0].UUID != due.UUID {
+				t.Errorf("Remind() after a new date = %d tasks, %v, want call the bank", len(reminded), err)
+			}
+		})
+	}
+}
+
+func TestTaskDAORestoreCascade(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			dao, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+
+			// root has the subtasks a, with a1, and b.
+			ctx := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			create := func(whatToDo string, parent *model.Task) *model.Task {
+				t.Helper()
+				taskToCreate := &model.TaskCreateDTO{WhatToDo: whatToDo, Status: "todo"}
+				if parent != nil {
+					taskToCreate.ParentUUID = &parent.UUID
+				}
+				task, err := dao.Create(ctx, taskToCreate)
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				return task
+			}
+			root := create("root", nil)
+			a := create("a", root)
+			a1 := create("a1", a)
+			b := create("b", root)
+			live := func(task *model.Task) *model.Task {
+				t.Helper()
+				got, err := dao.ReadByUUID(ctx, task.UUID)
+				if err != nil {
+					t.Fatalf("ReadByUUID(%s) error = %v, want it live", task.WhatToDo, err)
+				}
+				return got
+			}
+			trashed := func(task *model.Task) {
+				t.Helper()
+				if _, err := dao.ReadByUUID(ctx, task.UUID); !isNotFound(err) {
+					t.Fatalf("ReadByUUID(%s) error = %v, want it in the trash", task.WhatToDo, err)
+				}
+			}
+			restore := func(task *model.Task, want ...*model.Task) {
+				t.Helper()
+				restored, err := dao.Restore(ctx, task.UUID)
+				if err != nil || len(restored) != len(want) || restored[0].UUID != task.UUID {
+					t.Fatalf("Restore(%s) = %d tasks, %v, want %d, %s first", task.WhatToDo, len(restored), err, len(want), task.WhatToDo)
+				}
+				for _, wanted := range want {
+					found := false
+					for _, got := range restored {
+						found = found || (got.UUID == wanted.UUID && got.DeletedAt == nil)
+					}
+					if !found {
+						t.Errorf("Restore(%s) didn't restore %s", task.WhatToDo, wanted.WhatToDo)
+					}
+				}
+			}
+
+			// b deleted alone stays in the trash when the subtree deleted later is restored.
+			if err := dao.Delete(ctx, b.UUID, 0, model.TaskDeleteRestrict); err != nil {
+				t.Fatalf("Delete(b) error = %v", err)
+			}
+			time.Sleep(10 * time.Millisecond)
+			if err := dao.Delete(ctx, root.UUID, 0, model.TaskDeleteSubtree); err != nil {
+				t.Fatalf("Delete(root) error = %v", err)
+			}
+			trashed(a1)
+			restore(root, root, a, a1)
+			trashed(b)
+			if got := live(a1); got.ParentUUID == nil || *got.ParentUUID != a.UUID {
+				t.Errorf("restored a1 parent = %v, want a", got.ParentUUID)
+			}
+			history, err := dao.ReadHistory(ctx, a1.UUID)
+			if err != nil || len(history) != 3 || history[2].Type != model.TaskEventRestored || history[2].Actor != "alice" {
+				t.Errorf("ReadHistory(a1) = %d events, %v, want created, deleted, restored by alice", len(history), err)
+			}
+
+			// A subtask restored alone brings back its own subtree, its parent stays in the trash.
+			if err := dao.Delete(ctx, root.UUID, 0, model.TaskDeleteSubtree); err != nil {
+				t.Fatalf("Delete(root) error = %v", err)
+			}
+			restore(a, a, a1)
+			trashed(root)
+			restore(root, root)
+
+			// The subtasks detached by the deletion aren't in the trash.
+			if err := dao.Delete(ctx, a.UUID, 0, model.TaskDeleteDetach); err != nil {
+				t.Fatalf("Delete(a) error = %v", err)
+			}
+			if got := live(a1); got.ParentUUID != nil {
+				t.Errorf("detached a1 parent = %v, want none", got.ParentUUID)
+			}
+			restore(a, a)
+		})
+	}
+}
+
+func TestTaskDAOBatch(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			dao, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+
+			ctx := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			create := func(whatToDo string) *model.Task {
+				t.Helper()
+				task, err := dao.Create(ctx, &model.TaskCreateDTO{WhatToDo: whatToDo, Status: "todo"})
+				if err != nil {
+					t.Fatalf("Create(%s) error = %v", whatToDo, err)
+				}
+				return task
+			}
+			// live returns the sorted descriptions of the live tasks of the TaskDAO.
+			live := func(dao ITaskDAO) []string {
+				t.Helper()
+				tasks, _, err := dao.ReadAll(ctx, &model.TaskFilterDTO{Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Limit: 100})
+				if err != nil {
+					t.Fatalf("ReadAll() error = %v", err)
+				}
+				descriptions := make([]string, 0, len(tasks))
+				for _, task := range tasks {
+					descriptions = append(descriptions, task.WhatToDo)
+				}
+				sort.Strings(descriptions)
+				return descriptions
+			}
+			rename := func(whatToDo string) *model.TaskPatch {
+				return &model.TaskPatch{WhatToDo: &whatToDo}
+			}
+
+			a, b := create("a"), create("b")
+
+			// A failing operation of an atomic batch rolls back the operations run before it,
+			// here the update of b deleted by the previous operation.
+			_, err = dao.Batch(ctx, []*model.TaskBatchOperation{
+				{Op: model.TaskBatchCreate, Create: &model.TaskCreateDTO{WhatToDo: "c", Status: "todo"}},
+				{Op: model.TaskBatchUpdate, TaskUUID: a.UUID, Patch: rename("a2"), Version: 1},
+				{Op: model.TaskBatchDelete, TaskUUID: b.UUID, Version: 1, Cascade: model.TaskDeleteRestrict},
+				{Op: model.TaskBatchUpdate, TaskUUID: b.UUID, Patch: rename("b2")},
+			}, true)
+			var errBatchOperation *BatchOperationError
+			if !errors.As(err, &errBatchOperation) || errBatchOperation.Index != 3 || !isNotFound(err) {
+				t.Fatalf("Batch() error = %v, want the operation 3 not found", err)
+			}
+			if got := live(dao); !reflect.DeepEqual(got, []string{"a", "b"}) {
+				t.Errorf("tasks after the rollback = %v, want a and b", got)
+			}
+			if task, err := dao.ReadByUUID(ctx, a.UUID); err != nil || task.Version != 1 {
+				t.Errorf("ReadByUUID(a) after the rollback = %+v, %v, want version 1", task, err)
+			}
+			if history, err := dao.ReadHistory(ctx, a.UUID); err != nil || len(history) != 1 {
+				t.Errorf("ReadHistory(a) after the rollback = %d events, %v, want 1", len(history), err)
+			}
+
+			// An atomic batch without failure runs every operation.
+			results, err := dao.Batch(ctx, []*model.TaskBatchOperation{
+				{Op: model.TaskBatchCreate, Create: &model.TaskCreateDTO{WhatToDo: "c", Status: "todo"}},
+				{Op: model.TaskBatchUpdate, TaskUUID: a.UUID, Patch: rename("a2"), Version: 1},
+				{Op: model.TaskBatchDelete, TaskUUID: b.UUID, Version: 1, Cascade: model.TaskDeleteRestrict},
+			}, true)
+			if err != nil || len(results) != 3 {
+				t.Fatalf("Batch() = %d results, %v, want 3", len(results), err)
+			}
+			if results[0].Task == nil || results[0].Task.WhatToDo != "c" || results[1].Task == nil || results[1].Task.Version != 2 || results[2].Task != nil {
+				t.Errorf("Batch() results = %+v %+v %+v, want c created, a version 2 and b deleted", results[0], results[1], results[2])
+			}
+			if got := live(dao); !reflect.DeepEqual(got, []string{"a2", "c"}) {
+				t.Errorf("tasks after the batch = %v, want a2 and c", got)
+			}
+
+			// A best-effort batch keeps the operations which don't fail.
+			results, err = dao.Batch(ctx, []*model.TaskBatchOperation{
+				{Op: model.TaskBatchUpdate, TaskUUID: a.UUID, Patch: rename("a3"), Version: 1},
+				{Op: model.TaskBatchCreate, Create: &model.TaskCreateDTO{WhatToDo: "d", Status: "todo"}},
+				{Op: model.TaskBatchDelete, TaskUUID: b.UUID},
+				{Op: model.TaskBatchUpdate, TaskUUID: a.UUID, Patch: rename("a3"), Version: 2},
+			}, false)
+			if err != nil || len(results) != 4 {
+				t.Fatalf("Batch() = %d results, %v, want 4", len(results), err)
+			}
+			var errVersionMismatch *VersionMismatchError
+			if !errors.As(results[0].Err, &errVersionMismatch) || errVersionMismatch.Current != 2 {
+				t.Errorf("result 0 error = %v, want a VersionMismatchError at version 2", results[0].Err)
+			}
+			if results[1].Err != nil || results[1].Task == nil || results[1].Task.WhatToDo != "d" {
+				t.Errorf("result 1 = %+v, want d created", results[1])
+			}
+			if !isNotFound(results[2].Err) {
+				t.Errorf("result 2 error = %v, want not found", results[2].Err)
+			}
+			if results[3].Err != nil || results[3].Task == nil || results[3].Task.Version != 3 {
+				t.Errorf("result 3 = %+v, want a version 3", results[3])
+			}
+			want := []string{"a3", "c", "d"}
+			if got := live(dao); !reflect.DeepEqual(got, want) {
+				t.Errorf("tasks after the best-effort batch = %v, want %v", got, want)
+			}
+
+			// The in-memory DAO reads the batches back from its file.
+			if backend.taskDAO.Type == TypeTaskInMemoryDAO {
+				reloaded, err := factoryTaskInMemoryDAO(backend.taskDAO)
+				if err != nil {
+					t.Fatalf("factoryTaskInMemoryDAO() error = %v", err)
+				}
+				if got := live(reloaded); !reflect.DeepEqual(got, want) {
+					t.Errorf("reloaded tasks = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
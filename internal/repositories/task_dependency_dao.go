@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// mapTaskDependencyDAO is used by ProxyFactoryTaskDependencyDAO to store TaskDependencyDAO.
+var mapTaskDependencyDAO = make(map[string]map[string]ITaskDependencyDAO)
+
+// taskDependencyDAOTypes maps the types of TaskDAO to the type of TaskDependencyDAO storing the dependencies next to their tasks.
+var taskDependencyDAOTypes = map[string]string{
+	TypeTaskVoidDAO:     TypeTaskDependencyVoidDAO,
+	TypeTaskInMemoryDAO: TypeTaskDependencyInMemoryDAO,
+	TypeTaskPostgresDAO: TypeTaskDependencyPostgresDAO,
+	TypeTaskMySQLDAO:    TypeTaskDependencyMySQLDAO,
+	TypeTaskSQLiteDAO:   TypeTaskDependencySQLiteDAO,
+}
+
+// ITaskDependencyDAO is a DAO interface to manage the TaskDependency blocking the tasks by other tasks.
+// The dependencies are stored next to the tasks of the TaskDAO of the same connector, which removes them with their tasks.
+type ITaskDependencyDAO interface {
+	// ReadDependencyGraph returns the live task, if it is visible by the principal of ctx, with its transitive blockers
+	// and the edges between them. The edges go through every task, only the live and visible tasks are returned.
+	ReadDependencyGraph(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, []*model.TaskDependency, error)
+	// ReadBlockers returns the live tasks blocking the task, whoever owns them.
+	ReadBlockers(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error)
+	// SaveDependency blocks a live task by another task, it replaces the previous edge between them.
+	// It sets the CreatedAt of the dependency and fails with a DependencyCycleError if the blocker is the task
+	// or is blocked by it, even transitively, checked in the same write.
+	SaveDependency(ctx context.Context, dependency *model.TaskDependency) error
+	// DeleteDependency removes the edge between the task and its blocker.
+	DeleteDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) error
+}
+
+// TaskDependencyDAOOptions returns the options of the TaskDependencyDAO storing the dependencies next to the tasks of the TaskDAO of taskDAO.
+func TaskDependencyDAOOptions(taskDAO DAOFactoryOptions) DAOFactoryOptions {
+	return DAOFactoryOptions{Type: taskDependencyDAOTypes[taskDAO.Type], Connector: taskDAO.Connector}
+}
+
+// ProxyFactoryTaskDependencyDAO uses FactoryTaskDependencyDAO if the TaskDependencyDAO don't exist, and returns TaskDependencyDAO.
+func ProxyFactoryTaskDependencyDAO(opt DAOFactoryOptions) (ITaskDependencyDAO, error) {
+	// Test if exist
+	mapConnector, mapExist := mapTaskDependencyDAO[opt.Type]
+	if mapExist {
+		daoTaskDependency, present := mapConnector[opt.Connector]
+		if present {
+			return daoTaskDependency, nil
+		}
+	}
+
+	// Build new TaskDependencyDAO
+	daoTaskDependency, err := FactoryTaskDependencyDAO(opt)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build new TaskDependencyDAO: %w", err)
+	}
+
+	// Save new TaskDependencyDAO
+	if !mapExist {
+		mapTaskDependencyDAO[opt.Type] = make(map[string]ITaskDependencyDAO)
+	}
+	mapTaskDependencyDAO[opt.Type][opt.Connector] = daoTaskDependency
+
+	return daoTaskDependency, nil
+}
+
+// FactoryTaskDependencyDAO builds a new TaskDependencyDAO according to the typename.
+func FactoryTaskDependencyDAO(opt DAOFactoryOptions) (ITaskDependencyDAO, error) {
+	var dao ITaskDependencyDAO
+	var err error
+
+	switch opt.Type {
+	case TypeTaskDependencyVoidDAO:
+		dao, err = factoryTaskDependencyVoidDAO(opt)
+	case TypeTaskDependencyInMemoryDAO:
+		dao, err = factoryTaskDependencyInMemoryDAO(opt)
+	case TypeTaskDependencyPostgresDAO:
+		dao, err = factoryTaskDependencyPostgresDAO(opt)
+	case TypeTaskDependencyMySQLDAO:
+		dao, err = factoryTaskDependencyMySQLDAO(opt)
+	case TypeTaskDependencySQLiteDAO:
+		dao, err = factoryTaskDependencySQLiteDAO(opt)
+	default:
+		return nil, &DAOTypeNotFoundError{Type: opt.Type}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to build %v: %w", opt.Type, err)
+	}
+
+	return dao, nil
+}
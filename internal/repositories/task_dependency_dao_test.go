@@ -0,0 +1,220 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestTaskDependencyDAOOptions(t *testing.T) {
+	for taskType, dependencyType := range taskDependencyDAOTypes {
+		got := TaskDependencyDAOOptions(DAOFactoryOptions{Type: taskType, Connector: "db"})
+		if got.Type != dependencyType || got.Connector != "db" {
+			t.Errorf("TaskDependencyDAOOptions(%s) = %+v, want %s on db", taskType, got, dependencyType)
+		}
+	}
+
+	var errDAOTypeNotFound *DAOTypeNotFoundError
+	if _, err := FactoryTaskDependencyDAO(TaskDependencyDAOOptions(DAOFactoryOptions{Type: "TaskRedisDAO"})); !errors.As(err, &errDAOTypeNotFound) {
+		t.Errorf("FactoryTaskDependencyDAO() of an unknown type error = %v, want a DAOTypeNotFoundError", err)
+	}
+}
+
+func TestTaskDependencyDAO(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			daoTask, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+			daoDependency, err := ProxyFactoryTaskDependencyDAO(TaskDependencyDAOOptions(backend.taskDAO))
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDependencyDAO() error = %v", err)
+			}
+
+			alice := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "bob"})
+			create := func(ctx context.Context, whatToDo string) *model.Task {
+				t.Helper()
+				task, err := daoTask.Create(ctx, &model.TaskCreateDTO{WhatToDo: whatToDo, Status: "todo"})
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				return task
+			}
+			save := func(ctx context.Context, task, blocker *model.Task, createdBy string) {
+				t.Helper()
+				dependency := &model.TaskDependency{TaskUUID: task.UUID, BlockerUUID: blocker.UUID, CreatedBy: createdBy}
+				if err := daoDependency.SaveDependency(ctx, dependency); err != nil {
+					t.Fatalf("SaveDependency(%s, %s) error = %v", task.WhatToDo, blocker.WhatToDo, err)
+				}
+				if time.Since(dependency.CreatedAt) > time.Minute {
+					t.Errorf("SaveDependency() CreatedAt = %v, want now", dependency.CreatedAt)
+				}
+			}
+
+			// release is blocked by tests, blocked by the review of bob, blocked by the draft.
+			release := create(alice, "release")
+			tests := create(alice, "run the tests")
+			review := create(bob, "review the code")
+			draft := create(alice, "write a draft")
+			save(alice, release, tests, "alice")
+			save(alice, tests, review, "alice")
+			save(bob, review, draft, "bob")
+
+			// The edges go through the tasks of bob, which alice doesn't see.
+			tasks, edges, err := daoDependency.ReadDependencyGraph(alice, release.UUID)
+			if err != nil {
+				t.Fatalf("ReadDependencyGraph() error = %v", err)
+			}
+			if len(tasks) != 3 || tasks[0].UUID != release.UUID || tasks[1].UUID != tests.UUID || tasks[2].UUID != draft.UUID {
+				t.Errorf("ReadDependencyGraph() tasks = %d, want release, tests and draft", len(tasks))
+			}
+			if len(edges) != 3 {
+				t.Fatalf("ReadDependencyGraph() edges = %d, want 3", len(edges))
+			}
+			for _, edge := range edges {
+				if edge.TaskUUID == review.UUID && (edge.BlockerUUID != draft.UUID || edge.CreatedBy != "bob") {
+					t.Errorf("edge of the review = %+v, want blocked by the draft, created by bob", edge)
+				}
+			}
+			if _, _, err := daoDependency.ReadDependencyGraph(bob, release.UUID); !isNotFound(err) {
+				t.Errorf("ReadDependencyGraph() by bob error = %v, want not found", err)
+			}
+
+			// The blockers are read whoever owns them, and not once in the trash.
+			if blockers, err := daoDependency.ReadBlockers(alice, tests.UUID); err != nil || len(blockers) != 1 || blockers[0].UUID != review.UUID {
+				t.Errorf("ReadBlockers() = %d tasks, %v, want the review", len(blockers), err)
+			}
+			if err := daoTask.Delete(bob, review.UUID, 0, model.TaskDeleteRestrict); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if blockers, err := daoDependency.ReadBlockers(alice, tests.UUID); err != nil || len(blockers) != 0 {
+				t.Errorf("ReadBlockers() of a trashed blocker = %d tasks, %v, want none", len(blockers), err)
+			}
+
+			// A new edge replaces the previous one between the tasks.
+			save(alice, release, tests, "carol")
+			_, edges, err = daoDependency.ReadDependencyGraph(alice, release.UUID)
+			if err != nil || len(edges) != 3 {
+				t.Fatalf("ReadDependencyGraph() after the replacement = %d edges, %v, want 3", len(edges), err)
+			}
+			for _, edge := range edges {
+				if edge.TaskUUID == release.UUID && edge.CreatedBy != "carol" {
+					t.Errorf("edge of the release = %+v, want created by carol", edge)
+				}
+			}
+
+			// The edges are only saved on the live tasks visible by the caller.
+			if err := daoDependency.SaveDependency(alice, &model.TaskDependency{TaskUUID: uuid.New(), BlockerUUID: tests.UUID}); !isNotFound(err) {
+				t.Errorf("SaveDependency() on an unknown task error = %v, want not found", err)
+			}
+			if err := daoDependency.SaveDependency(bob, &model.TaskDependency{TaskUUID: release.UUID, BlockerUUID: draft.UUID}); !isNotFound(err) {
+				t.Errorf("SaveDependency() by a stranger error = %v, want not found", err)
+			}
+			if err := daoDependency.SaveDependency(bob, &model.TaskDependency{TaskUUID: review.UUID, BlockerUUID: draft.UUID}); !isNotFound(err) {
+				t.Errorf("SaveDependency() on a trashed task error = %v, want not found", err)
+			}
+
+			if err := daoDependency.DeleteDependency(alice, release.UUID, tests.UUID); err != nil {
+				t.Fatalf("DeleteDependency() error = %v", err)
+			}
+			if err := daoDependency.DeleteDependency(alice, release.UUID, tests.UUID); !isNotFound(err) {
+				t.Errorf("DeleteDependency() of a missing edge error = %v, want not found", err)
+			}
+			if _, edges, _ := daoDependency.ReadDependencyGraph(alice, release.UUID); len(edges) != 0 {
+				t.Errorf("ReadDependencyGraph() after the removal = %d edges, want none", len(edges))
+			}
+
+			// The edges leave with their purged tasks, on both ends.
+			if _, err := daoTask.Purge(context.Background(), time.Now().Add(time.Second)); err != nil {
+				t.Fatalf("Purge() error = %v", err)
+			}
+			if _, edges, err := daoDependency.ReadDependencyGraph(alice, tests.UUID); err != nil || len(edges) != 0 {
+				t.Errorf("ReadDependencyGraph() after the purge = %d edges, %v, want none", len(edges), err)
+			}
+		})
+	}
+}
+
+func TestTaskDependencyDAOCycle(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			daoTask, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+			daoDependency, err := ProxyFactoryTaskDependencyDAO(TaskDependencyDAOOptions(backend.taskDAO))
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDependencyDAO() error = %v", err)
+			}
+
+			alice := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "bob"})
+			create := func(ctx context.Context, whatToDo string) *model.Task {
+				t.Helper()
+				task, err := daoTask.Create(ctx, &model.TaskCreateDTO{WhatToDo: whatToDo, Status: "todo"})
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				return task
+			}
+			save := func(ctx context.Context, task, blocker *model.Task) error {
+				return daoDependency.SaveDependency(ctx, &model.TaskDependency{TaskUUID: task.UUID, BlockerUUID: blocker.UUID})
+			}
+
+			// release is blocked by the tests, blocked by the review of bob.
+			release := create(alice, "release")
+			tests := create(alice, "run the tests")
+			review := create(bob, "review the code")
+			if err := save(alice, release, tests); err != nil {
+				t.Fatalf("SaveDependency() error = %v", err)
+			}
+			if err := save(alice, tests, review); err != nil {
+				t.Fatalf("SaveDependency() error = %v", err)
+			}
+
+			var errCycle *DependencyCycleError
+			if err := save(alice, release, release); !errors.As(err, &errCycle) {
+				t.Errorf("SaveDependency() of a task on itself error = %v, want a DependencyCycleError", err)
+			}
+			if err := save(alice, tests, release); !errors.As(err, &errCycle) {
+				t.Errorf("SaveDependency() on a task it blocks error = %v, want a DependencyCycleError", err)
+			}
+			// The cycle goes through the tasks the caller doesn't see.
+			if err := save(bob, review, release); !errors.As(err, &errCycle) {
+				t.Errorf("SaveDependency() on a task it blocks transitively error = %v, want a DependencyCycleError", err)
+			} else if errCycle.UUID != review.UUID || errCycle.BlockerUUID != release.UUID {
+				t.Errorf("DependencyCycleError = %+v, want the review blocked by the release", errCycle)
+			}
+
+			// Two tasks blocked by each other at the same time: one of the edges is refused.
+			first, second := create(alice, "first"), create(alice, "second")
+			errs := make(chan error, 2)
+			var wg sync.WaitGroup
+			for _, edge := range [][2]*model.Task{{first, second}, {second, first}} {
+				wg.Add(1)
+				go func(task, blocker *model.Task) {
+					defer wg.Done()
+					errs <- save(alice, task, blocker)
+				}(edge[0], edge[1])
+			}
+			wg.Wait()
+			close(errs)
+			saved := 0
+			for err := range errs {
+				if err == nil {
+					saved++
+				}
+			}
+			if saved != 1 {
+				t.Errorf("concurrent SaveDependency() in both directions saved %d edges, want 1", saved)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeTaskDependencyInMemoryDAO is an identifier to build TaskDependencyInMemoryDAO.
+	TypeTaskDependencyInMemoryDAO = "TaskDependencyInMemoryDAO"
+)
+
+var _ ITaskDependencyDAO = (*TaskDependencyInMemoryDAO)(nil)
+
+// TaskDependencyInMemoryDAO is a TaskDependencyDAO keeping the dependencies in the TaskInMemoryDAO of the same connector.
+// The dependencies are appended to its log file and removed with their tasks.
+type TaskDependencyInMemoryDAO struct {
+	tasks *TaskInMemoryDAO
+}
+
+func (dao *TaskDependencyInMemoryDAO) ReadDependencyGraph(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, []*model.TaskDependency, error) {
+	dao.tasks.mu.RLock()
+	defer dao.tasks.mu.RUnlock()
+
+	root, exist := dao.tasks.tasks[taskUUID]
+	if !exist || root.DeletedAt != nil || !dao.tasks.visible(ctx, taskUUID, root.OwnerID) {
+		return nil, nil, fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+
+	edges := make([]*model.TaskDependency, 0)
+	tasks := []*model.Task{dao.tasks.readTask(root)}
+	seen := map[uuid.UUID]bool{taskUUID: true}
+	for blocked := []uuid.UUID{taskUUID}; len(blocked) > 0; blocked = blocked[1:] {
+		for blockerUUID, dependency := range dao.tasks.dependencies[blocked[0]] {
+			dependencyCopy := *dependency
+			edges = append(edges, &dependencyCopy)
+			if seen[blockerUUID] {
+				continue
+			}
+			seen[blockerUUID] = true
+			blocked = append(blocked, blockerUUID)
+
+			if blocker, exist := dao.tasks.tasks[blockerUUID]; exist && blocker.DeletedAt == nil && dao.tasks.visible(ctx, blockerUUID, blocker.OwnerID) {
+				tasks = append(tasks, dao.tasks.readTask(blocker))
+			}
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return compareTasks(tasks[i], tasks[j], model.TaskSortCreatedAt) < 0
+	})
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].TaskUUID != edges[j].TaskUUID {
+			return edges[i].TaskUUID.String() < edges[j].TaskUUID.String()
+		}
+		return edges[i].BlockerUUID.String() < edges[j].BlockerUUID.String()
+	})
+	return tasks, edges, nil
+}
+
+func (dao *TaskDependencyInMemoryDAO) ReadBlockers(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	dao.tasks.mu.RLock()
+	defer dao.tasks.mu.RUnlock()
+
+	blockers := make([]*model.Task, 0, len(dao.tasks.dependencies[taskUUID]))
+	for blockerUUID := range dao.tasks.dependencies[taskUUID] {
+		if blocker, exist := dao.tasks.tasks[blockerUUID]; exist && blocker.DeletedAt == nil {
+			blockers = append(blockers, copyTask(blocker))
+		}
+	}
+
+	sort.Slice(blockers, func(i, j int) bool {
+		return compareTasks(blockers[i], blockers[j], model.TaskSortCreatedAt) < 0
+	})
+	return blockers, nil
+}
+
+func (dao *TaskDependencyInMemoryDAO) SaveDependency(ctx context.Context, dependency *model.TaskDependency) error {
+	dao.tasks.mu.Lock()
+	defer dao.tasks.mu.Unlock()
+
+	if _, err := dao.tasks.liveTask(ctx, dependency.TaskUUID, 0); err != nil {
+		return err
+	}
+	if dao.blocks(dependency.TaskUUID, dependency.BlockerUUID) {
+		return &DependencyCycleError{UUID: dependency.TaskUUID, BlockerUUID: dependency.BlockerUUID}
+	}
+
+	dependency.CreatedAt = time.Now()
+	dependencyCopy := *dependency
+	return dao.tasks.apply(taskRecord{Op: recordOpDepend, UUID: dependency.TaskUUID, Dependency: &dependencyCopy})
+}
+
+func (dao *TaskDependencyInMemoryDAO) DeleteDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) error {
+	dao.tasks.mu.Lock()
+	defer dao.tasks.mu.Unlock()
+
+	dependency, exist := dao.tasks.dependencies[taskUUID][blockerUUID]
+	if !exist {
+		return fmt.Errorf("no dependency of the task %s on %s : %w", taskUUID.String(), blockerUUID.String(), &NoDataFoundError{})
+	}
+
+	return dao.tasks.apply(taskRecord{Op: recordOpUndepend, UUID: taskUUID, Dependency: dependency})
+}
+
+// blocks tells whether the task blocks the other task, itself included, going through every task. The caller holds the lock.
+func (dao *TaskDependencyInMemoryDAO) blocks(taskUUID, otherUUID uuid.UUID) bool {
+	seen := map[uuid.UUID]bool{otherUUID: true}
+	for blocked := []uuid.UUID{otherUUID}; len(blocked) > 0; blocked = blocked[1:] {
+		if blocked[0] == taskUUID {
+			return true
+		}
+		for blockerUUID := range dao.tasks.dependencies[blocked[0]] {
+			if !seen[blockerUUID] {
+				seen[blockerUUID] = true
+				blocked = append(blocked, blockerUUID)
+			}
+		}
+	}
+	return false
+}
+
+// factoryTaskDependencyInMemoryDAO build TaskDependencyInMemoryDAO on the TaskInMemoryDAO of the connector.
+func factoryTaskDependencyInMemoryDAO(opt DAOFactoryOptions) (*TaskDependencyInMemoryDAO, error) {
+	daoTask, err := ProxyFactoryTaskDAO(DAOFactoryOptions{Type: TypeTaskInMemoryDAO, Connector: opt.Connector})
+	if err != nil {
+		return nil, fmt.Errorf("fail to get TaskInMemoryDAO: %w", err)
+	}
+
+	return &TaskDependencyInMemoryDAO{
+		tasks: daoTask.(*TaskInMemoryDAO),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTaskDependencyMySQLDAO is an identifier to build TaskDependencyMySQLDAO.
+	TypeTaskDependencyMySQLDAO = "TaskDependencyMySQLDAO"
+)
+
+var _ ITaskDependencyDAO = (*TaskDependencyMySQLDAO)(nil)
+
+// TaskDependencyMySQLDAO is a TaskDependencyDAO storing the dependencies in the database of TaskMySQLDAO.
+type TaskDependencyMySQLDAO struct {
+	taskDependencySQLDAO
+}
+
+// factoryTaskDependencyMySQLDAO build TaskDependencyMySQLDAO.
+func factoryTaskDependencyMySQLDAO(opt DAOFactoryOptions) (*TaskDependencyMySQLDAO, error) {
+	connector, err := connectors.GetConnectorMySQL(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskDependencyMySQLDAO{
+		taskDependencySQLDAO: newTaskDependencySQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       mysqlDialect,
+		}),
+	}, nil
+}
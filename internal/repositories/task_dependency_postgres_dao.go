@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTaskDependencyPostgresDAO is an identifier to build TaskDependencyPostgresDAO.
+	TypeTaskDependencyPostgresDAO = "TaskDependencyPostgresDAO"
+)
+
+var _ ITaskDependencyDAO = (*TaskDependencyPostgresDAO)(nil)
+
+// TaskDependencyPostgresDAO is a TaskDependencyDAO storing the dependencies in the database of TaskPostgresDAO.
+type TaskDependencyPostgresDAO struct {
+	taskDependencySQLDAO
+}
+
+// factoryTaskDependencyPostgresDAO build TaskDependencyPostgresDAO.
+func factoryTaskDependencyPostgresDAO(opt DAOFactoryOptions) (*TaskDependencyPostgresDAO, error) {
+	connector, err := connectors.GetConnectorPostgres(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskDependencyPostgresDAO{
+		taskDependencySQLDAO: newTaskDependencySQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       postgresDialect,
+		}),
+	}, nil
+}
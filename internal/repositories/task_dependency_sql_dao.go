@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// taskDependencyColumns are the columns of the task_dependencies table, in order.
+const taskDependencyColumns = "task_uuid, blocker_uuid, created_by, created_at"
+
+// taskDependencySQLDAO implements ITaskDependencyDAO with portable SQL, in the task_dependencies table of the database of the taskSQLDAO.
+type taskDependencySQLDAO struct {
+	sqlDAO
+	// tasks reads the tasks of the dependencies and locks the task of a dependency in the transaction saving it.
+	tasks taskSQLDAO
+}
+
+// newTaskDependencySQLDAO returns a taskDependencySQLDAO on the connection of dao.
+func newTaskDependencySQLDAO(dao sqlDAO) taskDependencySQLDAO {
+	return taskDependencySQLDAO{
+		sqlDAO: dao,
+		tasks:  taskSQLDAO{sqlDAO: dao},
+	}
+}
+
+func (dao *taskDependencySQLDAO) ReadDependencyGraph(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, []*model.TaskDependency, error) {
+	if _, err := dao.tasks.ReadByUUID(ctx, taskUUID); err != nil {
+		return nil, nil, err
+	}
+
+	// UNION stops the recursion on a cycle written by concurrent edges.
+	query := fmt.Sprintf(`WITH RECURSIVE graph (task_uuid, blocker_uuid, created_by, created_at) AS (
+		SELECT %s FROM task_dependencies WHERE task_uuid = %s
+		UNION
+		SELECT d.task_uuid, d.blocker_uuid, d.created_by, d.created_at FROM task_dependencies d JOIN graph g ON d.task_uuid = g.blocker_uuid
+	) SELECT %s FROM graph ORDER BY task_uuid, blocker_uuid;`, taskDependencyColumns, dao.bind(1), taskDependencyColumns)
+	rows, err := dao.connector.QueryContext(ctx, query, taskUUID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't query the dependencies : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	edges := make([]*model.TaskDependency, 0)
+	for rows.Next() {
+		edge := new(model.TaskDependency)
+		if err := rows.Scan(&edge.TaskUUID, &edge.BlockerUUID, &edge.CreatedBy, &edge.CreatedAt); err != nil {
+			return nil, nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		edges = append(edges, edge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	params := []any{taskUUID}
+	placeholders := []string{dao.bind(1)}
+	for _, edge := range edges {
+		params = append(params, edge.BlockerUUID)
+		placeholders = append(placeholders, dao.bind(len(params)))
+	}
+	visibleCondition, params := dao.tasks.visibleCondition(ctx, params)
+	query = fmt.Sprintf("SELECT %s FROM tasks WHERE task_uuid IN (%s) AND deleted_at IS NULL%s ORDER BY created_at, task_uuid;",
+		taskColumns, strings.Join(placeholders, ", "), visibleCondition)
+	tasks, err := dao.tasks.queryTasks(ctx, dao.connector, query, params...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't query the blockers : %w", err)
+	}
+	if err := dao.tasks.readTaskTags(ctx, dao.connector, tasks); err != nil {
+		return nil, nil, fmt.Errorf("can't query the tags : %w", err)
+	}
+
+	return tasks, edges, nil
+}
+
+func (dao *taskDependencySQLDAO) ReadBlockers(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE task_uuid IN (SELECT blocker_uuid FROM task_dependencies WHERE task_uuid = %s) AND deleted_at IS NULL ORDER BY created_at, task_uuid;",
+		taskColumns, dao.bind(1))
+	tasks, err := dao.tasks.queryTasks(ctx, dao.connector, query, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the blockers : %w", err)
+	}
+	return tasks, nil
+}
+
+func (dao *taskDependencySQLDAO) SaveDependency(ctx context.Context, dependency *model.TaskDependency) error {
+	dependency.CreatedAt = sqlNow()
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		// The locks of both tasks, taken in the same order by every write, serialize the dependencies
+		// which could close a cycle: the check below sees the edges committed before.
+		query := fmt.Sprintf("SELECT task_uuid FROM tasks WHERE task_uuid IN (%s, %s) ORDER BY task_uuid%s;", dao.bind(1), dao.bind(2), dao.dialect.lockClause)
+		rows, err := tx.QueryContext(ctx, query, dependency.TaskUUID, dependency.BlockerUUID)
+		if err != nil {
+			return dao.dialect.wrapError(err)
+		}
+		if err := rows.Close(); err != nil {
+			return dao.dialect.wrapError(err)
+		}
+		if _, err := dao.tasks.lockTask(ctx, tx, dependency.TaskUUID, false, 0); err != nil {
+			return err
+		}
+
+		cycle, err := dao.blocks(ctx, tx, dependency.TaskUUID, dependency.BlockerUUID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return &DependencyCycleError{UUID: dependency.TaskUUID, BlockerUUID: dependency.BlockerUUID}
+		}
+
+		query = fmt.Sprintf("DELETE FROM task_dependencies WHERE task_uuid = %s AND blocker_uuid = %s;", dao.bind(1), dao.bind(2))
+		if _, err := dao.connector.Exec(tx, query, dependency.TaskUUID, dependency.BlockerUUID); err != nil {
+			return dao.dialect.wrapError(err)
+		}
+
+		query = fmt.Sprintf("INSERT INTO task_dependencies (%s) VALUES (%s, %s, %s, %s);", taskDependencyColumns,
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4))
+		return dao.execOne(tx, query, dependency.TaskUUID, dependency.BlockerUUID, dependency.CreatedBy, dependency.CreatedAt)
+	})
+	if err != nil {
+		return fmt.Errorf("can't save the dependency : %w", err)
+	}
+	return nil
+}
+
+// blocks tells whether the task blocks the other task, itself included, in the transaction going through every task.
+func (dao *taskDependencySQLDAO) blocks(ctx context.Context, tx *sql.Tx, taskUUID, otherUUID uuid.UUID) (bool, error) {
+	if taskUUID == otherUUID {
+		return true, nil
+	}
+
+	query := fmt.Sprintf(`WITH RECURSIVE blockers (node_uuid) AS (
+		SELECT blocker_uuid FROM task_dependencies WHERE task_uuid = %s
+		UNION
+		SELECT d.blocker_uuid FROM task_dependencies d JOIN blockers b ON d.task_uuid = b.node_uuid
+	) SELECT EXISTS (SELECT 1 FROM blockers WHERE node_uuid = %s);`, dao.bind(1), dao.bind(2))
+	var exist bool
+	if err := tx.QueryRowContext(ctx, query, otherUUID, taskUUID).Scan(&exist); err != nil {
+		return false, fmt.Errorf("can't query the blockers : %w", dao.dialect.wrapError(err))
+	}
+	return exist, nil
+}
+
+func (dao *taskDependencySQLDAO) DeleteDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("DELETE FROM task_dependencies WHERE task_uuid = %s AND blocker_uuid = %s;", dao.bind(1), dao.bind(2))
+		return dao.execOne(tx, query, taskUUID, blockerUUID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't delete the dependency : %w", err)
+	}
+	return nil
+}
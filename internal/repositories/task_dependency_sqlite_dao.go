@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTaskDependencySQLiteDAO is an identifier to build TaskDependencySQLiteDAO.
+	TypeTaskDependencySQLiteDAO = "TaskDependencySQLiteDAO"
+)
+
+var _ ITaskDependencyDAO = (*TaskDependencySQLiteDAO)(nil)
+
+// TaskDependencySQLiteDAO is a TaskDependencyDAO storing the dependencies in the database of TaskSQLiteDAO.
+type TaskDependencySQLiteDAO struct {
+	taskDependencySQLDAO
+}
+
+// factoryTaskDependencySQLiteDAO build TaskDependencySQLiteDAO.
+func factoryTaskDependencySQLiteDAO(opt DAOFactoryOptions) (*TaskDependencySQLiteDAO, error) {
+	connector, err := connectors.GetConnectorSQLite(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskDependencySQLiteDAO{
+		taskDependencySQLDAO: newTaskDependencySQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       sqliteDialect,
+		}),
+	}, nil
+}
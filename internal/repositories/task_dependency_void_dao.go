@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeTaskDependencyVoidDAO is an identifier to build TaskDependencyVoidDAO.
+	TypeTaskDependencyVoidDAO = "TaskDependencyVoidDAO"
+)
+
+var _ ITaskDependencyDAO = (*TaskDependencyVoidDAO)(nil)
+
+// TaskDependencyVoidDAO is a TaskDependencyDAO with not implemented features.
+type TaskDependencyVoidDAO struct {
+	connectorName string
+}
+
+func (dao *TaskDependencyVoidDAO) ReadDependencyGraph(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, []*model.TaskDependency, error) {
+	return nil, nil, ErrFeatureNotImplemented
+}
+
+func (dao *TaskDependencyVoidDAO) ReadBlockers(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TaskDependencyVoidDAO) SaveDependency(ctx context.Context, dependency *model.TaskDependency) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *TaskDependencyVoidDAO) DeleteDependency(ctx context.Context, taskUUID, blockerUUID uuid.UUID) error {
+	return ErrFeatureNotImplemented
+}
+
+// factoryTaskDependencyVoidDAO build TaskDependencyVoidDAO.
+func factoryTaskDependencyVoidDAO(opt DAOFactoryOptions) (*TaskDependencyVoidDAO, error) {
+	return &TaskDependencyVoidDAO{
+		connectorName: opt.Connector,
+	}, nil
+}
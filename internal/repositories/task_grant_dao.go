@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// mapTaskGrantDAO is used by ProxyFactoryTaskGrantDAO to store TaskGrantDAO.
+var mapTaskGrantDAO = make(map[string]map[string]ITaskGrantDAO)
+
+// taskGrantDAOTypes maps the types of TaskDAO to the type of TaskGrantDAO storing the grants next to their tasks.
+var taskGrantDAOTypes = map[string]string{
+	TypeTaskVoidDAO:     TypeTaskGrantVoidDAO,
+	TypeTaskInMemoryDAO: TypeTaskGrantInMemoryDAO,
+	TypeTaskPostgresDAO: TypeTaskGrantPostgresDAO,
+	TypeTaskMySQLDAO:    TypeTaskGrantMySQLDAO,
+	TypeTaskSQLiteDAO:   TypeTaskGrantSQLiteDAO,
+}
+
+// ITaskGrantDAO is a DAO interface to manage the TaskGrant sharing the tasks with other principals.
+// The grants are stored next to the tasks of the TaskDAO of the same connector, which reads them to find the visible tasks.
+type ITaskGrantDAO interface {
+	// ReadGrants returns the roles given on the task, ordered by principal.
+	ReadGrants(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskGrant, error)
+	// SaveGrant gives a role on a live task to a principal, it replaces the previous role of the principal.
+	// It sets the GrantedAt of the grant.
+	SaveGrant(ctx context.Context, grant *model.TaskGrant) error
+	// DeleteGrant removes the role of the principal on the task.
+	DeleteGrant(ctx context.Context, taskUUID uuid.UUID, principalID string) error
+}
+
+// TaskGrantDAOOptions returns the options of the TaskGrantDAO storing the grants next to the tasks of the TaskDAO of taskDAO.
+func TaskGrantDAOOptions(taskDAO DAOFactoryOptions) DAOFactoryOptions {
+	return DAOFactoryOptions{Type: taskGrantDAOTypes[taskDAO.Type], Connector: taskDAO.Connector}
+}
+
+// ProxyFactoryTaskGrantDAO uses FactoryTaskGrantDAO if the TaskGrantDAO don't exist, and returns TaskGrantDAO.
+func ProxyFactoryTaskGrantDAO(opt DAOFactoryOptions) (ITaskGrantDAO, error) {
+	// Test if exist
+	mapConnector, mapExist := mapTaskGrantDAO[opt.Type]
+	if mapExist {
+		daoTaskGrant, present := mapConnector[opt.Connector]
+		if present {
+			return daoTaskGrant, nil
+		}
+	}
+
+	// Build new TaskGrantDAO
+	daoTaskGrant, err := FactoryTaskGrantDAO(opt)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build new TaskGrantDAO: %w", err)
+	}
+
+	// Save new TaskGrantDAO
+	if !mapExist {
+		mapTaskGrantDAO[opt.Type] = make(map[string]ITaskGrantDAO)
+	}
+	mapTaskGrantDAO[opt.Type][opt.Connector] = daoTaskGrant
+
+	return daoTaskGrant, nil
+}
+
+// FactoryTaskGrantDAO builds a new TaskGrantDAO according to the typename.
+func FactoryTaskGrantDAO(opt DAOFactoryOptions) (ITaskGrantDAO, error) {
+	var dao ITaskGrantDAO
+	var err error
+
+	switch opt.Type {
+	case TypeTaskGrantVoidDAO:
+		dao, err = factoryTaskGrantVoidDAO(opt)
+	case TypeTaskGrantInMemoryDAO:
+		dao, err = factoryTaskGrantInMemoryDAO(opt)
+	case TypeTaskGrantPostgresDAO:
+		dao, err = factoryTaskGrantPostgresDAO(opt)
+	case TypeTaskGrantMySQLDAO:
+		dao, err = factoryTaskGrantMySQLDAO(opt)
+	case TypeTaskGrantSQLiteDAO:
+		dao, err = factoryTaskGrantSQLiteDAO(opt)
+	default:
+		return nil, &DAOTypeNotFoundError{Type: opt.Type}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to build %v: %w", opt.Type, err)
+	}
+
+	return dao, nil
+}
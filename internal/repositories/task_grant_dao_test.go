@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestTaskGrantDAOOptions(t *testing.T) {
+	for taskType, grantType := range taskGrantDAOTypes {
+		got := TaskGrantDAOOptions(DAOFactoryOptions{Type: taskType, Connector: "db"})
+		if got.Type != grantType || got.Connector != "db" {
+			t.Errorf("TaskGrantDAOOptions(%s) = %+v, want %s on db", taskType, got, grantType)
+		}
+	}
+
+	var errDAOTypeNotFound *DAOTypeNotFoundError
+	if _, err := FactoryTaskGrantDAO(TaskGrantDAOOptions(DAOFactoryOptions{Type: "TaskRedisDAO"})); !errors.As(err, &errDAOTypeNotFound) {
+		t.Errorf("FactoryTaskGrantDAO() of an unknown type error = %v, want a DAOTypeNotFoundError", err)
+	}
+}
+
+// isNotFound tells if err is the NoDataFoundError or the ErrNoRowAffected of a missing row.
+func isNotFound(err error) bool {
+	var errNoDataFound *NoDataFoundError
+	return errors.As(err, &errNoDataFound) || errors.Is(err, ErrNoRowAffected)
+}
+
+func TestTaskGrantDAO(t *testing.T) {
+	for _, backend := range newTestTaskDAOBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			daoTask, err := ProxyFactoryTaskDAO(backend.taskDAO)
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+			}
+			daoGrant, err := ProxyFactoryTaskGrantDAO(TaskGrantDAOOptions(backend.taskDAO))
+			if err != nil {
+				t.Fatalf("ProxyFactoryTaskGrantDAO() error = %v", err)
+			}
+
+			alice := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "bob"})
+			task, err := daoTask.Create(alice, &model.TaskCreateDTO{WhatToDo: "write the report", Status: "todo"})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err := daoTask.ReadByUUID(bob, task.UUID); !isNotFound(err) {
+				t.Fatalf("ReadByUUID() by bob before the grant error = %v, want not found", err)
+			}
+
+			before := time.Now().Add(-time.Second)
+			for _, grant := range []*model.TaskGrant{
+				{TaskUUID: task.UUID, PrincipalID: "carol", Role: model.TaskRoleEditor, GrantedBy: "alice"},
+				{TaskUUID: task.UUID, PrincipalID: "bob", Role: model.TaskRoleViewer, GrantedBy: "alice"},
+			} {
+				if err := daoGrant.SaveGrant(alice, grant); err != nil {
+					t.Fatalf("SaveGrant() error = %v", err)
+				}
+				if grant.GrantedAt.Before(before) {
+					t.Errorf("SaveGrant() GrantedAt = %v, want now", grant.GrantedAt)
+				}
+			}
+
+			// The grants are ordered by principal and make the task visible.
+			grants, err := daoGrant.ReadGrants(alice, task.UUID)
+			if err != nil || len(grants) != 2 || grants[0].PrincipalID != "bob" || grants[1].PrincipalID != "carol" {
+				t.Fatalf("ReadGrants() = %+v, %v, want the grants of bob and carol", grants, err)
+			}
+			if grants[0].Role != model.TaskRoleViewer || grants[0].GrantedBy != "alice" || grants[0].TaskUUID != task.UUID {
+				t.Errorf("grant of bob = %+v, want viewer granted by alice", grants[0])
+			}
+			if _, err := daoTask.ReadByUUID(bob, task.UUID); err != nil {
+				t.Errorf("ReadByUUID() by bob error = %v", err)
+			}
+			if role, err := daoTask.ReadRole(bob, task.UUID); err != nil || role != model.TaskRoleViewer {
+				t.Errorf("ReadRole() of bob = %s, %v, want viewer", role, err)
+			}
+
+			// A new grant replaces the previous role.
+			if err := daoGrant.SaveGrant(alice, &model.TaskGrant{TaskUUID: task.UUID, PrincipalID: "bob", Role: model.TaskRoleEditor, GrantedBy: "alice"}); err != nil {
+				t.Fatalf("SaveGrant() error = %v", err)
+			}
+			if grants, _ := daoGrant.ReadGrants(alice, task.UUID); len(grants) != 2 || grants[0].Role != model.TaskRoleEditor {
+				t.Errorf("ReadGrants() = %+v, want bob editor", grants)
+			}
+
+			// The grants are only given on the live tasks visible by the caller.
+			grant := &model.TaskGrant{TaskUUID: task.UUID, PrincipalID: "dave", Role: model.TaskRoleViewer}
+			if err := daoGrant.SaveGrant(alice, &model.TaskGrant{TaskUUID: uuid.New(), PrincipalID: "dave", Role: model.TaskRoleViewer}); !isNotFound(err) {
+				t.Errorf("SaveGrant() on an unknown task error = %v, want not found", err)
+			}
+			if err := daoGrant.SaveGrant(model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "eve"}), grant); !isNotFound(err) {
+				t.Errorf("SaveGrant() by a stranger error = %v, want not found", err)
+			}
+
+			if err := daoGrant.DeleteGrant(alice, task.UUID, "bob"); err != nil {
+				t.Fatalf("DeleteGrant() error = %v", err)
+			}
+			if _, err := daoTask.ReadByUUID(bob, task.UUID); !isNotFound(err) {
+				t.Errorf("ReadByUUID() by bob after the revoke error = %v, want not found", err)
+			}
+			if err := daoGrant.DeleteGrant(alice, task.UUID, "bob"); !isNotFound(err) {
+				t.Errorf("DeleteGrant() of a missing grant error = %v, want not found", err)
+			}
+
+			// The grants leave with their task.
+			if err := daoTask.Delete(alice, task.UUID, 0, model.TaskDeleteRestrict); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if err := daoGrant.SaveGrant(alice, grant); !isNotFound(err) {
+				t.Errorf("SaveGrant() on a trashed task error = %v, want not found", err)
+			}
+			if _, err := daoTask.Purge(context.Background(), time.Now().Add(time.Second)); err != nil {
+				t.Fatalf("Purge() error = %v", err)
+			}
+			if grants, err := daoGrant.ReadGrants(alice, task.UUID); err != nil || len(grants) != 0 {
+				t.Errorf("ReadGrants() of a purged task = %+v, %v, want none", grants, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeTaskGrantInMemoryDAO is an identifier to build TaskGrantInMemoryDAO.
+	TypeTaskGrantInMemoryDAO = "TaskGrantInMemoryDAO"
+)
+
+var _ ITaskGrantDAO = (*TaskGrantInMemoryDAO)(nil)
+
+// TaskGrantInMemoryDAO is a TaskGrantDAO keeping the grants in the TaskInMemoryDAO of the same connector,
+// which finds the visible tasks with them. The grants are appended to its log file and removed with their task.
+type TaskGrantInMemoryDAO struct {
+	tasks *TaskInMemoryDAO
+}
+
+func (dao *TaskGrantInMemoryDAO) ReadGrants(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskGrant, error) {
+	dao.tasks.mu.RLock()
+	defer dao.tasks.mu.RUnlock()
+
+	grants := make([]*model.TaskGrant, 0, len(dao.tasks.grants[taskUUID]))
+	for _, grant := range dao.tasks.grants[taskUUID] {
+		grantCopy := *grant
+		grants = append(grants, &grantCopy)
+	}
+
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].PrincipalID < grants[j].PrincipalID
+	})
+	return grants, nil
+}
+
+func (dao *TaskGrantInMemoryDAO) SaveGrant(ctx context.Context, grant *model.TaskGrant) error {
+	dao.tasks.mu.Lock()
+	defer dao.tasks.mu.Unlock()
+
+	if _, err := dao.tasks.liveTask(ctx, grant.TaskUUID, 0); err != nil {
+		return err
+	}
+
+	grant.GrantedAt = time.Now()
+	grantCopy := *grant
+	return dao.tasks.apply(taskRecord{Op: recordOpGrant, UUID: grant.TaskUUID, Grant: &grantCopy})
+}
+
+func (dao *TaskGrantInMemoryDAO) DeleteGrant(ctx context.Context, taskUUID uuid.UUID, principalID string) error {
+	dao.tasks.mu.Lock()
+	defer dao.tasks.mu.Unlock()
+
+	grant, exist := dao.tasks.grants[taskUUID][principalID]
+	if !exist {
+		return fmt.Errorf("no grant of the task %s to %s : %w", taskUUID.String(), principalID, &NoDataFoundError{})
+	}
+
+	return dao.tasks.apply(taskRecord{Op: recordOpRevoke, UUID: taskUUID, Grant: grant})
+}
+
+// factoryTaskGrantInMemoryDAO build TaskGrantInMemoryDAO on the TaskInMemoryDAO of the connector.
+func factoryTaskGrantInMemoryDAO(opt DAOFactoryOptions) (*TaskGrantInMemoryDAO, error) {
+	daoTask, err := ProxyFactoryTaskDAO(DAOFactoryOptions{Type: TypeTaskInMemoryDAO, Connector: opt.Connector})
+	if err != nil {
+		return nil, fmt.Errorf("fail to get TaskInMemoryDAO: %w", err)
+	}
+
+	return &TaskGrantInMemoryDAO{
+		tasks: daoTask.(*TaskInMemoryDAO),
+	}, nil
+}
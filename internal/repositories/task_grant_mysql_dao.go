@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTaskGrantMySQLDAO is an identifier to build TaskGrantMySQLDAO.
+	TypeTaskGrantMySQLDAO = "TaskGrantMySQLDAO"
+)
+
+var _ ITaskGrantDAO = (*TaskGrantMySQLDAO)(nil)
+
+// TaskGrantMySQLDAO is a TaskGrantDAO storing the grants in the database of TaskMySQLDAO.
+type TaskGrantMySQLDAO struct {
+	taskGrantSQLDAO
+}
+
+// factoryTaskGrantMySQLDAO build TaskGrantMySQLDAO.
+func factoryTaskGrantMySQLDAO(opt DAOFactoryOptions) (*TaskGrantMySQLDAO, error) {
+	connector, err := connectors.GetConnectorMySQL(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskGrantMySQLDAO{
+		taskGrantSQLDAO: newTaskGrantSQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       mysqlDialect,
+		}),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTaskGrantPostgresDAO is an identifier to build TaskGrantPostgresDAO.
+	TypeTaskGrantPostgresDAO = "TaskGrantPostgresDAO"
+)
+
+var _ ITaskGrantDAO = (*TaskGrantPostgresDAO)(nil)
+
+// TaskGrantPostgresDAO is a TaskGrantDAO storing the grants in the database of TaskPostgresDAO.
+type TaskGrantPostgresDAO struct {
+	taskGrantSQLDAO
+}
+
+// factoryTaskGrantPostgresDAO build TaskGrantPostgresDAO.
+func factoryTaskGrantPostgresDAO(opt DAOFactoryOptions) (*TaskGrantPostgresDAO, error) {
+	connector, err := connectors.GetConnectorPostgres(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskGrantPostgresDAO{
+		taskGrantSQLDAO: newTaskGrantSQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       postgresDialect,
+		}),
+	}, nil
+}
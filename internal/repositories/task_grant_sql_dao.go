@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// taskGrantColumns are the columns of the task_grants table, in order.
+const taskGrantColumns = "task_uuid, principal_id, role, granted_by, granted_at"
+
+// taskGrantSQLDAO implements ITaskGrantDAO with portable SQL, in the task_grants table read by the taskSQLDAO of the database.
+type taskGrantSQLDAO struct {
+	sqlDAO
+	// tasks locks the task of a grant in the transaction saving it.
+	tasks taskSQLDAO
+}
+
+// newTaskGrantSQLDAO returns a taskGrantSQLDAO on the connection of dao.
+func newTaskGrantSQLDAO(dao sqlDAO) taskGrantSQLDAO {
+	return taskGrantSQLDAO{
+		sqlDAO: dao,
+		tasks:  taskSQLDAO{sqlDAO: dao},
+	}
+}
+
+func (dao *taskGrantSQLDAO) ReadGrants(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskGrant, error) {
+	query := fmt.Sprintf("SELECT %s FROM task_grants WHERE task_uuid = %s ORDER BY principal_id;", taskGrantColumns, dao.bind(1))
+	rows, err := dao.connector.QueryContext(ctx, query, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the grants : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	grants := make([]*model.TaskGrant, 0)
+	for rows.Next() {
+		grant := new(model.TaskGrant)
+		if err := rows.Scan(&grant.TaskUUID, &grant.PrincipalID, &grant.Role, &grant.GrantedBy, &grant.GrantedAt); err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		grants = append(grants, grant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	return grants, nil
+}
+
+func (dao *taskGrantSQLDAO) SaveGrant(ctx context.Context, grant *model.TaskGrant) error {
+	grant.GrantedAt = sqlNow()
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		// The lock of the task serializes the grants of the task.
+		if _, err := dao.tasks.lockTask(ctx, tx, grant.TaskUUID, false, 0); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("DELETE FROM task_grants WHERE task_uuid = %s AND principal_id = %s;", dao.bind(1), dao.bind(2))
+		if _, err := dao.connector.Exec(tx, query, grant.TaskUUID, grant.PrincipalID); err != nil {
+			return dao.dialect.wrapError(err)
+		}
+
+		query = fmt.Sprintf("INSERT INTO task_grants (%s) VALUES (%s, %s, %s, %s, %s);", taskGrantColumns,
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5))
+		return dao.execOne(tx, query, grant.TaskUUID, grant.PrincipalID, grant.Role, grant.GrantedBy, grant.GrantedAt)
+	})
+	if err != nil {
+		return fmt.Errorf("can't save the grant : %w", err)
+	}
+	return nil
+}
+
+func (dao *taskGrantSQLDAO) DeleteGrant(ctx context.Context, taskUUID uuid.UUID, principalID string) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("DELETE FROM task_grants WHERE task_uuid = %s AND principal_id = %s;", dao.bind(1), dao.bind(2))
+		return dao.execOne(tx, query, taskUUID, principalID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't delete the grant : %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeTaskGrantSQLiteDAO is an identifier to build TaskGrantSQLiteDAO.
+	TypeTaskGrantSQLiteDAO = "TaskGrantSQLiteDAO"
+)
+
+var _ ITaskGrantDAO = (*TaskGrantSQLiteDAO)(nil)
+
+// TaskGrantSQLiteDAO is a TaskGrantDAO storing the grants in the database of TaskSQLiteDAO.
+type TaskGrantSQLiteDAO struct {
+	taskGrantSQLDAO
+}
+
+// factoryTaskGrantSQLiteDAO build TaskGrantSQLiteDAO.
+func factoryTaskGrantSQLiteDAO(opt DAOFactoryOptions) (*TaskGrantSQLiteDAO, error) {
+	connector, err := connectors.GetConnectorSQLite(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskGrantSQLiteDAO{
+		taskGrantSQLDAO: newTaskGrantSQLDAO(sqlDAO{
+			connector:     connector,
+			connectorName: opt.Connector,
+			dialect:       sqliteDialect,
+		}),
+	}, nil
+}
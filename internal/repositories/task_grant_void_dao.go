@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeTaskGrantVoidDAO is an identifier to build TaskGrantVoidDAO.
+	TypeTaskGrantVoidDAO = "TaskGrantVoidDAO"
+)
+
+var _ ITaskGrantDAO = (*TaskGrantVoidDAO)(nil)
+
+// TaskGrantVoidDAO is a TaskGrantDAO with not implemented features.
+type TaskGrantVoidDAO struct {
+	connectorName string
+}
+
+func (dao *TaskGrantVoidDAO) ReadGrants(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskGrant, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TaskGrantVoidDAO) SaveGrant(ctx context.Context, grant *model.TaskGrant) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *TaskGrantVoidDAO) DeleteGrant(ctx context.Context, taskUUID uuid.UUID, principalID string) error {
+	return ErrFeatureNotImplemented
+}
+
+// factoryTaskGrantVoidDAO build TaskGrantVoidDAO.
+func factoryTaskGrantVoidDAO(opt DAOFactoryOptions) (*TaskGrantVoidDAO, error) {
+	return &TaskGrantVoidDAO{
+		connectorName: opt.Connector,
+	}, nil
+}
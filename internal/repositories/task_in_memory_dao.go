@@ -2,11 +2,19 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/CamilleLange/todolist/internal/connectors"
 	model "github.com/CamilleLange/todolist/pkg/structs"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 const (
@@ -14,118 +22,1190 @@ const (
 	TypeTaskInMemoryDAO = "TaskInMemoryDAO"
 )
 
+const (
+	recordOpPut    = "put"
+	recordOpDelete = "delete"
+	// recordOpEvent only appends its event to the history, it is written by the compaction.
+	recordOpEvent = "event"
+	// recordOpGrant saves the grant of the record, recordOpRevoke removes it.
+	recordOpGrant  = "grant"
+	recordOpRevoke = "revoke"
+	// recordOpDepend saves the dependency of the record, recordOpUndepend removes it.
+	recordOpDepend   = "depend"
+	recordOpUndepend = "undepend"
+	// recordOpPutTag saves the tag of the record, recordOpDeleteTag removes it and takes it off the tasks.
+	recordOpPutTag    = "put_tag"
+	recordOpDeleteTag = "delete_tag"
+	// recordOpTag puts the tag of the record on the task, recordOpUntag takes it off.
+	// The task of the record is the task with its new version.
+	recordOpTag   = "tag"
+	recordOpUntag = "untag"
+	// recordOpBatch applies the records of an all-or-nothing batch, written on a single line.
+	recordOpBatch = "batch"
+)
+
 var _ ITaskDAO = (*TaskInMemoryDAO)(nil)
 
-// TaskInMemoryDAO is a TaskDAO with not implemented features.
+// TaskInMemoryDAO is a TaskDAO storing the tasks in a map, safe for concurrent use.
+// With a File connector, each change is appended to the file and the tasks are reloaded from it at startup.
 type TaskInMemoryDAO struct {
 	connectorName string
+	file          *connectors.FileConnector
 
-	tasks map[uuid.UUID]*model.Task
+	mu sync.RWMutex
+	taskState
+	// staged collects the records of an atomic batch instead of persisting them, nil otherwise.
+	staged []taskRecord
 }
 
-func (dao *TaskInMemoryDAO) Create(ctx context.Context) (*model.Task, error) {
-	strTask := ctx.Value("create_task")
-	taskToCreate, castable := strTask.(*model.TaskCreateDTO)
-	if !castable {
-		return nil, fmt.Errorf("can't cast the context value to *model.TaskCreateDTO")
-	}
+// taskState is the content of a TaskInMemoryDAO, rebuilt by replaying the records of its log file.
+type taskState struct {
+	tasks  map[uuid.UUID]*model.Task
+	events map[uuid.UUID][]*model.TaskEvent
+	// grants are indexed by task then by principal.
+	grants map[uuid.UUID]map[string]*model.TaskGrant
+	// dependencies are indexed by task then by blocker.
+	dependencies map[uuid.UUID]map[uuid.UUID]*model.TaskDependency
+	tags         map[uuid.UUID]*model.Tag
+	// taskTags are the tags of the tasks, indexed by task then by tag.
+	taskTags map[uuid.UUID]map[uuid.UUID]bool
+	// index is the full-text index of the descriptions of the tasks.
+	index taskSearchIndex
+}
 
-	task := taskToCreate.ReverseCreateDTO()
+// taskRecord is a line of the log file of a TaskInMemoryDAO, a change and its event are written on the same line.
+type taskRecord struct {
+	Op    string           `json:"op"`
+	Task  *model.Task      `json:"task,omitempty"`
+	UUID  uuid.UUID        `json:"uuid"`
+	Event *model.TaskEvent `json:"event,omitempty"`
+	Grant *model.TaskGrant `json:"grant,omitempty"`
+	// Dependency is the edge saved or removed by a dependency record.
+	Dependency *model.TaskDependency `json:"dependency,omitempty"`
+	// Tag is the tag saved or removed by a tag record, or put on or taken off the task.
+	Tag *model.Tag `json:"tag,omitempty"`
+	// Records are the records of a batch record, applied in order.
+	Records []taskRecord `json:"records,omitempty"`
+}
+
+func (dao *TaskInMemoryDAO) Create(ctx context.Context, taskToCreate *model.TaskCreateDTO) (*model.Task, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	return dao.create(ctx, taskToCreate)
+}
+
+// create stores a new task and returns it.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) create(ctx context.Context, taskToCreate *model.TaskCreateDTO) (*model.Task, error) {
+	// The stored task must not share the dates and UUIDs of the caller.
+	task := copyTask(taskToCreate.ReverseCreateDTO())
 	task.UUID = uuid.New()
 	task.CreatedAt = time.Now()
 	task.LastUpdated = task.CreatedAt
+	task.Version = 1
+	if task.OwnerID == "" {
+		task.OwnerID = model.OwnerFromContext(ctx)
+	}
+	if task.RRule != "" && task.SeriesUUID == nil {
+		task.SeriesUUID = &task.UUID
+	}
+
+	event := model.NewTaskEvent(model.TaskEventCreated, nil, task, model.ActorFromContext(ctx), task.CreatedAt)
+	if err := dao.apply(taskRecord{Op: recordOpPut, Task: task, UUID: task.UUID, Event: event}); err != nil {
+		return nil, err
+	}
 
-	dao.tasks[task.UUID] = task
-	return task, nil
+	return copyTask(task), nil
 }
 
-func (dao *TaskInMemoryDAO) ReadByUUID(ctx context.Context) (*model.Task, error) {
-	strUUID := ctx.Value("task_uuid")
-	taskUUID, castable := strUUID.(*uuid.UUID)
-	if !castable {
-		return nil, fmt.Errorf("can't cast the context value to *uuid.UUID")
-	}
+func (dao *TaskInMemoryDAO) ReadByUUID(ctx context.Context, taskUUID uuid.UUID) (*model.Task, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
 
-	task, exist := dao.tasks[*taskUUID]
-	if !exist {
-		return nil, fmt.Errorf("no task with this UUID (%s) exist", taskUUID.String())
+	task, exist := dao.tasks[taskUUID]
+	if !exist || task.DeletedAt != nil || !dao.visible(ctx, taskUUID, task.OwnerID) {
+		return nil, fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
 	}
-	return task, nil
+	return dao.readTask(task), nil
 }
 
-func (dao *TaskInMemoryDAO) ReadAll(ctx context.Context) ([]*model.Task, error) {
+func (dao *TaskInMemoryDAO) ReadAll(ctx context.Context, filter *model.TaskFilterDTO) ([]*model.Task, string, error) {
+	cursor, err := decodeTaskCursor(filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	dao.mu.RLock()
 	tasks := make([]*model.Task, 0)
-	for _, task := range dao.tasks {
-		tasks = append(tasks, task)
+	for _, storedTask := range dao.tasks {
+		if !dao.visible(ctx, storedTask.UUID, storedTask.OwnerID) {
+			continue
+		}
+		if task := dao.readTask(storedTask); matchTaskFilter(task, filter) {
+			tasks = append(tasks, task)
+		}
 	}
-	return tasks, nil
+	dao.mu.RUnlock()
+
+	desc := filter.Order == model.SortOrderDesc
+	sort.Slice(tasks, func(i, j int) bool {
+		cmp := compareTasks(tasks[i], tasks[j], filter.Sort)
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	// Skip the tasks up to the cursor.
+	if cursor != nil {
+		start := sort.Search(len(tasks), func(i int) bool {
+			cmp := compareToCursor(tasks[i], cursor)
+			if desc {
+				return cmp < 0
+			}
+			return cmp > 0
+		})
+		tasks = tasks[start:]
+	}
+
+	nextCursor := ""
+	if len(tasks) > filter.Limit {
+		tasks = tasks[:filter.Limit]
+		nextCursor = encodeTaskCursor(tasks[len(tasks)-1], filter)
+	}
+
+	return tasks, nextCursor, nil
 }
 
-func (dao *TaskInMemoryDAO) Update(ctx context.Context) error {
-	strFieldsName := ctx.Value("task_fields_name")
-	fieldsName, castable := strFieldsName.([]string)
-	if !castable {
-		return fmt.Errorf("can't cast the context value to *[]string")
+// Search scans the tasks of the index having the words of the query, then ranks the live tasks matching it.
+func (dao *TaskInMemoryDAO) Search(ctx context.Context, search *model.TaskSearchDTO) ([]*model.TaskSearchResult, error) {
+	query := parseSearchQuery(search.Query, search.Language)
+
+	dao.mu.RLock()
+	results := make([]*model.TaskSearchResult, 0)
+	for taskUUID := range dao.index.candidates(query) {
+		storedTask := dao.tasks[taskUUID]
+		if storedTask.DeletedAt != nil || !dao.visible(ctx, taskUUID, storedTask.OwnerID) {
+			continue
+		}
+		if result := query.rank(storedTask.WhatToDo); result != nil {
+			result.Task = dao.readTask(storedTask)
+			results = append(results, result)
+		}
 	}
+	dao.mu.RUnlock()
 
-	strValuesToUpdate := ctx.Value("task_values_to_update")
-	valuesToUpdate, castable := strValuesToUpdate.(map[string]any)
-	if !castable {
-		return fmt.Errorf("can't cast the context value to *map[string]any")
+	return sortSearchResults(results, search.Limit), nil
+}
+
+// matchTaskFilter reports whether the task matches the filtering criteria.
+func matchTaskFilter(task *model.Task, filter *model.TaskFilterDTO) bool {
+	if (task.DeletedAt != nil) != filter.Deleted {
+		return false
+	}
+	if filter.ListUUID != nil && (task.ListUUID == nil || *task.ListUUID != *filter.ListUUID) {
+		return false
+	}
+	if filter.SeriesUUID != nil && (task.SeriesUUID == nil || *task.SeriesUUID != *filter.SeriesUUID) {
+		return false
+	}
+	if filter.ParentUUID != nil && (task.ParentUUID == nil || *task.ParentUUID != *filter.ParentUUID) {
+		return false
+	}
+	if len(filter.Status) > 0 && !slices.Contains(filter.Status, task.Status) {
+		return false
+	}
+	if slices.Contains(filter.ExcludedStatus, task.Status) {
+		return false
+	}
+	if filter.Search != "" && !strings.Contains(strings.ToLower(task.WhatToDo), strings.ToLower(filter.Search)) {
+		return false
+	}
+	if filter.CreatedAfter != nil && task.CreatedAt.Before(*filter.CreatedAfter) {
+		return false
+	}
+	if filter.CreatedBefore != nil && !task.CreatedAt.Before(*filter.CreatedBefore) {
+		return false
+	}
+	if filter.UpdatedAfter != nil && task.LastUpdated.Before(*filter.UpdatedAfter) {
+		return false
+	}
+	if filter.UpdatedBefore != nil && !task.LastUpdated.Before(*filter.UpdatedBefore) {
+		return false
+	}
+	if filter.DueAfter != nil && (task.DueAt == nil || task.DueAt.Before(*filter.DueAfter)) {
+		return false
+	}
+	if filter.DueBefore != nil && (task.DueAt == nil || !task.DueAt.Before(*filter.DueBefore)) {
+		return false
+	}
+	if len(filter.Tag) > 0 && !matchTags(task.Tags, filter.Tag, filter.TagMatch) {
+		return false
+	}
+	return true
+}
+
+// matchTags reports whether the tags have all the requested tags with TagMatchAll, or any of them otherwise.
+func matchTags(tags, requested []string, match string) bool {
+	if match == model.TagMatchAll {
+		for _, tag := range requested {
+			if !slices.Contains(tags, tag) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, tag := range requested {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleOwner reports whether the tasks and lists of the owner are visible by the principal of ctx.
+func visibleOwner(ctx context.Context, ownerID string) bool {
+	owner, scoped := model.OwnerScopeFromContext(ctx)
+	return !scoped || owner == ownerID
+}
+
+// compareTasks compares two tasks on the sort field then on their UUID, like the SQL keyset does.
+func compareTasks(a, b *model.Task, field string) int {
+	var cmp int
+	switch field {
+	case model.TaskSortLastUpdated:
+		cmp = a.LastUpdated.Compare(b.LastUpdated)
+	case model.TaskSortDescription:
+		cmp = strings.Compare(a.WhatToDo, b.WhatToDo)
+	case model.TaskSortStatus:
+		cmp = strings.Compare(string(a.Status), string(b.Status))
+	default:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	}
+
+	if cmp != 0 {
+		return cmp
+	}
+	return strings.Compare(a.UUID.String(), b.UUID.String())
+}
+
+// compareToCursor compares a task to the keyset position of a cursor.
+func compareToCursor(task *model.Task, cursor *taskCursor) int {
+	var cmp int
+	if isTimeSort(cursor.Sort) {
+		value, _ := time.Parse(time.RFC3339Nano, cursor.Value)
+		cmp = taskTime(task, cursor.Sort).Compare(value)
+	} else {
+		cmp = strings.Compare(sortValue(task, cursor.Sort), cursor.Value)
 	}
 
-	strUUID := ctx.Value("task_uuid")
-	taskUUID, castable := strUUID.(*uuid.UUID)
-	if !castable {
-		return fmt.Errorf("can't cast the context value to *uuid.UUID")
+	if cmp != 0 {
+		return cmp
 	}
+	return strings.Compare(task.UUID.String(), cursor.UUID.String())
+}
+
+// taskTime returns the timestamp of the task used by a time sort.
+func taskTime(task *model.Task, field string) time.Time {
+	if field == model.TaskSortLastUpdated {
+		return task.LastUpdated
+	}
+	return task.CreatedAt
+}
+
+func (dao *TaskInMemoryDAO) Update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	_, err := dao.update(ctx, taskUUID, patch, version)
+	return err
+}
+
+// update applies the patch on the task and returns the updated task.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) (*model.Task, error) {
+	storedTask, err := dao.liveTask(ctx, taskUUID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on a copy so a failure to persist leaves the stored task untouched.
+	taskToUpdate := copyTask(storedTask)
+	if patch.WhatToDo != nil {
+		taskToUpdate.WhatToDo = *patch.WhatToDo
+	}
+	if patch.Status != nil {
+		taskToUpdate.Status = *patch.Status
+	}
+	if patch.ListUUID != nil {
+		taskToUpdate.ListUUID = optionalUUID(*patch.ListUUID)
+	}
+	if patch.DueAt != nil {
+		taskToUpdate.DueAt = optionalTime(*patch.DueAt)
+	}
+	if patch.RemindAt != nil {
+		taskToUpdate.RemindAt = optionalTime(*patch.RemindAt)
+		if !sameTime(taskToUpdate.RemindAt, storedTask.RemindAt) {
+			taskToUpdate.RemindedAt = nil
+		}
+	}
+	if patch.RRule != nil {
+		taskToUpdate.RRule = *patch.RRule
+		if taskToUpdate.RRule != "" && taskToUpdate.SeriesUUID == nil {
+			taskToUpdate.SeriesUUID = &taskToUpdate.UUID
+		}
+	}
+	if patch.ParentUUID != nil {
+		taskToUpdate.ParentUUID = optionalUUID(*patch.ParentUUID)
+	}
+	taskToUpdate.LastUpdated = time.Now()
+	taskToUpdate.Version++
+
+	event := model.NewTaskEvent(model.TaskEventUpdated, storedTask, taskToUpdate, model.ActorFromContext(ctx), taskToUpdate.LastUpdated)
+	if err := dao.apply(taskRecord{Op: recordOpPut, Task: taskToUpdate, UUID: taskUUID, Event: event}); err != nil {
+		return nil, err
+	}
+
+	return dao.readTask(taskToUpdate), nil
+}
+
+func (dao *TaskInMemoryDAO) Delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	return dao.delete(ctx, taskUUID, version, cascade)
+}
+
+// delete moves the task to the trash, with its subtree according to cascade.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error {
+	storedTask, err := dao.liveTask(ctx, taskUUID, version)
+	if err != nil {
+		return err
+	}
+
+	trashed, detached, err := planTaskDelete(storedTask, dao.descendants(taskUUID), cascade)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, task := range detached {
+		taskToDetach := copyTask(task)
+		taskToDetach.ParentUUID = nil
+		taskToDetach.LastUpdated = now
+		taskToDetach.Version++
+
+		event := model.NewTaskEvent(model.TaskEventUpdated, task, taskToDetach, model.ActorFromContext(ctx), now)
+		if err := dao.apply(taskRecord{Op: recordOpPut, Task: taskToDetach, UUID: task.UUID, Event: event}); err != nil {
+			return err
+		}
+	}
+	for _, task := range append([]*model.Task{storedTask}, trashed...) {
+		taskToDelete := copyTask(task)
+		taskToDelete.DeletedAt = &now
+		taskToDelete.LastUpdated = now
+		taskToDelete.Version++
+
+		event := model.NewTaskEvent(model.TaskEventDeleted, task, taskToDelete, model.ActorFromContext(ctx), now)
+		if err := dao.apply(taskRecord{Op: recordOpPut, Task: taskToDelete, UUID: task.UUID, Event: event}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dao *TaskInMemoryDAO) Batch(ctx context.Context, operations []*model.TaskBatchOperation, atomic bool) ([]*model.TaskBatchResult, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	results := make([]*model.TaskBatchResult, len(operations))
+	if !atomic {
+		for i, operation := range operations {
+			task, err := dao.runOperation(ctx, operation)
+			results[i] = &model.TaskBatchResult{Task: task, Err: err}
+		}
+		return results, nil
+	}
+
+	// The records of the operations are staged on the current state, which is restored if one of them fails.
+	// They are then persisted on a single line, applied again on the restored state.
+	snapshot := dao.taskState.clone()
+	dao.staged = make([]taskRecord, 0, len(operations))
+	for i, operation := range operations {
+		task, err := dao.runOperation(ctx, operation)
+		if err != nil {
+			dao.taskState, dao.staged = snapshot, nil
+			return nil, &BatchOperationError{Index: i, Err: err}
+		}
+		results[i] = &model.TaskBatchResult{Task: task}
+	}
+
+	batch := taskRecord{Op: recordOpBatch, Records: dao.staged}
+	dao.taskState, dao.staged = snapshot, nil
+	if err := dao.apply(batch); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// runOperation runs an operation of a batch and returns the created or updated task.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) runOperation(ctx context.Context, operation *model.TaskBatchOperation) (*model.Task, error) {
+	switch operation.Op {
+	case model.TaskBatchCreate:
+		return dao.create(ctx, operation.Create)
+	case model.TaskBatchUpdate:
+		return dao.update(ctx, operation.TaskUUID, operation.Patch, operation.Version)
+	case model.TaskBatchDelete:
+		return nil, dao.delete(ctx, operation.TaskUUID, operation.Version, operation.Cascade)
+	default:
+		return nil, fmt.Errorf("unknown batch operation %q", operation.Op)
+	}
+}
+
+func (dao *TaskInMemoryDAO) Restore(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	storedTask, exist := dao.tasks[taskUUID]
+	if !exist || storedTask.DeletedAt == nil || !dao.visible(ctx, taskUUID, storedTask.OwnerID) {
+		return nil, fmt.Errorf("no task with this UUID (%s) in the trash : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+
+	now := time.Now()
+	restored := make([]*model.Task, 0)
+	for _, task := range append([]*model.Task{storedTask}, dao.trashedDescendants(storedTask)...) {
+		taskToRestore := copyTask(task)
+		taskToRestore.DeletedAt = nil
+		taskToRestore.LastUpdated = now
+		taskToRestore.Version++
+
+		event := model.NewTaskEvent(model.TaskEventRestored, task, taskToRestore, model.ActorFromContext(ctx), now)
+		if err := dao.apply(taskRecord{Op: recordOpPut, Task: taskToRestore, UUID: task.UUID, Event: event}); err != nil {
+			return restored, err
+		}
+		restored = append(restored, copyTask(taskToRestore))
+	}
+	return restored, nil
+}
+
+func (dao *TaskInMemoryDAO) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	var count int64
+	now := time.Now()
+	purged := make(map[uuid.UUID]*model.Task)
+	for taskUUID, task := range dao.tasks {
+		if task.DeletedAt != nil && task.DeletedAt.Before(deletedBefore) {
+			purged[taskUUID] = task
+		}
+	}
+
+	// The subtasks left by the purged tasks are detached.
+	for taskUUID, task := range dao.tasks {
+		if task.ParentUUID == nil || purged[*task.ParentUUID] == nil || purged[taskUUID] != nil {
+			continue
+		}
+
+		taskToDetach := copyTask(task)
+		taskToDetach.ParentUUID = nil
+		taskToDetach.LastUpdated = now
+		taskToDetach.Version++
+
+		event := model.NewTaskEvent(model.TaskEventUpdated, task, taskToDetach, model.SystemActor, now)
+		if err := dao.apply(taskRecord{Op: recordOpPut, Task: taskToDetach, UUID: taskUUID, Event: event}); err != nil {
+			return count, err
+		}
+	}
+
+	for taskUUID, task := range purged {
+		event := model.NewTaskEvent(model.TaskEventPurged, task, nil, model.SystemActor, now)
+		if err := dao.apply(taskRecord{Op: recordOpDelete, UUID: taskUUID, Event: event}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (dao *TaskInMemoryDAO) Remind(ctx context.Context, remindBefore time.Time) ([]*model.Task, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	var reminded []*model.Task
+	now := time.Now()
+	for taskUUID, task := range dao.tasks {
+		if task.DeletedAt != nil || task.RemindedAt != nil || task.RemindAt == nil || task.RemindAt.After(remindBefore) {
+			continue
+		}
+
+		taskToRemind := copyTask(task)
+		taskToRemind.RemindedAt = &now
+		taskToRemind.LastUpdated = now
+		taskToRemind.Version++
+
+		event := model.NewTaskEvent(model.TaskEventReminded, task, taskToRemind, model.SystemActor, now)
+		if err := dao.apply(taskRecord{Op: recordOpPut, Task: taskToRemind, UUID: taskUUID, Event: event}); err != nil {
+			return reminded, err
+		}
+		reminded = append(reminded, copyTask(taskToRemind))
+	}
+
+	return reminded, nil
+}
+
+func (dao *TaskInMemoryDAO) ReadHistory(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskEvent, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	// The events of a task share its owner.
+	events := dao.events[taskUUID]
+	if len(events) == 0 || !dao.visible(ctx, taskUUID, events[0].OwnerID) {
+		return nil, fmt.Errorf("no history for the task %s : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+
+	history := make([]*model.TaskEvent, 0, len(events))
+	for _, event := range events {
+		history = append(history, copyTaskEvent(event))
+	}
+	return history, nil
+}
+
+func (dao *TaskInMemoryDAO) StopSeries(ctx context.Context, seriesUUID uuid.UUID) (int64, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	var (
+		count int64
+		found bool
+	)
+	now := time.Now()
+	for taskUUID, task := range dao.tasks {
+		if task.SeriesUUID == nil || *task.SeriesUUID != seriesUUID || !visibleOwner(ctx, task.OwnerID) {
+			continue
+		}
+		found = true
+		if task.RRule == "" {
+			continue
+		}
+
+		taskToStop := copyTask(task)
+		taskToStop.RRule = ""
+		taskToStop.LastUpdated = now
+		taskToStop.Version++
+
+		event := model.NewTaskEvent(model.TaskEventUpdated, task, taskToStop, model.ActorFromContext(ctx), now)
+		if err := dao.apply(taskRecord{Op: recordOpPut, Task: taskToStop, UUID: taskUUID, Event: event}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if !found {
+		return 0, fmt.Errorf("no series with this UUID (%s) exist : %w", seriesUUID.String(), &NoDataFoundError{})
+	}
+	return count, nil
+}
 
-	taskToUpdate, exist := dao.tasks[*taskUUID]
+func (dao *TaskInMemoryDAO) ReadSubtree(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	root, exist := dao.tasks[taskUUID]
+	if !exist || root.DeletedAt != nil || !dao.visible(ctx, taskUUID, root.OwnerID) {
+		return nil, fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+
+	children := dao.liveChildren()
+	subtree := []*model.Task{dao.readTask(root)}
+	seen := map[uuid.UUID]bool{taskUUID: true}
+	// The walk only goes through the tasks visible by the caller.
+	for i := 0; i < len(subtree); i++ {
+		for _, child := range children[subtree[i].UUID] {
+			if !seen[child.UUID] && dao.visible(ctx, child.UUID, child.OwnerID) {
+				seen[child.UUID] = true
+				subtree = append(subtree, dao.readTask(child))
+			}
+		}
+	}
+
+	sort.Slice(subtree, func(i, j int) bool {
+		return compareTasks(subtree[i], subtree[j], model.TaskSortCreatedAt) < 0
+	})
+	return subtree, nil
+}
+
+func (dao *TaskInMemoryDAO) ReadAncestors(ctx context.Context, taskUUID uuid.UUID) ([]uuid.UUID, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	ancestors := make([]uuid.UUID, 0)
+	seen := map[uuid.UUID]bool{taskUUID: true}
+	for task := dao.tasks[taskUUID]; task != nil && task.ParentUUID != nil && !seen[*task.ParentUUID]; task = dao.tasks[*task.ParentUUID] {
+		seen[*task.ParentUUID] = true
+		ancestors = append(ancestors, *task.ParentUUID)
+	}
+	return ancestors, nil
+}
+
+func (dao *TaskInMemoryDAO) ReadRole(ctx context.Context, taskUUID uuid.UUID) (model.TaskRole, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	task, exist := dao.tasks[taskUUID]
+	if !exist {
+		return "", fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+
+	principalID, scoped := model.OwnerScopeFromContext(ctx)
+	if !scoped || principalID == task.OwnerID {
+		return model.TaskRoleOwner, nil
+	}
+
+	grant, exist := dao.grants[taskUUID][principalID]
 	if !exist {
-		return fmt.Errorf("no task with this UUID (%s) exist", taskUUID.String())
+		return "", fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+	return grant.Role, nil
+}
+
+func (dao *TaskInMemoryDAO) SaveTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	storedTask, err := dao.liveTask(ctx, taskUUID, version)
+	if err != nil {
+		return err
+	}
+	tag, err := dao.visibleTag(ctx, tagUUID)
+	if err != nil {
+		return err
+	}
+	if dao.taskTags[taskUUID][tagUUID] {
+		return nil
+	}
+
+	return dao.applyTaskTag(ctx, recordOpTag, storedTask, tag)
+}
+
+func (dao *TaskInMemoryDAO) DeleteTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	storedTask, err := dao.liveTask(ctx, taskUUID, version)
+	if err != nil {
+		return err
+	}
+	if !dao.taskTags[taskUUID][tagUUID] {
+		return fmt.Errorf("no tag %s on the task %s : %w", tagUUID.String(), taskUUID.String(), &NoDataFoundError{})
+	}
+
+	return dao.applyTaskTag(ctx, recordOpUntag, storedTask, dao.tags[tagUUID])
+}
+
+// applyTaskTag puts the tag on the task or takes it off according to op, as a new version of the task.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) applyTaskTag(ctx context.Context, op string, storedTask *model.Task, tag *model.Tag) error {
+	taskToTag := copyTask(storedTask)
+	taskToTag.LastUpdated = time.Now()
+	taskToTag.Version++
+
+	// The tags are only set on the tasks of the event, the stored task never holds them.
+	before, after := dao.readTask(storedTask), copyTask(taskToTag)
+	if op == recordOpTag {
+		after.Tags = sortedTagNames(append(before.Tags, tag.Name))
+	} else {
+		after.Tags = dao.tagNames(storedTask.UUID, tag.UUID)
+	}
+
+	event := model.NewTaskEvent(model.TaskEventUpdated, before, after, model.ActorFromContext(ctx), taskToTag.LastUpdated)
+	return dao.apply(taskRecord{Op: op, Task: taskToTag, UUID: storedTask.UUID, Event: event, Tag: tag})
+}
+
+// visibleTag returns the stored tag if it is owned by the principal of ctx.
+// It must be called with the lock held.
+func (dao *TaskInMemoryDAO) visibleTag(ctx context.Context, tagUUID uuid.UUID) (*model.Tag, error) {
+	tag, exist := dao.tags[tagUUID]
+	if !exist || !visibleOwner(ctx, tag.OwnerID) {
+		return nil, fmt.Errorf("no tag with this UUID (%s) exist : %w", tagUUID.String(), &NoDataFoundError{})
+	}
+	return tag, nil
+}
+
+// readTask returns a copy of the stored task with the names of its tags.
+// It must be called with the lock held.
+func (dao *TaskInMemoryDAO) readTask(task *model.Task) *model.Task {
+	taskCopy := copyTask(task)
+	taskCopy.Tags = dao.tagNames(task.UUID, uuid.Nil)
+	return taskCopy
+}
+
+// tagNames returns the sorted names of the tags of the task, without the excluded tag.
+// It must be called with the lock held.
+func (dao *TaskInMemoryDAO) tagNames(taskUUID, excluded uuid.UUID) []string {
+	var names []string
+	for tagUUID := range dao.taskTags[taskUUID] {
+		if tag, exist := dao.tags[tagUUID]; exist && tagUUID != excluded {
+			names = append(names, tag.Name)
+		}
 	}
+	return sortedTagNames(names)
+}
+
+// sortedTagNames sorts the names of the tags of a task, the tags of different owners may share a name.
+func sortedTagNames(names []string) []string {
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// visible reports whether the task is owned by or shared with the principal of ctx.
+// It must be called with the lock held.
+func (dao *TaskInMemoryDAO) visible(ctx context.Context, taskUUID uuid.UUID, ownerID string) bool {
+	if visibleOwner(ctx, ownerID) {
+		return true
+	}
+
+	principalID, _ := model.OwnerScopeFromContext(ctx)
+	_, granted := dao.grants[taskUUID][principalID]
+	return granted
+}
 
-	for _, field := range fieldsName {
-		value, castable := valuesToUpdate[field].(string)
-		if !castable {
-			return fmt.Errorf("value for field %s not castable to string", field)
+// liveChildren returns the live tasks indexed by their parent.
+// It must be called with the lock held.
+func (dao *TaskInMemoryDAO) liveChildren() map[uuid.UUID][]*model.Task {
+	children := make(map[uuid.UUID][]*model.Task)
+	for _, task := range dao.tasks {
+		if task.ParentUUID != nil && task.DeletedAt == nil {
+			children[*task.ParentUUID] = append(children[*task.ParentUUID], task)
 		}
+	}
+	return children
+}
 
-		switch field {
-		case "description":
-			taskToUpdate.WhatToDo = value
+// descendants returns the live descendants of the task, whoever owns them.
+// It must be called with the lock held.
+func (dao *TaskInMemoryDAO) descendants(taskUUID uuid.UUID) []*model.Task {
+	children := dao.liveChildren()
+	descendants := make([]*model.Task, 0)
+	seen := map[uuid.UUID]bool{taskUUID: true}
+	for parents := []uuid.UUID{taskUUID}; len(parents) > 0; parents = parents[1:] {
+		for _, child := range children[parents[0]] {
+			if !seen[child.UUID] {
+				seen[child.UUID] = true
+				descendants = append(descendants, child)
+				parents = append(parents, child.UUID)
+			}
+		}
+	}
+	return descendants
+}
 
-		case "status":
-			taskToUpdate.Status = value
+// trashedDescendants returns the descendants of the trashed task deleted at the same time, by a cascade delete of the task,
+// whoever owns them. The subtasks deleted before it and their subtrees are left out.
+// It must be called with the lock held.
+func (dao *TaskInMemoryDAO) trashedDescendants(task *model.Task) []*model.Task {
+	children := make(map[uuid.UUID][]*model.Task)
+	for _, child := range dao.tasks {
+		if child.ParentUUID != nil && child.DeletedAt != nil && child.DeletedAt.Equal(*task.DeletedAt) {
+			children[*child.ParentUUID] = append(children[*child.ParentUUID], child)
+		}
+	}
 
-		default:
-			return fmt.Errorf("unknown field name %s", field)
+	descendants := make([]*model.Task, 0)
+	for parents := []uuid.UUID{task.UUID}; len(parents) > 0; parents = parents[1:] {
+		for _, child := range children[parents[0]] {
+			descendants = append(descendants, child)
+			parents = append(parents, child.UUID)
 		}
 	}
+	return descendants
+}
 
-	taskToUpdate.LastUpdated = time.Now()
+// apply persists the record then applies it on the tasks, the record is only staged during an atomic batch.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) apply(record taskRecord) error {
+	if dao.staged != nil {
+		dao.staged = append(dao.staged, record)
+		dao.applyRecord(record)
+		return nil
+	}
+
+	if err := dao.persist(record); err != nil {
+		return err
+	}
+
+	dao.applyRecord(record)
 	return nil
 }
 
-func (dao *TaskInMemoryDAO) Delete(ctx context.Context) error {
-	strUUID := ctx.Value("task_uuid")
-	taskUUID, castable := strUUID.(*uuid.UUID)
-	if !castable {
-		return fmt.Errorf("can't cast the context value to *uuid.UUID")
+// liveTask returns the stored task if it isn't in the trash, is visible by the principal of ctx
+// and is at the expected version, 0 skips the check.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) liveTask(ctx context.Context, taskUUID uuid.UUID, version int64) (*model.Task, error) {
+	storedTask, exist := dao.tasks[taskUUID]
+	if !exist || storedTask.DeletedAt != nil || !dao.visible(ctx, taskUUID, storedTask.OwnerID) {
+		return nil, fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+	if version != 0 && version != storedTask.Version {
+		return nil, &VersionMismatchError{Expected: version, Current: storedTask.Version}
+	}
+
+	return storedTask, nil
+}
+
+// persist appends the record to the file, and compacts the file when it grew too much.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) persist(record taskRecord) error {
+	if dao.file == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("can't marshal the record : %w", err)
 	}
 
-	if _, exist := dao.tasks[*taskUUID]; !exist {
-		return fmt.Errorf("no task with this UUID (%s) exist", taskUUID.String())
+	if err := dao.file.Append(raw); err != nil {
+		return fmt.Errorf("can't persist the record : %w", err)
+	}
+
+	if dao.file.NeedCompaction() {
+		// The record is already safe in the file, a failed compaction is retried on the next change.
+		if err := dao.compact(record); err != nil {
+			log.Error("fail to compact the tasks file", zap.String("connector", dao.connectorName), zap.Error(err))
+		}
 	}
 
-	delete(dao.tasks, *taskUUID)
 	return nil
 }
 
-// factoryTaskInMemoryDAO build TaskInMemoryDAO.
+// compact replaces the file content by a snapshot of the tasks, including the pending record.
+// It must be called with the write lock held.
+func (dao *TaskInMemoryDAO) compact(pending taskRecord) error {
+	snapshot := dao.taskState.clone()
+	snapshot.applyRecord(pending)
+
+	records, err := snapshot.records()
+	if err != nil {
+		return err
+	}
+
+	return dao.file.Compact(records)
+}
+
+// load replays the records of the file then compacts it.
+func (dao *TaskInMemoryDAO) load() error {
+	records, err := dao.file.Records()
+	if err != nil {
+		return fmt.Errorf("can't read the tasks file : %w", err)
+	}
+
+	for i, raw := range records {
+		var record taskRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("can't unmarshal the record %d : %w", i+1, err)
+		}
+		dao.applyRecord(record)
+	}
+
+	snapshot, err := dao.records()
+	if err != nil {
+		return err
+	}
+
+	return dao.file.Compact(snapshot)
+}
+
+// newTaskState returns an empty taskState.
+func newTaskState() taskState {
+	return taskState{
+		tasks:        make(map[uuid.UUID]*model.Task),
+		events:       make(map[uuid.UUID][]*model.TaskEvent),
+		grants:       make(map[uuid.UUID]map[string]*model.TaskGrant),
+		dependencies: make(map[uuid.UUID]map[uuid.UUID]*model.TaskDependency),
+		tags:         make(map[uuid.UUID]*model.Tag),
+		taskTags:     make(map[uuid.UUID]map[uuid.UUID]bool),
+		index:        newTaskSearchIndex(),
+	}
+}
+
+// clone returns a copy of the state sharing its tasks, events, grants, dependencies and tags, which are never mutated.
+// The full-text index is copied.
+func (state taskState) clone() taskState {
+	snapshot := taskState{
+		tasks:        maps.Clone(state.tasks),
+		events:       make(map[uuid.UUID][]*model.TaskEvent, len(state.events)+1),
+		grants:       make(map[uuid.UUID]map[string]*model.TaskGrant, len(state.grants)+1),
+		dependencies: make(map[uuid.UUID]map[uuid.UUID]*model.TaskDependency, len(state.dependencies)+1),
+		tags:         maps.Clone(state.tags),
+		taskTags:     make(map[uuid.UUID]map[uuid.UUID]bool, len(state.taskTags)+1),
+		index:        state.index.clone(),
+	}
+	for taskUUID, events := range state.events {
+		snapshot.events[taskUUID] = slices.Clip(events)
+	}
+	for taskUUID, grants := range state.grants {
+		snapshot.grants[taskUUID] = maps.Clone(grants)
+	}
+	for taskUUID, dependencies := range state.dependencies {
+		snapshot.dependencies[taskUUID] = maps.Clone(dependencies)
+	}
+	for taskUUID, tags := range state.taskTags {
+		snapshot.taskTags[taskUUID] = maps.Clone(tags)
+	}
+	return snapshot
+}
+
+// records returns one event record per event, in the order of the history, one put record per task,
+// one grant record per grant, one depend record per dependency, one put_tag record per tag
+// and one tag record per tag of a task.
+func (state taskState) records() ([][]byte, error) {
+	tasks, events, grants, dependencies := state.tasks, state.events, state.grants, state.dependencies
+	records := make([][]byte, 0, len(tasks)+len(events))
+	for taskUUID, history := range events {
+		for _, event := range history {
+			raw, err := json.Marshal(taskRecord{Op: recordOpEvent, UUID: taskUUID, Event: event})
+			if err != nil {
+				return nil, fmt.Errorf("can't marshal the record : %w", err)
+			}
+			records = append(records, raw)
+		}
+	}
+	for taskUUID, task := range tasks {
+		raw, err := json.Marshal(taskRecord{Op: recordOpPut, Task: task, UUID: taskUUID})
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal the record : %w", err)
+		}
+		records = append(records, raw)
+	}
+	for taskUUID, taskGrants := range grants {
+		for _, grant := range taskGrants {
+			raw, err := json.Marshal(taskRecord{Op: recordOpGrant, UUID: taskUUID, Grant: grant})
+			if err != nil {
+				return nil, fmt.Errorf("can't marshal the record : %w", err)
+			}
+			records = append(records, raw)
+		}
+	}
+	for taskUUID, taskDependencies := range dependencies {
+		for _, dependency := range taskDependencies {
+			raw, err := json.Marshal(taskRecord{Op: recordOpDepend, UUID: taskUUID, Dependency: dependency})
+			if err != nil {
+				return nil, fmt.Errorf("can't marshal the record : %w", err)
+			}
+			records = append(records, raw)
+		}
+	}
+	for tagUUID, tag := range state.tags {
+		raw, err := json.Marshal(taskRecord{Op: recordOpPutTag, UUID: tagUUID, Tag: tag})
+		if err != nil {
+			return nil, fmt.Errorf("can't marshal the record : %w", err)
+		}
+		records = append(records, raw)
+	}
+	for taskUUID, tags := range state.taskTags {
+		for tagUUID := range tags {
+			raw, err := json.Marshal(taskRecord{Op: recordOpTag, UUID: taskUUID, Tag: state.tags[tagUUID]})
+			if err != nil {
+				return nil, fmt.Errorf("can't marshal the record : %w", err)
+			}
+			records = append(records, raw)
+		}
+	}
+
+	return records, nil
+}
+
+// applyRecord applies a record of the log file on the tasks, their history, their grants, their dependencies, their tags
+// and the full-text index.
+func (state taskState) applyRecord(record taskRecord) {
+	tasks, events, grants, dependencies := state.tasks, state.events, state.grants, state.dependencies
+	if record.Event != nil {
+		events[record.UUID] = append(events[record.UUID], record.Event)
+	}
+
+	switch record.Op {
+	case recordOpBatch:
+		for _, batchRecord := range record.Records {
+			state.applyRecord(batchRecord)
+		}
+	case recordOpPut:
+		if record.Task != nil {
+			// Tasks written before the versioning start at version 1.
+			if record.Task.Version == 0 {
+				record.Task.Version = 1
+			}
+			tasks[record.UUID] = record.Task
+			state.index.put(record.UUID, record.Task.WhatToDo)
+		}
+	case recordOpDelete:
+		delete(tasks, record.UUID)
+		state.index.remove(record.UUID)
+		delete(grants, record.UUID)
+		delete(dependencies, record.UUID)
+		for taskUUID := range dependencies {
+			delete(dependencies[taskUUID], record.UUID)
+			if len(dependencies[taskUUID]) == 0 {
+				delete(dependencies, taskUUID)
+			}
+		}
+		delete(state.taskTags, record.UUID)
+	case recordOpGrant:
+		if grants[record.UUID] == nil {
+			grants[record.UUID] = make(map[string]*model.TaskGrant)
+		}
+		grants[record.UUID][record.Grant.PrincipalID] = record.Grant
+	case recordOpRevoke:
+		delete(grants[record.UUID], record.Grant.PrincipalID)
+		if len(grants[record.UUID]) == 0 {
+			delete(grants, record.UUID)
+		}
+	case recordOpDepend:
+		if dependencies[record.UUID] == nil {
+			dependencies[record.UUID] = make(map[uuid.UUID]*model.TaskDependency)
+		}
+		dependencies[record.UUID][record.Dependency.BlockerUUID] = record.Dependency
+	case recordOpUndepend:
+		delete(dependencies[record.UUID], record.Dependency.BlockerUUID)
+		if len(dependencies[record.UUID]) == 0 {
+			delete(dependencies, record.UUID)
+		}
+	case recordOpPutTag:
+		state.tags[record.UUID] = record.Tag
+	case recordOpDeleteTag:
+		delete(state.tags, record.UUID)
+		for taskUUID := range state.taskTags {
+			delete(state.taskTags[taskUUID], record.UUID)
+			if len(state.taskTags[taskUUID]) == 0 {
+				delete(state.taskTags, taskUUID)
+			}
+		}
+	case recordOpTag:
+		if state.taskTags[record.UUID] == nil {
+			state.taskTags[record.UUID] = make(map[uuid.UUID]bool)
+		}
+		state.taskTags[record.UUID][record.Tag.UUID] = true
+		if record.Task != nil {
+			tasks[record.UUID] = record.Task
+			state.index.put(record.UUID, record.Task.WhatToDo)
+		}
+	case recordOpUntag:
+		delete(state.taskTags[record.UUID], record.Tag.UUID)
+		if len(state.taskTags[record.UUID]) == 0 {
+			delete(state.taskTags, record.UUID)
+		}
+		if record.Task != nil {
+			tasks[record.UUID] = record.Task
+			state.index.put(record.UUID, record.Task.WhatToDo)
+		}
+	}
+}
+
+// copyTask returns a copy of the task, so callers can't mutate the stored state.
+func copyTask(task *model.Task) *model.Task {
+	taskCopy := *task
+	if task.DeletedAt != nil {
+		deletedAt := *task.DeletedAt
+		taskCopy.DeletedAt = &deletedAt
+	}
+	if task.ListUUID != nil {
+		listUUID := *task.ListUUID
+		taskCopy.ListUUID = &listUUID
+	}
+	taskCopy.DueAt = copyTime(task.DueAt)
+	taskCopy.RemindAt = copyTime(task.RemindAt)
+	taskCopy.RemindedAt = copyTime(task.RemindedAt)
+	if task.SeriesUUID != nil {
+		seriesUUID := *task.SeriesUUID
+		taskCopy.SeriesUUID = &seriesUUID
+	}
+	if task.ParentUUID != nil {
+		parentUUID := *task.ParentUUID
+		taskCopy.ParentUUID = &parentUUID
+	}
+	taskCopy.Tags = slices.Clone(task.Tags)
+	return &taskCopy
+}
+
+// copyTaskEvent returns a deep copy of an event.
+func copyTaskEvent(event *model.TaskEvent) *model.TaskEvent {
+	eventCopy := *event
+	eventCopy.Changes = make([]model.TaskFieldChange, 0, len(event.Changes))
+	for _, change := range event.Changes {
+		change.Old = copyString(change.Old)
+		change.New = copyString(change.New)
+		eventCopy.Changes = append(eventCopy.Changes, change)
+	}
+	return &eventCopy
+}
+
+// copyString returns a copy of an optional string.
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	sCopy := *s
+	return &sCopy
+}
+
+// copyTime returns a copy of an optional date.
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	tCopy := *t
+	return &tCopy
+}
+
+// sameTime reports whether two optional dates are the same instant.
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// optionalUUID returns nil for uuid.Nil and a pointer to id otherwise.
+func optionalUUID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
+
+// optionalTime returns nil for the zero time and a pointer to t otherwise.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// factoryTaskInMemoryDAO build TaskInMemoryDAO, the tasks are persisted if opt.Connector names a File connector.
 func factoryTaskInMemoryDAO(opt DAOFactoryOptions) (*TaskInMemoryDAO, error) {
-	return &TaskInMemoryDAO{
+	dao := &TaskInMemoryDAO{
 		connectorName: opt.Connector,
-		tasks:         make(map[uuid.UUID]*model.Task),
-	}, nil
+		taskState:     newTaskState(),
+	}
+
+	if opt.Connector == "" {
+		return dao, nil
+	}
+
+	file, err := connectors.GetConnectorFile(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+	dao.file = file
+
+	if err := dao.load(); err != nil {
+		return nil, fmt.Errorf("fail to load tasks: %w", err)
+	}
+
+	return dao, nil
 }
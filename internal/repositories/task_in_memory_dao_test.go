@@ -0,0 +1,539 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// newTestTaskInMemoryDAO returns an empty TaskInMemoryDAO without file.
+func newTestTaskInMemoryDAO(t *testing.T) *TaskInMemoryDAO {
+	t.Helper()
+
+	dao, err := factoryTaskInMemoryDAO(DAOFactoryOptions{Type: TypeTaskInMemoryDAO})
+	if err != nil {
+		t.Fatalf("factoryTaskInMemoryDAO() error = %v", err)
+	}
+	return dao
+}
+
+func TestTaskInMemoryDAOUpdate(t *testing.T) {
+	dao := newTestTaskInMemoryDAO(t)
+	ctx := context.Background()
+
+	due := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	listUUID := uuid.New()
+	created, err := dao.Create(ctx, &model.TaskCreateDTO{WhatToDo: "write the report", Status: "todo", DueAt: &due, ListUUID: &listUUID})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.Version != 1 {
+		t.Errorf("created version = %d, want 1", created.Version)
+	}
+
+	// The nil fields of the patch are left unchanged.
+	status := model.TaskStatus("in_progress")
+	if err := dao.Update(ctx, created.UUID, &model.TaskPatch{Status: &status}, 1); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	task, err := dao.ReadByUUID(ctx, created.UUID)
+	if err != nil {
+		t.Fatalf("ReadByUUID() error = %v", err)
+	}
+	if task.Status != status || task.WhatToDo != "write the report" || task.DueAt == nil || !task.DueAt.Equal(due) || task.ListUUID == nil {
+		t.Errorf("updated task = %+v, want only the status changed", task)
+	}
+	if task.Version != 2 || !task.LastUpdated.After(created.LastUpdated) {
+		t.Errorf("updated task version %d at %v, want version 2 after %v", task.Version, task.LastUpdated, created.LastUpdated)
+	}
+
+	// The zero values remove the optional fields.
+	if err := dao.Update(ctx, created.UUID, &model.TaskPatch{DueAt: new(time.Time), ListUUID: &uuid.Nil}, 0); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if task, _ = dao.ReadByUUID(ctx, created.UUID); task.DueAt != nil || task.ListUUID != nil {
+		t.Errorf("updated task = %+v, want no due date and no list", task)
+	}
+
+	var errVersionMismatch *VersionMismatchError
+	if err := dao.Update(ctx, created.UUID, &model.TaskPatch{Status: &status}, 1); !errors.As(err, &errVersionMismatch) {
+		t.Errorf("Update() of version 1 error = %v, want a VersionMismatchError", err)
+	}
+
+	var errNoDataFound *NoDataFoundError
+	if err := dao.Update(ctx, uuid.New(), &model.TaskPatch{Status: &status}, 0); !errors.As(err, &errNoDataFound) {
+		t.Errorf("Update() of an unknown task error = %v, want a NoDataFoundError", err)
+	}
+}
+
+func TestTaskInMemoryDAOReadsCopies(t *testing.T) {
+	dao := newTestTaskInMemoryDAO(t)
+	daoGrant := &TaskGrantInMemoryDAO{tasks: dao}
+	daoTag := &TagInMemoryDAO{tasks: dao}
+	ctx := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+
+	due := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	listUUID := uuid.New()
+	taskToCreate := &model.TaskCreateDTO{WhatToDo: "write the report", Status: "todo", DueAt: &due, ListUUID: &listUUID}
+	created, err := dao.Create(ctx, taskToCreate)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Nor does the DTO of the creation.
+	read, err := dao.ReadByUUID(ctx, created.UUID)
+	if err != nil {
+		t.Fatalf("ReadByUUID() error = %v", err)
+	}
+	*taskToCreate.DueAt = due.AddDate(1, 0, 0)
+	*taskToCreate.ListUUID = uuid.New()
+	after, err := dao.ReadByUUID(ctx, created.UUID)
+	if err != nil {
+		t.Fatalf("ReadByUUID() error = %v", err)
+	}
+	if got, want := snapshotJSON(t, after), snapshotJSON(t, read); got != want {
+		t.Errorf("stored task = %s, want %s", got, want)
+	}
+
+	tag, err := daoTag.CreateTag(ctx, &model.TagCreateDTO{Name: "work"})
+	if err != nil {
+		t.Fatalf("CreateTag() error = %v", err)
+	}
+	if err := dao.SaveTaskTag(ctx, created.UUID, tag.UUID, 0); err != nil {
+		t.Fatalf("SaveTaskTag() error = %v", err)
+	}
+	if err := daoGrant.SaveGrant(ctx, &model.TaskGrant{TaskUUID: created.UUID, PrincipalID: "bob", Role: model.TaskRoleViewer}); err != nil {
+		t.Fatalf("SaveGrant() error = %v", err)
+	}
+	before, err := dao.ReadByUUID(ctx, created.UUID)
+	if err != nil {
+		t.Fatalf("ReadByUUID() error = %v", err)
+	}
+	want := snapshotJSON(t, before)
+
+	// The tasks returned don't share memory with the stored task.
+	mutateTask(created)
+	mutateTask(before)
+	all, _, err := dao.ReadAll(ctx, &model.TaskFilterDTO{Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Limit: 10})
+	if err != nil || len(all) != 1 {
+		t.Fatalf("ReadAll() = %v, %v, want the task", all, err)
+	}
+	mutateTask(all[0])
+	results, err := dao.Search(ctx, &model.TaskSearchDTO{Query: "report", Language: model.TaskSearchSimple, Limit: 10})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Search() = %v, %v, want the task", results, err)
+	}
+	mutateTask(results[0].Task)
+
+	after, err = dao.ReadByUUID(ctx, created.UUID)
+	if err != nil {
+		t.Fatalf("ReadByUUID() error = %v", err)
+	}
+	if got := snapshotJSON(t, after); got != want {
+		t.Errorf("stored task = %s, want %s", got, want)
+	}
+
+	// Nor do the history, the grants and the tags.
+	history, err := dao.ReadHistory(ctx, created.UUID)
+	if err != nil {
+		t.Fatalf("ReadHistory() error = %v", err)
+	}
+	wantHistory := snapshotJSON(t, history)
+	for _, event := range history {
+		event.Actor = "mallory"
+		for i := range event.Changes {
+			if event.Changes[i].New != nil {
+				*event.Changes[i].New = "mutated"
+			}
+		}
+	}
+	history, _ = dao.ReadHistory(ctx, created.UUID)
+	if got := snapshotJSON(t, history); got != wantHistory {
+		t.Errorf("stored history = %s, want %s", got, wantHistory)
+	}
+
+	grants, _ := daoGrant.ReadGrants(ctx, created.UUID)
+	grants[0].Role = model.TaskRoleOwner
+	if grants, _ = daoGrant.ReadGrants(ctx, created.UUID); grants[0].Role != model.TaskRoleViewer {
+		t.Errorf("stored grant role = %s, want viewer", grants[0].Role)
+	}
+
+	tags, _ := daoTag.ReadTags(ctx)
+	tags[0].Name = "mutated"
+	tag.Name = "mutated"
+	if tags, _ = daoTag.ReadTags(ctx); tags[0].Name != "work" {
+		t.Errorf("stored tag name = %s, want work", tags[0].Name)
+	}
+}
+
+// mutateTask changes every field of the task, and the values its pointers point to.
+func mutateTask(task *model.Task) {
+	task.WhatToDo = "mutated"
+	task.Status = "mutated"
+	task.Version = 42
+	*task.DueAt = task.DueAt.AddDate(1, 0, 0)
+	*task.ListUUID = uuid.New()
+	if len(task.Tags) > 0 {
+		task.Tags[0] = "mutated"
+	}
+}
+
+// snapshotJSON returns the JSON encoding of the value, to compare the values holding dates.
+func snapshotJSON(t *testing.T, value any) string {
+	t.Helper()
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("fail to marshal %v: %v", value, err)
+	}
+	return string(raw)
+}
+
+// TestTaskInMemoryDAOConcurrency runs the operations of the DAO from many goroutines, it is meant for go test -race.
+func TestTaskInMemoryDAOConcurrency(t *testing.T) {
+	tests := []struct {
+		name         string
+		compactEvery int
+	}{
+		{name: "memory"},
+		// The file is compacted many times while the goroutines write.
+		{name: "file", compactEvery: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := DAOFactoryOptions{Type: TypeTaskInMemoryDAO}
+			if tt.compactEvery > 0 {
+				opt.Connector = newTestFileConnector(t, tt.compactEvery)
+			}
+			dao, err := factoryTaskInMemoryDAO(opt)
+			if err != nil {
+				t.Fatalf("factoryTaskInMemoryDAO() error = %v", err)
+			}
+
+			const workers, tasksPerWorker = 8, 30
+			live := make([]int, workers)
+			var wg sync.WaitGroup
+			errs := make(chan error, workers+1)
+
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					errs <- runTaskDAOWorker(dao, fmt.Sprintf("worker-%d", w), tasksPerWorker, &live[w])
+				}(w)
+			}
+
+			// An admin reads everything meanwhile.
+			stop := make(chan struct{})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				admin := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "admin", Admin: true})
+				for {
+					select {
+					case <-stop:
+						errs <- nil
+						return
+					default:
+					}
+					if _, _, err := dao.ReadAll(admin, &model.TaskFilterDTO{Sort: model.TaskSortLastUpdated, Order: model.SortOrderDesc, Limit: 20}); err != nil {
+						errs <- err
+						return
+					}
+					if _, err := dao.Search(admin, &model.TaskSearchDTO{Query: "task*", Language: model.TaskSearchSimple, Limit: 20}); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+
+			for w := 0; w < workers; w++ {
+				if err := <-errs; err != nil {
+					t.Error(err)
+				}
+			}
+			close(stop)
+			wg.Wait()
+			if err := <-errs; err != nil {
+				t.Error(err)
+			}
+
+			checkWorkerTasks := func(dao *TaskInMemoryDAO) {
+				for w := 0; w < workers; w++ {
+					ctx := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: fmt.Sprintf("worker-%d", w)})
+					tasks, _, err := dao.ReadAll(ctx, &model.TaskFilterDTO{Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Limit: model.MaxTaskListLimit})
+					if err != nil {
+						t.Fatalf("ReadAll() error = %v", err)
+					}
+					if len(tasks) != live[w] {
+						t.Errorf("worker-%d has %d live tasks, want %d", w, len(tasks), live[w])
+					}
+					for _, task := range tasks {
+						if task.Status != "done" || task.Version < 2 {
+							t.Errorf("task %v is %s at version %d, want done after its update", task.UUID, task.Status, task.Version)
+						}
+					}
+				}
+			}
+			checkWorkerTasks(dao)
+
+			if opt.Connector != "" {
+				reopenTestFileConnector(t, opt.Connector)
+				reloaded, err := factoryTaskInMemoryDAO(opt)
+				if err != nil {
+					t.Fatalf("factoryTaskInMemoryDAO() error = %v", err)
+				}
+				checkWorkerTasks(reloaded)
+			}
+		})
+	}
+}
+
+// runTaskDAOWorker creates, reads, updates, deletes and restores tasks as the principal, and counts its live tasks.
+func runTaskDAOWorker(dao *TaskInMemoryDAO, principal string, count int, live *int) error {
+	ctx := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: principal})
+	done := model.TaskStatus("done")
+
+	for i := 0; i < count; i++ {
+		task, err := dao.Create(ctx, &model.TaskCreateDTO{WhatToDo: fmt.Sprintf("task %d of %s", i, principal), Status: "todo"})
+		if err != nil {
+			return fmt.Errorf("Create() error = %w", err)
+		}
+		if err := dao.Update(ctx, task.UUID, &model.TaskPatch{Status: &done}, task.Version); err != nil {
+			return fmt.Errorf("Update() error = %w", err)
+		}
+		read, err := dao.ReadByUUID(ctx, task.UUID)
+		if err != nil {
+			return fmt.Errorf("ReadByUUID() error = %w", err)
+		}
+		if read.Version != 2 || read.Status != done || read.OwnerID != principal {
+			return fmt.Errorf("ReadByUUID() = %+v, want the updated task of %s", read, principal)
+		}
+		if _, _, err := dao.ReadAll(ctx, &model.TaskFilterDTO{Sort: model.TaskSortDescription, Order: model.SortOrderAsc, Limit: 5}); err != nil {
+			return fmt.Errorf("ReadAll() error = %w", err)
+		}
+		if _, err := dao.Search(ctx, &model.TaskSearchDTO{Query: "task", Language: model.TaskSearchSimple, Limit: 5}); err != nil {
+			return fmt.Errorf("Search() error = %w", err)
+		}
+		*live++
+
+		if i%3 == 0 {
+			if err := dao.Delete(ctx, task.UUID, read.Version, model.TaskDeleteRestrict); err != nil {
+				return fmt.Errorf("Delete() error = %w", err)
+			}
+			*live--
+		}
+		if i%6 == 0 {
+			if _, err := dao.Restore(ctx, task.UUID); err != nil {
+				return fmt.Errorf("Restore() error = %w", err)
+			}
+			*live++
+		}
+	}
+
+	return nil
+}
+
+// TestTaskInMemoryDAOReload writes through a File connector and checks a new DAO reading the file finds the same state.
+func TestTaskInMemoryDAOReload(t *testing.T) {
+	tests := []struct {
+		name         string
+		compactEvery int
+	}{
+		{name: "log", compactEvery: 1000},
+		{name: "compacted", compactEvery: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := DAOFactoryOptions{Type: TypeTaskInMemoryDAO, Connector: newTestFileConnector(t, tt.compactEvery)}
+			dao, err := factoryTaskInMemoryDAO(opt)
+			if err != nil {
+				t.Fatalf("factoryTaskInMemoryDAO() error = %v", err)
+			}
+			daoGrant := &TaskGrantInMemoryDAO{tasks: dao}
+			daoDependency := &TaskDependencyInMemoryDAO{tasks: dao}
+			daoTag := &TagInMemoryDAO{tasks: dao}
+
+			alice := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice"})
+			due := time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)
+			report, err := dao.Create(alice, &model.TaskCreateDTO{WhatToDo: "write the report", Status: "todo", DueAt: &due})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			review, err := dao.Create(alice, &model.TaskCreateDTO{WhatToDo: "review the report", Status: "todo", ParentUUID: &report.UUID})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			trashed, err := dao.Create(alice, &model.TaskCreateDTO{WhatToDo: "old idea", Status: "todo"})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			status := model.TaskStatus("in_progress")
+			description := "write the quarterly report"
+			steps := []func() error{
+				func() error { return dao.Update(alice, report.UUID, &model.TaskPatch{Status: &status}, 1) },
+				func() error { return dao.Update(alice, report.UUID, &model.TaskPatch{WhatToDo: &description}, 2) },
+				func() error { return dao.Delete(alice, trashed.UUID, 1, model.TaskDeleteRestrict) },
+				func() error {
+					return daoGrant.SaveGrant(alice, &model.TaskGrant{TaskUUID: report.UUID, PrincipalID: "bob", Role: model.TaskRoleEditor})
+				},
+				func() error {
+					return daoDependency.SaveDependency(alice, &model.TaskDependency{TaskUUID: report.UUID, BlockerUUID: review.UUID})
+				},
+				func() error {
+					tag, err := daoTag.CreateTag(alice, &model.TagCreateDTO{Name: "work"})
+					if err != nil {
+						return err
+					}
+					return dao.SaveTaskTag(alice, report.UUID, tag.UUID, 0)
+				},
+			}
+			for i, step := range steps {
+				if err := step(); err != nil {
+					t.Fatalf("step %d error = %v", i, err)
+				}
+			}
+
+			want := taskDAOSnapshot(t, dao, alice, report.UUID, trashed.UUID)
+
+			reopenTestFileConnector(t, opt.Connector)
+			reloaded, err := factoryTaskInMemoryDAO(opt)
+			if err != nil {
+				t.Fatalf("factoryTaskInMemoryDAO() error = %v", err)
+			}
+
+			got := taskDAOSnapshot(t, reloaded, alice, report.UUID, trashed.UUID)
+			for part := range want {
+				if got[part] != want[part] {
+					t.Errorf("reloaded %s = %s, want %s", part, got[part], want[part])
+				}
+			}
+
+			// The reloaded DAO goes on from the reloaded versions.
+			if err := reloaded.Update(alice, report.UUID, &model.TaskPatch{Status: &status}, 2); err == nil {
+				t.Error("Update() of an old version succeeded after the reload")
+			}
+			if _, err := reloaded.Restore(alice, trashed.UUID); err != nil {
+				t.Errorf("Restore() after the reload error = %v", err)
+			}
+		})
+	}
+}
+
+// taskDAOSnapshot returns the JSON encoding of the state of the DAO seen by ctx, by part.
+func taskDAOSnapshot(t *testing.T, dao *TaskInMemoryDAO, ctx context.Context, taskUUID, trashedUUID uuid.UUID) map[string]string {
+	t.Helper()
+
+	snapshot := map[string]string{}
+	must := func(part string, value any, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("fail to read the %s: %v", part, err)
+		}
+		snapshot[part] = snapshotJSON(t, value)
+	}
+
+	filter := &model.TaskFilterDTO{Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Limit: 10}
+	tasks, _, err := dao.ReadAll(ctx, filter)
+	must("tasks", tasks, err)
+	trash, _, err := dao.ReadAll(ctx, &model.TaskFilterDTO{Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Limit: 10, Deleted: true})
+	must("trash", trash, err)
+	history, err := dao.ReadHistory(ctx, taskUUID)
+	must("history", history, err)
+	trashedHistory, err := dao.ReadHistory(ctx, trashedUUID)
+	must("history of the trashed task", trashedHistory, err)
+	grants, err := (&TaskGrantInMemoryDAO{tasks: dao}).ReadGrants(ctx, taskUUID)
+	must("grants", grants, err)
+	blockers, err := (&TaskDependencyInMemoryDAO{tasks: dao}).ReadBlockers(ctx, taskUUID)
+	must("blockers", blockers, err)
+	tags, err := (&TagInMemoryDAO{tasks: dao}).ReadTags(ctx)
+	must("tags", tags, err)
+	results, err := dao.Search(ctx, &model.TaskSearchDTO{Query: "quarterly", Language: model.TaskSearchSimple, Limit: 10})
+	must("search results", results, err)
+	role, err := dao.ReadRole(model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "bob"}), taskUUID)
+	must("role of bob", role, err)
+
+	if len(tasks) != 2 || len(trash) != 1 || len(history) != 4 || len(results) != 1 {
+		t.Fatalf("snapshot = %v, want 2 tasks, 1 in the trash, 4 events and 1 search result", snapshot)
+	}
+	return snapshot
+}
+
+func TestTaskInMemoryDAOPurge(t *testing.T) {
+	dao := newTestTaskInMemoryDAO(t)
+	daoGrant := &TaskGrantInMemoryDAO{tasks: dao}
+	ctx := context.Background()
+
+	kept, err := dao.Create(ctx, &model.TaskCreateDTO{WhatToDo: "kept", Status: "todo"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	parent, _ := dao.Create(ctx, &model.TaskCreateDTO{WhatToDo: "parent", Status: "todo"})
+	child, _ := dao.Create(ctx, &model.TaskCreateDTO{WhatToDo: "child", Status: "todo", ParentUUID: &parent.UUID})
+	if err := daoGrant.SaveGrant(ctx, &model.TaskGrant{TaskUUID: parent.UUID, PrincipalID: "bob", Role: model.TaskRoleViewer}); err != nil {
+		t.Fatalf("SaveGrant() error = %v", err)
+	}
+
+	// The child is restored alone, its parent stays in the trash.
+	if err := dao.Delete(ctx, parent.UUID, 0, model.TaskDeleteSubtree); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := dao.Restore(ctx, child.UUID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	deletedAt := time.Now()
+
+	// Nothing was deleted before the deletion.
+	if count, err := dao.Purge(ctx, parent.CreatedAt); err != nil || count != 0 {
+		t.Fatalf("Purge() before the deletion = %d, %v, want 0", count, err)
+	}
+	trashFilter := &model.TaskFilterDTO{Deleted: true, Sort: model.TaskSortCreatedAt, Order: model.SortOrderAsc, Limit: model.MaxTaskListLimit}
+	trash, _, err := dao.ReadAll(ctx, trashFilter)
+	if err != nil || len(trash) != 1 || trash[0].UUID != parent.UUID {
+		t.Fatalf("ReadAll() of the trash = %d tasks, %v, want the parent", len(trash), err)
+	}
+
+	count, err := dao.Purge(ctx, deletedAt)
+	if err != nil || count != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1", count, err)
+	}
+
+	if trash, _, err := dao.ReadAll(ctx, trashFilter); err != nil || len(trash) != 0 {
+		t.Errorf("ReadAll() of the trash = %d tasks, %v, want none", len(trash), err)
+	}
+	var errNoDataFound *NoDataFoundError
+	if _, err := dao.Restore(ctx, parent.UUID); !errors.As(err, &errNoDataFound) {
+		t.Errorf("Restore() of the purged task error = %v, want a NoDataFoundError", err)
+	}
+	if grants, err := daoGrant.ReadGrants(ctx, parent.UUID); err != nil || len(grants) != 0 {
+		t.Errorf("ReadGrants() of the purged task = %d grants, %v, want none", len(grants), err)
+	}
+
+	// The subtask left by the purged task is detached, the other tasks are unchanged.
+	task, err := dao.ReadByUUID(ctx, child.UUID)
+	if err != nil || task.ParentUUID != nil || task.DeletedAt != nil {
+		t.Errorf("ReadByUUID() of the child = %+v, %v, want a live task without parent", task, err)
+	}
+	if task, err := dao.ReadByUUID(ctx, kept.UUID); err != nil || task.Version != 1 {
+		t.Errorf("ReadByUUID() of the kept task = %+v, %v, want version 1", task, err)
+	}
+
+	// The history outlives the task.
+	history, err := dao.ReadHistory(ctx, parent.UUID)
+	if err != nil || len(history) == 0 {
+		t.Fatalf("ReadHistory() of the purged task = %d events, %v", len(history), err)
+	}
+	if last := history[len(history)-1]; last.Type != model.TaskEventPurged || last.Actor != model.SystemActor {
+		t.Errorf("last event = %s by %s, want purged by %s", last.Type, last.Actor, model.SystemActor)
+	}
+}
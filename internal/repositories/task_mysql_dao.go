@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// TypeTaskMySQLDAO is an identifier to build TaskMySQLDAO.
+	TypeTaskMySQLDAO = "TaskMySQLDAO"
+)
+
+var _ ITaskDAO = (*TaskMySQLDAO)(nil)
+
+// mysqlDialect is the sqlDialect of MySQL and MariaDB, the tasks table uses the utf8mb4_bin collation
+// so texts are already compared byte-wise, and LIKE escapes with a backslash by default.
+var mysqlDialect = sqlDialect{
+	placeholder: func(int) string { return "?" },
+	sortColumns: map[string]string{
+		model.TaskSortCreatedAt:   "created_at",
+		model.TaskSortLastUpdated: "last_updated",
+		model.TaskSortDescription: "description",
+		model.TaskSortStatus:      "status",
+	},
+	containsCondition: "LOWER(description) LIKE LOWER(%s)",
+	lockClause:        " FOR UPDATE",
+	wrapError:         wrapMySQLError,
+}
+
+// TaskMySQLDAO is a TaskDAO storing the tasks in a MySQL or MariaDB database.
+type TaskMySQLDAO struct {
+	taskSQLDAO
+}
+
+// wrapMySQLError wraps the errors of the MySQL driver into the error types of the repositories package.
+func wrapMySQLError(err error) error {
+	var errMySQL *mysql.MySQLError
+	if errors.As(err, &errMySQL) {
+		switch errMySQL.Number {
+		// ER_DUP_ENTRY, ER_ROW_IS_REFERENCED_2, ER_NO_REFERENCED_ROW_2, ER_LOCK_DEADLOCK
+		case 1062, 1451, 1452, 1213:
+			return &ConflictError{Err: err}
+		// ER_CON_COUNT_ERROR, ER_SERVER_SHUTDOWN, ER_LOCK_WAIT_TIMEOUT, ER_OPTION_PREVENTS_STATEMENT (read only)
+		case 1040, 1053, 1205, 1290:
+			return &UnavailableError{Err: err}
+		}
+		return err
+	}
+
+	if errors.Is(err, mysql.ErrInvalidConn) {
+		return &UnavailableError{Err: err}
+	}
+
+	return wrapConnectionError(err)
+}
+
+// factoryTaskMySQLDAO build TaskMySQLDAO.
+func factoryTaskMySQLDAO(opt DAOFactoryOptions) (*TaskMySQLDAO, error) {
+	connector, err := connectors.GetConnectorMySQL(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskMySQLDAO{
+		taskSQLDAO: taskSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       mysqlDialect,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestWrapMySQLError(t *testing.T) {
+	errUnknownTable := &mysql.MySQLError{Number: 1146, Message: "Table 'tasks' doesn't exist"}
+
+	tests := []struct {
+		name string
+		err  error
+		// want is the type of the wrapping error, nil if the error is returned unchanged.
+		want error
+	}{
+		{name: "duplicate entry", err: &mysql.MySQLError{Number: 1062}, want: &ConflictError{}},
+		{name: "row referenced", err: &mysql.MySQLError{Number: 1451}, want: &ConflictError{}},
+		{name: "no referenced row", err: &mysql.MySQLError{Number: 1452}, want: &ConflictError{}},
+		{name: "deadlock", err: fmt.Errorf("fail to update: %w", &mysql.MySQLError{Number: 1213}), want: &ConflictError{}},
+		{name: "too many connections", err: &mysql.MySQLError{Number: 1040}, want: &UnavailableError{}},
+		{name: "lock wait timeout", err: &mysql.MySQLError{Number: 1205}, want: &UnavailableError{}},
+		{name: "read only", err: &mysql.MySQLError{Number: 1290}, want: &UnavailableError{}},
+		{name: "invalid connection", err: mysql.ErrInvalidConn, want: &UnavailableError{}},
+		{name: "other error", err: errUnknownTable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapMySQLError(tt.err)
+			var errConflict *ConflictError
+			var errUnavailable *UnavailableError
+			switch tt.want.(type) {
+			case *ConflictError:
+				if !errors.As(err, &errConflict) || !errors.Is(err, tt.err) {
+					t.Errorf("wrapMySQLError() = %v, want a ConflictError wrapping the error", err)
+				}
+			case *UnavailableError:
+				if !errors.As(err, &errUnavailable) || !errors.Is(err, tt.err) {
+					t.Errorf("wrapMySQLError() = %v, want an UnavailableError wrapping the error", err)
+				}
+			default:
+				if err != tt.err {
+					t.Errorf("wrapMySQLError() = %v, want the error unchanged", err)
+				}
+			}
+		})
+	}
+}
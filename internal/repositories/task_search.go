@@ -0,0 +1,424 @@
+package repositories
+
+import (
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// snippetMaxWords is the number of words of a snippet cut out of a long description, like ts_headline.
+	snippetMaxWords = 35
+	snippetStartSel = "<mark>"
+	snippetStopSel  = "</mark>"
+)
+
+// searchLanguage is the processing of the words of a language of the full-text search.
+type searchLanguage struct {
+	stopWords map[string]bool
+	// stem returns the stem of a lowercase word. It only removes letters from the end of the word
+	// and may then change its last letter, so a word starts with its stem but the last letter.
+	stem func(word string) string
+}
+
+// searchLanguages are the languages of model.TaskSearchLanguages, the stemmers are lighter than the Snowball stemmers of Postgres.
+var searchLanguages = map[string]searchLanguage{
+	model.TaskSearchSimple: {
+		stem: func(word string) string { return word },
+	},
+	model.TaskSearchEnglish: {
+		stopWords: wordSet("a an and are as at be but by for from has have if in into is it its not of on or so " +
+			"than that the their then there these they this to was were will with"),
+		stem: stemEnglish,
+	},
+	model.TaskSearchFrench: {
+		stopWords: wordSet("à au aux avec ce ces dans de des du elle en et il je la le les leur lui ma mais me mon ne " +
+			"nos notre nous on ou par pas pour qui que sa se ses son sur ta te tes ton tu un une vos votre vous"),
+		stem: stemFrench,
+	},
+}
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// stemEnglish removes the plural, -ing and -ed endings and the final e, and turns a final y into i.
+func stemEnglish(word string) string {
+	switch {
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		word = undouble(word[:len(word)-3])
+	case len(word) > 4 && (strings.HasSuffix(word, "ies") || strings.HasSuffix(word, "ied")):
+		return word[:len(word)-2]
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		word = undouble(word[:len(word)-2])
+	case len(word) > 4 && (strings.HasSuffix(word, "sses") || strings.HasSuffix(word, "xes") ||
+		strings.HasSuffix(word, "ches") || strings.HasSuffix(word, "shes")):
+		word = word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+
+	switch {
+	case len(word) > 3 && strings.HasSuffix(word, "e"):
+		word = word[:len(word)-1]
+	case len(word) > 2 && strings.HasSuffix(word, "y"):
+		word = word[:len(word)-1] + "i"
+	}
+	return word
+}
+
+// undouble removes the last letter of a word ending with a doubled consonant, like runn or plann.
+func undouble(word string) string {
+	n := len(word)
+	if n > 3 && word[n-1] == word[n-2] && !strings.ContainsRune("aeioulsz", rune(word[n-1])) {
+		return word[:n-1]
+	}
+	return word
+}
+
+// stemFrench removes the plural and the final e.
+func stemFrench(word string) string {
+	if utf8.RuneCountInString(word) > 3 && (strings.HasSuffix(word, "s") || strings.HasSuffix(word, "x")) && !strings.HasSuffix(word, "ss") {
+		word = word[:len(word)-1]
+	}
+	if utf8.RuneCountInString(word) > 3 && strings.HasSuffix(word, "e") {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// searchToken is a lowercase word of a text, start and end are its byte offsets in the text.
+type searchToken struct {
+	word       string
+	start, end int
+}
+
+// tokenizeSearch splits a text into its words, made of letters and digits.
+func tokenizeSearch(text string) []searchToken {
+	tokens := make([]searchToken, 0)
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			tokens = append(tokens, searchToken{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, searchToken{word: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// searchClause is a word or a phrase of consecutive words of a query.
+// The last word of a prefix clause matches the words starting with it.
+type searchClause struct {
+	words  []string
+	stems  []string
+	prefix bool
+}
+
+// searchQuery is a parsed full-text query, a task matches when its description matches every clause.
+type searchQuery struct {
+	languageName string
+	language     searchLanguage
+	clauses      []searchClause
+}
+
+// parseSearchQuery parses a query of model.TaskSearchDTO. A quoted phrase or a run of words joined by punctuation
+// like e-mail is a phrase clause, the other words are clauses of their own. The clauses made of stop words are dropped.
+func parseSearchQuery(query string, languageName string) *searchQuery {
+	parsed := &searchQuery{languageName: languageName, language: searchLanguages[languageName]}
+
+	chunks := make([]string, 0)
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			chunks = append(chunks, part)
+		} else {
+			chunks = append(chunks, strings.Fields(part)...)
+		}
+	}
+
+	for _, chunk := range chunks {
+		tokens := tokenizeSearch(chunk)
+		if len(tokens) == 0 {
+			continue
+		}
+		clause := searchClause{prefix: strings.HasSuffix(strings.TrimSpace(chunk), "*")}
+
+		// Like Postgres, the stop words only keep their place inside a phrase.
+		first, last := 0, len(tokens)-1
+		for first <= last && parsed.isStopWord(tokens[first].word) {
+			first++
+		}
+		for last >= first && parsed.isStopWord(tokens[last].word) && !(clause.prefix && last == len(tokens)-1) {
+			last--
+		}
+		if first > last {
+			continue
+		}
+		if last < len(tokens)-1 {
+			clause.prefix = false
+		}
+
+		for _, token := range tokens[first : last+1] {
+			clause.words = append(clause.words, token.word)
+			clause.stems = append(clause.stems, parsed.language.stem(token.word))
+		}
+		parsed.clauses = append(parsed.clauses, clause)
+	}
+	return parsed
+}
+
+func (q *searchQuery) isStopWord(word string) bool {
+	return q.language.stopWords[word]
+}
+
+// matchWord reports whether a word of a text matches the i-th word of the clause, a stop word of the query matches any word.
+func (q *searchQuery) matchWord(clause searchClause, i int, word string) bool {
+	last := clause.prefix && i == len(clause.words)-1
+	if !last && q.isStopWord(clause.words[i]) {
+		return true
+	}
+	if q.isStopWord(word) {
+		return false
+	}
+
+	stem := q.language.stem(word)
+	if last {
+		return strings.HasPrefix(word, clause.words[i]) || strings.HasPrefix(stem, clause.stems[i])
+	}
+	return stem == clause.stems[i]
+}
+
+// rank matches the text against the query and returns its rank and snippet, nil if it doesn't match every clause.
+func (q *searchQuery) rank(text string) *model.TaskSearchResult {
+	if len(q.clauses) == 0 {
+		return nil
+	}
+
+	tokens := tokenizeSearch(text)
+	matched := make([]bool, len(tokens))
+	occurrences := 0
+	for _, clause := range q.clauses {
+		found := false
+		for start := 0; start+len(clause.words) <= len(tokens); start++ {
+			match := true
+			for i := range clause.words {
+				if !q.matchWord(clause, i, tokens[start+i].word) {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+
+			found = true
+			occurrences++
+			for i := range clause.words {
+				if !q.isStopWord(tokens[start+i].word) {
+					matched[start+i] = true
+				}
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+
+	return &model.TaskSearchResult{
+		// Like ts_rank with the normalization 1, the rank is divided by 1 + the logarithm of the length of the text.
+		Rank:    float64(occurrences) / (1 + math.Log(float64(len(tokens)))),
+		Snippet: searchSnippet(text, tokens, matched),
+	}
+}
+
+// searchSnippet returns the text, or the snippetMaxWords words from just before the first match of a long text,
+// with the matched words between snippetStartSel and snippetStopSel.
+func searchSnippet(text string, tokens []searchToken, matched []bool) string {
+	first, last := 0, len(tokens)
+	if len(tokens) > snippetMaxWords {
+		for first < len(tokens) && !matched[first] {
+			first++
+		}
+		first = max(0, min(first-5, len(tokens)-snippetMaxWords))
+		last = first + snippetMaxWords
+	}
+
+	var snippet strings.Builder
+	position := 0
+	if first > 0 {
+		position = tokens[first].start
+	}
+	for i := first; i < last; i++ {
+		if !matched[i] {
+			continue
+		}
+		snippet.WriteString(text[position:tokens[i].start])
+		snippet.WriteString(snippetStartSel + text[tokens[i].start:tokens[i].end] + snippetStopSel)
+		position = tokens[i].end
+	}
+	end := len(text)
+	if last < len(tokens) {
+		end = tokens[last-1].end
+	}
+	snippet.WriteString(text[position:end])
+	return snippet.String()
+}
+
+// tsquery returns the query in the syntax of to_tsquery, the words only have letters and digits so they can be quoted.
+func (q *searchQuery) tsquery() string {
+	clauses := make([]string, 0, len(q.clauses))
+	for _, clause := range q.clauses {
+		words := make([]string, 0, len(clause.words))
+		for _, word := range clause.words {
+			words = append(words, "'"+word+"'")
+		}
+		if clause.prefix {
+			words[len(words)-1] += ":*"
+		}
+		clauses = append(clauses, strings.Join(words, " <-> "))
+	}
+	return strings.Join(clauses, " & ")
+}
+
+// likePatterns returns a LIKE pattern per word of the query, the texts matching the query match all of them.
+// A word matches the words starting with its stem but the last letter, the pattern is the longest ASCII run of it
+// since the databases only ignore the case of the ASCII letters.
+func (q *searchQuery) likePatterns() []string {
+	patterns := make([]string, 0)
+	for _, clause := range q.clauses {
+		for i, word := range clause.words {
+			if q.isStopWord(word) && !(clause.prefix && i == len(clause.words)-1) {
+				continue
+			}
+			stem := clause.stems[i]
+			_, size := utf8.DecodeLastRuneInString(stem)
+			patterns = append(patterns, "%"+escapeLike(longestASCIIRun(stem[:len(stem)-size]))+"%")
+		}
+	}
+	return patterns
+}
+
+// longestASCIIRun returns the longest substring of s made of ASCII characters.
+func longestASCIIRun(s string) string {
+	longest := ""
+	for _, run := range strings.FieldsFunc(s, func(r rune) bool { return r >= utf8.RuneSelf }) {
+		if len(run) > len(longest) {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// sortSearchResults sorts the results by decreasing rank then by creation, and keeps the limit first ones.
+func sortSearchResults(results []*model.TaskSearchResult, limit int) []*model.TaskSearchResult {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return compareTasks(results[i].Task, results[j].Task, model.TaskSortCreatedAt) < 0
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// taskSearchIndex is an inverted index of the words of the descriptions of the tasks.
+type taskSearchIndex struct {
+	// postings are the tasks whose description has the word.
+	postings map[string]map[uuid.UUID]bool
+	// descriptions are the indexed descriptions.
+	descriptions map[uuid.UUID]string
+}
+
+func newTaskSearchIndex() taskSearchIndex {
+	return taskSearchIndex{
+		postings:     make(map[string]map[uuid.UUID]bool),
+		descriptions: make(map[uuid.UUID]string),
+	}
+}
+
+// clone returns a copy of the index.
+func (index taskSearchIndex) clone() taskSearchIndex {
+	snapshot := taskSearchIndex{
+		postings:     make(map[string]map[uuid.UUID]bool, len(index.postings)),
+		descriptions: maps.Clone(index.descriptions),
+	}
+	for word, tasks := range index.postings {
+		snapshot.postings[word] = maps.Clone(tasks)
+	}
+	return snapshot
+}
+
+// put indexes the description of the task, in place of its previous description.
+func (index taskSearchIndex) put(taskUUID uuid.UUID, description string) {
+	if indexed, exist := index.descriptions[taskUUID]; exist {
+		if indexed == description {
+			return
+		}
+		index.remove(taskUUID)
+	}
+
+	index.descriptions[taskUUID] = description
+	for _, token := range tokenizeSearch(description) {
+		if index.postings[token.word] == nil {
+			index.postings[token.word] = make(map[uuid.UUID]bool)
+		}
+		index.postings[token.word][taskUUID] = true
+	}
+}
+
+// remove takes the task out of the index.
+func (index taskSearchIndex) remove(taskUUID uuid.UUID) {
+	for _, token := range tokenizeSearch(index.descriptions[taskUUID]) {
+		delete(index.postings[token.word], taskUUID)
+		if len(index.postings[token.word]) == 0 {
+			delete(index.postings, token.word)
+		}
+	}
+	delete(index.descriptions, taskUUID)
+}
+
+// candidates returns the tasks having a word matching each word of the query, a superset of the tasks matching the query.
+func (index taskSearchIndex) candidates(q *searchQuery) map[uuid.UUID]bool {
+	var candidates map[uuid.UUID]bool
+	for _, clause := range q.clauses {
+		for i, word := range clause.words {
+			if q.isStopWord(word) && !(clause.prefix && i == len(clause.words)-1) {
+				continue
+			}
+
+			tasks := make(map[uuid.UUID]bool)
+			for indexedWord, postings := range index.postings {
+				if !q.matchWord(clause, i, indexedWord) {
+					continue
+				}
+				for taskUUID := range postings {
+					if candidates == nil || candidates[taskUUID] {
+						tasks[taskUUID] = true
+					}
+				}
+			}
+			candidates = tasks
+		}
+	}
+	return candidates
+}
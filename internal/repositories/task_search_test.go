@@ -0,0 +1,393 @@
+package repositories
+
+import (
+	"context"
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestTokenizeSearch(t *testing.T) {
+	got := tokenizeSearch("E-mail the  Café's report2!")
+	want := []searchToken{
+		{word: "e", start: 0, end: 1},
+		{word: "mail", start: 2, end: 6},
+		{word: "the", start: 7, end: 10},
+		{word: "café", start: 12, end: 17},
+		{word: "s", start: 18, end: 19},
+		{word: "report2", start: 20, end: 27},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenizeSearch() = %+v, want %+v", got, want)
+	}
+	if got := tokenizeSearch(" -- "); len(got) != 0 {
+		t.Errorf("tokenizeSearch() of punctuation = %+v, want none", got)
+	}
+}
+
+func TestSearchStem(t *testing.T) {
+	tests := []struct {
+		language string
+		words    map[string]string
+	}{
+		{language: model.TaskSearchSimple, words: map[string]string{"running": "running", "boxes": "boxes"}},
+		{language: model.TaskSearchEnglish, words: map[string]string{
+			"running": "run", "planned": "plan", "calling": "call", "boxes": "box", "wishes": "wish",
+			"stories": "stori", "copied": "copi", "story": "stori", "tasks": "task", "make": "mak",
+			"status": "status", "analysis": "analysis", "glass": "glass", "use": "use", "sing": "sing",
+		}},
+		{language: model.TaskSearchFrench, words: map[string]string{
+			"tâches": "tâch", "tâche": "tâch", "travaux": "travau", "courses": "cours", "vite": "vit", "mes": "mes", "classe": "class",
+		}},
+	}
+	for _, tt := range tests {
+		for word, want := range tt.words {
+			if got := searchLanguages[tt.language].stem(word); got != want {
+				t.Errorf("%s stem(%q) = %q, want %q", tt.language, word, got, want)
+			}
+		}
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		language string
+		want     []searchClause
+		tsquery  string
+	}{
+		{query: "Buy  milk", language: model.TaskSearchSimple,
+			want:    []searchClause{{words: []string{"buy"}, stems: []string{"buy"}}, {words: []string{"milk"}, stems: []string{"milk"}}},
+			tsquery: "'buy' & 'milk'"},
+		// The stop words only keep their place inside a phrase.
+		{query: `"buy the milk" for the kids`, language: model.TaskSearchEnglish,
+			want: []searchClause{
+				{words: []string{"buy", "the", "milk"}, stems: []string{"bui", "the", "milk"}},
+				{words: []string{"kids"}, stems: []string{"kid"}},
+			},
+			tsquery: "'buy' <-> 'the' <-> 'milk' & 'kids'"},
+		{query: `"the milk of"`, language: model.TaskSearchEnglish,
+			want:    []searchClause{{words: []string{"milk"}, stems: []string{"milk"}}},
+			tsquery: "'milk'"},
+		{query: `the "of a"`, language: model.TaskSearchEnglish},
+		{query: `the "of a"`, language: model.TaskSearchSimple,
+			want:    []searchClause{{words: []string{"the"}, stems: []string{"the"}}, {words: []string{"of", "a"}, stems: []string{"of", "a"}}},
+			tsquery: "'the' & 'of' <-> 'a'"},
+		// Words joined by punctuation are a phrase.
+		{query: "e-mail", language: model.TaskSearchSimple,
+			want:    []searchClause{{words: []string{"e", "mail"}, stems: []string{"e", "mail"}}},
+			tsquery: "'e' <-> 'mail'"},
+		// A * at the end of a word or a phrase makes its last word a prefix.
+		{query: "rep* bud", language: model.TaskSearchSimple,
+			want:    []searchClause{{words: []string{"rep"}, stems: []string{"rep"}, prefix: true}, {words: []string{"bud"}, stems: []string{"bud"}}},
+			tsquery: "'rep':* & 'bud'"},
+		{query: `"milk and*"`, language: model.TaskSearchEnglish,
+			want:    []searchClause{{words: []string{"milk", "and"}, stems: []string{"milk", "and"}, prefix: true}},
+			tsquery: "'milk' <-> 'and':*"},
+		{query: `"milk and the" *`, language: model.TaskSearchEnglish,
+			want:    []searchClause{{words: []string{"milk"}, stems: []string{"milk"}}},
+			tsquery: "'milk'"},
+		{query: `report *`, language: model.TaskSearchSimple,
+			want:    []searchClause{{words: []string{"report"}, stems: []string{"report"}}},
+			tsquery: "'report'"},
+		{query: `"" - *`, language: model.TaskSearchSimple},
+	}
+	for _, tt := range tests {
+		query := parseSearchQuery(tt.query, tt.language)
+		if !reflect.DeepEqual(query.clauses, tt.want) {
+			t.Errorf("parseSearchQuery(%q, %s) = %+v, want %+v", tt.query, tt.language, query.clauses, tt.want)
+			continue
+		}
+		if got := query.tsquery(); got != tt.tsquery {
+			t.Errorf("parseSearchQuery(%q, %s).tsquery() = %q, want %q", tt.query, tt.language, got, tt.tsquery)
+		}
+	}
+}
+
+func TestSearchQueryLikePatterns(t *testing.T) {
+	query := parseSearchQuery(`"buy the milk" tâches 100% rep*`, model.TaskSearchEnglish)
+	want := []string{"%bu%", "%mil%", "%t%", `%10%`, "%re%"}
+	if got := query.likePatterns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("likePatterns() = %q, want %q", got, want)
+	}
+}
+
+func TestSearchQueryRank(t *testing.T) {
+	tests := []struct {
+		query    string
+		language string
+		text     string
+		match    bool
+		snippet  string
+	}{
+		{query: "milk", language: model.TaskSearchSimple, text: "Buy milk and bread", match: true, snippet: "Buy <mark>milk</mark> and bread"},
+		{query: "milk bread", language: model.TaskSearchSimple, text: "Buy milk", match: false},
+		{query: "milk", language: model.TaskSearchSimple, text: "Buy milkshakes", match: false},
+		// Stemming.
+		{query: "running", language: model.TaskSearchEnglish, text: "Run the tests", match: true, snippet: "<mark>Run</mark> the tests"},
+		{query: "running", language: model.TaskSearchSimple, text: "Run the tests", match: false},
+		{query: "tâche", language: model.TaskSearchFrench, text: "Finir les Tâches", match: true, snippet: "Finir les <mark>Tâches</mark>"},
+		// The words of a phrase follow each other, a stop word of the phrase matches any word.
+		{query: `"buy the milk"`, language: model.TaskSearchEnglish, text: "Buy some milk, buy milk", match: true, snippet: "<mark>Buy</mark> <mark>some</mark> <mark>milk</mark>, buy milk"},
+		{query: `"buy the milk"`, language: model.TaskSearchEnglish, text: "Milk to buy", match: false},
+		{query: `"buy milk"`, language: model.TaskSearchSimple, text: "buy, milk!", match: true, snippet: "<mark>buy</mark>, <mark>milk</mark>!"},
+		{query: "the milk", language: model.TaskSearchEnglish, text: "the milk", match: true, snippet: "the <mark>milk</mark>"},
+		// Prefixes.
+		{query: "rep*", language: model.TaskSearchSimple, text: "Write the reports", match: true, snippet: "Write the <mark>reports</mark>"},
+		{query: "rep*", language: model.TaskSearchSimple, text: "Prepare the talk", match: false},
+		{query: `"write rep*"`, language: model.TaskSearchSimple, text: "write the reports", match: false},
+		{query: "the", language: model.TaskSearchEnglish, text: "the milk", match: false},
+	}
+	for _, tt := range tests {
+		result := parseSearchQuery(tt.query, tt.language).rank(tt.text)
+		if (result != nil) != tt.match {
+			t.Errorf("rank(%q) of %q = %+v, want a match %t", tt.text, tt.query, result, tt.match)
+			continue
+		}
+		if result != nil && result.Snippet != tt.snippet {
+			t.Errorf("rank(%q) of %q snippet = %q, want %q", tt.text, tt.query, result.Snippet, tt.snippet)
+		}
+	}
+
+	// The rank is the number of matches divided by 1 + the logarithm of the number of words.
+	query := parseSearchQuery("milk", model.TaskSearchSimple)
+	ranks := map[string]float64{
+		"milk":                    1,
+		"buy milk":                1 / (1 + math.Log(2)),
+		"milk milk":               2 / (1 + math.Log(2)),
+		"buy milk and more milk":  2 / (1 + math.Log(5)),
+		"buy a lot of fresh milk": 1 / (1 + math.Log(6)),
+	}
+	for text, want := range ranks {
+		if got := query.rank(text); got == nil || math.Abs(got.Rank-want) > 1e-9 {
+			t.Errorf("rank(%q) = %+v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	words := make([]string, 50)
+	for i := range words {
+		words[i] = "w" + string(rune('a'+i/26)) + string(rune('a'+i%26))
+	}
+	text := strings.Join(words, " ")
+
+	// A long text is cut to snippetMaxWords words from 5 words before the first match, within the text.
+	result := parseSearchQuery(words[10], model.TaskSearchSimple).rank(text)
+	want := strings.Join(words[5:10], " ") + " <mark>" + words[10] + "</mark> " + strings.Join(words[11:40], " ")
+	if result == nil || result.Snippet != want {
+		t.Errorf("snippet = %+v, want %q", result, want)
+	}
+	result = parseSearchQuery(words[45], model.TaskSearchSimple).rank(text)
+	want = strings.Join(words[15:45], " ") + " <mark>" + words[45] + "</mark> " + strings.Join(words[46:], " ")
+	if result == nil || result.Snippet != want {
+		t.Errorf("snippet = %+v, want %q", result, want)
+	}
+}
+
+func TestSortSearchResults(t *testing.T) {
+	now := time.Now()
+	result := func(name string, rank float64, age time.Duration) *model.TaskSearchResult {
+		return &model.TaskSearchResult{Task: &model.Task{UUID: uuid.New(), WhatToDo: name, CreatedAt: now.Add(-age)}, Rank: rank}
+	}
+	names := func(results []*model.TaskSearchResult) []string {
+		names := make([]string, 0, len(results))
+		for _, result := range results {
+			names = append(names, result.Task.WhatToDo)
+		}
+		return names
+	}
+
+	// The best ranked first, the oldest first among the same rank.
+	results := []*model.TaskSearchResult{
+		result("recent", 1, time.Minute),
+		result("best", 3, time.Minute),
+		result("old", 1, time.Hour),
+		result("second", 2, 0),
+		result("last", 0.5, 2*time.Hour),
+	}
+	if got := names(sortSearchResults(results, 10)); !reflect.DeepEqual(got, []string{"best", "second", "old", "recent", "last"}) {
+		t.Errorf("sortSearchResults() = %v, want best, second, old, recent and last", got)
+	}
+	if got := names(sortSearchResults(results, 3)); !reflect.DeepEqual(got, []string{"best", "second", "old"}) {
+		t.Errorf("sortSearchResults() limited to 3 = %v, want best, second and old", got)
+	}
+	if got := sortSearchResults([]*model.TaskSearchResult{}, 3); len(got) != 0 {
+		t.Errorf("sortSearchResults() of no result = %v, want none", got)
+	}
+}
+
+// searchParityDescriptions are the descriptions searched by the parity tests, created in this order.
+var searchParityDescriptions = []string{
+	"Buy milk and bread",
+	"Buy some milk for the kids",
+	"milk",
+	"Write the quarterly report",
+	"Report the bug of the e-mail client",
+	"Reports of the running tasks",
+	"Run the tests before the release",
+	"Plan the planning of the planned release",
+	"Finir les tâches de la semaine",
+}
+
+// searchParityQueries are the queries of the parity tests, with their language.
+var searchParityQueries = []model.TaskSearchDTO{
+	{Query: "milk", Language: model.TaskSearchSimple},
+	{Query: "buy milk", Language: model.TaskSearchSimple},
+	{Query: `"buy milk"`, Language: model.TaskSearchSimple},
+	{Query: "rep*", Language: model.TaskSearchSimple},
+	{Query: "e-mail", Language: model.TaskSearchSimple},
+	{Query: "release", Language: model.TaskSearchSimple},
+	{Query: "the", Language: model.TaskSearchSimple},
+	{Query: `"buy the milk"`, Language: model.TaskSearchEnglish},
+	{Query: "report", Language: model.TaskSearchEnglish},
+	{Query: "run", Language: model.TaskSearchEnglish},
+	{Query: "plan*", Language: model.TaskSearchEnglish},
+	{Query: "the", Language: model.TaskSearchEnglish},
+	{Query: "tâche", Language: model.TaskSearchFrench},
+	{Query: "nothing", Language: model.TaskSearchSimple},
+}
+
+// searchParityResult is a result of a parity search, without the UUIDs and the dates of its backend.
+type searchParityResult struct {
+	WhatToDo string
+	Rank     float64
+	Snippet  string
+}
+
+// searchParity creates the parity descriptions as alice, with a trashed task and a task of bob matching every query,
+// and returns the results of the parity queries by query.
+func searchParity(t *testing.T, dao ITaskDAO) map[string][]searchParityResult {
+	t.Helper()
+
+	alice := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "alice-" + uuid.NewString()})
+	bob := model.ContextWithPrincipal(context.Background(), &model.Principal{ID: "bob-" + uuid.NewString()})
+	everything := strings.Join(searchParityDescriptions, " ")
+	if _, err := dao.Create(bob, &model.TaskCreateDTO{WhatToDo: everything, Status: "todo"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	trashed, err := dao.Create(alice, &model.TaskCreateDTO{WhatToDo: everything, Status: "todo"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := dao.Delete(alice, trashed.UUID, 0, model.TaskDeleteRestrict); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	for _, description := range searchParityDescriptions {
+		// The results of the same rank are sorted by creation.
+		time.Sleep(time.Millisecond)
+		if _, err := dao.Create(alice, &model.TaskCreateDTO{WhatToDo: description, Status: "todo"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	results := make(map[string][]searchParityResult)
+	for _, search := range searchParityQueries {
+		search.Limit = 20
+		found, err := dao.Search(alice, &search)
+		if err != nil {
+			t.Fatalf("Search(%q) error = %v", search.Query, err)
+		}
+		key := search.Language + ":" + search.Query
+		results[key] = make([]searchParityResult, 0, len(found))
+		for _, result := range found {
+			results[key] = append(results[key], searchParityResult{WhatToDo: result.Task.WhatToDo, Rank: result.Rank, Snippet: result.Snippet})
+		}
+	}
+	return results
+}
+
+func TestTaskDAOSearchParity(t *testing.T) {
+	// The text search of Postgres ranks on its own, TestTaskDAOSearchPostgresParity compares what it finds.
+	backends := slices.DeleteFunc(newTestTaskDAOBackends(t), func(backend testTaskDAOBackend) bool {
+		return backend.taskDAO.Type == TypeTaskPostgresDAO
+	})
+	results := make([]map[string][]searchParityResult, len(backends))
+	for i, backend := range backends {
+		dao, err := ProxyFactoryTaskDAO(backend.taskDAO)
+		if err != nil {
+			t.Fatalf("ProxyFactoryTaskDAO(%s) error = %v", backend.name, err)
+		}
+		results[i] = searchParity(t, dao)
+	}
+
+	// The searches of the in-memory DAO are checked, the other backends must find the same results.
+	want := map[string][]string{
+		"simple:milk":            {"milk", "Buy milk and bread", "Buy some milk for the kids"},
+		"simple:buy milk":        {"Buy milk and bread", "Buy some milk for the kids"},
+		`simple:"buy milk"`:      {"Buy milk and bread"},
+		"simple:rep*":            {"Write the quarterly report", "Reports of the running tasks", "Report the bug of the e-mail client"},
+		"simple:e-mail":          {"Report the bug of the e-mail client"},
+		"simple:release":         {"Run the tests before the release", "Plan the planning of the planned release"},
+		`english:"buy the milk"`: {"Buy some milk for the kids"},
+		"english:report":         {"Write the quarterly report", "Reports of the running tasks", "Report the bug of the e-mail client"},
+		"english:run":            {"Reports of the running tasks", "Run the tests before the release"},
+		"english:plan*":          {"Plan the planning of the planned release"},
+		"english:the":            {},
+		"french:tâche":           {"Finir les tâches de la semaine"},
+		"simple:nothing":         {},
+	}
+	for key, descriptions := range want {
+		got := make([]string, 0)
+		for _, result := range results[0][key] {
+			got = append(got, result.WhatToDo)
+		}
+		if !reflect.DeepEqual(got, descriptions) {
+			t.Errorf("memory search %s = %q, want %q", key, got, descriptions)
+		}
+	}
+	for i, backend := range backends[1:] {
+		for key, memoryResults := range results[0] {
+			if got := results[i+1][key]; !reflect.DeepEqual(got, memoryResults) {
+				t.Errorf("%s search %s = %+v, want the in-memory results %+v", backend.name, key, got, memoryResults)
+			}
+		}
+	}
+}
+
+// TestTaskDAOSearchPostgresParity runs the parity searches on the database of TEST_POSTGRES_DSN.
+// The text search of Postgres has its own stemmers and ranks, so it must find the same tasks as the in-memory DAO
+// but may rank them differently.
+func TestTaskDAOSearchPostgresParity(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN isn't set")
+	}
+
+	memory, err := ProxyFactoryTaskDAO(DAOFactoryOptions{Type: TypeTaskInMemoryDAO})
+	if err != nil {
+		t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+	}
+	postgres, err := ProxyFactoryTaskDAO(DAOFactoryOptions{Type: TypeTaskPostgresDAO, Connector: newTestPostgresConnector(t, dsn)})
+	if err != nil {
+		t.Fatalf("ProxyFactoryTaskDAO() error = %v", err)
+	}
+
+	// found returns the sorted descriptions of the results of each query.
+	found := func(results map[string][]searchParityResult) map[string][]string {
+		descriptions := make(map[string][]string, len(results))
+		for key, keyResults := range results {
+			descriptions[key] = make([]string, 0, len(keyResults))
+			for _, result := range keyResults {
+				descriptions[key] = append(descriptions[key], result.WhatToDo)
+			}
+			sort.Strings(descriptions[key])
+		}
+		return descriptions
+	}
+	want := found(searchParity(t, memory))
+	got := found(searchParity(t, postgres))
+	for key := range want {
+		if !reflect.DeepEqual(got[key], want[key]) {
+			t.Errorf("postgres search %s = %q, want %q", key, got[key], want[key])
+		}
+	}
+}
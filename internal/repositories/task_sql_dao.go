@@ -0,0 +1,1057 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// taskColumns are the columns read by scanTask, in order.
+	taskColumns = "task_uuid, description, status, created_at, last_updated, version, deleted_at, owner_id, list_uuid, due_at, remind_at, reminded_at, rrule, series_uuid, parent_uuid"
+	// tagColumns are the columns read by scanTag, in order.
+	tagColumns = "tag_uuid, name, owner_id, created_at, last_updated"
+	// taskEventColumns are the columns read by scanTaskEvent, in order.
+	taskEventColumns = "event_uuid, task_uuid, version, type, actor, occurred_at, changes, owner_id"
+)
+
+// taskSQLDAO implements ITaskDAO with portable SQL, the specificities of each database are in its dialect.
+type taskSQLDAO struct {
+	sqlDAO
+}
+
+func (dao *taskSQLDAO) Create(ctx context.Context, taskToCreate *model.TaskCreateDTO) (*model.Task, error) {
+	var task *model.Task
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		task, err = dao.createTask(ctx, tx, taskToCreate)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't insert the task : %w", err)
+	}
+
+	return task, nil
+}
+
+// createTask inserts a new task in the transaction and returns it.
+func (dao *taskSQLDAO) createTask(ctx context.Context, tx *sql.Tx, taskToCreate *model.TaskCreateDTO) (*model.Task, error) {
+	// The UUID and the dates are set here so every database stores the same values.
+	task := taskToCreate.ReverseCreateDTO()
+	task.UUID = uuid.New()
+	task.CreatedAt = sqlNow()
+	task.LastUpdated = task.CreatedAt
+	task.Version = 1
+	if task.OwnerID == "" {
+		task.OwnerID = model.OwnerFromContext(ctx)
+	}
+	if task.RRule != "" && task.SeriesUUID == nil {
+		task.SeriesUUID = &task.UUID
+	}
+	task.DueAt = sqlTime(task.DueAt)
+	task.RemindAt = sqlTime(task.RemindAt)
+
+	query := fmt.Sprintf("INSERT INTO tasks (%s) VALUES (%s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s, %s);", taskColumns,
+		dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5), dao.bind(6), dao.bind(7), dao.bind(8), dao.bind(9),
+		dao.bind(10), dao.bind(11), dao.bind(12), dao.bind(13), dao.bind(14), dao.bind(15))
+	if err := dao.execOne(tx, query, task.UUID, task.WhatToDo, task.Status, task.CreatedAt, task.LastUpdated, task.Version, task.DeletedAt,
+		task.OwnerID, task.ListUUID, task.DueAt, task.RemindAt, task.RemindedAt, task.RRule, task.SeriesUUID, task.ParentUUID); err != nil {
+		return nil, err
+	}
+
+	if err := dao.insertEvent(tx, model.NewTaskEvent(model.TaskEventCreated, nil, task, model.ActorFromContext(ctx), task.CreatedAt)); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (dao *taskSQLDAO) ReadByUUID(ctx context.Context, taskUUID uuid.UUID) (*model.Task, error) {
+	// Query the database with the task UUID.
+	visibleCondition, params := dao.visibleCondition(ctx, []any{taskUUID})
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE task_uuid = %s AND deleted_at IS NULL%s;", taskColumns, dao.bind(1), visibleCondition)
+	task, err := scanTask(dao.connector.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+
+	if err := dao.readTaskTags(ctx, dao.connector, []*model.Task{task}); err != nil {
+		return nil, fmt.Errorf("can't query the tags : %w", err)
+	}
+	return task, nil
+}
+
+func (dao *taskSQLDAO) ReadAll(ctx context.Context, filter *model.TaskFilterDTO) ([]*model.Task, string, error) {
+	cursor, err := decodeTaskCursor(filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Create the query string, all values are sent as parameters.
+	var (
+		conditions []string
+		params     []any
+	)
+	bind := func(value any) string {
+		params = append(params, value)
+		return dao.bind(len(params))
+	}
+
+	if filter.Deleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	} else {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+	if owner, scoped := model.OwnerScopeFromContext(ctx); scoped {
+		conditions = append(conditions, fmt.Sprintf("(owner_id = %s OR task_uuid IN (SELECT task_uuid FROM task_grants WHERE principal_id = %s))", bind(owner), bind(owner)))
+	}
+	if filter.ListUUID != nil {
+		conditions = append(conditions, "list_uuid = "+bind(*filter.ListUUID))
+	}
+	if filter.SeriesUUID != nil {
+		conditions = append(conditions, "series_uuid = "+bind(*filter.SeriesUUID))
+	}
+	if filter.ParentUUID != nil {
+		conditions = append(conditions, "parent_uuid = "+bind(*filter.ParentUUID))
+	}
+	if len(filter.Status) > 0 {
+		placeholders := make([]string, 0, len(filter.Status))
+		for _, status := range filter.Status {
+			placeholders = append(placeholders, bind(status))
+		}
+		conditions = append(conditions, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if len(filter.ExcludedStatus) > 0 {
+		placeholders := make([]string, 0, len(filter.ExcludedStatus))
+		for _, status := range filter.ExcludedStatus {
+			placeholders = append(placeholders, bind(status))
+		}
+		conditions = append(conditions, fmt.Sprintf("status NOT IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, fmt.Sprintf(dao.dialect.containsCondition, bind("%"+escapeLike(filter.Search)+"%")))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, "created_at >= "+bind(filter.CreatedAfter.UTC()))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, "created_at < "+bind(filter.CreatedBefore.UTC()))
+	}
+	if filter.UpdatedAfter != nil {
+		conditions = append(conditions, "last_updated >= "+bind(filter.UpdatedAfter.UTC()))
+	}
+	if filter.UpdatedBefore != nil {
+		conditions = append(conditions, "last_updated < "+bind(filter.UpdatedBefore.UTC()))
+	}
+	if filter.DueAfter != nil {
+		conditions = append(conditions, "due_at >= "+bind(filter.DueAfter.UTC()))
+	}
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "due_at < "+bind(filter.DueBefore.UTC()))
+	}
+	if len(filter.Tag) > 0 {
+		placeholders := make([]string, 0, len(filter.Tag))
+		for _, tag := range filter.Tag {
+			placeholders = append(placeholders, bind(tag))
+		}
+		condition := fmt.Sprintf("task_uuid IN (SELECT tt.task_uuid FROM task_tags tt JOIN tags g ON g.tag_uuid = tt.tag_uuid WHERE g.name IN (%s)",
+			strings.Join(placeholders, ", "))
+		if filter.TagMatch == model.TagMatchAll {
+			condition += " GROUP BY tt.task_uuid HAVING COUNT(DISTINCT g.name) = " + bind(len(filter.Tag))
+		}
+		conditions = append(conditions, condition+")")
+	}
+
+	// The sort column comes from a whitelist.
+	column, order := dao.dialect.sortColumns[filter.Sort], "ASC"
+	comparator := ">"
+	if filter.Order == model.SortOrderDesc {
+		order, comparator = "DESC", "<"
+	}
+
+	// Keyset pagination: resume right after the last task of the previous page.
+	if cursor != nil {
+		var value any = cursor.Value
+		if isTimeSort(cursor.Sort) {
+			value, _ = time.Parse(time.RFC3339Nano, cursor.Value)
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, task_uuid) %s (%s, %s)", column, comparator, bind(value), bind(cursor.UUID)))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE %s", taskColumns, strings.Join(conditions, " AND "))
+	// Query one more task than the limit to know if there is a next page.
+	query += fmt.Sprintf(" ORDER BY %s %s, task_uuid %s LIMIT %s;", column, order, order, bind(filter.Limit+1))
+
+	tasks, err := dao.queryTasks(ctx, dao.connector, query, params...)
+	if err != nil {
+		return nil, "", fmt.Errorf("can't query all tasks : %w", err)
+	}
+	if err := dao.readTaskTags(ctx, dao.connector, tasks); err != nil {
+		return nil, "", fmt.Errorf("can't query the tags : %w", err)
+	}
+
+	nextCursor := ""
+	if len(tasks) > filter.Limit {
+		tasks = tasks[:filter.Limit]
+		nextCursor = encodeTaskCursor(tasks[len(tasks)-1], filter)
+	}
+
+	return tasks, nextCursor, nil
+}
+
+func (dao *taskSQLDAO) Search(ctx context.Context, search *model.TaskSearchDTO) ([]*model.TaskSearchResult, error) {
+	query := parseSearchQuery(search.Query, search.Language)
+	if len(query.clauses) == 0 {
+		return []*model.TaskSearchResult{}, nil
+	}
+
+	var (
+		results []*model.TaskSearchResult
+		err     error
+	)
+	if dao.dialect.textSearch {
+		results, err = dao.searchText(ctx, query, search.Limit)
+	} else {
+		results, err = dao.searchWords(ctx, query, search.Limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't search the tasks : %w", err)
+	}
+
+	tasks := make([]*model.Task, 0, len(results))
+	for _, result := range results {
+		tasks = append(tasks, result.Task)
+	}
+	if err := dao.readTaskTags(ctx, dao.connector, tasks); err != nil {
+		return nil, fmt.Errorf("can't query the tags : %w", err)
+	}
+	return results, nil
+}
+
+// searchText searches the tasks with the Postgres text search, matching the expression of the indexes of the languages.
+func (dao *taskSQLDAO) searchText(ctx context.Context, query *searchQuery, limit int) ([]*model.TaskSearchResult, error) {
+	// The language comes from a whitelist.
+	vector := fmt.Sprintf("to_tsvector('%s', description)", query.languageName)
+	visibleCondition, params := dao.visibleCondition(ctx, []any{query.tsquery()})
+	params = append(params, limit)
+	statement := fmt.Sprintf("SELECT %s, ts_rank(%s, search_query, 1) AS rank, ts_headline('%s', description, search_query, 'StartSel=%s, StopSel=%s, MaxWords=%d, MinWords=15')"+
+		" FROM tasks, to_tsquery('%s', %s) search_query WHERE deleted_at IS NULL AND %s @@ search_query%s ORDER BY rank DESC, created_at, task_uuid LIMIT %s;",
+		taskColumns, vector, query.languageName, snippetStartSel, snippetStopSel, snippetMaxWords,
+		query.languageName, dao.bind(1), vector, visibleCondition, dao.bind(len(params)))
+
+	rows, err := dao.connector.QueryContext(ctx, statement, params...)
+	if err != nil {
+		return nil, dao.dialect.wrapError(err)
+	}
+	defer rows.Close()
+
+	results := make([]*model.TaskSearchResult, 0)
+	for rows.Next() {
+		result := new(model.TaskSearchResult)
+		if result.Task, err = scanTask(rows, &result.Rank, &result.Snippet); err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+	return results, nil
+}
+
+// searchWords reads the tasks whose description contains the words of the query and ranks the ones matching it.
+func (dao *taskSQLDAO) searchWords(ctx context.Context, query *searchQuery, limit int) ([]*model.TaskSearchResult, error) {
+	visibleCondition, params := dao.visibleCondition(ctx, nil)
+	conditions := []string{"deleted_at IS NULL" + visibleCondition}
+	for _, pattern := range query.likePatterns() {
+		params = append(params, pattern)
+		conditions = append(conditions, fmt.Sprintf(dao.dialect.containsCondition, dao.bind(len(params))))
+	}
+
+	statement := fmt.Sprintf("SELECT %s FROM tasks WHERE %s;", taskColumns, strings.Join(conditions, " AND "))
+	tasks, err := dao.queryTasks(ctx, dao.connector, statement, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*model.TaskSearchResult, 0)
+	for _, task := range tasks {
+		if result := query.rank(task.WhatToDo); result != nil {
+			result.Task = task
+			results = append(results, result)
+		}
+	}
+	return sortSearchResults(results, limit), nil
+}
+
+func (dao *taskSQLDAO) Update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		_, err := dao.updateTask(ctx, tx, taskUUID, patch, version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("can't update the task : %w", err)
+	}
+	return nil
+}
+
+// updateTask applies the patch on the task in the transaction and returns the updated task.
+func (dao *taskSQLDAO) updateTask(ctx context.Context, tx *sql.Tx, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) (*model.Task, error) {
+	before, err := dao.lockTask(ctx, tx, taskUUID, false, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := dao.readTaskTags(ctx, tx, []*model.Task{before}); err != nil {
+		return nil, err
+	}
+
+	after := copyTask(before)
+	if patch.WhatToDo != nil {
+		after.WhatToDo = *patch.WhatToDo
+	}
+	if patch.Status != nil {
+		after.Status = *patch.Status
+	}
+	if patch.ListUUID != nil {
+		after.ListUUID = optionalUUID(*patch.ListUUID)
+	}
+	if patch.DueAt != nil {
+		after.DueAt = sqlTime(optionalTime(*patch.DueAt))
+	}
+	if patch.RemindAt != nil {
+		after.RemindAt = sqlTime(optionalTime(*patch.RemindAt))
+		if !sameTime(after.RemindAt, before.RemindAt) {
+			after.RemindedAt = nil
+		}
+	}
+	if patch.RRule != nil {
+		after.RRule = *patch.RRule
+		if after.RRule != "" && after.SeriesUUID == nil {
+			after.SeriesUUID = &after.UUID
+		}
+	}
+	if patch.ParentUUID != nil {
+		after.ParentUUID = optionalUUID(*patch.ParentUUID)
+	}
+	after.LastUpdated = sqlNow()
+	after.Version++
+
+	if err := dao.saveTask(ctx, tx, model.TaskEventUpdated, before, after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+func (dao *taskSQLDAO) Delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		return dao.deleteTask(ctx, tx, taskUUID, version, cascade)
+	})
+	if err != nil {
+		return fmt.Errorf("can't delete the task : %w", err)
+	}
+	return nil
+}
+
+// deleteTask moves the task to the trash in the transaction, with its subtree according to cascade.
+func (dao *taskSQLDAO) deleteTask(ctx context.Context, tx *sql.Tx, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error {
+	root, err := dao.lockTask(ctx, tx, taskUUID, false, version)
+	if err != nil {
+		return err
+	}
+
+	descendants, err := dao.lockDescendants(ctx, tx, taskUUID)
+	if err != nil {
+		return err
+	}
+	trashed, detached, err := planTaskDelete(root, descendants, cascade)
+	if err != nil {
+		return err
+	}
+
+	now := sqlNow()
+	for _, before := range detached {
+		after := copyTask(before)
+		after.ParentUUID = nil
+		after.LastUpdated = now
+		after.Version++
+
+		if err := dao.saveTask(ctx, tx, model.TaskEventUpdated, before, after); err != nil {
+			return err
+		}
+	}
+	for _, before := range append([]*model.Task{root}, trashed...) {
+		after := copyTask(before)
+		after.DeletedAt = &now
+		after.LastUpdated = now
+		after.Version++
+
+		if err := dao.saveTask(ctx, tx, model.TaskEventDeleted, before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dao *taskSQLDAO) Batch(ctx context.Context, operations []*model.TaskBatchOperation, atomic bool) ([]*model.TaskBatchResult, error) {
+	results := make([]*model.TaskBatchResult, len(operations))
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		for i, operation := range operations {
+			if atomic {
+				task, err := dao.runOperation(ctx, tx, operation)
+				if err != nil {
+					return &BatchOperationError{Index: i, Err: err}
+				}
+				results[i] = &model.TaskBatchResult{Task: task}
+				continue
+			}
+
+			// A savepoint rolls back the failed operation alone, the batch is still a single transaction.
+			if _, err := dao.connector.Exec(tx, "SAVEPOINT batch_operation;"); err != nil {
+				return dao.dialect.wrapError(err)
+			}
+			task, err := dao.runOperation(ctx, tx, operation)
+			release := "RELEASE SAVEPOINT batch_operation;"
+			if err != nil {
+				release = "ROLLBACK TO SAVEPOINT batch_operation;"
+			}
+			if _, errRelease := dao.connector.Exec(tx, release); errRelease != nil {
+				return dao.dialect.wrapError(errRelease)
+			}
+			results[i] = &model.TaskBatchResult{Task: task, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't run the batch : %w", err)
+	}
+
+	return results, nil
+}
+
+// runOperation runs an operation of a batch in the transaction and returns the created or updated task.
+func (dao *taskSQLDAO) runOperation(ctx context.Context, tx *sql.Tx, operation *model.TaskBatchOperation) (*model.Task, error) {
+	switch operation.Op {
+	case model.TaskBatchCreate:
+		return dao.createTask(ctx, tx, operation.Create)
+	case model.TaskBatchUpdate:
+		return dao.updateTask(ctx, tx, operation.TaskUUID, operation.Patch, operation.Version)
+	case model.TaskBatchDelete:
+		return nil, dao.deleteTask(ctx, tx, operation.TaskUUID, operation.Version, operation.Cascade)
+	default:
+		return nil, fmt.Errorf("unknown batch operation %q", operation.Op)
+	}
+}
+
+func (dao *taskSQLDAO) Restore(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	var restored []*model.Task
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		root, err := dao.lockTask(ctx, tx, taskUUID, true, 0)
+		if err != nil {
+			return err
+		}
+
+		descendants, err := dao.lockTrashedDescendants(ctx, tx, taskUUID)
+		if err != nil {
+			return err
+		}
+
+		now := sqlNow()
+		restored = make([]*model.Task, 0, len(descendants)+1)
+		for _, before := range append([]*model.Task{root}, descendants...) {
+			after := copyTask(before)
+			after.DeletedAt = nil
+			after.LastUpdated = now
+			after.Version++
+
+			if err := dao.saveTask(ctx, tx, model.TaskEventRestored, before, after); err != nil {
+				return err
+			}
+			restored = append(restored, after)
+		}
+		return dao.readTaskTags(ctx, tx, restored)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't restore the task : %w", err)
+	}
+	return restored, nil
+}
+
+func (dao *taskSQLDAO) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	// The subtasks left by the purged tasks are detached by the system.
+	ctx = model.ContextWithPrincipal(ctx, &model.Principal{ID: model.SystemActor, Admin: true})
+
+	var count int64
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < %s%s;",
+			taskColumns, dao.bind(1), dao.dialect.lockClause)
+		tasks, err := dao.queryTasks(ctx, tx, query, deletedBefore.UTC())
+		if err != nil {
+			return err
+		}
+
+		purged := make(map[uuid.UUID]bool, len(tasks))
+		for _, task := range tasks {
+			purged[task.UUID] = true
+		}
+
+		now := sqlNow()
+		for _, task := range tasks {
+			query := fmt.Sprintf("SELECT %s FROM tasks WHERE parent_uuid = %s%s;", taskColumns, dao.bind(1), dao.dialect.lockClause)
+			children, err := dao.queryTasks(ctx, tx, query, task.UUID)
+			if err != nil {
+				return err
+			}
+			for _, before := range children {
+				if purged[before.UUID] {
+					continue
+				}
+
+				after := copyTask(before)
+				after.ParentUUID = nil
+				after.LastUpdated = now
+				after.Version++
+
+				if err := dao.saveTask(ctx, tx, model.TaskEventUpdated, before, after); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, task := range tasks {
+			query := fmt.Sprintf("DELETE FROM task_grants WHERE task_uuid = %s;", dao.bind(1))
+			if _, err := dao.connector.Exec(tx, query, task.UUID); err != nil {
+				return dao.dialect.wrapError(err)
+			}
+
+			query = fmt.Sprintf("DELETE FROM task_dependencies WHERE task_uuid = %s OR blocker_uuid = %s;", dao.bind(1), dao.bind(2))
+			if _, err := dao.connector.Exec(tx, query, task.UUID, task.UUID); err != nil {
+				return dao.dialect.wrapError(err)
+			}
+
+			query = fmt.Sprintf("DELETE FROM task_tags WHERE task_uuid = %s;", dao.bind(1))
+			if _, err := dao.connector.Exec(tx, query, task.UUID); err != nil {
+				return dao.dialect.wrapError(err)
+			}
+
+			query = fmt.Sprintf("DELETE FROM tasks WHERE task_uuid = %s;", dao.bind(1))
+			if err := dao.execOne(tx, query, task.UUID); err != nil {
+				return err
+			}
+			if err := dao.insertEvent(tx, model.NewTaskEvent(model.TaskEventPurged, task, nil, model.SystemActor, now)); err != nil {
+				return err
+			}
+		}
+
+		count = int64(len(tasks))
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("can't purge the trash : %w", err)
+	}
+	return count, nil
+}
+
+func (dao *taskSQLDAO) Remind(ctx context.Context, remindBefore time.Time) ([]*model.Task, error) {
+	// The reminders are changes of the system, on the tasks of every owner.
+	ctx = model.ContextWithPrincipal(ctx, &model.Principal{ID: model.SystemActor, Admin: true})
+
+	var reminded []*model.Task
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM tasks WHERE deleted_at IS NULL AND reminded_at IS NULL AND remind_at <= %s ORDER BY remind_at%s;",
+			taskColumns, dao.bind(1), dao.dialect.lockClause)
+		tasks, err := dao.queryTasks(ctx, tx, query, remindBefore.UTC())
+		if err != nil {
+			return err
+		}
+
+		now := sqlNow()
+		for _, before := range tasks {
+			after := copyTask(before)
+			after.RemindedAt = &now
+			after.LastUpdated = now
+			after.Version++
+
+			if err := dao.saveTask(ctx, tx, model.TaskEventReminded, before, after); err != nil {
+				return err
+			}
+			reminded = append(reminded, after)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't send the reminders : %w", err)
+	}
+	return reminded, nil
+}
+
+func (dao *taskSQLDAO) StopSeries(ctx context.Context, seriesUUID uuid.UUID) (int64, error) {
+	var count int64
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		ownerCondition, params := dao.ownerCondition(ctx, []any{seriesUUID})
+		query := fmt.Sprintf("SELECT %s FROM tasks WHERE series_uuid = %s%s%s;", taskColumns, dao.bind(1), ownerCondition, dao.dialect.lockClause)
+		tasks, err := dao.queryTasks(ctx, tx, query, params...)
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return fmt.Errorf("no series with this UUID (%s) exist : %w", seriesUUID.String(), &NoDataFoundError{})
+		}
+
+		now := sqlNow()
+		for _, before := range tasks {
+			if before.RRule == "" {
+				continue
+			}
+
+			after := copyTask(before)
+			after.RRule = ""
+			after.LastUpdated = now
+			after.Version++
+
+			if err := dao.saveTask(ctx, tx, model.TaskEventUpdated, before, after); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("can't stop the series : %w", err)
+	}
+	return count, nil
+}
+
+func (dao *taskSQLDAO) ReadHistory(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskEvent, error) {
+	visibleCondition, params := dao.visibleCondition(ctx, []any{taskUUID})
+	query := fmt.Sprintf("SELECT %s FROM task_events WHERE task_uuid = %s%s ORDER BY version;", taskEventColumns, dao.bind(1), visibleCondition)
+	rows, err := dao.connector.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the history : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	events := make([]*model.TaskEvent, 0)
+	for rows.Next() {
+		event, err := scanTaskEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("no history for the task %s : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+	return events, nil
+}
+
+func (dao *taskSQLDAO) ReadSubtree(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	// The recursion only goes through the tasks visible by the caller.
+	rootCondition, params := dao.visibleCondition(ctx, []any{taskUUID})
+	childCondition, params := dao.visibleCondition(ctx, params)
+	query := fmt.Sprintf(`WITH RECURSIVE subtree (node_uuid) AS (
+		SELECT task_uuid FROM tasks WHERE task_uuid = %s AND deleted_at IS NULL%s
+		UNION
+		SELECT task_uuid FROM tasks JOIN subtree ON parent_uuid = node_uuid WHERE deleted_at IS NULL%s
+	) SELECT %s FROM tasks WHERE task_uuid IN (SELECT node_uuid FROM subtree) ORDER BY created_at, task_uuid;`,
+		dao.bind(1), rootCondition, childCondition, taskColumns)
+
+	tasks, err := dao.queryTasks(ctx, dao.connector, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the subtree : %w", err)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+	}
+	if err := dao.readTaskTags(ctx, dao.connector, tasks); err != nil {
+		return nil, fmt.Errorf("can't query the tags : %w", err)
+	}
+	return tasks, nil
+}
+
+func (dao *taskSQLDAO) ReadAncestors(ctx context.Context, taskUUID uuid.UUID) ([]uuid.UUID, error) {
+	// UNION stops the recursion on a cycle written by concurrent moves.
+	query := fmt.Sprintf(`WITH RECURSIVE ancestors (node_uuid) AS (
+		SELECT parent_uuid FROM tasks WHERE task_uuid = %s AND parent_uuid IS NOT NULL
+		UNION
+		SELECT parent_uuid FROM tasks JOIN ancestors ON task_uuid = node_uuid WHERE parent_uuid IS NOT NULL
+	) SELECT node_uuid FROM ancestors;`, dao.bind(1))
+	rows, err := dao.connector.QueryContext(ctx, query, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the ancestors : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	ancestors := make([]uuid.UUID, 0)
+	for rows.Next() {
+		var ancestor uuid.UUID
+		if err := rows.Scan(&ancestor); err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		ancestors = append(ancestors, ancestor)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	return ancestors, nil
+}
+
+func (dao *taskSQLDAO) ReadRole(ctx context.Context, taskUUID uuid.UUID) (model.TaskRole, error) {
+	var ownerID string
+	query := fmt.Sprintf("SELECT owner_id FROM tasks WHERE task_uuid = %s;", dao.bind(1))
+	if err := dao.connector.QueryRowContext(ctx, query, taskUUID).Scan(&ownerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+		}
+		return "", fmt.Errorf("can't query the owner : %w", dao.dialect.wrapError(err))
+	}
+
+	principalID, scoped := model.OwnerScopeFromContext(ctx)
+	if !scoped || principalID == ownerID {
+		return model.TaskRoleOwner, nil
+	}
+
+	var role model.TaskRole
+	query = fmt.Sprintf("SELECT role FROM task_grants WHERE task_uuid = %s AND principal_id = %s;", dao.bind(1), dao.bind(2))
+	if err := dao.connector.QueryRowContext(ctx, query, taskUUID, principalID).Scan(&role); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+		}
+		return "", fmt.Errorf("can't query the grant : %w", dao.dialect.wrapError(err))
+	}
+	return role, nil
+}
+
+func (dao *taskSQLDAO) SaveTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := dao.lockTask(ctx, tx, taskUUID, false, version)
+		if err != nil {
+			return err
+		}
+		tag, err := dao.lockTag(ctx, tx, tagUUID)
+		if err != nil {
+			return err
+		}
+		tagged, err := dao.hasTaskTag(ctx, tx, taskUUID, tagUUID)
+		if err != nil || tagged {
+			return err
+		}
+		if err := dao.readTaskTags(ctx, tx, []*model.Task{before}); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("INSERT INTO task_tags (task_uuid, tag_uuid) VALUES (%s, %s);", dao.bind(1), dao.bind(2))
+		if err := dao.execOne(tx, query, taskUUID, tagUUID); err != nil {
+			return err
+		}
+
+		after := copyTask(before)
+		after.Tags = sortedTagNames(append(after.Tags, tag.Name))
+		after.LastUpdated = sqlNow()
+		after.Version++
+		return dao.saveTask(ctx, tx, model.TaskEventUpdated, before, after)
+	})
+	if err != nil {
+		return fmt.Errorf("can't tag the task : %w", err)
+	}
+	return nil
+}
+
+func (dao *taskSQLDAO) DeleteTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := dao.lockTask(ctx, tx, taskUUID, false, version)
+		if err != nil {
+			return err
+		}
+		if err := dao.readTaskTags(ctx, tx, []*model.Task{before}); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf("DELETE FROM task_tags WHERE task_uuid = %s AND tag_uuid = %s;", dao.bind(1), dao.bind(2))
+		if err := dao.execOne(tx, query, taskUUID, tagUUID); err != nil {
+			return err
+		}
+
+		after := copyTask(before)
+		after.Tags = nil
+		if err := dao.readTaskTags(ctx, tx, []*model.Task{after}); err != nil {
+			return err
+		}
+		after.LastUpdated = sqlNow()
+		after.Version++
+		return dao.saveTask(ctx, tx, model.TaskEventUpdated, before, after)
+	})
+	if err != nil {
+		return fmt.Errorf("can't untag the task : %w", err)
+	}
+	return nil
+}
+
+// lockTag reads the tag of the principal of ctx in the transaction and locks its row until the end of the transaction.
+func (dao *taskSQLDAO) lockTag(ctx context.Context, tx *sql.Tx, tagUUID uuid.UUID) (*model.Tag, error) {
+	ownerCondition, params := dao.ownerCondition(ctx, []any{tagUUID})
+	query := fmt.Sprintf("SELECT %s FROM tags WHERE tag_uuid = %s%s%s;", tagColumns, dao.bind(1), ownerCondition, dao.dialect.lockClause)
+	tag, err := scanTag(tx.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no tag with this UUID (%s) exist : %w", tagUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+	return tag, nil
+}
+
+// hasTaskTag reports whether the tag is on the task.
+func (dao *taskSQLDAO) hasTaskTag(ctx context.Context, tx *sql.Tx, taskUUID, tagUUID uuid.UUID) (bool, error) {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM task_tags WHERE task_uuid = %s AND tag_uuid = %s;", dao.bind(1), dao.bind(2))
+	if err := tx.QueryRowContext(ctx, query, taskUUID, tagUUID).Scan(&count); err != nil {
+		return false, dao.dialect.wrapError(err)
+	}
+	return count > 0, nil
+}
+
+// readTaskTags fills the Tags of the tasks with the sorted names of their tags, on the database or in a transaction.
+func (dao *taskSQLDAO) readTaskTags(ctx context.Context, q querier, tasks []*model.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	byUUID := make(map[uuid.UUID]*model.Task, len(tasks))
+	params := make([]any, 0, len(tasks))
+	placeholders := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		byUUID[task.UUID] = task
+		params = append(params, task.UUID)
+		placeholders = append(placeholders, dao.bind(len(params)))
+	}
+
+	query := fmt.Sprintf("SELECT tt.task_uuid, g.name FROM task_tags tt JOIN tags g ON g.tag_uuid = tt.tag_uuid WHERE tt.task_uuid IN (%s);",
+		strings.Join(placeholders, ", "))
+	rows, err := q.QueryContext(ctx, query, params...)
+	if err != nil {
+		return dao.dialect.wrapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			taskUUID uuid.UUID
+			name     string
+		)
+		if err := rows.Scan(&taskUUID, &name); err != nil {
+			return fmt.Errorf("can't scan row : %w", err)
+		}
+		if task, exist := byUUID[taskUUID]; exist {
+			task.Tags = append(task.Tags, name)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	for _, task := range tasks {
+		task.Tags = sortedTagNames(task.Tags)
+	}
+	return nil
+}
+
+// visibleCondition returns the condition restricting a query on tasks or task_events to the tasks owned by
+// or shared with the principal of ctx, empty if it sees every task. Its parameters are appended to params.
+func (dao *taskSQLDAO) visibleCondition(ctx context.Context, params []any) (string, []any) {
+	owner, scoped := model.OwnerScopeFromContext(ctx)
+	if !scoped {
+		return "", params
+	}
+
+	params = append(params, owner, owner)
+	return fmt.Sprintf(" AND (owner_id = %s OR task_uuid IN (SELECT task_uuid FROM task_grants WHERE principal_id = %s))",
+		dao.bind(len(params)-1), dao.bind(len(params))), params
+}
+
+// lockTask reads the task in the transaction and locks its row until the end of the transaction.
+// The tasks not visible by the principal of ctx aren't found, deleted selects a task of the trash instead of a live task, version is checked unless it is 0.
+func (dao *taskSQLDAO) lockTask(ctx context.Context, tx *sql.Tx, taskUUID uuid.UUID, deleted bool, version int64) (*model.Task, error) {
+	condition := "deleted_at IS NULL"
+	if deleted {
+		condition = "deleted_at IS NOT NULL"
+	}
+
+	visibleCondition, params := dao.visibleCondition(ctx, []any{taskUUID})
+	query := fmt.Sprintf("SELECT %s FROM tasks WHERE task_uuid = %s AND %s%s%s;", taskColumns, dao.bind(1), condition, visibleCondition, dao.dialect.lockClause)
+	task, err := scanTask(tx.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no task with this UUID (%s) exist : %w", taskUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+
+	if version != 0 && version != task.Version {
+		return nil, &VersionMismatchError{Expected: version, Current: task.Version}
+	}
+	return task, nil
+}
+
+// lockDescendants reads the live descendants of a task in the transaction, whoever owns them, and locks their rows.
+func (dao *taskSQLDAO) lockDescendants(ctx context.Context, tx *sql.Tx, taskUUID uuid.UUID) ([]*model.Task, error) {
+	query := fmt.Sprintf(`WITH RECURSIVE subtree (node_uuid) AS (
+		SELECT task_uuid FROM tasks WHERE parent_uuid = %s AND deleted_at IS NULL
+		UNION
+		SELECT task_uuid FROM tasks JOIN subtree ON parent_uuid = node_uuid WHERE deleted_at IS NULL
+	) SELECT %s FROM tasks WHERE task_uuid IN (SELECT node_uuid FROM subtree)%s;`, dao.bind(1), taskColumns, dao.dialect.lockClause)
+
+	tasks, err := dao.queryTasks(ctx, tx, query, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the descendants : %w", err)
+	}
+	return tasks, nil
+}
+
+// lockTrashedDescendants reads the descendants of a trashed task deleted at the same time, by a cascade delete of the task,
+// in the transaction, whoever owns them, and locks their rows. The subtasks deleted before it and their subtrees are left out.
+func (dao *taskSQLDAO) lockTrashedDescendants(ctx context.Context, tx *sql.Tx, taskUUID uuid.UUID) ([]*model.Task, error) {
+	query := fmt.Sprintf(`WITH RECURSIVE subtree (node_uuid, node_deleted_at) AS (
+		SELECT task_uuid, deleted_at FROM tasks WHERE task_uuid = %s
+		UNION
+		SELECT task_uuid, deleted_at FROM tasks JOIN subtree ON parent_uuid = node_uuid WHERE deleted_at = node_deleted_at
+	) SELECT %s FROM tasks WHERE task_uuid IN (SELECT node_uuid FROM subtree) AND task_uuid <> %s%s;`, dao.bind(1), taskColumns, dao.bind(2), dao.dialect.lockClause)
+
+	tasks, err := dao.queryTasks(ctx, tx, query, taskUUID, taskUUID)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the trashed descendants : %w", err)
+	}
+	return tasks, nil
+}
+
+// saveTask writes the changes of a task read by lockTask and records them in its history.
+// The version guard protects the databases without row locks.
+func (dao *taskSQLDAO) saveTask(ctx context.Context, tx *sql.Tx, eventType model.TaskEventType, before, after *model.Task) error {
+	query := fmt.Sprintf("UPDATE tasks SET description = %s, status = %s, last_updated = %s, version = %s, deleted_at = %s, list_uuid = %s, due_at = %s, remind_at = %s, reminded_at = %s, rrule = %s, series_uuid = %s, parent_uuid = %s WHERE task_uuid = %s AND version = %s;",
+		dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5), dao.bind(6), dao.bind(7), dao.bind(8), dao.bind(9), dao.bind(10), dao.bind(11), dao.bind(12), dao.bind(13), dao.bind(14))
+	err := dao.execOne(tx, query, after.WhatToDo, after.Status, after.LastUpdated, after.Version, after.DeletedAt, after.ListUUID,
+		after.DueAt, after.RemindAt, after.RemindedAt, after.RRule, after.SeriesUUID, after.ParentUUID, after.UUID, before.Version)
+	if err != nil {
+		if errors.Is(err, ErrNoRowAffected) {
+			return &ConflictError{Err: err}
+		}
+		return err
+	}
+
+	return dao.insertEvent(tx, model.NewTaskEvent(eventType, before, after, model.ActorFromContext(ctx), after.LastUpdated))
+}
+
+// insertEvent appends an event to the history.
+func (dao *taskSQLDAO) insertEvent(tx *sql.Tx, event *model.TaskEvent) error {
+	changes, err := json.Marshal(event.Changes)
+	if err != nil {
+		return fmt.Errorf("can't marshal the changes : %w", err)
+	}
+
+	query := fmt.Sprintf("INSERT INTO task_events (%s) VALUES (%s, %s, %s, %s, %s, %s, %s, %s);", taskEventColumns,
+		dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5), dao.bind(6), dao.bind(7), dao.bind(8))
+	if err := dao.execOne(tx, query, event.UUID, event.TaskUUID, event.Version, event.Type, event.Actor, event.OccurredAt, string(changes), event.OwnerID); err != nil {
+		return fmt.Errorf("can't insert the event : %w", err)
+	}
+	return nil
+}
+
+// queryTasks runs a query selecting taskColumns, on the database or in a transaction, and scans every row.
+func (dao *taskSQLDAO) queryTasks(ctx context.Context, q querier, query string, params ...any) ([]*model.Task, error) {
+	rows, err := q.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, dao.dialect.wrapError(err)
+	}
+	defer rows.Close()
+
+	// Scan the result to retrieve each task.
+	tasks := make([]*model.Task, 0)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	return tasks, nil
+}
+
+// scanTask scans the taskColumns of a row, then the extra columns into extra.
+func scanTask(row rowScanner, extra ...any) (*model.Task, error) {
+	task := new(model.Task)
+	if err := row.Scan(append([]any{
+		&task.UUID,
+		&task.WhatToDo,
+		&task.Status,
+		&task.CreatedAt,
+		&task.LastUpdated,
+		&task.Version,
+		&task.DeletedAt,
+		&task.OwnerID,
+		&task.ListUUID,
+		&task.DueAt,
+		&task.RemindAt,
+		&task.RemindedAt,
+		&task.RRule,
+		&task.SeriesUUID,
+		&task.ParentUUID,
+	}, extra...)...); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// scanTag scans the tagColumns of a row.
+func scanTag(row rowScanner) (*model.Tag, error) {
+	tag := new(model.Tag)
+	if err := row.Scan(
+		&tag.UUID,
+		&tag.Name,
+		&tag.OwnerID,
+		&tag.CreatedAt,
+		&tag.LastUpdated,
+	); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// scanTaskEvent scans the taskEventColumns of a row.
+func scanTaskEvent(row rowScanner) (*model.TaskEvent, error) {
+	var changes []byte
+	event := new(model.TaskEvent)
+	if err := row.Scan(
+		&event.UUID,
+		&event.TaskUUID,
+		&event.Version,
+		&event.Type,
+		&event.Actor,
+		&event.OccurredAt,
+		&changes,
+		&event.OwnerID,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(changes, &event.Changes); err != nil {
+		return nil, fmt.Errorf("can't unmarshal the changes : %w", err)
+	}
+	return event, nil
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// TypeTaskSQLiteDAO is an identifier to build TaskSQLiteDAO.
+	TypeTaskSQLiteDAO = "TaskSQLiteDAO"
+)
+
+var _ ITaskDAO = (*TaskSQLiteDAO)(nil)
+
+// sqliteDialect is the sqlDialect of SQLite, its default BINARY collation already compares texts byte-wise.
+var sqliteDialect = sqlDialect{
+	placeholder: func(n int) string { return "?" + strconv.Itoa(n) },
+	sortColumns: map[string]string{
+		model.TaskSortCreatedAt:   "created_at",
+		model.TaskSortLastUpdated: "last_updated",
+		model.TaskSortDescription: "description",
+		model.TaskSortStatus:      "status",
+	},
+	containsCondition: `description LIKE %s ESCAPE '\'`,
+	wrapError:         wrapSQLiteError,
+}
+
+// TaskSQLiteDAO is a TaskDAO storing the tasks in a SQLite database file.
+type TaskSQLiteDAO struct {
+	taskSQLDAO
+}
+
+// wrapSQLiteError wraps the errors of the SQLite driver into the error types of the repositories package.
+func wrapSQLiteError(err error) error {
+	var errSQLite sqlite3.Error
+	if errors.As(err, &errSQLite) {
+		switch errSQLite.Code {
+		case sqlite3.ErrConstraint:
+			return &ConflictError{Err: err}
+		case sqlite3.ErrBusy, sqlite3.ErrLocked, sqlite3.ErrCantOpen, sqlite3.ErrIoErr, sqlite3.ErrFull:
+			return &UnavailableError{Err: err}
+		}
+		return err
+	}
+
+	return wrapConnectionError(err)
+}
+
+// factoryTaskSQLiteDAO build TaskSQLiteDAO.
+func factoryTaskSQLiteDAO(opt DAOFactoryOptions) (*TaskSQLiteDAO, error) {
+	connector, err := connectors.GetConnectorSQLite(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &TaskSQLiteDAO{
+		taskSQLDAO: taskSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       sqliteDialect,
+			},
+		},
+	}, nil
+}
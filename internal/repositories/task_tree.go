@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// planTaskDelete returns the subtasks moved to the trash and the subtasks detached by the deletion of root.
+// descendants are the live descendants of root, whoever owns them.
+func planTaskDelete(root *model.Task, descendants []*model.Task, cascade model.TaskDeleteCascade) (trashed, detached []*model.Task, err error) {
+	children := make(map[uuid.UUID][]*model.Task)
+	for _, task := range descendants {
+		if task.ParentUUID != nil {
+			children[*task.ParentUUID] = append(children[*task.ParentUUID], task)
+		}
+	}
+
+	switch cascade {
+	case model.TaskDeleteDetach:
+		return nil, children[root.UUID], nil
+	case model.TaskDeleteSubtree:
+		// The subtasks of another owner aren't trashed, they leave the subtree with their own subtasks.
+		queue := []uuid.UUID{root.UUID}
+		seen := map[uuid.UUID]bool{root.UUID: true}
+		for len(queue) > 0 {
+			for _, child := range children[queue[0]] {
+				if seen[child.UUID] {
+					continue
+				}
+				seen[child.UUID] = true
+				if child.OwnerID != root.OwnerID {
+					detached = append(detached, child)
+					continue
+				}
+				trashed = append(trashed, child)
+				queue = append(queue, child.UUID)
+			}
+			queue = queue[1:]
+		}
+		return trashed, detached, nil
+	default:
+		if count := len(children[root.UUID]); count > 0 {
+			return nil, nil, &HasSubtasksError{UUID: root.UUID, Count: count}
+		}
+		return nil, nil, nil
+	}
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+func TestPlanTaskDelete(t *testing.T) {
+	// root has the subtasks a, with a1, and b of bob, with b1 of alice.
+	task := func(whatToDo, owner string, parent *model.Task) *model.Task {
+		task := &model.Task{UUID: uuid.New(), WhatToDo: whatToDo, OwnerID: owner}
+		if parent != nil {
+			task.ParentUUID = &parent.UUID
+		}
+		return task
+	}
+	root := task("root", "alice", nil)
+	a := task("a", "alice", root)
+	a1 := task("a1", "alice", a)
+	b := task("b", "bob", root)
+	b1 := task("b1", "alice", b)
+	descendants := []*model.Task{a, a1, b, b1}
+
+	names := func(tasks []*model.Task) map[string]bool {
+		set := make(map[string]bool)
+		for _, task := range tasks {
+			set[task.WhatToDo] = true
+		}
+		return set
+	}
+
+	tests := []struct {
+		cascade  model.TaskDeleteCascade
+		trashed  []string
+		detached []string
+	}{
+		{cascade: model.TaskDeleteDetach, detached: []string{"a", "b"}},
+		{cascade: model.TaskDeleteSubtree, trashed: []string{"a", "a1"}, detached: []string{"b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.cascade), func(t *testing.T) {
+			trashed, detached, err := planTaskDelete(root, descendants, tt.cascade)
+			if err != nil {
+				t.Fatalf("planTaskDelete() error = %v", err)
+			}
+			gotTrashed, gotDetached := names(trashed), names(detached)
+			if len(gotTrashed) != len(tt.trashed) || len(gotDetached) != len(tt.detached) {
+				t.Fatalf("planTaskDelete() trashes %v and detaches %v, want %v and %v", gotTrashed, gotDetached, tt.trashed, tt.detached)
+			}
+			for _, name := range tt.trashed {
+				if !gotTrashed[name] {
+					t.Errorf("planTaskDelete() didn't trash %s", name)
+				}
+			}
+			for _, name := range tt.detached {
+				if !gotDetached[name] {
+					t.Errorf("planTaskDelete() didn't detach %s", name)
+				}
+			}
+		})
+	}
+
+	t.Run(string(model.TaskDeleteRestrict), func(t *testing.T) {
+		var errHasSubtasks *HasSubtasksError
+		if _, _, err := planTaskDelete(root, descendants, model.TaskDeleteRestrict); !errors.As(err, &errHasSubtasks) || errHasSubtasks.Count != 2 || errHasSubtasks.UUID != root.UUID {
+			t.Errorf("planTaskDelete() error = %v, want a HasSubtasksError of 2 subtasks", err)
+		}
+		if trashed, detached, err := planTaskDelete(a1, nil, model.TaskDeleteRestrict); err != nil || len(trashed) != 0 || len(detached) != 0 {
+			t.Errorf("planTaskDelete() of a leaf = %v, %v, %v, want nothing to do", trashed, detached, err)
+		}
+	})
+}
@@ -2,7 +2,10 @@ package repositories
 
 import (
 	"context"
+	"time"
+
 	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
 )
 
 const (
@@ -17,27 +20,74 @@ type TaskVoidDAO struct {
 	connectorName string
 }
 
+func (dao *TaskVoidDAO) Create(ctx context.Context, taskToCreate *model.TaskCreateDTO) (*model.Task, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) ReadByUUID(ctx context.Context, taskUUID uuid.UUID) (*model.Task, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) ReadAll(ctx context.Context, filter *model.TaskFilterDTO) ([]*model.Task, string, error) {
+	return nil, "", ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) Search(ctx context.Context, search *model.TaskSearchDTO) ([]*model.TaskSearchResult, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) Update(ctx context.Context, taskUUID uuid.UUID, patch *model.TaskPatch, version int64) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) Delete(ctx context.Context, taskUUID uuid.UUID, version int64, cascade model.TaskDeleteCascade) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) Restore(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) Remind(ctx context.Context, remindBefore time.Time) ([]*model.Task, error) {
+	return nil, ErrFeatureNotImplemented
+}
 
-func (dao *TaskVoidDAO) Create(ctx context.Context) (*model.Task, error) {
+func (dao *TaskVoidDAO) ReadHistory(ctx context.Context, taskUUID uuid.UUID) ([]*model.TaskEvent, error) {
 	return nil, ErrFeatureNotImplemented
 }
 
-func (dao *TaskVoidDAO) ReadByUUID(ctx context.Context) (*model.Task, error) {
+func (dao *TaskVoidDAO) StopSeries(ctx context.Context, seriesUUID uuid.UUID) (int64, error) {
+	return 0, ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) ReadSubtree(ctx context.Context, taskUUID uuid.UUID) ([]*model.Task, error) {
 	return nil, ErrFeatureNotImplemented
 }
 
-func (dao *TaskVoidDAO) ReadAll(ctx context.Context) ([]*model.Task, error) {
+func (dao *TaskVoidDAO) ReadAncestors(ctx context.Context, taskUUID uuid.UUID) ([]uuid.UUID, error) {
 	return nil, ErrFeatureNotImplemented
 }
 
-func (dao *TaskVoidDAO) Update(ctx context.Context) error {
+func (dao *TaskVoidDAO) ReadRole(ctx context.Context, taskUUID uuid.UUID) (model.TaskRole, error) {
+	return "", ErrFeatureNotImplemented
+}
+
+func (dao *TaskVoidDAO) SaveTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
 	return ErrFeatureNotImplemented
 }
 
-func (dao *TaskVoidDAO) Delete(ctx context.Context) error {
+func (dao *TaskVoidDAO) DeleteTaskTag(ctx context.Context, taskUUID, tagUUID uuid.UUID, version int64) error {
 	return ErrFeatureNotImplemented
 }
 
+func (dao *TaskVoidDAO) Batch(ctx context.Context, operations []*model.TaskBatchOperation, atomic bool) ([]*model.TaskBatchResult, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
 // factoryTaskVoidDAO build TaskVoidDAO.
 func factoryTaskVoidDAO(opt DAOFactoryOptions) (*TaskVoidDAO, error) {
 	return &TaskVoidDAO{
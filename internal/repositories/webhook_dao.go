@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+// mapWebhookDAO is used by ProxyFactoryWebhookDAO to store WebhookDAO.
+var mapWebhookDAO = make(map[string]map[string]IWebhookDAO)
+
+// IWebhookDAO is a DAO interface to manage Webhook and the queue of their deliveries.
+// The webhooks of another owner than the principal of ctx aren't found, unless it is an admin,
+// and neither are their deliveries.
+type IWebhookDAO interface {
+	Create(ctx context.Context, webhookToCreate *model.WebhookCreateDTO) (*model.Webhook, error)
+	ReadByUUID(ctx context.Context, webhookUUID uuid.UUID) (*model.Webhook, error)
+	// ReadAll returns the webhooks, oldest first.
+	ReadAll(ctx context.Context) ([]*model.Webhook, error)
+	Update(ctx context.Context, webhookUUID uuid.UUID, patch *model.WebhookPatch) error
+	// Delete removes the webhook with its deliveries.
+	Delete(ctx context.Context, webhookUUID uuid.UUID) error
+
+	// CreateDeliveries queues the deliveries.
+	CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error
+	ReadDelivery(ctx context.Context, webhookUUID, deliveryUUID uuid.UUID) (*model.WebhookDelivery, error)
+	// ReadDeliveries returns the last deliveries of the webhook with the status, or with any status if it is empty, newest first.
+	ReadDeliveries(ctx context.Context, webhookUUID uuid.UUID, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error)
+	// ClaimDeliveries returns up to limit pending deliveries due at now, of every owner, the oldest first.
+	// Their next attempt is pushed to leaseUntil so another replica doesn't send them meanwhile.
+	ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error)
+	// UpdateDelivery stores the status and the attempts of the delivery, whoever owns its webhook.
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	// PurgeDeliveries removes the deliveries delivered before the date and returns how many were removed.
+	PurgeDeliveries(ctx context.Context, deliveredBefore time.Time) (int64, error)
+}
+
+// ProxyFactoryWebhookDAO uses FactoryWebhookDAO if the WebhookDAO don't exist, and returns WebhookDAO.
+func ProxyFactoryWebhookDAO(opt DAOFactoryOptions) (IWebhookDAO, error) {
+	// Test if exist
+	mapConnector, mapExist := mapWebhookDAO[opt.Type]
+	if mapExist {
+		daoWebhook, present := mapConnector[opt.Connector]
+		if present {
+			return daoWebhook, nil
+		}
+	}
+
+	// Build new WebhookDAO
+	daoWebhook, err := FactoryWebhookDAO(opt)
+	if err != nil {
+		return nil, fmt.Errorf("fail to build new WebhookDAO: %w", err)
+	}
+
+	// Save new WebhookDAO
+	if !mapExist {
+		mapWebhookDAO[opt.Type] = make(map[string]IWebhookDAO)
+	}
+	mapWebhookDAO[opt.Type][opt.Connector] = daoWebhook
+
+	return daoWebhook, nil
+}
+
+// FactoryWebhookDAO builds a new WebhookDAO according to the typename.
+func FactoryWebhookDAO(opt DAOFactoryOptions) (IWebhookDAO, error) {
+	var dao IWebhookDAO
+	var err error
+
+	switch opt.Type {
+	case TypeWebhookVoidDAO:
+		dao, err = factoryWebhookVoidDAO(opt)
+	case TypeWebhookInMemoryDAO:
+		dao, err = factoryWebhookInMemoryDAO(opt)
+	case TypeWebhookPostgresDAO:
+		dao, err = factoryWebhookPostgresDAO(opt)
+	case TypeWebhookMySQLDAO:
+		dao, err = factoryWebhookMySQLDAO(opt)
+	case TypeWebhookSQLiteDAO:
+		dao, err = factoryWebhookSQLiteDAO(opt)
+	default:
+		return nil, &DAOTypeNotFoundError{Type: opt.Type}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to build %v: %w", opt.Type, err)
+	}
+
+	return dao, nil
+}
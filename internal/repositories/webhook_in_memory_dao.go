@@ -0,0 +1,442 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// TypeWebhookInMemoryDAO is an identifier to build WebhookInMemoryDAO.
+	TypeWebhookInMemoryDAO = "WebhookInMemoryDAO"
+)
+
+const (
+	// recordOpPutDeliveries saves the deliveries of the record.
+	recordOpPutDeliveries = "put_deliveries"
+	// recordOpPurgeDeliveries removes the deliveries delivered before the date of the record.
+	recordOpPurgeDeliveries = "purge_deliveries"
+)
+
+var _ IWebhookDAO = (*WebhookInMemoryDAO)(nil)
+
+// WebhookInMemoryDAO is a WebhookDAO storing the webhooks and their deliveries in maps, safe for concurrent use.
+// With a File connector, each change is appended to the file and the webhooks are reloaded from it at startup.
+type WebhookInMemoryDAO struct {
+	connectorName string
+	file          *connectors.FileConnector
+
+	mu sync.RWMutex
+	webhookState
+}
+
+// webhookState is the content of a WebhookInMemoryDAO, rebuilt by replaying the records of its log file.
+// The stored webhooks and deliveries are never mutated, they are replaced by their new version.
+type webhookState struct {
+	webhooks   map[uuid.UUID]*model.Webhook
+	deliveries map[uuid.UUID]*model.WebhookDelivery
+}
+
+// webhookRecord is a line of the log file of a WebhookInMemoryDAO.
+type webhookRecord struct {
+	Op         string                   `json:"op"`
+	Webhook    *model.Webhook           `json:"webhook,omitempty"`
+	UUID       uuid.UUID                `json:"uuid"`
+	Deliveries []*model.WebhookDelivery `json:"deliveries,omitempty"`
+	// DeliveredBefore is the date of a purge record.
+	DeliveredBefore *time.Time `json:"delivered_before,omitempty"`
+}
+
+func (dao *WebhookInMemoryDAO) Create(ctx context.Context, webhookToCreate *model.WebhookCreateDTO) (*model.Webhook, error) {
+	webhook := webhookToCreate.ReverseCreateDTO()
+	webhook.OwnerID = model.OwnerFromContext(ctx)
+	webhook.CreatedAt = time.Now()
+	webhook.LastUpdated = webhook.CreatedAt
+
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if err := dao.apply(webhookRecord{Op: recordOpPut, Webhook: webhook, UUID: webhook.UUID}); err != nil {
+		return nil, err
+	}
+
+	return copyWebhook(webhook), nil
+}
+
+func (dao *WebhookInMemoryDAO) ReadByUUID(ctx context.Context, webhookUUID uuid.UUID) (*model.Webhook, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	webhook, err := dao.visibleWebhook(ctx, webhookUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyWebhook(webhook), nil
+}
+
+func (dao *WebhookInMemoryDAO) ReadAll(ctx context.Context) ([]*model.Webhook, error) {
+	dao.mu.RLock()
+	webhooks := make([]*model.Webhook, 0, len(dao.webhooks))
+	for _, webhook := range dao.webhooks {
+		if visibleOwner(ctx, webhook.OwnerID) {
+			webhooks = append(webhooks, copyWebhook(webhook))
+		}
+	}
+	dao.mu.RUnlock()
+
+	slices.SortFunc(webhooks, func(a, b *model.Webhook) int {
+		if cmp := a.CreatedAt.Compare(b.CreatedAt); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(a.UUID.String(), b.UUID.String())
+	})
+
+	return webhooks, nil
+}
+
+func (dao *WebhookInMemoryDAO) Update(ctx context.Context, webhookUUID uuid.UUID, patch *model.WebhookPatch) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	storedWebhook, err := dao.visibleWebhook(ctx, webhookUUID)
+	if err != nil {
+		return err
+	}
+
+	// Work on a copy so a failure to persist leaves the stored webhook untouched.
+	webhookToUpdate := copyWebhook(storedWebhook)
+	if patch.URL != nil {
+		webhookToUpdate.URL = *patch.URL
+	}
+	if patch.Secret != nil {
+		webhookToUpdate.Secret = *patch.Secret
+	}
+	if patch.Events != nil {
+		webhookToUpdate.Events = slices.Clone(patch.Events)
+	}
+	if patch.Active != nil {
+		webhookToUpdate.Active = *patch.Active
+	}
+	webhookToUpdate.LastUpdated = time.Now()
+
+	return dao.apply(webhookRecord{Op: recordOpPut, Webhook: webhookToUpdate, UUID: webhookUUID})
+}
+
+func (dao *WebhookInMemoryDAO) Delete(ctx context.Context, webhookUUID uuid.UUID) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if _, err := dao.visibleWebhook(ctx, webhookUUID); err != nil {
+		return err
+	}
+
+	return dao.apply(webhookRecord{Op: recordOpDelete, UUID: webhookUUID})
+}
+
+func (dao *WebhookInMemoryDAO) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		if _, exist := dao.webhooks[delivery.WebhookUUID]; !exist {
+			return fmt.Errorf("no webhook with this UUID (%s) exist : %w", delivery.WebhookUUID.String(), &NoDataFoundError{})
+		}
+	}
+
+	stored := make([]*model.WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryCopy := *delivery
+		stored = append(stored, &deliveryCopy)
+	}
+	return dao.apply(webhookRecord{Op: recordOpPutDeliveries, Deliveries: stored})
+}
+
+func (dao *WebhookInMemoryDAO) ReadDelivery(ctx context.Context, webhookUUID, deliveryUUID uuid.UUID) (*model.WebhookDelivery, error) {
+	dao.mu.RLock()
+	defer dao.mu.RUnlock()
+
+	if _, err := dao.visibleWebhook(ctx, webhookUUID); err != nil {
+		return nil, err
+	}
+
+	delivery, exist := dao.deliveries[deliveryUUID]
+	if !exist || delivery.WebhookUUID != webhookUUID {
+		return nil, fmt.Errorf("no delivery with this UUID (%s) exist : %w", deliveryUUID.String(), &NoDataFoundError{})
+	}
+
+	deliveryCopy := *delivery
+	return &deliveryCopy, nil
+}
+
+func (dao *WebhookInMemoryDAO) ReadDeliveries(ctx context.Context, webhookUUID uuid.UUID, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	dao.mu.RLock()
+	if _, err := dao.visibleWebhook(ctx, webhookUUID); err != nil {
+		dao.mu.RUnlock()
+		return nil, err
+	}
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for _, delivery := range dao.deliveries {
+		if delivery.WebhookUUID == webhookUUID && (status == "" || delivery.Status == status) {
+			deliveryCopy := *delivery
+			deliveries = append(deliveries, &deliveryCopy)
+		}
+	}
+	dao.mu.RUnlock()
+
+	slices.SortFunc(deliveries, func(a, b *model.WebhookDelivery) int {
+		if cmp := b.CreatedAt.Compare(a.CreatedAt); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(b.UUID.String(), a.UUID.String())
+	})
+
+	return deliveries[:min(len(deliveries), limit)], nil
+}
+
+// ClaimDeliveries doesn't persist the lease, the file of the DAO is only used by this process.
+func (dao *WebhookInMemoryDAO) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	due := make([]*model.WebhookDelivery, 0)
+	for _, delivery := range dao.deliveries {
+		if delivery.Status == model.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	slices.SortFunc(due, func(a, b *model.WebhookDelivery) int {
+		if cmp := a.NextAttemptAt.Compare(b.NextAttemptAt); cmp != 0 {
+			return cmp
+		}
+		return strings.Compare(a.UUID.String(), b.UUID.String())
+	})
+
+	claimed := make([]*model.WebhookDelivery, 0, min(len(due), limit))
+	for _, delivery := range due[:min(len(due), limit)] {
+		leased := *delivery
+		leased.NextAttemptAt = leaseUntil
+		dao.deliveries[leased.UUID] = &leased
+
+		deliveryCopy := *delivery
+		claimed = append(claimed, &deliveryCopy)
+	}
+
+	return claimed, nil
+}
+
+func (dao *WebhookInMemoryDAO) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	if _, exist := dao.deliveries[delivery.UUID]; !exist {
+		return fmt.Errorf("no delivery with this UUID (%s) exist : %w", delivery.UUID.String(), &NoDataFoundError{})
+	}
+
+	deliveryCopy := *delivery
+	return dao.apply(webhookRecord{Op: recordOpPutDeliveries, Deliveries: []*model.WebhookDelivery{&deliveryCopy}})
+}
+
+func (dao *WebhookInMemoryDAO) PurgeDeliveries(ctx context.Context, deliveredBefore time.Time) (int64, error) {
+	dao.mu.Lock()
+	defer dao.mu.Unlock()
+
+	var count int64
+	for _, delivery := range dao.deliveries {
+		if purgeable(delivery, deliveredBefore) {
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	if err := dao.apply(webhookRecord{Op: recordOpPurgeDeliveries, DeliveredBefore: &deliveredBefore}); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// visibleWebhook returns the stored webhook if it is visible by the principal of ctx.
+// It must be called with the lock held.
+func (dao *WebhookInMemoryDAO) visibleWebhook(ctx context.Context, webhookUUID uuid.UUID) (*model.Webhook, error) {
+	webhook, exist := dao.webhooks[webhookUUID]
+	if !exist || !visibleOwner(ctx, webhook.OwnerID) {
+		return nil, fmt.Errorf("no webhook with this UUID (%s) exist : %w", webhookUUID.String(), &NoDataFoundError{})
+	}
+	return webhook, nil
+}
+
+// apply persists the record then applies it on the state.
+// It must be called with the write lock held.
+func (dao *WebhookInMemoryDAO) apply(record webhookRecord) error {
+	if err := dao.persist(record); err != nil {
+		return err
+	}
+
+	dao.applyRecord(record)
+	return nil
+}
+
+// persist appends the record to the file, and compacts the file when it grew too much.
+// It must be called with the write lock held.
+func (dao *WebhookInMemoryDAO) persist(record webhookRecord) error {
+	if dao.file == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("can't marshal the record : %w", err)
+	}
+
+	if err := dao.file.Append(raw); err != nil {
+		return fmt.Errorf("can't persist the record : %w", err)
+	}
+
+	if dao.file.NeedCompaction() {
+		// The record is already safe in the file, a failed compaction is retried on the next change.
+		snapshot := dao.webhookState.clone()
+		snapshot.applyRecord(record)
+
+		if err := dao.compact(snapshot); err != nil {
+			log.Error("fail to compact the webhooks file", zap.String("connector", dao.connectorName), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// compact replaces the file content by one put record per webhook, followed by their deliveries.
+func (dao *WebhookInMemoryDAO) compact(state webhookState) error {
+	records := make([][]byte, 0, len(state.webhooks)+1)
+	for webhookUUID, webhook := range state.webhooks {
+		raw, err := json.Marshal(webhookRecord{Op: recordOpPut, Webhook: webhook, UUID: webhookUUID})
+		if err != nil {
+			return fmt.Errorf("can't marshal the record : %w", err)
+		}
+		records = append(records, raw)
+	}
+
+	if len(state.deliveries) > 0 {
+		deliveries := make([]*model.WebhookDelivery, 0, len(state.deliveries))
+		for _, delivery := range state.deliveries {
+			deliveries = append(deliveries, delivery)
+		}
+
+		raw, err := json.Marshal(webhookRecord{Op: recordOpPutDeliveries, Deliveries: deliveries})
+		if err != nil {
+			return fmt.Errorf("can't marshal the record : %w", err)
+		}
+		records = append(records, raw)
+	}
+
+	return dao.file.Compact(records)
+}
+
+// load replays the records of the file then compacts it.
+func (dao *WebhookInMemoryDAO) load() error {
+	records, err := dao.file.Records()
+	if err != nil {
+		return fmt.Errorf("can't read the webhooks file : %w", err)
+	}
+
+	for i, raw := range records {
+		var record webhookRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return fmt.Errorf("can't unmarshal the record %d : %w", i+1, err)
+		}
+		dao.applyRecord(record)
+	}
+
+	return dao.compact(dao.webhookState)
+}
+
+// clone returns a copy of the state sharing its webhooks and deliveries, which are never mutated.
+func (state webhookState) clone() webhookState {
+	return webhookState{
+		webhooks:   maps.Clone(state.webhooks),
+		deliveries: maps.Clone(state.deliveries),
+	}
+}
+
+// applyRecord applies a record of the log file on the state.
+func (state webhookState) applyRecord(record webhookRecord) {
+	switch record.Op {
+	case recordOpPut:
+		if record.Webhook != nil {
+			state.webhooks[record.UUID] = record.Webhook
+		}
+	case recordOpDelete:
+		delete(state.webhooks, record.UUID)
+		maps.DeleteFunc(state.deliveries, func(_ uuid.UUID, delivery *model.WebhookDelivery) bool {
+			return delivery.WebhookUUID == record.UUID
+		})
+	case recordOpPutDeliveries:
+		for _, delivery := range record.Deliveries {
+			state.deliveries[delivery.UUID] = delivery
+		}
+	case recordOpPurgeDeliveries:
+		if record.DeliveredBefore != nil {
+			maps.DeleteFunc(state.deliveries, func(_ uuid.UUID, delivery *model.WebhookDelivery) bool {
+				return purgeable(delivery, *record.DeliveredBefore)
+			})
+		}
+	}
+}
+
+// purgeable reports whether the delivery was delivered before the date.
+func purgeable(delivery *model.WebhookDelivery, deliveredBefore time.Time) bool {
+	return delivery.Status == model.WebhookDeliveryDelivered &&
+		delivery.DeliveredAt != nil && delivery.DeliveredAt.Before(deliveredBefore)
+}
+
+// copyWebhook returns a copy of the webhook that doesn't share its events.
+func copyWebhook(webhook *model.Webhook) *model.Webhook {
+	webhookCopy := *webhook
+	webhookCopy.Events = slices.Clone(webhook.Events)
+	return &webhookCopy
+}
+
+// factoryWebhookInMemoryDAO build WebhookInMemoryDAO, the webhooks are persisted if opt.Connector names a File connector.
+// The File connector can't be shared with another DAO.
+func factoryWebhookInMemoryDAO(opt DAOFactoryOptions) (*WebhookInMemoryDAO, error) {
+	dao := &WebhookInMemoryDAO{
+		connectorName: opt.Connector,
+		webhookState: webhookState{
+			webhooks:   make(map[uuid.UUID]*model.Webhook),
+			deliveries: make(map[uuid.UUID]*model.WebhookDelivery),
+		},
+	}
+
+	if opt.Connector == "" {
+		return dao, nil
+	}
+
+	file, err := connectors.GetConnectorFile(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+	dao.file = file
+
+	if err := dao.load(); err != nil {
+		return nil, fmt.Errorf("fail to load webhooks: %w", err)
+	}
+
+	return dao, nil
+}
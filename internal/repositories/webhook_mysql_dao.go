@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeWebhookMySQLDAO is an identifier to build WebhookMySQLDAO.
+	TypeWebhookMySQLDAO = "WebhookMySQLDAO"
+)
+
+var _ IWebhookDAO = (*WebhookMySQLDAO)(nil)
+
+// WebhookMySQLDAO is a WebhookDAO storing the webhooks and their deliveries in the database of TaskMySQLDAO.
+type WebhookMySQLDAO struct {
+	webhookSQLDAO
+}
+
+// factoryWebhookMySQLDAO build WebhookMySQLDAO.
+func factoryWebhookMySQLDAO(opt DAOFactoryOptions) (*WebhookMySQLDAO, error) {
+	connector, err := connectors.GetConnectorMySQL(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &WebhookMySQLDAO{
+		webhookSQLDAO: webhookSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       mysqlDialect,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeWebhookPostgresDAO is an identifier to build WebhookPostgresDAO.
+	TypeWebhookPostgresDAO = "WebhookPostgresDAO"
+)
+
+var _ IWebhookDAO = (*WebhookPostgresDAO)(nil)
+
+// WebhookPostgresDAO is a WebhookDAO storing the webhooks and their deliveries in the database of TaskPostgresDAO.
+type WebhookPostgresDAO struct {
+	webhookSQLDAO
+}
+
+// factoryWebhookPostgresDAO build WebhookPostgresDAO.
+func factoryWebhookPostgresDAO(opt DAOFactoryOptions) (*WebhookPostgresDAO, error) {
+	connector, err := connectors.GetConnectorPostgres(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &WebhookPostgresDAO{
+		webhookSQLDAO: webhookSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       postgresDialect,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,377 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// webhookColumns are the columns read by scanWebhook, in order.
+	webhookColumns = "webhook_uuid, url, secret, events, active, owner_id, created_at, last_updated"
+	// webhookDeliveryColumns are the columns read by scanWebhookDelivery, in order.
+	webhookDeliveryColumns = "delivery_uuid, webhook_uuid, event_type, payload, status, attempts, next_attempt_at, " +
+		"last_attempt_at, last_status_code, last_error, created_at, delivered_at"
+)
+
+// webhookSQLDAO implements IWebhookDAO with portable SQL, the specificities of each database are in its dialect.
+type webhookSQLDAO struct {
+	sqlDAO
+}
+
+func (dao *webhookSQLDAO) Create(ctx context.Context, webhookToCreate *model.WebhookCreateDTO) (*model.Webhook, error) {
+	webhook := webhookToCreate.ReverseCreateDTO()
+	webhook.OwnerID = model.OwnerFromContext(ctx)
+	webhook.CreatedAt = sqlNow()
+	webhook.LastUpdated = webhook.CreatedAt
+
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("INSERT INTO webhooks (%s) VALUES (%s, %s, %s, %s, %s, %s, %s, %s);", webhookColumns,
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5), dao.bind(6), dao.bind(7), dao.bind(8))
+		return dao.execOne(tx, query, webhook.UUID, webhook.URL, webhook.Secret, joinWebhookEvents(webhook.Events),
+			webhook.Active, webhook.OwnerID, webhook.CreatedAt, webhook.LastUpdated)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't insert the webhook : %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (dao *webhookSQLDAO) ReadByUUID(ctx context.Context, webhookUUID uuid.UUID) (*model.Webhook, error) {
+	ownerCondition, params := dao.ownerCondition(ctx, []any{webhookUUID})
+	query := fmt.Sprintf("SELECT %s FROM webhooks WHERE webhook_uuid = %s%s;", webhookColumns, dao.bind(1), ownerCondition)
+	webhook, err := scanWebhook(dao.connector.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no webhook with this UUID (%s) exist : %w", webhookUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+	return webhook, nil
+}
+
+func (dao *webhookSQLDAO) ReadAll(ctx context.Context) ([]*model.Webhook, error) {
+	query := fmt.Sprintf("SELECT %s FROM webhooks", webhookColumns)
+	var params []any
+	if owner, scoped := model.OwnerScopeFromContext(ctx); scoped {
+		params = append(params, owner)
+		query += " WHERE owner_id = " + dao.bind(1)
+	}
+	query += " ORDER BY created_at, webhook_uuid;"
+
+	rows, err := dao.connector.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query all webhooks : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	webhooks := make([]*model.Webhook, 0)
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	return webhooks, nil
+}
+
+func (dao *webhookSQLDAO) Update(ctx context.Context, webhookUUID uuid.UUID, patch *model.WebhookPatch) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		webhook, err := dao.lockWebhook(ctx, tx, webhookUUID)
+		if err != nil {
+			return err
+		}
+
+		if patch.URL != nil {
+			webhook.URL = *patch.URL
+		}
+		if patch.Secret != nil {
+			webhook.Secret = *patch.Secret
+		}
+		if patch.Events != nil {
+			webhook.Events = patch.Events
+		}
+		if patch.Active != nil {
+			webhook.Active = *patch.Active
+		}
+		webhook.LastUpdated = sqlNow()
+
+		query := fmt.Sprintf("UPDATE webhooks SET url = %s, secret = %s, events = %s, active = %s, last_updated = %s WHERE webhook_uuid = %s;",
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5), dao.bind(6))
+		return dao.execOne(tx, query, webhook.URL, webhook.Secret, joinWebhookEvents(webhook.Events), webhook.Active, webhook.LastUpdated, webhook.UUID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't update the webhook : %w", err)
+	}
+	return nil
+}
+
+func (dao *webhookSQLDAO) Delete(ctx context.Context, webhookUUID uuid.UUID) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		if _, err := dao.lockWebhook(ctx, tx, webhookUUID); err != nil {
+			return err
+		}
+
+		// SQLite only enforces the foreign key of the deliveries when the connection enables it, they are removed by the DAO.
+		query := fmt.Sprintf("DELETE FROM webhook_deliveries WHERE webhook_uuid = %s;", dao.bind(1))
+		if _, err := dao.connector.Exec(tx, query, webhookUUID); err != nil {
+			return dao.dialect.wrapError(err)
+		}
+
+		query = fmt.Sprintf("DELETE FROM webhooks WHERE webhook_uuid = %s;", dao.bind(1))
+		return dao.execOne(tx, query, webhookUUID)
+	})
+	if err != nil {
+		return fmt.Errorf("can't delete the webhook : %w", err)
+	}
+	return nil
+}
+
+func (dao *webhookSQLDAO) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		placeholders := make([]string, 12)
+		for i := range placeholders {
+			placeholders[i] = dao.bind(i + 1)
+		}
+		query := fmt.Sprintf("INSERT INTO webhook_deliveries (%s) VALUES (%s);", webhookDeliveryColumns, strings.Join(placeholders, ", "))
+
+		for _, delivery := range deliveries {
+			if err := dao.execOne(tx, query, deliveryParams(delivery)...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("can't insert the deliveries : %w", err)
+	}
+	return nil
+}
+
+func (dao *webhookSQLDAO) ReadDelivery(ctx context.Context, webhookUUID, deliveryUUID uuid.UUID) (*model.WebhookDelivery, error) {
+	if _, err := dao.ReadByUUID(ctx, webhookUUID); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM webhook_deliveries WHERE delivery_uuid = %s AND webhook_uuid = %s;",
+		webhookDeliveryColumns, dao.bind(1), dao.bind(2))
+	delivery, err := scanWebhookDelivery(dao.connector.QueryRowContext(ctx, query, deliveryUUID, webhookUUID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no delivery with this UUID (%s) exist : %w", deliveryUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+	return delivery, nil
+}
+
+func (dao *webhookSQLDAO) ReadDeliveries(ctx context.Context, webhookUUID uuid.UUID, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	if _, err := dao.ReadByUUID(ctx, webhookUUID); err != nil {
+		return nil, err
+	}
+
+	params := []any{webhookUUID}
+	query := fmt.Sprintf("SELECT %s FROM webhook_deliveries WHERE webhook_uuid = %s", webhookDeliveryColumns, dao.bind(1))
+	if status != "" {
+		params = append(params, status)
+		query += " AND status = " + dao.bind(len(params))
+	}
+	params = append(params, limit)
+	query += " ORDER BY created_at DESC, delivery_uuid DESC LIMIT " + dao.bind(len(params)) + ";"
+
+	return dao.queryDeliveries(ctx, dao.connector, query, params...)
+}
+
+func (dao *webhookSQLDAO) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("SELECT %s FROM webhook_deliveries WHERE status = %s AND next_attempt_at <= %s ORDER BY next_attempt_at, delivery_uuid LIMIT %s%s;",
+			webhookDeliveryColumns, dao.bind(1), dao.bind(2), dao.bind(3), dao.dialect.lockClause)
+		var err error
+		deliveries, err = dao.queryDeliveries(ctx, tx, query, model.WebhookDeliveryPending, sqlTime(&now), limit)
+		if err != nil {
+			return err
+		}
+
+		query = fmt.Sprintf("UPDATE webhook_deliveries SET next_attempt_at = %s WHERE delivery_uuid = %s;", dao.bind(1), dao.bind(2))
+		for _, delivery := range deliveries {
+			if err := dao.execOne(tx, query, sqlTime(&leaseUntil), delivery.UUID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't claim the deliveries : %w", err)
+	}
+	return deliveries, nil
+}
+
+func (dao *webhookSQLDAO) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("UPDATE webhook_deliveries SET status = %s, attempts = %s, next_attempt_at = %s, last_attempt_at = %s, "+
+			"last_status_code = %s, last_error = %s, delivered_at = %s WHERE delivery_uuid = %s;",
+			dao.bind(1), dao.bind(2), dao.bind(3), dao.bind(4), dao.bind(5), dao.bind(6), dao.bind(7), dao.bind(8))
+		err := dao.execOne(tx, query, delivery.Status, delivery.Attempts, sqlTime(&delivery.NextAttemptAt), sqlTime(delivery.LastAttemptAt),
+			delivery.LastStatusCode, delivery.LastError, sqlTime(delivery.DeliveredAt), delivery.UUID)
+		if errors.Is(err, ErrNoRowAffected) {
+			return fmt.Errorf("no delivery with this UUID (%s) exist : %w", delivery.UUID.String(), &NoDataFoundError{})
+		}
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("can't update the delivery : %w", err)
+	}
+	return nil
+}
+
+func (dao *webhookSQLDAO) PurgeDeliveries(ctx context.Context, deliveredBefore time.Time) (int64, error) {
+	var count int64
+	err := dao.withTx(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf("DELETE FROM webhook_deliveries WHERE status = %s AND delivered_at < %s;", dao.bind(1), dao.bind(2))
+		result, err := dao.connector.Exec(tx, query, model.WebhookDeliveryDelivered, sqlTime(&deliveredBefore))
+		if err != nil {
+			return dao.dialect.wrapError(err)
+		}
+
+		count, err = result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("can't get the number of affected rows : %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("can't purge the deliveries : %w", err)
+	}
+	return count, nil
+}
+
+// lockWebhook reads the webhook in the transaction and locks its row until the end of the transaction.
+func (dao *webhookSQLDAO) lockWebhook(ctx context.Context, tx *sql.Tx, webhookUUID uuid.UUID) (*model.Webhook, error) {
+	ownerCondition, params := dao.ownerCondition(ctx, []any{webhookUUID})
+	query := fmt.Sprintf("SELECT %s FROM webhooks WHERE webhook_uuid = %s%s%s;", webhookColumns, dao.bind(1), ownerCondition, dao.dialect.lockClause)
+	webhook, err := scanWebhook(tx.QueryRowContext(ctx, query, params...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("no webhook with this UUID (%s) exist : %w", webhookUUID.String(), &NoDataFoundError{})
+		}
+		return nil, fmt.Errorf("can't query by UUID : %w", dao.dialect.wrapError(err))
+	}
+	return webhook, nil
+}
+
+// queryDeliveries returns the deliveries selected by the query.
+func (dao *webhookSQLDAO) queryDeliveries(ctx context.Context, db querier, query string, params ...any) ([]*model.WebhookDelivery, error) {
+	rows, err := db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("can't query the deliveries : %w", dao.dialect.wrapError(err))
+	}
+	defer rows.Close()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan row : %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't iterate over rows : %w", dao.dialect.wrapError(err))
+	}
+
+	return deliveries, nil
+}
+
+// deliveryParams returns the values of the webhookDeliveryColumns of the delivery, in order.
+func deliveryParams(delivery *model.WebhookDelivery) []any {
+	return []any{
+		delivery.UUID,
+		delivery.WebhookUUID,
+		delivery.EventType,
+		string(delivery.Payload),
+		delivery.Status,
+		delivery.Attempts,
+		sqlTime(&delivery.NextAttemptAt),
+		sqlTime(delivery.LastAttemptAt),
+		delivery.LastStatusCode,
+		delivery.LastError,
+		sqlTime(&delivery.CreatedAt),
+		sqlTime(delivery.DeliveredAt),
+	}
+}
+
+// joinWebhookEvents returns the events stored in the events column, separated by commas.
+func joinWebhookEvents(events []model.TaskEventType) string {
+	names := make([]string, 0, len(events))
+	for _, event := range events {
+		names = append(names, string(event))
+	}
+	return strings.Join(names, ",")
+}
+
+// scanWebhook scans the webhookColumns of a row.
+func scanWebhook(row rowScanner) (*model.Webhook, error) {
+	var events string
+	webhook := new(model.Webhook)
+	if err := row.Scan(
+		&webhook.UUID,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Active,
+		&webhook.OwnerID,
+		&webhook.CreatedAt,
+		&webhook.LastUpdated,
+	); err != nil {
+		return nil, err
+	}
+
+	if events != "" {
+		for _, event := range strings.Split(events, ",") {
+			webhook.Events = append(webhook.Events, model.TaskEventType(event))
+		}
+	}
+	return webhook, nil
+}
+
+// scanWebhookDelivery scans the webhookDeliveryColumns of a row.
+func scanWebhookDelivery(row rowScanner) (*model.WebhookDelivery, error) {
+	var payload []byte
+	delivery := new(model.WebhookDelivery)
+	if err := row.Scan(
+		&delivery.UUID,
+		&delivery.WebhookUUID,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	); err != nil {
+		return nil, err
+	}
+
+	delivery.Payload = payload
+	return delivery, nil
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/CamilleLange/todolist/internal/connectors"
+)
+
+const (
+	// TypeWebhookSQLiteDAO is an identifier to build WebhookSQLiteDAO.
+	TypeWebhookSQLiteDAO = "WebhookSQLiteDAO"
+)
+
+var _ IWebhookDAO = (*WebhookSQLiteDAO)(nil)
+
+// WebhookSQLiteDAO is a WebhookDAO storing the webhooks and their deliveries in the database of TaskSQLiteDAO.
+type WebhookSQLiteDAO struct {
+	webhookSQLDAO
+}
+
+// factoryWebhookSQLiteDAO build WebhookSQLiteDAO.
+func factoryWebhookSQLiteDAO(opt DAOFactoryOptions) (*WebhookSQLiteDAO, error) {
+	connector, err := connectors.GetConnectorSQLite(opt.Connector)
+	if err != nil {
+		return nil, fmt.Errorf("fail to get connector: %w", err)
+	}
+
+	return &WebhookSQLiteDAO{
+		webhookSQLDAO: webhookSQLDAO{
+			sqlDAO: sqlDAO{
+				connector:     connector,
+				connectorName: opt.Connector,
+				dialect:       sqliteDialect,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	model "github.com/CamilleLange/todolist/pkg/structs"
+	"github.com/google/uuid"
+)
+
+const (
+	// TypeWebhookVoidDAO is an identifier to build WebhookVoidDAO.
+	TypeWebhookVoidDAO = "WebhookVoidDAO"
+)
+
+var _ IWebhookDAO = (*WebhookVoidDAO)(nil)
+
+// WebhookVoidDAO is a WebhookDAO with not implemented features.
+type WebhookVoidDAO struct {
+	connectorName string
+}
+
+func (dao *WebhookVoidDAO) Create(ctx context.Context, webhookToCreate *model.WebhookCreateDTO) (*model.Webhook, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) ReadByUUID(ctx context.Context, webhookUUID uuid.UUID) (*model.Webhook, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) ReadAll(ctx context.Context) ([]*model.Webhook, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) Update(ctx context.Context, webhookUUID uuid.UUID, patch *model.WebhookPatch) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) Delete(ctx context.Context, webhookUUID uuid.UUID) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) ReadDelivery(ctx context.Context, webhookUUID, deliveryUUID uuid.UUID) (*model.WebhookDelivery, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) ReadDeliveries(ctx context.Context, webhookUUID uuid.UUID, status model.WebhookDeliveryStatus, limit int) ([]*model.WebhookDelivery, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) ClaimDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	return nil, ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return ErrFeatureNotImplemented
+}
+
+func (dao *WebhookVoidDAO) PurgeDeliveries(ctx context.Context, deliveredBefore time.Time) (int64, error) {
+	return 0, ErrFeatureNotImplemented
+}
+
+// factoryWebhookVoidDAO build WebhookVoidDAO.
+func factoryWebhookVoidDAO(opt DAOFactoryOptions) (*WebhookVoidDAO, error) {
+	return &WebhookVoidDAO{
+		connectorName: opt.Connector,
+	}, nil
+}
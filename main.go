@@ -16,6 +16,7 @@ import (
 	"github.com/Aloe-Corporation/logs"
 	"github.com/CamilleLange/todolist/internal/configuration"
 	"github.com/CamilleLange/todolist/internal/connectors"
+	"github.com/CamilleLange/todolist/internal/controllers"
 	"github.com/CamilleLange/todolist/internal/ginrouters"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -30,6 +31,13 @@ const (
 var log = logs.Get()
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == CMD_MIGRATE {
+		if err := RunMigrate(os.Args[2:]); err != nil {
+			panic(fmt.Errorf("fail to run migrations: %w", err))
+		}
+		return
+	}
+
 	go func() {
 		log.Error("pprof", zap.Error(http.ListenAndServe("0.0.0.0:6060", nil)))
 	}()
@@ -100,6 +108,8 @@ func WaitSignalShutdown(srv *http.Server) {
 	<-quit
 	log.Info("Shutdown Server ...")
 
+	controllers.Close()
+
 	if err := connectors.Close(); err != nil {
 		log.Error("error during repositories.Close()", zap.Error(err))
 	}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/CamilleLange/todolist/internal/configuration"
+	"github.com/CamilleLange/todolist/internal/connectors"
+	"go.uber.org/zap"
+)
+
+const (
+	CMD_MIGRATE = "migrate"
+
+	MIGRATE_UP      = "up"
+	MIGRATE_DOWN    = "down"
+	MIGRATE_VERSION = "version"
+)
+
+// RunMigrate executes the migrate subcommand: migrate up | down [steps] | version.
+func RunMigrate(args []string) error {
+	action := MIGRATE_UP
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	pathFileConfig, present := os.LookupEnv(ENV_CONFIG)
+	if !present {
+		pathFileConfig = DEFAULT_PATH_CONFIG
+	}
+
+	if err := configuration.LoadConf(pathFileConfig, PREFIX_ENV); err != nil {
+		return fmt.Errorf("fail to load config: %w", err)
+	}
+
+	if err := configuration.InitAllModules(); err != nil {
+		return fmt.Errorf("fail to init modules: %w", err)
+	}
+
+	// The subcommand decides which migrations run, not the configuration.
+	connectors.Config.AutoMigrate = false
+	if err := connectors.Init(); err != nil {
+		return fmt.Errorf("fail to init connectors package: %w", err)
+	}
+	defer func() {
+		if err := connectors.Close(); err != nil {
+			log.Error("error during connectors.Close()", zap.Error(err))
+		}
+	}()
+
+	switch action {
+	case MIGRATE_UP:
+		return connectors.MigrateUp()
+
+	case MIGRATE_DOWN:
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		return connectors.MigrateDown(steps)
+
+	case MIGRATE_VERSION:
+		versions, err := connectors.MigrationVersions()
+		if err != nil {
+			return err
+		}
+		for connector, version := range versions {
+			fmt.Printf("%s\t%d\n", connector, version)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate action %q, expected %s, %s or %s", action, MIGRATE_UP, MIGRATE_DOWN, MIGRATE_VERSION)
+	}
+}
@@ -0,0 +1,75 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// List is a project grouping tasks, like a sprint backlog or an ops checklist.
+type List struct {
+	UUID        uuid.UUID
+	Name        string
+	Description string
+	// OwnerID is the id of the principal who created the list, empty if it was created without principal.
+	OwnerID     string
+	CreatedAt   time.Time
+	LastUpdated time.Time
+}
+
+type ListPublicDTO struct {
+	UUID        uuid.UUID `json:"list_uuid"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	OwnerID     string    `json:"owner_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+func FactoryListPublicDTO(list *List) *ListPublicDTO {
+	return &ListPublicDTO{
+		UUID:        list.UUID,
+		Name:        list.Name,
+		Description: list.Description,
+		OwnerID:     list.OwnerID,
+		CreatedAt:   list.CreatedAt,
+		LastUpdated: list.LastUpdated,
+	}
+}
+
+// ListsPublicDTO is the collection of the lists, oldest first.
+type ListsPublicDTO struct {
+	Lists []ListPublicDTO `json:"lists"`
+}
+
+type ListCreateDTO struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description" binding:"max=1024"`
+}
+
+func (dto *ListCreateDTO) ReverseCreateDTO() *List {
+	return &List{
+		UUID:        uuid.New(),
+		Name:        dto.Name,
+		Description: dto.Description,
+	}
+}
+
+// ListUpdateDTO is the body of a list update, the fields left out are unchanged.
+type ListUpdateDTO struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Description *string `json:"description" binding:"omitempty,max=1024"`
+}
+
+func (dto *ListUpdateDTO) ReversePatch() *ListPatch {
+	return &ListPatch{
+		Name:        dto.Name,
+		Description: dto.Description,
+	}
+}
+
+// ListPatch is a partial update of a list, nil fields are left unchanged.
+type ListPatch struct {
+	Name        *string
+	Description *string
+}
@@ -0,0 +1,59 @@
+package structs
+
+import "context"
+
+const (
+	// AnonymousActor is the actor recorded for the requests without principal.
+	AnonymousActor = "anonymous"
+	// SystemActor is the actor recorded for the changes made by the background jobs.
+	SystemActor = "system"
+)
+
+// principalKey is the context key of the Principal.
+type principalKey struct{}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	ID    string
+	Roles []string
+	// Admin sees and changes the tasks of every owner.
+	Admin bool
+}
+
+// ContextWithPrincipal returns a copy of ctx carrying the principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal carried by ctx, or nil.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// ActorFromContext returns the id of the principal carried by ctx, or AnonymousActor.
+func ActorFromContext(ctx context.Context) string {
+	if principal := PrincipalFromContext(ctx); principal != nil && principal.ID != "" {
+		return principal.ID
+	}
+	return AnonymousActor
+}
+
+// OwnerFromContext returns the id of the principal carried by ctx, it owns the tasks it creates.
+// It is empty without principal.
+func OwnerFromContext(ctx context.Context) string {
+	if principal := PrincipalFromContext(ctx); principal != nil {
+		return principal.ID
+	}
+	return ""
+}
+
+// OwnerScopeFromContext returns the owner the tasks visible by the principal of ctx are restricted to.
+// scoped is false when every task is visible: without principal, like the background jobs, or for an admin.
+func OwnerScopeFromContext(ctx context.Context) (owner string, scoped bool) {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil || principal.Admin {
+		return "", false
+	}
+	return principal.ID, true
+}
@@ -0,0 +1,44 @@
+package structs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPrincipalFromContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *Principal
+		actor     string
+		owner     string
+		scoped    bool
+	}{
+		{name: "no principal", actor: AnonymousActor},
+		{name: "principal", principal: &Principal{ID: "alice"}, actor: "alice", owner: "alice", scoped: true},
+		{name: "admin", principal: &Principal{ID: "root", Roles: []string{"admin"}, Admin: true}, actor: "root", owner: "root"},
+		{name: "empty id", principal: &Principal{}, actor: AnonymousActor, scoped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = ContextWithPrincipal(ctx, tt.principal)
+			}
+
+			if got := PrincipalFromContext(ctx); got != tt.principal {
+				t.Errorf("PrincipalFromContext() = %+v, want %+v", got, tt.principal)
+			}
+			if got := ActorFromContext(ctx); got != tt.actor {
+				t.Errorf("ActorFromContext() = %q, want %q", got, tt.actor)
+			}
+			if got := OwnerFromContext(ctx); got != tt.owner {
+				t.Errorf("OwnerFromContext() = %q, want %q", got, tt.owner)
+			}
+			// The admins and the background jobs see every task, the others only their own.
+			if owner, scoped := OwnerScopeFromContext(ctx); scoped != tt.scoped || (scoped && owner != tt.owner) {
+				t.Errorf("OwnerScopeFromContext() = %q, %v, want %q, %v", owner, scoped, tt.owner, tt.scoped)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TagMatchAny keeps the tasks with at least one of the requested tags.
+	TagMatchAny = "any"
+	// TagMatchAll keeps the tasks with all the requested tags.
+	TagMatchAll = "all"
+)
+
+// Tag is a label categorizing tasks, a task has many tags and a tag is put on many tasks.
+// The names of the tags of an owner are unique.
+type Tag struct {
+	UUID uuid.UUID
+	Name string
+	// OwnerID is the id of the principal who created the tag, empty if it was created without principal.
+	OwnerID     string
+	CreatedAt   time.Time
+	LastUpdated time.Time
+}
+
+type TagPublicDTO struct {
+	UUID        uuid.UUID `json:"tag_uuid"`
+	Name        string    `json:"name"`
+	OwnerID     string    `json:"owner_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+func FactoryTagPublicDTO(tag *Tag) *TagPublicDTO {
+	return &TagPublicDTO{
+		UUID:        tag.UUID,
+		Name:        tag.Name,
+		OwnerID:     tag.OwnerID,
+		CreatedAt:   tag.CreatedAt,
+		LastUpdated: tag.LastUpdated,
+	}
+}
+
+// TagsPublicDTO is the collection of the tags, ordered by name.
+type TagsPublicDTO struct {
+	Tags []TagPublicDTO `json:"tags"`
+}
+
+// TagCreateDTO is the body of a tag creation, the blanks around the name are trimmed.
+type TagCreateDTO struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
+
+func (dto *TagCreateDTO) ReverseCreateDTO() *Tag {
+	return &Tag{
+		UUID: uuid.New(),
+		Name: dto.Name,
+	}
+}
+
+// TagUpdateDTO is the body of a tag renaming.
+type TagUpdateDTO struct {
+	Name string `json:"name" binding:"required,max=64"`
+}
@@ -0,0 +1,80 @@
+package structs
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// TaskBatchOp is the kind of an operation of a batch.
+type TaskBatchOp string
+
+const (
+	TaskBatchCreate TaskBatchOp = "create"
+	TaskBatchUpdate TaskBatchOp = "update"
+	TaskBatchDelete TaskBatchOp = "delete"
+)
+
+// TaskBatchMode tells what happens to a batch when one of its operations fails.
+type TaskBatchMode string
+
+const (
+	// TaskBatchAllOrNothing runs the operations in a single transaction, rolled back if one of them fails.
+	TaskBatchAllOrNothing TaskBatchMode = "all_or_nothing"
+	// TaskBatchBestEffort runs every operation and reports the result of each of them.
+	TaskBatchBestEffort TaskBatchMode = "best_effort"
+)
+
+// TaskBatchOperation is an operation of a batch, run in the order of the batch.
+type TaskBatchOperation struct {
+	Op TaskBatchOp
+	// TaskUUID is the updated or deleted task.
+	TaskUUID uuid.UUID
+	// Version is the expected version of the updated or deleted task, 0 skips the check.
+	Version int64
+	// Create is the task created by a create.
+	Create *TaskCreateDTO
+	// Patch is the change of an update.
+	Patch *TaskPatch
+	// Cascade tells what happens to the subtasks of the task removed by a delete.
+	Cascade TaskDeleteCascade
+}
+
+// TaskBatchResult is the outcome of an operation of a batch, Task is the created or updated task.
+type TaskBatchResult struct {
+	Task *Task
+	Err  error
+}
+
+// TaskBatchDTO is the body of a batch of up to 500 operations, the mode defaults to TaskBatchAllOrNothing.
+type TaskBatchDTO struct {
+	Mode       TaskBatchMode           `json:"mode" binding:"omitempty,oneof=all_or_nothing best_effort"`
+	Operations []TaskBatchOperationDTO `json:"operations" binding:"required,min=1,max=500,dive"`
+}
+
+// TaskBatchOperationDTO is an operation of a batch. Task is the body of a create like POST /task,
+// or the fields of an update like PUT /task/{task_uuid}, version stands for the If-Match header.
+type TaskBatchOperationDTO struct {
+	Op       TaskBatchOp       `json:"op" binding:"required,oneof=create update delete"`
+	TaskUUID *uuid.UUID        `json:"task_uuid" binding:"required_unless=Op create"`
+	Version  int64             `json:"version" binding:"min=0"`
+	Cascade  TaskDeleteCascade `json:"cascade" binding:"omitempty,oneof=restrict detach delete"`
+	Task     json.RawMessage   `json:"task"`
+}
+
+// TaskBatchPublicDTO holds the result of each operation of a batch, in the order of the operations.
+type TaskBatchPublicDTO struct {
+	Mode    TaskBatchMode              `json:"mode"`
+	Results []TaskBatchResultPublicDTO `json:"results"`
+}
+
+// TaskBatchResultPublicDTO is the result of an operation, its status is the HTTP status of the same single request.
+// A failed operation has the type and the detail of the problem it would have answered.
+type TaskBatchResultPublicDTO struct {
+	Index  int            `json:"index"`
+	Op     TaskBatchOp    `json:"op"`
+	Status int            `json:"status"`
+	Task   *TaskPublicDTO `json:"task,omitempty"`
+	Type   string         `json:"type,omitempty"`
+	Detail string         `json:"detail,omitempty"`
+}
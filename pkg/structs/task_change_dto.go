@@ -0,0 +1,53 @@
+package structs
+
+import (
+	"time"
+)
+
+// TaskChangeReset is the type of the change telling a subscriber resuming the feed that some changes were missed,
+// it must read the tasks again.
+const TaskChangeReset TaskEventType = "reset"
+
+// TaskChange is a change of a task published on the change feed of the tasks.
+type TaskChange struct {
+	// ID grows with each change published by the process, a subscriber resumes the feed after it.
+	ID   uint64
+	Type TaskEventType
+	// Task is the task after the change, or as it was before its deletion. A reset has no task.
+	Task *Task
+	// PreviousStatus is the status of the task before an update changing it.
+	PreviousStatus TaskStatus
+	Actor          string
+	OccurredAt     time.Time
+}
+
+// TaskFeedDTO holds the query parameters of a subscription to the change feed.
+// Status keeps the changes of the tasks with one of the statuses, before or after the change.
+// LastEventID resumes the feed after the change with this id, like the Last-Event-ID header.
+type TaskFeedDTO struct {
+	Status      []TaskStatus `form:"status"`
+	LastEventID *uint64      `form:"last_event_id"`
+}
+
+type TaskChangePublicDTO struct {
+	ID             uint64         `json:"id"`
+	Type           TaskEventType  `json:"type"`
+	Task           *TaskPublicDTO `json:"task,omitempty"`
+	PreviousStatus TaskStatus     `json:"previous_status,omitempty"`
+	Actor          string         `json:"actor,omitempty"`
+	OccurredAt     time.Time      `json:"occurred_at"`
+}
+
+func FactoryTaskChangePublicDTO(change *TaskChange) *TaskChangePublicDTO {
+	dto := &TaskChangePublicDTO{
+		ID:             change.ID,
+		Type:           change.Type,
+		PreviousStatus: change.PreviousStatus,
+		Actor:          change.Actor,
+		OccurredAt:     change.OccurredAt,
+	}
+	if change.Task != nil {
+		dto.Task = FactoryTaskPublicDTO(change.Task)
+	}
+	return dto
+}
@@ -0,0 +1,39 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskDependency tells that a task is blocked by another task, the task can't be closed while its blocker is open.
+type TaskDependency struct {
+	TaskUUID    uuid.UUID
+	BlockerUUID uuid.UUID
+	CreatedBy   string
+	CreatedAt   time.Time
+}
+
+type TaskDependencyPublicDTO struct {
+	TaskUUID    uuid.UUID `json:"task_uuid"`
+	BlockerUUID uuid.UUID `json:"blocker_uuid"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func FactoryTaskDependencyPublicDTO(dependency *TaskDependency) *TaskDependencyPublicDTO {
+	return &TaskDependencyPublicDTO{
+		TaskUUID:    dependency.TaskUUID,
+		BlockerUUID: dependency.BlockerUUID,
+		CreatedBy:   dependency.CreatedBy,
+		CreatedAt:   dependency.CreatedAt,
+	}
+}
+
+// TaskDependencyGraphPublicDTO is the graph of the transitive blockers of a task.
+// The tasks are ordered so that each task comes after its blockers, the tasks not visible
+// by the caller are only referenced by the edges.
+type TaskDependencyGraphPublicDTO struct {
+	Tasks []TaskPublicDTO           `json:"tasks"`
+	Edges []TaskDependencyPublicDTO `json:"edges"`
+}
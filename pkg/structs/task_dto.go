@@ -1,25 +1,69 @@
 package structs
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// TaskStatus is the state of a task in the status workflow.
+type TaskStatus string
+
+// NormalizeTaskStatus lowers the status and replaces blanks and hyphens by underscores, so "In progress " is "in_progress".
+func NormalizeTaskStatus(status string) TaskStatus {
+	fields := strings.FieldsFunc(strings.ToLower(status), func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '-' || r == '_'
+	})
+	return TaskStatus(strings.Join(fields, "_"))
+}
+
 type Task struct {
 	UUID        uuid.UUID
 	WhatToDo    string
-	Status      string
+	Status      TaskStatus
 	CreatedAt   time.Time
 	LastUpdated time.Time
+	// Version starts at 1 and is incremented by each update, it is the ETag of the task.
+	Version int64
+	// DeletedAt is set while the task is in the trash.
+	DeletedAt *time.Time
+	// OwnerID is the id of the principal who created the task, empty if it was created without principal.
+	OwnerID string
+	// ListUUID is the list of the task, nil if it isn't in a list.
+	ListUUID *uuid.UUID
+	// DueAt is the deadline of the task, a task past it and not closed is overdue.
+	DueAt *time.Time
+	// RemindAt is when the reminder of the task fires, RemindedAt is set once it fired.
+	RemindAt   *time.Time
+	RemindedAt *time.Time
+	// RRule is the RFC 5545 recurrence rule of the task, empty if it doesn't repeat.
+	RRule string
+	// SeriesUUID groups the occurrences of a recurring task, it is the UUID of the first occurrence.
+	SeriesUUID *uuid.UUID
+	// ParentUUID is the task this task is a subtask of, nil for a top-level task.
+	ParentUUID *uuid.UUID
+	// Tags are the names of the tags of the task, sorted. They are filled by the reads of the TaskDAO, not stored with the task.
+	Tags []string
 }
 
 type TaskPublicDTO struct {
-	UUID        uuid.UUID `json:"task_uuid" mapstructure:"task_uuid" binding:"required"`
-	WhatToDo    string    `json:"description" mapstructure:"description" binding:"required"`
-	Status      string    `json:"status" mapstructure:"status" binding:"required"`
-	CreatedAt   time.Time `json:"created_at" mapstructure:"created_at" binding:"required"`
-	LastUpdated time.Time `json:"last_updated" mapstructure:"last_updated" binding:"required"`
+	UUID        uuid.UUID  `json:"task_uuid" mapstructure:"task_uuid" binding:"required"`
+	WhatToDo    string     `json:"description" mapstructure:"description" binding:"required"`
+	Status      TaskStatus `json:"status" mapstructure:"status" binding:"required"`
+	CreatedAt   time.Time  `json:"created_at" mapstructure:"created_at" binding:"required"`
+	LastUpdated time.Time  `json:"last_updated" mapstructure:"last_updated" binding:"required"`
+	Version     int64      `json:"version" mapstructure:"version" binding:"required"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" mapstructure:"deleted_at"`
+	OwnerID     string     `json:"owner_id,omitempty" mapstructure:"owner_id"`
+	ListUUID    *uuid.UUID `json:"list_uuid,omitempty" mapstructure:"list_uuid"`
+	DueAt       *time.Time `json:"due_at,omitempty" mapstructure:"due_at"`
+	RemindAt    *time.Time `json:"remind_at,omitempty" mapstructure:"remind_at"`
+	RemindedAt  *time.Time `json:"reminded_at,omitempty" mapstructure:"reminded_at"`
+	RRule       string     `json:"rrule,omitempty" mapstructure:"rrule"`
+	SeriesUUID  *uuid.UUID `json:"series_uuid,omitempty" mapstructure:"series_uuid"`
+	ParentUUID  *uuid.UUID `json:"parent_uuid,omitempty" mapstructure:"parent_uuid"`
+	Tags        []string   `json:"tags,omitempty" mapstructure:"tags"`
 }
 
 func (dto *TaskPublicDTO) ReversePublicDTO() *Task {
@@ -29,6 +73,17 @@ func (dto *TaskPublicDTO) ReversePublicDTO() *Task {
 		Status:      dto.Status,
 		CreatedAt:   dto.CreatedAt,
 		LastUpdated: dto.LastUpdated,
+		Version:     dto.Version,
+		DeletedAt:   dto.DeletedAt,
+		OwnerID:     dto.OwnerID,
+		ListUUID:    dto.ListUUID,
+		DueAt:       dto.DueAt,
+		RemindAt:    dto.RemindAt,
+		RemindedAt:  dto.RemindedAt,
+		RRule:       dto.RRule,
+		SeriesUUID:  dto.SeriesUUID,
+		ParentUUID:  dto.ParentUUID,
+		Tags:        dto.Tags,
 	}
 }
 
@@ -39,32 +94,78 @@ func FactoryTaskPublicDTO(task *Task) *TaskPublicDTO {
 		Status:      task.Status,
 		CreatedAt:   task.CreatedAt,
 		LastUpdated: task.LastUpdated,
+		Version:     task.Version,
+		DeletedAt:   task.DeletedAt,
+		OwnerID:     task.OwnerID,
+		ListUUID:    task.ListUUID,
+		DueAt:       task.DueAt,
+		RemindAt:    task.RemindAt,
+		RemindedAt:  task.RemindedAt,
+		RRule:       task.RRule,
+		SeriesUUID:  task.SeriesUUID,
+		ParentUUID:  task.ParentUUID,
+		Tags:        task.Tags,
 	}
 }
 
+// TaskCreateDTO is the body of a task creation, the initial status of the workflow is used when Status is empty.
+// A task with a RRule starts a new series, unless SeriesUUID is set.
 type TaskCreateDTO struct {
-	WhatToDo string `json:"description" mapstructure:"description" binding:"required"`
-	Status   string `json:"status" mapstructure:"status" binding:"required"`
+	WhatToDo string     `json:"description" mapstructure:"description" binding:"required"`
+	Status   TaskStatus `json:"status" mapstructure:"status"`
+	ListUUID *uuid.UUID `json:"list_uuid" mapstructure:"list_uuid"`
+	DueAt    *time.Time `json:"due_at" mapstructure:"due_at"`
+	RemindAt *time.Time `json:"remind_at" mapstructure:"remind_at"`
+	RRule    string     `json:"rrule" mapstructure:"rrule" binding:"max=1024"`
+	// ParentUUID makes the task a subtask, the parent must be visible by the caller.
+	ParentUUID *uuid.UUID `json:"parent_uuid" mapstructure:"parent_uuid"`
+	// SeriesUUID and OwnerID are set by the controller for the next occurrence of a recurring task,
+	// the owner is the principal of the request when OwnerID is empty.
+	SeriesUUID *uuid.UUID `json:"-" mapstructure:"-"`
+	OwnerID    string     `json:"-" mapstructure:"-"`
 }
 
 func (dto *TaskCreateDTO) ReverseCreateDTO() *Task {
 	return &Task{
-		UUID:     uuid.New(),
-		WhatToDo: dto.WhatToDo,
-		Status:   dto.Status,
+		UUID:       uuid.New(),
+		WhatToDo:   dto.WhatToDo,
+		Status:     dto.Status,
+		ListUUID:   dto.ListUUID,
+		DueAt:      dto.DueAt,
+		RemindAt:   dto.RemindAt,
+		RRule:      dto.RRule,
+		SeriesUUID: dto.SeriesUUID,
+		OwnerID:    dto.OwnerID,
+		ParentUUID: dto.ParentUUID,
 	}
 }
 
 func FactoryTaskCreateDTO(task *Task) *TaskCreateDTO {
 	return &TaskCreateDTO{
-		WhatToDo: task.WhatToDo,
-		Status:   task.Status,
+		WhatToDo:   task.WhatToDo,
+		Status:     task.Status,
+		ListUUID:   task.ListUUID,
+		DueAt:      task.DueAt,
+		RemindAt:   task.RemindAt,
+		RRule:      task.RRule,
+		SeriesUUID: task.SeriesUUID,
+		OwnerID:    task.OwnerID,
+		ParentUUID: task.ParentUUID,
 	}
 }
 
+// TaskUpdateDTO holds the patchable fields of a task, replaced by PUT and changed by PATCH.
+// A null list_uuid moves the task out of its list and a null due_at or remind_at removes the date.
+// A null rrule stops the recurrence and a null parent_uuid makes the task a top-level task.
 type TaskUpdateDTO struct {
-	WhatToDo string `json:"description" mapstructure:"description" binding:"required"`
-	Status   string `json:"status" mapstructure:"status" binding:"required"`
+	WhatToDo string     `json:"description" mapstructure:"description" binding:"required"`
+	Status   TaskStatus `json:"status" mapstructure:"status" binding:"required"`
+	ListUUID *uuid.UUID `json:"list_uuid" mapstructure:"list_uuid"`
+	DueAt    *time.Time `json:"due_at" mapstructure:"due_at"`
+	RemindAt *time.Time `json:"remind_at" mapstructure:"remind_at"`
+	RRule    *string    `json:"rrule" mapstructure:"rrule" binding:"omitempty,max=1024"`
+	// ParentUUID is checked by the controller, a task can't be moved under its own subtree.
+	ParentUUID *uuid.UUID `json:"parent_uuid" mapstructure:"parent_uuid"`
 }
 
 func (dto *TaskUpdateDTO) ReverseUpdateDTO() *Task {
@@ -80,3 +181,87 @@ func FactoryTaskUpdateDTO(task *Task) *TaskUpdateDTO {
 		Status:   task.Status,
 	}
 }
+
+// ReversePatch builds the TaskPatch of the fields present in the request body.
+func (dto *TaskUpdateDTO) ReversePatch(fieldsName []string) *TaskPatch {
+	patch := new(TaskPatch)
+	for _, fieldName := range fieldsName {
+		switch fieldName {
+		case "description":
+			patch.WhatToDo = &dto.WhatToDo
+		case "status":
+			patch.Status = &dto.Status
+		case "list_uuid":
+			patch.ListUUID = patchUUID(dto.ListUUID)
+		case "parent_uuid":
+			patch.ParentUUID = patchUUID(dto.ParentUUID)
+		case "due_at":
+			patch.DueAt = patchTime(dto.DueAt)
+		case "remind_at":
+			patch.RemindAt = patchTime(dto.RemindAt)
+		case "rrule":
+			rule := ""
+			if dto.RRule != nil {
+				rule = *dto.RRule
+			}
+			patch.RRule = &rule
+		}
+	}
+	return patch
+}
+
+// patchUUID returns the TaskPatch value of an optional UUID, uuid.Nil for nil.
+func patchUUID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return &uuid.Nil
+	}
+	return id
+}
+
+// patchTime returns the TaskPatch value of an optional date, the zero time for nil.
+func patchTime(t *time.Time) *time.Time {
+	if t == nil {
+		return new(time.Time)
+	}
+	return t
+}
+
+// TaskPatch is a partial update of a task, nil fields are left unchanged.
+type TaskPatch struct {
+	WhatToDo *string
+	Status   *TaskStatus
+	// ListUUID is uuid.Nil to move the task out of its list.
+	ListUUID *uuid.UUID
+	// DueAt and RemindAt are the zero time to remove the date, a RemindAt other than the current one rearms the reminder.
+	DueAt    *time.Time
+	RemindAt *time.Time
+	// RRule is empty to stop the recurrence, a task getting its first rule starts a new series.
+	RRule *string
+	// ParentUUID is uuid.Nil to make the task a top-level task.
+	ParentUUID *uuid.UUID
+}
+
+// TaskDeleteCascade tells what happens to the subtasks of a deleted task.
+type TaskDeleteCascade string
+
+const (
+	// TaskDeleteRestrict refuses to delete a task with live subtasks.
+	TaskDeleteRestrict TaskDeleteCascade = "restrict"
+	// TaskDeleteDetach makes the subtasks top-level tasks.
+	TaskDeleteDetach TaskDeleteCascade = "detach"
+	// TaskDeleteSubtree moves the whole subtree to the trash, the subtasks of other owners are detached.
+	TaskDeleteSubtree TaskDeleteCascade = "delete"
+)
+
+// TaskDeleteDTO holds the query parameters of a task deletion.
+type TaskDeleteDTO struct {
+	Cascade TaskDeleteCascade `form:"cascade" binding:"omitempty,oneof=restrict detach delete"`
+}
+
+// TaskNodePublicDTO is a task of a subtree with its subtasks.
+// Completion is the percentage of closed tasks among the leaves of the subtree, 0 or 100 for a leaf.
+type TaskNodePublicDTO struct {
+	TaskPublicDTO
+	Completion int                 `json:"completion"`
+	Children   []TaskNodePublicDTO `json:"children"`
+}
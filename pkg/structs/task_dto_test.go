@@ -0,0 +1,71 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTaskUpdateDTOReversePatch(t *testing.T) {
+	due := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	listUUID := uuid.New()
+	rule := "FREQ=WEEKLY"
+	dto := &TaskUpdateDTO{
+		WhatToDo: "write the report",
+		Status:   "done",
+		ListUUID: &listUUID,
+		DueAt:    &due,
+		RRule:    &rule,
+	}
+
+	// Only the fields of the body are in the patch.
+	patch := dto.ReversePatch([]string{"status"})
+	if patch.Status == nil || *patch.Status != "done" {
+		t.Errorf("ReversePatch().Status = %v, want done", patch.Status)
+	}
+	if patch.WhatToDo != nil || patch.ListUUID != nil || patch.DueAt != nil || patch.RemindAt != nil || patch.RRule != nil || patch.ParentUUID != nil {
+		t.Errorf("ReversePatch() = %+v, want only the status", patch)
+	}
+
+	patch = dto.ReversePatch([]string{"description", "list_uuid", "due_at", "rrule", "unknown"})
+	if patch.WhatToDo == nil || *patch.WhatToDo != dto.WhatToDo {
+		t.Errorf("ReversePatch().WhatToDo = %v, want %q", patch.WhatToDo, dto.WhatToDo)
+	}
+	if patch.ListUUID == nil || *patch.ListUUID != listUUID {
+		t.Errorf("ReversePatch().ListUUID = %v, want %v", patch.ListUUID, listUUID)
+	}
+	if patch.DueAt == nil || !patch.DueAt.Equal(due) {
+		t.Errorf("ReversePatch().DueAt = %v, want %v", patch.DueAt, due)
+	}
+	if patch.RRule == nil || *patch.RRule != rule {
+		t.Errorf("ReversePatch().RRule = %v, want %q", patch.RRule, rule)
+	}
+	if patch.Status != nil || patch.RemindAt != nil || patch.ParentUUID != nil {
+		t.Errorf("ReversePatch() = %+v, want no status, remind date or parent", patch)
+	}
+}
+
+func TestTaskUpdateDTOReversePatchNull(t *testing.T) {
+	// The null fields of a merge patch are decoded as nil, the patch removes them.
+	dto := &TaskUpdateDTO{}
+	patch := dto.ReversePatch([]string{"list_uuid", "due_at", "remind_at", "rrule", "parent_uuid"})
+	if patch.ListUUID == nil || *patch.ListUUID != uuid.Nil {
+		t.Errorf("ReversePatch().ListUUID = %v, want uuid.Nil moving the task out of its list", patch.ListUUID)
+	}
+	if patch.ParentUUID == nil || *patch.ParentUUID != uuid.Nil {
+		t.Errorf("ReversePatch().ParentUUID = %v, want uuid.Nil making the task a top-level task", patch.ParentUUID)
+	}
+	if patch.DueAt == nil || !patch.DueAt.IsZero() {
+		t.Errorf("ReversePatch().DueAt = %v, want the zero time removing the date", patch.DueAt)
+	}
+	if patch.RemindAt == nil || !patch.RemindAt.IsZero() {
+		t.Errorf("ReversePatch().RemindAt = %v, want the zero time removing the reminder", patch.RemindAt)
+	}
+	if patch.RRule == nil || *patch.RRule != "" {
+		t.Errorf("ReversePatch().RRule = %v, want an empty rule stopping the recurrence", patch.RRule)
+	}
+	if patch.WhatToDo != nil || patch.Status != nil {
+		t.Errorf("ReversePatch() = %+v, want no description or status", patch)
+	}
+}
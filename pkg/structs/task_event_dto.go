@@ -0,0 +1,139 @@
+package structs
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskEventType is the kind of change recorded by a TaskEvent.
+type TaskEventType string
+
+const (
+	TaskEventCreated  TaskEventType = "created"
+	TaskEventUpdated  TaskEventType = "updated"
+	TaskEventDeleted  TaskEventType = "deleted"
+	TaskEventRestored TaskEventType = "restored"
+	TaskEventPurged   TaskEventType = "purged"
+	// TaskEventReminded is recorded when the reminder of the task fires.
+	TaskEventReminded TaskEventType = "reminded"
+)
+
+// TaskFieldChange is the change of a field of a task, Old is nil when the task is created.
+type TaskFieldChange struct {
+	Field string  `json:"field"`
+	Old   *string `json:"old"`
+	New   *string `json:"new"`
+}
+
+// TaskEvent is an entry of the append-only history of a task.
+// Version is the version of the task after the change, it orders the events of a task.
+type TaskEvent struct {
+	UUID       uuid.UUID
+	TaskUUID   uuid.UUID
+	Version    int64
+	Type       TaskEventType
+	Actor      string
+	OccurredAt time.Time
+	Changes    []TaskFieldChange
+	// OwnerID is the owner of the task, it scopes the history once the task is purged.
+	OwnerID string
+}
+
+// NewTaskEvent builds the event of the change of a task from before to after, before is nil on creation.
+// A purge has no after, the task leaves the history unchanged. The tags are only compared when the caller filled them.
+func NewTaskEvent(eventType TaskEventType, before, after *Task, actor string, occurredAt time.Time) *TaskEvent {
+	event := &TaskEvent{
+		UUID:       uuid.New(),
+		Type:       eventType,
+		Actor:      actor,
+		OccurredAt: occurredAt,
+		Changes:    make([]TaskFieldChange, 0),
+	}
+
+	if after == nil {
+		event.TaskUUID = before.UUID
+		event.Version = before.Version + 1
+		event.OwnerID = before.OwnerID
+		return event
+	}
+	event.TaskUUID = after.UUID
+	event.Version = after.Version
+	event.OwnerID = after.OwnerID
+
+	if before == nil {
+		before = new(Task)
+	}
+	event.Changes = appendChange(event.Changes, "description", before.WhatToDo, after.WhatToDo)
+	event.Changes = appendChange(event.Changes, "status", string(before.Status), string(after.Status))
+	event.Changes = appendChange(event.Changes, "deleted_at", formatOptionalTime(before.DeletedAt), formatOptionalTime(after.DeletedAt))
+	event.Changes = appendChange(event.Changes, "owner_id", before.OwnerID, after.OwnerID)
+	event.Changes = appendChange(event.Changes, "list_uuid", formatOptionalUUID(before.ListUUID), formatOptionalUUID(after.ListUUID))
+	event.Changes = appendChange(event.Changes, "due_at", formatOptionalTime(before.DueAt), formatOptionalTime(after.DueAt))
+	event.Changes = appendChange(event.Changes, "remind_at", formatOptionalTime(before.RemindAt), formatOptionalTime(after.RemindAt))
+	event.Changes = appendChange(event.Changes, "reminded_at", formatOptionalTime(before.RemindedAt), formatOptionalTime(after.RemindedAt))
+	event.Changes = appendChange(event.Changes, "rrule", before.RRule, after.RRule)
+	event.Changes = appendChange(event.Changes, "series_uuid", formatOptionalUUID(before.SeriesUUID), formatOptionalUUID(after.SeriesUUID))
+	event.Changes = appendChange(event.Changes, "parent_uuid", formatOptionalUUID(before.ParentUUID), formatOptionalUUID(after.ParentUUID))
+	event.Changes = appendChange(event.Changes, "tags", strings.Join(before.Tags, ","), strings.Join(after.Tags, ","))
+
+	return event
+}
+
+// appendChange appends the change of the field if its value changed, empty values are recorded as nil.
+func appendChange(changes []TaskFieldChange, field, oldValue, newValue string) []TaskFieldChange {
+	if oldValue == newValue {
+		return changes
+	}
+
+	change := TaskFieldChange{Field: field}
+	if oldValue != "" {
+		change.Old = &oldValue
+	}
+	if newValue != "" {
+		change.New = &newValue
+	}
+	return append(changes, change)
+}
+
+func formatOptionalUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+type TaskEventPublicDTO struct {
+	UUID       uuid.UUID         `json:"event_uuid"`
+	TaskUUID   uuid.UUID         `json:"task_uuid"`
+	Version    int64             `json:"version"`
+	Type       TaskEventType     `json:"type"`
+	Actor      string            `json:"actor"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	Changes    []TaskFieldChange `json:"changes"`
+}
+
+func FactoryTaskEventPublicDTO(event *TaskEvent) *TaskEventPublicDTO {
+	return &TaskEventPublicDTO{
+		UUID:       event.UUID,
+		TaskUUID:   event.TaskUUID,
+		Version:    event.Version,
+		Type:       event.Type,
+		Actor:      event.Actor,
+		OccurredAt: event.OccurredAt,
+		Changes:    event.Changes,
+	}
+}
+
+// TaskHistoryPublicDTO is the history of a task, oldest event first.
+type TaskHistoryPublicDTO struct {
+	Events []TaskEventPublicDTO `json:"events"`
+}
@@ -0,0 +1,83 @@
+package structs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// changesOf returns the changes of the event by field, with the nil values as "<nil>".
+func changesOf(event *TaskEvent) map[string][2]string {
+	value := func(s *string) string {
+		if s == nil {
+			return "<nil>"
+		}
+		return *s
+	}
+
+	changes := make(map[string][2]string, len(event.Changes))
+	for _, change := range event.Changes {
+		changes[change.Field] = [2]string{value(change.Old), value(change.New)}
+	}
+	return changes
+}
+
+func TestNewTaskEvent(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	listUUID := uuid.New()
+	created := &Task{
+		UUID:     uuid.New(),
+		WhatToDo: "write the report",
+		Status:   "todo",
+		Version:  1,
+		OwnerID:  "alice",
+		ListUUID: &listUUID,
+	}
+
+	// The creation records every field set, from nil.
+	event := NewTaskEvent(TaskEventCreated, nil, created, "alice", now)
+	if event.TaskUUID != created.UUID || event.Version != 1 || event.Type != TaskEventCreated || event.Actor != "alice" || event.OwnerID != "alice" || !event.OccurredAt.Equal(now) {
+		t.Errorf("NewTaskEvent() = %+v, want the creation of version 1 by alice", event)
+	}
+	want := map[string][2]string{
+		"description": {"<nil>", "write the report"},
+		"status":      {"<nil>", "todo"},
+		"owner_id":    {"<nil>", "alice"},
+		"list_uuid":   {"<nil>", listUUID.String()},
+	}
+	if got := changesOf(event); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewTaskEvent() changes = %v, want %v", got, want)
+	}
+
+	// An update records the changed fields only, the times in UTC and the removed values as nil.
+	updated := *created
+	updated.Version = 2
+	updated.Status = "done"
+	updated.ListUUID = nil
+	updated.DueAt = &now
+	updated.Tags = []string{"home", "work"}
+	event = NewTaskEvent(TaskEventUpdated, created, &updated, "bob", now)
+	want = map[string][2]string{
+		"status":    {"todo", "done"},
+		"list_uuid": {listUUID.String(), "<nil>"},
+		"due_at":    {"<nil>", "2024-05-01T10:00:00Z"},
+		"tags":      {"<nil>", "home,work"},
+	}
+	if got := changesOf(event); event.Version != 2 || event.Actor != "bob" || !reflect.DeepEqual(got, want) {
+		t.Errorf("NewTaskEvent() = version %d by %s with %v, want version 2 by bob with %v", event.Version, event.Actor, got, want)
+	}
+
+	// An event without change keeps an empty list, not nil.
+	event = NewTaskEvent(TaskEventUpdated, &updated, &updated, "bob", now)
+	if event.Changes == nil || len(event.Changes) != 0 {
+		t.Errorf("NewTaskEvent() changes = %#v, want an empty list", event.Changes)
+	}
+
+	// A purge has no after, it follows the last version.
+	event = NewTaskEvent(TaskEventPurged, &updated, nil, SystemActor, now)
+	if event.TaskUUID != created.UUID || event.Version != 3 || event.OwnerID != "alice" || len(event.Changes) != 0 {
+		t.Errorf("NewTaskEvent() of a purge = %+v, want version 3 of the task without changes", event)
+	}
+}
@@ -0,0 +1,74 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskRole is the access given on a task, each role includes the rights of the previous ones.
+type TaskRole string
+
+const (
+	// TaskRoleViewer reads the task and its history.
+	TaskRoleViewer TaskRole = "viewer"
+	// TaskRoleEditor also updates the task.
+	TaskRoleEditor TaskRole = "editor"
+	// TaskRoleOwner also deletes and restores the task, and shares it.
+	TaskRoleOwner TaskRole = "owner"
+)
+
+// rank orders the roles, 0 is an unknown role.
+func (role TaskRole) rank() int {
+	switch role {
+	case TaskRoleViewer:
+		return 1
+	case TaskRoleEditor:
+		return 2
+	case TaskRoleOwner:
+		return 3
+	}
+	return 0
+}
+
+// Allows reports whether the role includes the rights of required.
+func (role TaskRole) Allows(required TaskRole) bool {
+	return role.rank() > 0 && role.rank() >= required.rank()
+}
+
+// TaskGrant gives a role on a task to another principal than its owner.
+type TaskGrant struct {
+	TaskUUID    uuid.UUID
+	PrincipalID string
+	Role        TaskRole
+	GrantedBy   string
+	GrantedAt   time.Time
+}
+
+type TaskGrantPublicDTO struct {
+	TaskUUID    uuid.UUID `json:"task_uuid"`
+	PrincipalID string    `json:"principal_id"`
+	Role        TaskRole  `json:"role"`
+	GrantedBy   string    `json:"granted_by"`
+	GrantedAt   time.Time `json:"granted_at"`
+}
+
+func FactoryTaskGrantPublicDTO(grant *TaskGrant) *TaskGrantPublicDTO {
+	return &TaskGrantPublicDTO{
+		TaskUUID:    grant.TaskUUID,
+		PrincipalID: grant.PrincipalID,
+		Role:        grant.Role,
+		GrantedBy:   grant.GrantedBy,
+		GrantedAt:   grant.GrantedAt,
+	}
+}
+
+// TaskGrantsPublicDTO lists the grants of a task, ordered by principal.
+type TaskGrantsPublicDTO struct {
+	Grants []TaskGrantPublicDTO `json:"grants"`
+}
+
+// TaskGrantDTO is the body of a grant.
+type TaskGrantDTO struct {
+	Role TaskRole `json:"role" binding:"required,oneof=viewer editor owner"`
+}
@@ -0,0 +1,85 @@
+package structs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// TaskSortCreatedAt sorts tasks by creation date.
+	TaskSortCreatedAt = "created_at"
+	// TaskSortLastUpdated sorts tasks by last update date.
+	TaskSortLastUpdated = "last_updated"
+	// TaskSortDescription sorts tasks by description.
+	TaskSortDescription = "description"
+	// TaskSortStatus sorts tasks by status.
+	TaskSortStatus = "status"
+
+	// SortOrderAsc sorts in ascending order.
+	SortOrderAsc = "asc"
+	// SortOrderDesc sorts in descending order.
+	SortOrderDesc = "desc"
+
+	// DefaultTaskListLimit is the page size used when none is requested.
+	DefaultTaskListLimit = 50
+	// MaxTaskListLimit is the biggest page size a client can request.
+	MaxTaskListLimit = 500
+)
+
+// TaskFilterDTO holds the query parameters used to filter, sort and paginate tasks.
+// Date ranges are half-open: the after bound is included, the before bound is excluded.
+// Overdue keeps the tasks past their due date whose status isn't closed.
+// Tag keeps the tasks with any of the tag names, or all of them when TagMatch is all.
+type TaskFilterDTO struct {
+	Status        []TaskStatus `form:"status"`
+	Search        string       `form:"q"`
+	CreatedAfter  *time.Time   `form:"created_after"`
+	CreatedBefore *time.Time   `form:"created_before"`
+	UpdatedAfter  *time.Time   `form:"updated_after"`
+	UpdatedBefore *time.Time   `form:"updated_before"`
+	DueAfter      *time.Time   `form:"due_after"`
+	DueBefore     *time.Time   `form:"due_before"`
+	Overdue       bool         `form:"overdue"`
+	Tag           []string     `form:"tag"`
+	TagMatch      string       `form:"tag_match" binding:"omitempty,oneof=any all"`
+	Sort          string       `form:"sort" binding:"omitempty,oneof=created_at last_updated description status"`
+	Order         string       `form:"order" binding:"omitempty,oneof=asc desc"`
+	Limit         int          `form:"limit" binding:"omitempty,min=1,max=500"`
+	Cursor        string       `form:"cursor"`
+	// Deleted lists the tasks of the trash instead of the live tasks, it is set by the trash endpoint.
+	Deleted bool `form:"-"`
+	// ListUUID keeps the tasks of a list, it is set by the list endpoint.
+	ListUUID *uuid.UUID `form:"-"`
+	// SeriesUUID keeps the occurrences of a recurring task, it is set by the series endpoint.
+	SeriesUUID *uuid.UUID `form:"-"`
+	// ParentUUID keeps the subtasks of a task, it is set by the children endpoint.
+	ParentUUID *uuid.UUID `form:"-"`
+	// ExcludedStatus drops the tasks with these statuses, it is set from Overdue.
+	ExcludedStatus []TaskStatus `form:"-"`
+}
+
+// SetDefaults fills the sort, order, limit and tag match left empty by the client.
+func (dto *TaskFilterDTO) SetDefaults() {
+	if dto.TagMatch == "" {
+		dto.TagMatch = TagMatchAny
+	}
+	if dto.Sort == "" {
+		dto.Sort = TaskSortCreatedAt
+	}
+	if dto.Order == "" {
+		dto.Order = SortOrderAsc
+	}
+	if dto.Limit <= 0 {
+		dto.Limit = DefaultTaskListLimit
+	}
+	if dto.Limit > MaxTaskListLimit {
+		dto.Limit = MaxTaskListLimit
+	}
+}
+
+// TaskListPublicDTO is a page of tasks, NextCursor is empty on the last page.
+type TaskListPublicDTO struct {
+	Tasks      []TaskPublicDTO `json:"tasks"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
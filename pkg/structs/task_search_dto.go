@@ -0,0 +1,56 @@
+package structs
+
+const (
+	// TaskSearchSimple splits the descriptions into lowercase words, without stop words nor stemming.
+	TaskSearchSimple = "simple"
+	// TaskSearchEnglish and TaskSearchFrench drop the stop words of the language and match the words on their stem.
+	TaskSearchEnglish = "english"
+	TaskSearchFrench  = "french"
+
+	// DefaultTaskSearchLimit is the number of results used when none is requested.
+	DefaultTaskSearchLimit = 20
+	// MaxTaskSearchLimit is the biggest number of results a client can request.
+	MaxTaskSearchLimit = 100
+)
+
+// TaskSearchLanguages are the languages of the full-text search, named after the Postgres text search configurations.
+var TaskSearchLanguages = []string{TaskSearchSimple, TaskSearchEnglish, TaskSearchFrench}
+
+// TaskSearchDTO holds the query parameters of a full-text search of the live tasks.
+// The words of the query must all be in the description, a "quoted phrase" matches consecutive words
+// and a word ending with * matches the words starting with it.
+type TaskSearchDTO struct {
+	Query    string `form:"q" binding:"required,max=256"`
+	Language string `form:"language"`
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// TaskSearchResult is a task matching a full-text search.
+type TaskSearchResult struct {
+	Task *Task
+	// Rank grows with the number of matches and shrinks with the length of the description,
+	// it only compares the results of a search.
+	Rank float64
+	// Snippet is an excerpt of the description with the matches between <mark> and </mark>, the description isn't escaped.
+	Snippet string
+}
+
+type TaskSearchResultPublicDTO struct {
+	Task    TaskPublicDTO `json:"task"`
+	Rank    float64       `json:"rank"`
+	Snippet string        `json:"snippet"`
+}
+
+func FactoryTaskSearchResultPublicDTO(result *TaskSearchResult) *TaskSearchResultPublicDTO {
+	return &TaskSearchResultPublicDTO{
+		Task:    *FactoryTaskPublicDTO(result.Task),
+		Rank:    result.Rank,
+		Snippet: result.Snippet,
+	}
+}
+
+// TaskSearchPublicDTO holds the results of a full-text search, best ranked first.
+type TaskSearchPublicDTO struct {
+	Language string                      `json:"language"`
+	Results  []TaskSearchResultPublicDTO `json:"results"`
+}
@@ -0,0 +1,247 @@
+package structs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryStatus is the state of a delivery in the queue of the webhooks.
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is waiting for its next attempt.
+	WebhookDeliveryPending WebhookDeliveryStatus = "pending"
+	// WebhookDeliveryDelivered was accepted by the receiver with a 2xx.
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	// WebhookDeliveryDead failed all its attempts, it stays in the dead letters until it is retried.
+	WebhookDeliveryDead WebhookDeliveryStatus = "dead"
+)
+
+const (
+	// DefaultWebhookDeliveryLimit is the number of deliveries listed when none is requested.
+	DefaultWebhookDeliveryLimit = 50
+	// MaxWebhookDeliveryLimit is the biggest number of deliveries a client can request.
+	MaxWebhookDeliveryLimit = 500
+)
+
+const (
+	// HeaderWebhookEvent is the type of the change sent to the receiver.
+	HeaderWebhookEvent = "X-Todolist-Event"
+	// HeaderWebhookDelivery is the UUID of the delivery, the same for all its attempts.
+	HeaderWebhookDelivery = "X-Todolist-Delivery"
+	// HeaderWebhookTimestamp is the Unix time of the attempt, in seconds, a receiver rejects the old ones.
+	HeaderWebhookTimestamp = "X-Todolist-Timestamp"
+	// HeaderWebhookSignature is the WebhookSignature of the payload.
+	HeaderWebhookSignature = "X-Todolist-Signature"
+)
+
+// WebhookEvents are the changes of the tasks a webhook can subscribe to.
+var WebhookEvents = []TaskEventType{TaskEventCreated, TaskEventUpdated, TaskEventDeleted, TaskEventRestored}
+
+// Webhook is a subscription of a receiver to the changes of the tasks visible by its owner.
+type Webhook struct {
+	UUID uuid.UUID
+	URL  string
+	// Secret is the key of the HMAC-SHA256 signature of the payloads.
+	Secret string
+	// Events are the types of the changes sent to the receiver, all of WebhookEvents if empty.
+	Events []TaskEventType
+	// Active is false while the webhook is paused, the changes are not queued for it.
+	Active bool
+	// OwnerID is the id of the principal who created the webhook, empty if it was created without principal.
+	OwnerID     string
+	CreatedAt   time.Time
+	LastUpdated time.Time
+}
+
+// Subscribed reports whether the webhook is active and sends the changes of this type.
+func (webhook *Webhook) Subscribed(eventType TaskEventType) bool {
+	if !webhook.Active {
+		return false
+	}
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, event := range webhook.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPublicDTO never shows the secret, except in the response of the creation.
+type WebhookPublicDTO struct {
+	UUID        uuid.UUID       `json:"webhook_uuid"`
+	URL         string          `json:"url"`
+	Secret      string          `json:"secret,omitempty"`
+	Events      []TaskEventType `json:"events"`
+	Active      bool            `json:"active"`
+	OwnerID     string          `json:"owner_id,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	LastUpdated time.Time       `json:"last_updated"`
+}
+
+func FactoryWebhookPublicDTO(webhook *Webhook) *WebhookPublicDTO {
+	events := webhook.Events
+	if events == nil {
+		events = []TaskEventType{}
+	}
+
+	return &WebhookPublicDTO{
+		UUID:        webhook.UUID,
+		URL:         webhook.URL,
+		Events:      events,
+		Active:      webhook.Active,
+		OwnerID:     webhook.OwnerID,
+		CreatedAt:   webhook.CreatedAt,
+		LastUpdated: webhook.LastUpdated,
+	}
+}
+
+// WebhooksPublicDTO is the collection of the webhooks, oldest first.
+type WebhooksPublicDTO struct {
+	Webhooks []WebhookPublicDTO `json:"webhooks"`
+}
+
+// WebhookCreateDTO is the body of a webhook creation, a secret is generated when none is given.
+type WebhookCreateDTO struct {
+	URL    string          `json:"url" binding:"required,url,max=2048"`
+	Secret string          `json:"secret" binding:"omitempty,min=16,max=255"`
+	Events []TaskEventType `json:"events" binding:"omitempty,dive,oneof=created updated deleted restored"`
+	Active *bool           `json:"active"`
+}
+
+func (dto *WebhookCreateDTO) ReverseCreateDTO() *Webhook {
+	active := true
+	if dto.Active != nil {
+		active = *dto.Active
+	}
+
+	return &Webhook{
+		UUID:   uuid.New(),
+		URL:    dto.URL,
+		Secret: dto.Secret,
+		Events: dto.Events,
+		Active: active,
+	}
+}
+
+// WebhookUpdateDTO is the body of a webhook update, the fields left out are unchanged.
+// An empty list of events subscribes the webhook to all of them.
+type WebhookUpdateDTO struct {
+	URL    *string         `json:"url" binding:"omitempty,url,max=2048"`
+	Secret *string         `json:"secret" binding:"omitempty,min=16,max=255"`
+	Events []TaskEventType `json:"events" binding:"omitempty,dive,oneof=created updated deleted restored"`
+	Active *bool           `json:"active"`
+}
+
+func (dto *WebhookUpdateDTO) ReversePatch() *WebhookPatch {
+	return &WebhookPatch{
+		URL:    dto.URL,
+		Secret: dto.Secret,
+		Events: dto.Events,
+		Active: dto.Active,
+	}
+}
+
+// WebhookPatch is a partial update of a webhook, nil fields are left unchanged.
+type WebhookPatch struct {
+	URL    *string
+	Secret *string
+	Events []TaskEventType
+	Active *bool
+}
+
+// WebhookDelivery is the sending of a change of a task to a webhook, retried until the receiver accepts it.
+type WebhookDelivery struct {
+	UUID        uuid.UUID
+	WebhookUUID uuid.UUID
+	EventType   TaskEventType
+	// Payload is the signed body sent to the receiver.
+	Payload json.RawMessage
+	Status  WebhookDeliveryStatus
+	// Attempts is the number of attempts since the delivery was queued or retried.
+	Attempts      int
+	NextAttemptAt time.Time
+	LastAttemptAt *time.Time
+	// LastStatusCode is the status of the last response of the receiver, 0 if it didn't answer.
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+}
+
+type WebhookDeliveryPublicDTO struct {
+	UUID           uuid.UUID             `json:"delivery_uuid"`
+	WebhookUUID    uuid.UUID             `json:"webhook_uuid"`
+	EventType      TaskEventType         `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+func FactoryWebhookDeliveryPublicDTO(delivery *WebhookDelivery) *WebhookDeliveryPublicDTO {
+	dto := &WebhookDeliveryPublicDTO{
+		UUID:           delivery.UUID,
+		WebhookUUID:    delivery.WebhookUUID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	// Only a pending delivery has a next attempt.
+	if delivery.Status == WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		dto.NextAttemptAt = &nextAttemptAt
+	}
+	return dto
+}
+
+// WebhookDeliveriesPublicDTO is the delivery log of a webhook, newest first.
+type WebhookDeliveriesPublicDTO struct {
+	Deliveries []WebhookDeliveryPublicDTO `json:"deliveries"`
+}
+
+// WebhookDeliveryFilterDTO holds the query parameters of the delivery log of a webhook.
+// The dead letters are listed with the dead status.
+type WebhookDeliveryFilterDTO struct {
+	Status WebhookDeliveryStatus `form:"status" binding:"omitempty,oneof=pending delivered dead"`
+	Limit  int                   `form:"limit" binding:"omitempty,min=1,max=500"`
+}
+
+// SetDefaults fills the limit left out of the query.
+func (dto *WebhookDeliveryFilterDTO) SetDefaults() {
+	if dto.Limit <= 0 {
+		dto.Limit = DefaultWebhookDeliveryLimit
+	}
+	if dto.Limit > MaxWebhookDeliveryLimit {
+		dto.Limit = MaxWebhookDeliveryLimit
+	}
+}
+
+// WebhookSignature returns the signature of a payload sent at the timestamp: "sha256=" followed by
+// the hex encoded HMAC-SHA256 of the timestamp, a dot and the payload, keyed with the secret of the webhook.
+// A receiver computes it again and compares it to the HeaderWebhookSignature with hmac.Equal.
+func WebhookSignature(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}